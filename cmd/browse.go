@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/cmd/options"
+	"github.com/jetstack/paranoia/internal/browse"
+)
+
+func newBrowse(ctx context.Context) *cobra.Command {
+	var imgOpts *options.Image
+
+	cmd := &cobra.Command{
+		Use:   "browse [flags] image",
+		Short: "Explore the certificates found in an image from a command prompt",
+		Long: `
+Browse is a prompt-driven browser of the certificates found in an image, rather than a full-screen interface.
+It lists the certificates with their subject, issuer and validity, then reads commands, one per line, from standard input to explore them.
+The list can be filtered by subject, issuer or location, and sorted by expiry, subject or location.
+Entering the number of a certificate prints every detail of it, along with each location it was found in.
+Type help at the prompt for a list of commands.
+As commands are read a line at a time, they may also be piped in, such as to script a session.
+
+A PEM bundle, or any other single file, can be browsed by giving it with the bundle:// scheme.
+`,
+		Example: `
+Browse the certificates in an image:
+
+	$ paranoia browse alpine:latest
+
+Browse the certificates in a PEM bundle:
+
+	$ paranoia browse bundle://./ca-certificates.crt
+
+Show the details of the first certificate expiring in a PEM bundle, without a prompt:
+
+	$ printf 'sort expiry\n1\nquit\n' | paranoia browse bundle://./ca-certificates.crt
+`,
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			return options.MustSingleImageArgs(args)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			imageName := args[0]
+			if imageName == "-" {
+				return errors.New("browse reads commands from standard input, so can't read the image from it")
+			}
+
+			iOpts, err := imgOpts.Options()
+			if err != nil {
+				return errors.Wrap(err, "constructing image options")
+			}
+
+			parsedCertificates, err := findImageCertificates(ctx, imageName, imgOpts, iOpts)
+			if err != nil {
+				return err
+			}
+			if imgOpts.Verbose {
+				printScanStats(imageName, parsedCertificates)
+			}
+
+			return browse.New(parsedCertificates.Found, os.Stdin, os.Stdout).Run()
+		},
+	}
+
+	imgOpts = options.RegisterImage(cmd)
+	cmd.Args = cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs)
+
+	return cmd
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func newConfig(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with configuration files for the validate command",
+	}
+
+	cmd.AddCommand(newConfigSchema(ctx))
+
+	return cmd
+}
+
+func newConfigSchema(_ context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print a JSON Schema for configuration files for the validate command",
+		Long: `
+Print a JSON Schema describing configuration files for the validate command.
+The schema can be given to editors for autocompletion, or used to lint configuration files in CI.
+It is generated from the configuration structure, so always matches the options supported by this version of Paranoia.
+`,
+		Example: `
+Save the schema, to be referenced from a configuration file with a "# yaml-language-server: $schema=paranoia.schema.json" comment:
+
+	$ paranoia config schema > paranoia.schema.json
+`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			schema, err := validate.Schema()
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(schema))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/cmd/options"
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/output"
+)
+
+func newDiff(ctx context.Context) *cobra.Command {
+	var (
+		imgOpts  *options.Image
+		diffOpts *options.Diff
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff [flags] image1 image2",
+		Short: "Compare the certificate authorities in two container images",
+		Long: `
+Compare the certificates found in two container images.
+Certificates found only in the first image, only in the second image, and in both images are reported.
+Certificates are identified by their SHA256 fingerprint, so a certificate which has moved location is common to both images.
+
+This is useful when upgrading a base image, to see exactly which certificate authorities were added or removed.
+`,
+		Example: `
+Compare the certificates in two versions of an image:
+
+	$ paranoia diff alpine:3.16 alpine:3.17
+
+List the fingerprints of certificates added by an upgrade:
+
+	$ paranoia diff --output json alpine:3.16 alpine:3.17 | jq '.onlyInSecond[].fingerprintSHA256'
+`,
+		PreRunE: func(_ *cobra.Command, args []string) error {
+			return diffOpts.Validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			first, second := args[0], args[1]
+
+			iOpts, err := imgOpts.Options()
+			if err != nil {
+				return errors.Wrap(err, "constructing image options")
+			}
+
+			firstCertificates, err := findImageCertificates(ctx, first, imgOpts, iOpts)
+			if err != nil {
+				return errors.Wrapf(err, "finding certificates in image %s", first)
+			}
+			secondCertificates, err := findImageCertificates(ctx, second, imgOpts, iOpts)
+			if err != nil {
+				return errors.Wrapf(err, "finding certificates in image %s", second)
+			}
+			if imgOpts.Verbose {
+				printScanStats(first, firstCertificates)
+				printScanStats(second, secondCertificates)
+			}
+
+			diff := certificate.Compare(firstCertificates.Found, secondCertificates.Found)
+
+			if diffOpts.Output == options.OutputModeJSON {
+				m, err := json.Marshal(output.NewJSONDiffOutput(first, second, diff))
+				if err != nil {
+					return errors.Wrap(err, "failed to marshall output JSON")
+				}
+				fmt.Println(string(m))
+				return nil
+			}
+
+			printDiffSection(fmt.Sprintf("only in %s", first), "-", diff.OnlyFirst)
+			printDiffSection(fmt.Sprintf("only in %s", second), "+", diff.OnlySecond)
+			fmt.Printf("%d certificates only in %s, %d certificates only in %s, and %d certificates in both\n",
+				len(diff.OnlyFirst), first, len(diff.OnlySecond), second, len(diff.Common))
+
+			return nil
+		},
+	}
+
+	imgOpts = options.RegisterImage(cmd)
+	diffOpts = options.RegisterDiff(cmd)
+	cmd.Args = cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs)
+
+	return cmd
+}
+
+func printDiffSection(heading, lead string, founds []certificate.Found) {
+	if len(founds) == 0 {
+		return
+	}
+	fmt.Printf("Certificates %s:\n", heading)
+	for _, f := range founds {
+		fmt.Printf("%s %s with SHA256 fingerprint %X in location %s\n",
+			lead, f.Certificate.Subject, f.FingerprintSha256, describeLocation(f))
+	}
+}
@@ -6,19 +6,22 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/pkg/errors"
 	"github.com/rodaine/table"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
 	"github.com/jetstack/paranoia/cmd/options"
-	"github.com/jetstack/paranoia/internal/image"
+	"github.com/jetstack/paranoia/internal/certificate"
 	"github.com/jetstack/paranoia/internal/output"
+	"github.com/jetstack/paranoia/internal/validate"
 )
 
 func newExport(ctx context.Context) *cobra.Command {
@@ -44,6 +47,39 @@ Export certificates for an image:
 Pipe certificate information into jq:
 
 	$ paranoia export --output json alpine:latest | jq '.certificates[].fingerprintSHA256'
+
+Generate a validate configuration file allowing every certificate in an image:
+
+	$ paranoia export --output config alpine:latest > .paranoia.yaml
+
+Export certificates, and any partial certificates, as CSV for use in a spreadsheet:
+
+	$ paranoia export --output csv --include-partials alpine:latest > certificates.csv
+
+Attest to the certificates in an image, and their validation result, with cosign:
+
+	$ paranoia export --output attestation --attestation-config .paranoia.yaml example.com/image@sha256:... | jq .predicate > predicate.json
+	$ cosign attest --type https://github.com/jetstack/paranoia/attestation/certificates/v1 --predicate predicate.json example.com/image@sha256:...
+
+List the certificates in an image, without revealing their subjects:
+
+	$ paranoia export --redact --output wide example.com/image:v0.1.0
+
+Summarise which organizations issued the certificates in an image:
+
+	$ paranoia export --output summary alpine:latest
+
+Build a minimal trust bundle of just the root certificates in an image:
+
+	$ paranoia export --output pem --roots-only alpine:latest > roots.pem
+
+Replace a trust bundle with the certificates in an image, without readers ever seeing it partially written:
+
+	$ paranoia export --output pem --output-file /etc/ssl/bundle.pem alpine:latest
+
+Compare the certificates in two images by their SHA256 fingerprints:
+
+	$ diff <(paranoia export --output fingerprints alpine:3.17 | sort) <(paranoia export --output fingerprints alpine:3.18 | sort)
 `,
 		PreRunE: func(_ *cobra.Command, args []string) error {
 			if err := options.MustSingleImageArgs(args); err != nil {
@@ -59,10 +95,30 @@ Pipe certificate information into jq:
 				return errors.Wrap(err, "constructing image options")
 			}
 
-			parsedCertificates, err := image.FindImageCertificates(ctx, imageName, iOpts...)
+			parsedCertificates, err := findImageCertificates(ctx, imageName, imgOpts, iOpts)
 			if err != nil {
 				return err
 			}
+			if imgOpts.Verbose {
+				printScanStats(imageName, parsedCertificates)
+			}
+
+			// Certificates are validated for the attestation before they are
+			// redacted, as entries may match their subjects.
+			var validation *output.AttestationValidation
+			if len(outOpts.AttestationConfigs) > 0 {
+				res, err := validateWithConfigs(ctx, outOpts.AttestationConfigs, parsedCertificates.Found)
+				if err != nil {
+					return err
+				}
+				validation = output.NewAttestationValidation(res)
+			}
+			if outOpts.Redact {
+				certificate.Redact(parsedCertificates.Found)
+			}
+			if outOpts.RootsOnly {
+				parsedCertificates.Found = certificate.Roots(parsedCertificates.Found)
+			}
 
 			if outOpts.Mode == options.OutputModePretty || outOpts.Mode == options.OutputModeWide {
 				wide := outOpts.Mode == options.OutputModeWide
@@ -106,19 +162,51 @@ Pipe certificate information into jq:
 					fmt.Printf("Found %d partial certificates\n", len(parsedCertificates.Partials))
 				}
 
+				if len(parsedCertificates.Distrusted) > 0 {
+					headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+					columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+					tbl := table.New("File Location", "Parser", "SHA-1", "Rejected For")
+					tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+
+					for _, d := range parsedCertificates.Distrusted {
+						tbl.AddRow(d.Location, d.Parser, describeDistrustedFingerprint(d), strings.Join(d.RejectedPurposes, ", "))
+					}
+
+					tbl.Print()
+					fmt.Printf("Found %d distrusted certificates which are not in the image\n", len(parsedCertificates.Distrusted))
+				}
+
 			} else if outOpts.Mode == options.OutputModeJSON {
 				var out output.JSONOutput
 
 				for _, cert := range parsedCertificates.Found {
+					var distrustAfter string
+					if !cert.ServerDistrustAfter.IsZero() {
+						distrustAfter = cert.ServerDistrustAfter.Format(time.RFC3339)
+					}
 					out.Certificates = append(out.Certificates, output.JSONCertificate{
 						FileLocation:      cert.Location,
 						Owner:             cert.Certificate.Subject.String(),
 						Parser:            cert.Parser,
+						Encoding:          cert.Encoding,
+						ContainerFormat:   cert.ContainerFormat,
 						Signature:         fmt.Sprintf("%X", cert.Certificate.Signature),
 						NotBefore:         cert.Certificate.NotBefore.Format(time.RFC3339),
 						NotAfter:          cert.Certificate.NotAfter.Format(time.RFC3339),
 						FingerprintSHA1:   hex.EncodeToString(cert.FingerprintSha1[:]),
 						FingerprintSHA256: hex.EncodeToString(cert.FingerprintSha256[:]),
+						FingerprintSHA512: hex.EncodeToString(cert.FingerprintSha512[:]),
+						SpkiSHA256:        hex.EncodeToString(cert.SpkiSha256[:]),
+						KeyAlgorithm:      cert.KeyAlgorithm,
+						KeySizeBits:       cert.KeySizeBits,
+						SelfSigned:        cert.SelfSigned,
+						IsCA:              cert.IsCA,
+						LayerDigest:       cert.LayerDigest,
+						TrustedPurposes:   cert.TrustedPurposes,
+						RejectedPurposes:  cert.RejectedPurposes,
+
+						ServerDistrustAfter: distrustAfter,
 					})
 				}
 
@@ -130,6 +218,19 @@ Pipe certificate information into jq:
 					})
 				}
 
+				for _, d := range parsedCertificates.Distrusted {
+					var fingerprint string
+					if d.FingerprintSha1 != ([20]byte{}) {
+						fingerprint = hex.EncodeToString(d.FingerprintSha1[:])
+					}
+					out.Distrusted = append(out.Distrusted, output.JSONDistrusted{
+						FileLocation:     d.Location,
+						Parser:           d.Parser,
+						FingerprintSHA1:  fingerprint,
+						RejectedPurposes: d.RejectedPurposes,
+					})
+				}
+
 				m, err := json.Marshal(out)
 				if err != nil {
 					return errors.Wrap(err, "failed to marshall output JSON")
@@ -137,12 +238,49 @@ Pipe certificate information into jq:
 
 				fmt.Println(string(m))
 			} else if outOpts.Mode == options.OutputModePEM {
-				for _, cert := range parsedCertificates.Found {
-					pem.Encode(os.Stdout, &pem.Block{
-						Type:  "CERTIFICATE",
-						Bytes: cert.Certificate.Raw,
+				if outOpts.OutputFile != "" {
+					err := output.WriteFileAtomic(outOpts.OutputFile, 0644, func(w io.Writer) error {
+						return output.WritePEMBundle(w, parsedCertificates.Found)
 					})
+					if err != nil {
+						return errors.Wrapf(err, "failed to write output PEM to %s", outOpts.OutputFile)
+					}
+				} else if err := output.WritePEMBundle(os.Stdout, parsedCertificates.Found); err != nil {
+					return errors.Wrap(err, "failed to write output PEM")
 				}
+			} else if outOpts.Mode == options.OutputModeConfig {
+				config := validate.NewAllowConfig(parsedCertificates.Found)
+
+				fmt.Printf("# Generated by paranoia from image %s.\n", imageName)
+				fmt.Println("# Delete the entries for any certificates which should not be trusted.")
+				enc := yaml.NewEncoder(os.Stdout)
+				enc.SetIndent(2)
+				if err := enc.Encode(&config); err != nil {
+					return errors.Wrap(err, "failed to marshall output config")
+				}
+				if err := enc.Close(); err != nil {
+					return errors.Wrap(err, "failed to marshall output config")
+				}
+			} else if outOpts.Mode == options.OutputModeCSV {
+				if err := output.WriteCSV(os.Stdout, parsedCertificates, outOpts.IncludePartials); err != nil {
+					return errors.Wrap(err, "failed to write output CSV")
+				}
+			} else if outOpts.Mode == options.OutputModeSummary {
+				output.WriteSummary(os.Stdout, parsedCertificates.Found)
+			} else if outOpts.Mode == options.OutputModeFingerprints {
+				if err := output.WriteFingerprints(os.Stdout, parsedCertificates.Found, outOpts.Digest); err != nil {
+					return errors.Wrap(err, "failed to write fingerprints")
+				}
+			} else if outOpts.Mode == options.OutputModeAttestation {
+				statement, err := output.NewAttestation(imageName, parsedCertificates, validation)
+				if err != nil {
+					return err
+				}
+				m, err := json.Marshal(statement)
+				if err != nil {
+					return errors.Wrap(err, "failed to marshall output attestation")
+				}
+				fmt.Println(string(m))
 			}
 
 			return nil
@@ -155,3 +293,12 @@ Pipe certificate information into jq:
 
 	return cmd
 }
+
+// describeDistrustedFingerprint describes the SHA1 fingerprint of a
+// distrusted certificate, or "(unknown)" if the trust store doesn't give one.
+func describeDistrustedFingerprint(d certificate.Distrusted) string {
+	if d.FingerprintSha1 == ([20]byte{}) {
+		return "(unknown)"
+	}
+	return hex.EncodeToString(d.FingerprintSha1[:])
+}
@@ -5,6 +5,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/pkg/errors"
@@ -12,11 +14,15 @@ import (
 
 	"github.com/jetstack/paranoia/cmd/options"
 	"github.com/jetstack/paranoia/internal/analyse"
-	"github.com/jetstack/paranoia/internal/image"
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/output"
 )
 
 func newInspect(ctx context.Context) *cobra.Command {
-	var imgOpts *options.Image
+	var (
+		imgOpts     *options.Image
+		inspectOpts *options.Inspect
+	)
 
 	cmd := &cobra.Command{
 		Use:   "inspect [flags] image",
@@ -28,10 +34,27 @@ Inspect prints out certificates that have one or more of the following faults:
 - Close to expiry (based on current system time).
 - Removed by Mozilla from their certificate authority bundle.
 
+Certificates found in more than one location are listed along with each of their locations.
+Intermediate certificates whose issuer is not found in the image are also listed, as these often indicate a misconfigured bundle.
 Partial certificates are also all printed for further inspection.
+
+Alternatively, when given the SHA256 fingerprint of a certificate, inspect prints every detail of that certificate along with each location it was found in.
+Inspect fails if the certificate is not found in the image.
+`,
+		Example: `
+Summarise potential issues with the certificates in an image:
+
+	$ paranoia inspect alpine:latest
+
+Print the details of a single certificate:
+
+	$ paranoia inspect --sha256 f9e67d336c51002ac054c632022d66dda2e7e3fff10ad061ed31d8bbb410cfb2 alpine:latest
 `,
 		PreRunE: func(_ *cobra.Command, args []string) error {
-			return options.MustSingleImageArgs(args)
+			if err := options.MustSingleImageArgs(args); err != nil {
+				return err
+			}
+			return inspectOpts.Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			imageName := args[0]
@@ -41,10 +64,29 @@ Partial certificates are also all printed for further inspection.
 				return errors.Wrap(err, "constructing image options")
 			}
 
-			parsedCertificates, err := image.FindImageCertificates(ctx, imageName, iOpts...)
+			parsedCertificates, err := findImageCertificates(ctx, imageName, imgOpts, iOpts)
 			if err != nil {
 				return err
 			}
+			if imgOpts.Verbose {
+				printScanStats(imageName, parsedCertificates)
+			}
+
+			if inspectOpts.SHA256 != "" {
+				fingerprint, err := inspectOpts.Fingerprint()
+				if err != nil {
+					return err
+				}
+				found, err := printDetails(fingerprint, parsedCertificates)
+				if err != nil {
+					return err
+				}
+				if !found {
+					fmt.Printf("No certificate with SHA256 fingerprint %x found in image %s\n", fingerprint, imageName)
+					os.Exit(1)
+				}
+				return nil
+			}
 
 			analyser, err := analyse.NewAnalyser()
 			if err != nil {
@@ -61,6 +103,9 @@ Partial certificates are also all printed for further inspection.
 				if len(notes) > 0 {
 					numIssues++
 					fmt.Printf("Certificate %s\n", cert.Certificate.Subject)
+					if cert.LayerDigest != "" {
+						fmt.Printf("┣ Found in %s, added by layer %s\n", cert.Location, cert.LayerDigest)
+					}
 					for i, n := range notes {
 						var lead string
 						if i == len(notes)-1 {
@@ -82,6 +127,50 @@ Partial certificates are also all printed for further inspection.
 				}
 			}
 			fmt.Printf("Found %d certificates total, of which %d had issues\n", len(parsedCertificates.Found), numIssues)
+			if parsedCertificates.SkippedLayers > 0 {
+				fmt.Printf("Skipped %d layers identical to layers already scanned\n", parsedCertificates.SkippedLayers)
+			}
+
+			duplicates := parsedCertificates.Duplicates()
+			if len(duplicates) > 0 {
+				// Print in the order certificates were found, so output is
+				// stable between runs.
+				printed := make(map[[32]byte]bool)
+				for _, cert := range parsedCertificates.Found {
+					locations, ok := duplicates[cert.FingerprintSha256]
+					if !ok || printed[cert.FingerprintSha256] {
+						continue
+					}
+					printed[cert.FingerprintSha256] = true
+
+					name := fmt.Sprintf("with SHA256 fingerprint %X", cert.FingerprintSha256)
+					if cert.Certificate != nil {
+						name = cert.Certificate.Subject.String()
+					}
+					fmt.Printf("Certificate %s has %d copies\n", name, len(locations))
+					for i, l := range locations {
+						lead := "┣"
+						if i == len(locations)-1 {
+							lead = "┗"
+						}
+						fmt.Printf("%s %s\n", lead, l)
+					}
+				}
+				fmt.Printf("Found %d certificates with more than one copy\n", len(duplicates))
+			}
+
+			var orphans int
+			for _, chain := range certificate.BuildChains(parsedCertificates.Found) {
+				if o := chain.Orphan(); o != nil {
+					orphans++
+					fmtFn := color.New(color.FgYellow).SprintfFunc()
+					fmt.Printf(fmtFn("⚠️ Intermediate certificate %s in %s has no issuer in the image\n", o.Certificate.Subject, describeLocation(*o)))
+				}
+			}
+			if orphans > 0 {
+				fmt.Printf("Found %d intermediate certificates without an issuer\n", orphans)
+			}
+
 			if len(parsedCertificates.Partials) > 0 {
 				for _, p := range parsedCertificates.Partials {
 					fmtFn := color.New(color.FgYellow).SprintfFunc()
@@ -90,12 +179,38 @@ Partial certificates are also all printed for further inspection.
 				fmt.Printf("Found %d partial certificates\n", len(parsedCertificates.Partials))
 			}
 
+			if len(parsedCertificates.Distrusted) > 0 {
+				for _, d := range parsedCertificates.Distrusted {
+					fmtFn := color.New(color.FgYellow).SprintfFunc()
+					fmt.Printf(fmtFn("ℹ️ %s distrusts certificate %s for %s\n", d.Location, describeDistrustedFingerprint(d), strings.Join(d.RejectedPurposes, ", ")))
+				}
+				fmt.Printf("Found %d distrusted certificates which are not in the image\n", len(parsedCertificates.Distrusted))
+			}
+
 			return nil
 		},
 	}
 
 	imgOpts = options.RegisterImage(cmd)
+	inspectOpts = options.RegisterInspect(cmd)
 	cmd.Args = cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs)
 
 	return cmd
 }
+
+// printDetails prints every detail of the certificate with the given SHA256
+// fingerprint, and returns false if it wasn't found.
+func printDetails(fingerprint [32]byte, parsed *certificate.ParsedCertificates) (bool, error) {
+	var matches []certificate.Found
+	for _, cert := range parsed.Found {
+		if cert.Certificate != nil && cert.FingerprintSha256 == fingerprint {
+			matches = append(matches, cert)
+		}
+	}
+
+	if len(matches) == 0 {
+		return false, nil
+	}
+
+	return true, output.WriteDetails(os.Stdout, matches)
+}
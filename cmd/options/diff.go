@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var diffOutputModes = []string{
+	OutputModePretty,
+	OutputModeJSON,
+}
+
+// Diff are options for configuring the diff command.
+type Diff struct {
+	// Output is the output format of the difference. Defaults to "pretty".
+	Output string `json:"output"`
+}
+
+func RegisterDiff(cmd *cobra.Command) *Diff {
+	var opts Diff
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", OutputModePretty, `
+The output mode controls how Paranoia reports the difference between the images.
+Supported modes are *pretty* and *json*.
+
+*pretty*: Certificates are listed using human-readable text.
+
+*json*: The JSON output mode emits only JSON to STDOUT.
+The output includes "first" and "second" keys with the image names, along with "onlyInFirst", "onlyInSecond", and "common" keys containing arrays of certificate objects.
+Certificate objects have keys for "fileLocation", "parser", "encoding", "containerFormat", "subject", "issuer", "notBefore", "notAfter", "fingerprintSHA1", "fingerprintSHA256", and optionally "layerDigest".
+`)
+	return &opts
+}
+
+func (d *Diff) Validate() error {
+	for _, m := range diffOutputModes {
+		if d.Output == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output mode %q, must be one of %s", d.Output, strings.Join(diffOutputModes, ", "))
+}
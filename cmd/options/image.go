@@ -5,8 +5,10 @@ package options
 import (
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/jetstack/paranoia/internal/certificate"
 	"github.com/jetstack/paranoia/internal/image"
 )
 
@@ -15,6 +17,55 @@ type Image struct {
 	// Platform specifies the platform in the form
 	// os/arch[/variant][:osversion] (e.g. linux/amd64)
 	Platform string `json:"platform"`
+
+	// SpillThreshold is the size, in bytes, above which files are written to
+	// a temporary file while they are scanned, rather than held in memory.
+	// Parsers which decode whole files don't read files larger than this.
+	SpillThreshold int64 `json:"spillThreshold"`
+
+	// MaxFileSize is the size, in bytes, above which files are skipped
+	// without being scanned. If zero, files of any size are scanned.
+	MaxFileSize int64 `json:"maxFileSize"`
+
+	// Concurrency is the number of files scanned concurrently. If zero, this
+	// is the number of CPUs usable.
+	Concurrency int `json:"concurrency"`
+
+	// ContainerdSocket and ContainerdNamespace are where images given with
+	// the containerd:// scheme are read from.
+	ContainerdSocket    string `json:"containerdSocket"`
+	ContainerdNamespace string `json:"containerdNamespace"`
+
+	// Include and Exclude are glob patterns selecting the paths of the files
+	// which are scanned. Excludes take precedence over includes.
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+
+	// StrictParse fails the scan when a parser fails to scan any file,
+	// rather than recording it as a partial certificate.
+	StrictParse bool `json:"strictParse"`
+
+	// ResolveSymlinks reports certificates at the location of every
+	// symbolic link to their file, as well as the file itself.
+	ResolveSymlinks bool `json:"resolveSymlinks"`
+
+	// EnableParsers and DisableParsers select the parsers files are scanned
+	// with, by name. If EnableParsers is empty, the parsers enabled by
+	// default are used.
+	EnableParsers  []string `json:"enableParsers"`
+	DisableParsers []string `json:"disableParsers"`
+
+	// PullConcurrency is the number of layers of remote images downloaded
+	// at once.
+	PullConcurrency int `json:"pullConcurrency"`
+
+	// Verbose prints statistics about the scan, such as the number of files
+	// scanned.
+	Verbose bool `json:"verbose"`
+
+	// Progress periodically prints the number of files scanned and
+	// certificates found while images are scanned.
+	Progress bool `json:"progress"`
 }
 
 // Options converts the options to a slice of image.Options
@@ -29,6 +80,55 @@ func (i *Image) Options() ([]image.Option, error) {
 		opts = append(opts, image.WithPlatform(platform))
 	}
 
+	if i.SpillThreshold <= 0 {
+		return []image.Option{}, errors.Errorf("spill threshold must be positive, got %d", i.SpillThreshold)
+	}
+	opts = append(opts, image.WithScanOptions(certificate.WithSpillThreshold(i.SpillThreshold)))
+
+	if i.MaxFileSize < 0 {
+		return []image.Option{}, errors.Errorf("max file size must not be negative, got %d", i.MaxFileSize)
+	}
+	if i.MaxFileSize > 0 {
+		opts = append(opts, image.WithScanOptions(certificate.WithMaxFileSize(i.MaxFileSize)))
+	}
+
+	// The logger is configured by the root command's log options.
+	opts = append(opts, image.WithScanOptions(certificate.WithLogger(logrus.StandardLogger())))
+
+	if i.Concurrency < 0 {
+		return []image.Option{}, errors.Errorf("concurrency must not be negative, got %d", i.Concurrency)
+	}
+	if i.Concurrency > 0 {
+		opts = append(opts, image.WithScanOptions(certificate.WithConcurrency(i.Concurrency)))
+	}
+
+	opts = append(opts, image.WithContainerd(i.ContainerdSocket, i.ContainerdNamespace))
+
+	if i.PullConcurrency < 1 {
+		return []image.Option{}, errors.Errorf("pull concurrency must be at least 1, got %d", i.PullConcurrency)
+	}
+	opts = append(opts, image.WithPullConcurrency(i.PullConcurrency))
+
+	if i.StrictParse {
+		opts = append(opts, image.WithScanOptions(certificate.WithStrictParse(true)))
+	}
+
+	if i.ResolveSymlinks {
+		opts = append(opts, image.WithScanOptions(certificate.WithResolveSymlinks(true)))
+	}
+
+	if len(i.EnableParsers) > 0 || len(i.DisableParsers) > 0 {
+		parsers := certificate.WithParsers(i.EnableParsers, i.DisableParsers)
+		if err := certificate.ValidateOptions(parsers); err != nil {
+			return []image.Option{}, err
+		}
+		opts = append(opts, image.WithScanOptions(parsers))
+	}
+
+	if len(i.Include) > 0 || len(i.Exclude) > 0 {
+		opts = append(opts, image.WithScanOptions(certificate.WithPathFilter(i.Include, i.Exclude)))
+	}
+
 	return opts, nil
 }
 
@@ -36,5 +136,19 @@ func (i *Image) Options() ([]image.Option, error) {
 func RegisterImage(cmd *cobra.Command) *Image {
 	var opts Image
 	cmd.Flags().StringVar(&opts.Platform, "platform", "", "Specifies the platform in the form os/arch[/variant][:osversion] (e.g. linux/amd64)")
+	cmd.Flags().Int64Var(&opts.SpillThreshold, "spill-threshold", 1<<30, "Files larger than this size, in bytes, are written to a temporary file while they are scanned, rather than held in memory. PKCS#7, PKCS#12, NSS database, and Windows registry files larger than this are reported as partial certificates rather than decoded, as they must be read whole. Lower this on memory-constrained machines.")
+	cmd.Flags().Int64Var(&opts.MaxFileSize, "max-file-size", 0, "Files larger than this size, in bytes, are skipped without being scanned, such as large databases or media which won't contain certificates. Defaults to scanning files of any size.")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", 0, "The number of files to scan concurrently. Defaults to the number of CPUs. Each file being scanned may be held in memory, up to the spill threshold.")
+	cmd.Flags().IntVar(&opts.PullConcurrency, "pull-concurrency", 1, "The number of layers of images pulled from a registry to download at once. Above 1, layers are downloaded ahead of being scanned to temporary files, which is faster, but uses more disk space and is more likely to hit registry rate limits.")
+	cmd.Flags().StringVar(&opts.ContainerdSocket, "containerd-socket", image.DefaultContainerdSocket, "The containerd socket images given as containerd://reference are read from.")
+	cmd.Flags().StringVar(&opts.ContainerdNamespace, "containerd-namespace", image.DefaultContainerdNamespace, "The containerd namespace images given as containerd://reference are read from. Kubernetes uses k8s.io, and Docker uses moby.")
+	cmd.Flags().StringArrayVar(&opts.Include, "include", nil, "Glob pattern of the paths of files to scan, such as /etc/ssl. A pattern matching a directory includes every file below it. May be given multiple times, in which case files matching any pattern are scanned. Defaults to every file.")
+	cmd.Flags().StringArrayVar(&opts.Exclude, "exclude", nil, "Glob pattern of the paths of files not to scan, such as /usr/share/*/testdata. A pattern matching a directory excludes every file below it. May be given multiple times. Takes precedence over --include.")
+	cmd.Flags().BoolVar(&opts.StrictParse, "strict-parse", false, "Fail the scan as soon as a parser fails to scan any file. By default, such files are reported as partial certificates, and the rest of the image is still scanned.")
+	cmd.Flags().BoolVar(&opts.ResolveSymlinks, "resolve-symlinks", false, "Also report certificates at the location of every symbolic link to their file, or to a directory containing it, such as the hash-named links of OpenSSL trust stores. Links to files excluded from the scan are not resolved.")
+	cmd.Flags().StringSliceVar(&opts.EnableParsers, "enable-parser", nil, "Comma separated names of the only parsers to scan files with, such as pem,pkcs7. Defaults to every parser enabled by default. Parsers which aren't enabled by default, such as executable, are only scanned with when named here, along with the other parsers to scan with. See \"paranoia parsers\" for the names of every parser.")
+	cmd.Flags().StringSliceVar(&opts.DisableParsers, "disable-parser", nil, "Comma separated names of parsers not to scan files with, such as nss,manifest, to speed up scans or avoid false positives. Takes precedence over --enable-parser.")
+	cmd.Flags().BoolVarP(&opts.Verbose, "verbose", "v", false, "Print a summary of the scan to standard error, with the number of files and bytes scanned, and the certificates found. A scan of no files suggests the image was empty or malformed.")
+	cmd.Flags().BoolVar(&opts.Progress, "progress", false, "Periodically print the number of files scanned and certificates found to standard error while images are scanned, so that a slow scan of a large image can be seen to be making progress.")
 	return &opts
 }
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+// Inspect are options for configuring the inspect command.
+type Inspect struct {
+	// SHA256 is the SHA256 fingerprint of a single certificate to print the
+	// details of. If empty, every certificate is inspected for issues.
+	SHA256 string `json:"sha256"`
+}
+
+func RegisterInspect(cmd *cobra.Command) *Inspect {
+	var opts Inspect
+	cmd.Flags().StringVar(&opts.SHA256, "sha256", "", `
+The SHA256 fingerprint of a single certificate to print every detail of, rather than summarising issues with all certificates.
+The fingerprint is hex encoded, and may be separated by colons.
+`)
+	return &opts
+}
+
+func (i *Inspect) Validate() error {
+	if i.SHA256 == "" {
+		return nil
+	}
+	if _, err := i.Fingerprint(); err != nil {
+		return fmt.Errorf("invalid SHA256 fingerprint %q: %w", i.SHA256, err)
+	}
+	return nil
+}
+
+// Fingerprint returns the parsed SHA256 fingerprint.
+func (i *Inspect) Fingerprint() ([32]byte, error) {
+	return checksum.ParseSHA256(strings.ReplaceAll(i.SHA256, ":", ""))
+}
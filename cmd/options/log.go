@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var (
+	logLevels  = []string{"debug", "info", "warn", "error"}
+	logFormats = []string{LogFormatText, LogFormatJSON}
+)
+
+// Log are options for configuring the diagnostics logged while scanning.
+type Log struct {
+	// Level is the least severe level of message which is logged, one of
+	// "debug", "info", "warn", or "error". Defaults to "warn".
+	Level string `json:"level"`
+
+	// Format is the format messages are logged in, either "text" or "json".
+	// Defaults to "text".
+	Format string `json:"format"`
+}
+
+func RegisterLog(cmd *cobra.Command) *Log {
+	var opts Log
+	cmd.PersistentFlags().StringVar(&opts.Level, "log-level", "warn", `
+The least severe level of diagnostic message logged to standard error, one of *debug*, *info*, *warn*, or *error*.
+At *warn*, each file a parser fails to scan is logged.
+At *info*, a summary of each image layer or directory scanned is also logged.
+At *debug*, each file scanned or skipped, and why, and each certificate and partial certificate found, is also logged.
+`)
+	cmd.PersistentFlags().StringVar(&opts.Format, "log-format", LogFormatText, "Format of diagnostic messages, either *text* or *json*.")
+	return &opts
+}
+
+func (l *Log) Validate() error {
+	if !contains(logLevels, l.Level) {
+		return fmt.Errorf("invalid log level %q, must be one of %s", l.Level, strings.Join(logLevels, ", "))
+	}
+	if !contains(logFormats, l.Format) {
+		return fmt.Errorf("invalid log format %q, must be one of %s", l.Format, strings.Join(logFormats, ", "))
+	}
+	return nil
+}
+
+// Configure sets the level and format of the logger.
+func (l *Log) Configure(logger *logrus.Logger) error {
+	level, err := logrus.ParseLevel(l.Level)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(level)
+
+	if l.Format == LogFormatJSON {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		logger.SetFormatter(&logrus.TextFormatter{})
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
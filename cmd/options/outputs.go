@@ -7,13 +7,22 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/internal/output"
 )
 
 const (
-	OutputModePretty = "pretty"
-	OutputModeJSON   = "json"
-	OutputModeWide   = "wide"
-	OutputModePEM    = "pem"
+	OutputModePretty  = "pretty"
+	OutputModeJSON    = "json"
+	OutputModeWide    = "wide"
+	OutputModePEM     = "pem"
+	OutputModeSARIF   = "sarif"
+	OutputModeConfig  = "config"
+	OutputModeCSV     = "csv"
+	OutputModeSummary = "summary"
+
+	OutputModeFingerprints = "fingerprints"
+	OutputModeAttestation  = "attestation"
 )
 
 var outputModes = []string{
@@ -21,19 +30,48 @@ var outputModes = []string{
 	OutputModeJSON,
 	OutputModeWide,
 	OutputModePEM,
+	OutputModeConfig,
+	OutputModeCSV,
+	OutputModeSummary,
+	OutputModeFingerprints,
+	OutputModeAttestation,
 }
 
 // Output are options for configuring command outputs.
 type Output struct {
 	// Mode is the output format of the command. Defaults to "pretty".
 	Mode string `json:"format"`
+
+	// IncludePartials includes partial certificates in the CSV output mode.
+	IncludePartials bool `json:"includePartials"`
+
+	// Redact hides the subjects and subject alternative names of
+	// certificates in the output, keeping their fingerprints.
+	Redact bool `json:"redact"`
+
+	// RootsOnly only outputs the self-signed root certificates found, once
+	// each, in the pem and fingerprints output modes.
+	RootsOnly bool `json:"rootsOnly"`
+
+	// Digest is the digest used to print fingerprints in the fingerprints
+	// output mode. Defaults to "sha256".
+	Digest string `json:"digest"`
+
+	// AttestationConfigs are the filepath locations or HTTP(S) URLs of
+	// validation configurations, which are merged together, to validate the
+	// certificates against in the attestation output mode.
+	AttestationConfigs []string `json:"attestationConfigs"`
+
+	// OutputFile is the path of a file to write the output to, instead of
+	// stdout, in the pem output mode.
+	OutputFile string `json:"outputFile"`
 }
 
 func RegisterOutputs(cmd *cobra.Command) *Output {
 	var opts Output
 	cmd.Flags().StringVarP(&opts.Mode, "output", "o", "pretty", `
 The output mode controls how Paranoia displays the data, and what data is shown.
-Supported modes are *pretty*, *wide*, *json*, and *pem*.
+Supported modes are *pretty*, *wide*, *json*, *pem*, *config*, *csv*, *summary*, *fingerprints*, and *attestation*.
 
 *pretty*: Both certificates and partial certificates are output using a table to the terminal.
 This includes the file location (in the container) and the subject line of the certificate.
@@ -44,17 +82,85 @@ Wide includes additional columns including the SHA256 and other information.
 *json*: The JSON output mode emits only JSON to STDOUT.
 Therefore, it is suitable for piping either to file or into programs that consume JSON text.
 The output format will include a "certificates" key containing an array of certificate objects.
-Each certificate object will have keys for "fileLocation", "owner", "parser", "signature", "notBefore", "notAfter", "fingerprintSHA1", and "fingerprintSHA256".
+Each certificate object will have keys for "fileLocation", "owner", "parser", "encoding", "containerFormat", "signature", "notBefore", "notAfter", "fingerprintSHA1", "fingerprintSHA256", "fingerprintSHA512", "spkiSHA256", "keyAlgorithm", "selfSigned", and "isCA".
+The "keyAlgorithm" key is the algorithm of the certificate's public key, such as "RSA", "ECDSA", or "Ed25519", and the "keySizeBits" key is the size of the key in bits, if known.
+The "selfSigned" key is true for certificates issued and signed by their own key, such as roots, and the "isCA" key is true for certificate authorities, which may issue other certificates.
+The "encoding" key is how the certificate's data is encoded, either "PEM", "DER", or "base64" for a string value in a YAML or JSON file.
+The "containerFormat" key is the format the certificate was stored in, such as "X.509" for a certificate on its own, or "PKCS#7", "PKCS#12", "JKS", "NSS", "certdata", "Windows registry", "executable", "YAML", or "JSON".
+When the certificate was found in an image layer, the object will also have a "layerDigest" key with the digest of the layer which added it.
 Optionally, the output will include a "partials" key containing an array of partial certificate objects.
 Partial certificate objects will have keys for "fileLocation", "reason", and "parser".
+Certificates from a trust store which distrusts certificates after a given time, such as Mozilla's certdata.txt, have a "serverDistrustAfter" key with the time after which certificates they issue are distrusted for TLS servers.
+Optionally, the output will include a "distrusted" key containing an array of the certificates a trust store distrusts without including them, with keys for "fileLocation", "parser", "fingerprintSHA1", and "rejectedPurposes".
+
+*pem*: Emits every certificate found as a single concatenated PEM bundle, such as for the trust store of another tool.
+Each certificate is emitted once, even if it was found in several locations, preceded by a comment line with its subject and locations.
+In this output mode, partial certificates are omitted.
+
+*config*: Emits a configuration file for the validate command, allowing every certificate found.
+Each certificate is identified by its SHA256 fingerprint, with a comment of its subject common name.
+This is a starting point for a strict policy; delete the entries for any certificates which should not be trusted.
+In this output mode, partial certificates are omitted.
+
+*csv*: Emits a CSV document with a header row and one row for each certificate found.
+The columns are "location", "parser", "sha1", "sha256", "subjectCN", "issuerCN", "notBefore", "notAfter", "isCA", "keyType", and "keySize".
+Partial certificates are omitted unless --include-partials is set, in which case they are added as rows with a "reason" column.
+
+*summary*: Groups the certificates found by the organization of their issuer, using a table to the terminal.
+Each organization has a count of its certificates, how many are roots and intermediates, and the earliest time one of them expires.
+A certificate found in several locations is counted once.
+In this output mode, partial certificates are omitted.
 
-*pem*: Emits every certificate found in PEM format.
+*fingerprints*: Emits the hex encoded fingerprint of every certificate found, one per line, and nothing else.
+This is suitable for comparing images with standard tools such as diff, or for feeding into other pinning systems.
+The digest used is chosen with --digest.
 In this output mode, partial certificates are omitted.
+
+*attestation*: Emits an in-toto statement, whose subject is the digest of the image's manifest, for supply chain tooling.
+The predicate, of type "https://github.com/jetstack/paranoia/attestation/certificates/v1", has a "summary" key with counts of certificates and partials, and "certificates" and "partials" keys listing them with their fingerprints.
+With --attestation-config, it also has a "validation" key, with "pass", "failures", and the number of "findings" of each kind.
+The output is deterministic, so the same image always gives the same statement.
+The predicate may be signed and attached to the image with "cosign attest --type https://github.com/jetstack/paranoia/attestation/certificates/v1".
+Images must have a digest, so directories can't be attested.
+`)
+	cmd.Flags().BoolVar(&opts.IncludePartials, "include-partials", false, "Include partial certificates in the CSV output, with the reason they could not be parsed.")
+	cmd.Flags().BoolVar(&opts.Redact, "redact", false, `
+Hide the subjects and subject alternative names of certificates, replacing them with "[redacted]", so that the output can be shared without revealing internal names.
+The issuers of certificates are also hidden, as an internal certificate authority is named as the issuer of every certificate it signed, and IP address subject alternative names are removed.
+Fingerprints and counts are unchanged, so certificates can still be correlated.
+This is not supported by the *pem* output mode, as the certificates themselves include their names.
+`)
+	cmd.Flags().BoolVar(&opts.RootsOnly, "roots-only", false, `
+Only output the self-signed root certificates found, such as to build a minimal trust bundle of just the trust anchors, without intermediates or leaves.
+Each root is output once, even if it was found in several locations, ordered by SHA256 fingerprint.
+This is only supported by the *pem* and *fingerprints* output modes.
+`)
+	cmd.Flags().StringArrayVar(&opts.AttestationConfigs, "attestation-config", nil, "Path or HTTP(S) URL of a configuration file for the validate command, against which the certificates are validated in strict mode, including the result in the attestation output mode. May be given multiple times, in which case the configuration files are merged.")
+	cmd.Flags().StringVar(&opts.OutputFile, "output-file", "", `
+Path of a file to write the output to instead of stdout, such as a trust bundle for another tool.
+The output is written to a temporary file in the same directory which is then renamed, so the file is replaced in one step and is left unchanged if paranoia fails.
+This is only supported by the *pem* output mode.
 `)
+	cmd.Flags().StringVar(&opts.Digest, "digest", output.DigestSHA256, fmt.Sprintf("Digest used for fingerprints in the fingerprints output mode, one of %s.", strings.Join(output.Digests, ", ")))
 	return &opts
 }
 
 func (o *Output) Validate() error {
+	if err := o.validateDigest(); err != nil {
+		return err
+	}
+	if o.Redact && o.Mode == OutputModePEM {
+		return fmt.Errorf("--redact is not supported by the %s output mode", OutputModePEM)
+	}
+	if o.RootsOnly && o.Mode != OutputModePEM && o.Mode != OutputModeFingerprints {
+		return fmt.Errorf("--roots-only is only supported by the %s and %s output modes", OutputModePEM, OutputModeFingerprints)
+	}
+	if o.OutputFile != "" && o.Mode != OutputModePEM {
+		return fmt.Errorf("--output-file is only supported by the %s output mode", OutputModePEM)
+	}
+	if len(o.AttestationConfigs) > 0 && o.Mode != OutputModeAttestation {
+		return fmt.Errorf("--attestation-config is only supported by the %s output mode", OutputModeAttestation)
+	}
 	for _, m := range outputModes {
 		if o.Mode == m {
 			return nil
@@ -62,3 +168,12 @@ func (o *Output) Validate() error {
 	}
 	return fmt.Errorf("invalid output mode %q, must be one of %s", o.Mode, strings.Join(outputModes, ", "))
 }
+
+func (o *Output) validateDigest() error {
+	for _, d := range output.Digests {
+		if o.Digest == d {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid digest %q, must be one of %s", o.Digest, strings.Join(output.Digests, ", "))
+}
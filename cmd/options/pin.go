@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+// Pin are options for configuring the pin command.
+type Pin struct {
+	// ServerName is the name sent with server name indication, and which the
+	// server's certificate is verified for. If empty, the host is used.
+	ServerName string `json:"serverName"`
+
+	// Insecure skips verifying the server's certificate.
+	Insecure bool `json:"insecure"`
+
+	// Timeout is the time allowed for connecting to the server and
+	// completing the TLS handshake.
+	Timeout time.Duration `json:"timeout"`
+}
+
+func RegisterPin(cmd *cobra.Command) *Pin {
+	var opts Pin
+	cmd.Flags().StringVar(&opts.ServerName, "servername", "", "Server name to send with server name indication (SNI), and to verify the server's certificate for. Defaults to the host being connected to.")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure", false, "Capture the chain without verifying the server's certificate, such as for servers using a private certificate authority. The chain is then pinned as presented, which may not include its root.")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", validate.DefaultPinTimeout, "Time allowed for connecting to the server and completing the TLS handshake.")
+	return &opts
+}
+
+func (p *Pin) Validate() error {
+	if p.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive, got %s", p.Timeout)
+	}
+	return nil
+}
@@ -1,24 +1,500 @@
 package options
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/internal/util/checksum"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+var validationOutputModes = []string{
+	OutputModePretty,
+	OutputModeJSON,
+	OutputModeSARIF,
+}
+
+// ReportTokenEnv is the environment variable holding the bearer token sent
+// when posting results with --report-url, so that it isn't visible in the
+// command line.
+const ReportTokenEnv = "PARANOIA_REPORT_TOKEN"
+
+// failOnGroups are shorthands accepted by --fail-on for several kinds of
+// finding. A required certificate is required whether it is given alone or
+// in a group, so required includes both.
+var failOnGroups = map[string][]string{
+	"weak":                   {validate.FindingWeakSignature, validate.FindingWeakKey},
+	validate.FindingRequired: {validate.FindingRequired, validate.FindingRequiredAnyOf},
+}
+
+// canonicalFinding returns the kind of finding as it is named by validate,
+// accepting kebab-case spellings such as "not-allowed" for "notAllowed".
+func canonicalFinding(finding string) string {
+	parts := strings.Split(finding, "-")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
 
 // Validation are options for configuring validation command.
 type Validation struct {
-	// Config is the filepath location to the validation configuration.
-	Config string `json:"config"`
+	// Configs are the filepath locations of the validation configurations,
+	// which are merged together.
+	Configs []string `json:"configs"`
+
+	// ConfigChecksums are the SHA256 checksums of the remote validation
+	// configurations, in the order they are given in Configs.
+	ConfigChecksums []string `json:"configChecksums"`
+
+	// AllowBundles are the filepath locations of trust bundles, every
+	// certificate of which is allowed, in addition to the configs' allow
+	// lists.
+	AllowBundles []string `json:"allowBundles"`
+
+	// ForbidFeeds are the filepath locations or HTTP(S) URLs of lists of
+	// distrusted certificate fingerprints, every one of which is forbidden,
+	// in addition to the configs' forbid lists.
+	ForbidFeeds []string `json:"forbidFeeds"`
+
+	// CRLs are the filepath locations or HTTP(S) URLs of certificate
+	// revocation lists, whose revoked certificates fail validation.
+	CRLs []string `json:"crls"`
+
+	// VerifyChains verifies that every certificate which is not self-signed
+	// chains to a root, failing validation on those which do not.
+	VerifyChains bool `json:"verifyChains"`
+
+	// ChainRoots are the filepath locations of root bundles, whose
+	// certificates are trusted as roots when verifying chains, in addition
+	// to the self-signed certificates found.
+	ChainRoots []string `json:"chainRoots"`
+
+	// CheckOCSP queries the OCSP responders of certificates, failing
+	// validation on those which have been revoked.
+	CheckOCSP bool `json:"checkOCSP"`
+
+	// OCSPTimeout is the time allowed for each OCSP request.
+	OCSPTimeout time.Duration `json:"ocspTimeout"`
+
+	// OCSPConcurrency is the number of OCSP requests made at once.
+	OCSPConcurrency int `json:"ocspConcurrency"`
+
+	// Summarize collapses the certificates which were not allowed into a
+	// count and a few examples in the pretty output mode.
+	Summarize bool `json:"summarize"`
+
+	// SummaryExamples is the number of certificates which were not allowed
+	// listed when summarizing.
+	SummaryExamples int `json:"summaryExamples"`
+
+	// Redact hides the subjects and subject alternative names of
+	// certificates in the output, keeping their fingerprints.
+	Redact bool `json:"redact"`
+
+	// Silent suppresses all output when validation passes. Failures are
+	// reported as usual.
+	Silent bool `json:"silent"`
+
+	// ExitZero suppresses non-zero exit codes on validation failures.
+	ExitZero bool `json:"exitZero"`
 
 	// Quiet suppresses non-zero exit codes on validation failures.
+	//
+	// Deprecated: use ExitZero.
 	Quiet bool `json:"quiet"`
 
 	// Permissive allows any certificate that is not otherwise forbidden. This
 	// overrides the config's allow list.
 	Permissive bool `json:"permissive"`
+
+	// Output is the output format of the validation result. Defaults to
+	// "pretty".
+	Output string `json:"output"`
+
+	// Explain reports what strict mode validation would decide for each
+	// certificate, without affecting the result.
+	Explain bool `json:"explain"`
+
+	// ExitCodes maps kinds of finding to the exit code used when validation
+	// fails because of them. Kinds of finding which are not present exit
+	// with code 1.
+	ExitCodes map[string]int `json:"exitCodes"`
+
+	// FailOn are the kinds of finding which fail validation, which may
+	// include shorthands for several kinds, such as "weak". If empty, every
+	// kind of finding fails validation.
+	FailOn []string `json:"failOn"`
+
+	// AllowExpired reports expired certificates without them failing
+	// validation, such as when auditing an old image.
+	AllowExpired bool `json:"allowExpired"`
+
+	// AnchorPaths are glob patterns of the paths of trust stores, whose
+	// certificates must be valid certificate authorities. These are added to
+	// the anchor paths of the validation configuration.
+	AnchorPaths []string `json:"anchorPaths"`
+
+	// FailOnPartial records the partial certificates found as findings, so
+	// that any partial fails validation.
+	FailOnPartial bool `json:"failOnPartial"`
+
+	// Baseline is the filepath location of a baseline file, whose findings
+	// were previously accepted, so do not fail validation. If empty, no
+	// baseline is used.
+	Baseline string `json:"baseline"`
+
+	// UpdateBaseline writes the findings of the validation result to the
+	// baseline file, accepting all of them.
+	UpdateBaseline bool `json:"updateBaseline"`
+
+	// DiffConfig is the filepath location or HTTP(S) URL of a validation
+	// configuration to compare against, reporting the findings which the
+	// validation configurations add or remove. If empty, no comparison is
+	// made.
+	DiffConfig string `json:"diffConfig"`
+
+	// MetricsFile is the path of a file to write metrics describing the
+	// validation result to, in the Prometheus text format. If empty, no
+	// metrics are written.
+	MetricsFile string `json:"metricsFile"`
+
+	// ReportURL is the HTTP(S) URL the JSON validation result is posted to
+	// after each scan. If empty, the result isn't posted.
+	ReportURL string `json:"reportURL"`
+
+	// ReportTimeout is the time allowed for posting the result.
+	ReportTimeout time.Duration `json:"reportTimeout"`
+
+	// FailOnReportError fails the command if the result can't be posted.
+	// By default, such failures are only logged.
+	FailOnReportError bool `json:"failOnReportError"`
+
+	// Now is the time, as an RFC 3339 timestamp or a date, which certificates
+	// and entries are validated as of. If empty, the current time is used.
+	Now string `json:"now"`
 }
 
 func RegisterValidation(cmd *cobra.Command) *Validation {
 	var opts Validation
-	cmd.PersistentFlags().StringVarP(&opts.Config, "config", "c", ".paranoia.yaml", "Path to configuration file for Paranoia's validate mode.")
+	cmd.PersistentFlags().StringArrayVarP(&opts.Configs, "config", "c", []string{".paranoia.yaml"}, "Path or HTTP(S) URL of configuration file for Paranoia's validate mode. May be given multiple times, in which case the configuration files are merged.")
+	cmd.PersistentFlags().StringArrayVar(&opts.ConfigChecksums, "config-checksum", nil, "Hex encoded SHA256 checksum which a configuration file fetched from a URL must match. If given, it must be given once for each configuration URL, in the same order.")
+	cmd.PersistentFlags().StringArrayVar(&opts.AllowBundles, "allow-bundle", nil, `
+Path of a trust bundle, such as roots.pem, every certificate of which is allowed by its SHA256 fingerprint, in addition to the allow lists of the configuration files.
+This checks that an image only contains certificates from a known-good bundle, without writing an allow list by hand.
+Certificates in the bundle which are not found in the image are reported, but do not fail validation.
+May be given multiple times.
+`)
+	cmd.PersistentFlags().StringArrayVar(&opts.ForbidFeeds, "forbid-feed", nil, `
+Path or HTTP(S) URL of a list of distrusted certificates, every one of which is forbidden, in addition to the forbid lists of the configuration files.
+This lets a team subscribe to a shared list of distrusted certificate authorities, without transcribing their fingerprints by hand.
+The list has one SHA1, SHA256, or SHA512 fingerprint per line, optionally followed by a comment starting with "#".
+May be given multiple times.
+`)
+	cmd.PersistentFlags().StringArrayVar(&opts.CRLs, "crl", nil, `
+Path or HTTP(S) URL of a PEM or DER encoded certificate revocation list. Certificates revoked by their issuer's CRL fail validation.
+When the CRL's issuer is among the certificates found, the chain roots, or the allow bundles, the CRL's signature is checked, and a CRL which isn't signed by its issuer is not used.
+Otherwise, a warning is given that the CRL could not be authenticated.
+May be given multiple times.
+`)
+	cmd.PersistentFlags().BoolVar(&opts.VerifyChains, "verify-chains", false, `
+Verify that every certificate which is not self-signed chains to a trusted root, using the certificate authorities found in the image as intermediates.
+The self-signed certificates found are trusted as roots, along with those given by *--chain-roots*.
+Certificates for which no valid chain can be built fail validation, which catches incomplete bundles that would fail at runtime.
+`)
+	cmd.PersistentFlags().StringArrayVar(&opts.ChainRoots, "chain-roots", nil, "Path of a root bundle, such as roots.pem, whose certificates are trusted as roots by --verify-chains, in addition to the self-signed certificates found. May be given multiple times.")
+	cmd.PersistentFlags().BoolVar(&opts.CheckOCSP, "check-ocsp", false, "Query the OCSP responder of each certificate which gives one, failing validation on certificates which have been revoked. Certificates whose status cannot be determined are reported as a warning.")
+	cmd.PersistentFlags().DurationVar(&opts.OCSPTimeout, "ocsp-timeout", validate.DefaultOCSPTimeout, "Time allowed for each OCSP request made by --check-ocsp.")
+	cmd.PersistentFlags().IntVar(&opts.OCSPConcurrency, "ocsp-concurrency", validate.DefaultOCSPConcurrency, "Number of OCSP requests made at once by --check-ocsp.")
+	cmd.PersistentFlags().BoolVar(&opts.Summarize, "summarize", false, "In the pretty output mode, report certificates which were not allowed as a count with a few examples, rather than listing each of them. This keeps logs readable when an image adds many certificates at once.")
+	cmd.PersistentFlags().IntVar(&opts.SummaryExamples, "summary-examples", 3, "Number of example certificates listed by --summarize.")
+	cmd.PersistentFlags().BoolVar(&opts.Redact, "redact", false, `
+Hide the subjects and subject alternative names of certificates in every output mode, replacing them with "[redacted]", so that the result can be shared without revealing internal names.
+The issuers of certificates are also hidden, as an internal certificate authority is named as the issuer of every certificate it signed, and IP address subject alternative names are removed.
+Fingerprints and counts are unchanged, so certificates can still be correlated.
+Certificates are validated before they are redacted.
+`)
+	cmd.PersistentFlags().BoolVar(&opts.Silent, "silent", false, "Print nothing when validation passes, such as in pre-commit hooks. When validation fails, the result is printed in the chosen output mode as usual. This does not affect the exit code.")
+	cmd.PersistentFlags().BoolVar(&opts.ExitZero, "exit-zero", false, "Suppress nonzero exit code on validation failures.")
 	cmd.PersistentFlags().BoolVar(&opts.Quiet, "quiet", false, "Suppress nonzero exit code on validation failures.")
+	// --quiet is kept with its original meaning, but warns so that it can be
+	// removed in a later release.
+	_ = cmd.PersistentFlags().MarkDeprecated("quiet", "use --exit-zero instead")
 	cmd.PersistentFlags().BoolVar(&opts.Permissive, "permissive", false, "Allow any certificate that is not otherwise forbidden. This overrides the config's allow list.")
+	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", OutputModePretty, `
+The output mode controls how Paranoia reports the validation result.
+Supported modes are *pretty*, *json*, and *sarif*.
+
+*pretty*: Issues are described using human-readable text.
+
+*json*: The JSON output mode emits only JSON to STDOUT.
+The output includes "image", "scanned", and "pass" keys, along with a key for each kind of issue, such as "notAllowedCertificates", "forbiddenCertificates", and "requiredButAbsent".
+Certificate objects have keys for "fileLocation", "parser", "encoding", "containerFormat", "subject", "issuer", "notBefore", "notAfter", "fingerprintSHA1", "fingerprintSHA256", and optionally "layerDigest".
+Forbidden certificates have keys for the "certificate", the "entry" which forbade it, and the "rule" which forbade it, either "forbidByFingerprint" or "forbidByAttribute".
+With the *--explain* flag, the output also includes an "explanations" key, with a "certificate", "verdict", "reason", and "rule" for each certificate.
+With the *--allow-bundle* flag, certificates of the bundles which were not found in the image are listed under the "unseenBundleCertificates" key.
+Findings accepted by *--baseline* are listed under the "baselinedFindings" key, each with a "kind", "certificate", and optionally "fileLocation".
+With the *--diff-config* flag, the output also includes a "configDiff" key, with "added" and "removed" lists of findings, each with a "kind", "certificate", and optionally "fileLocation".
+
+*sarif*: Emits a SARIF 2.1.0 report to STDOUT, suitable for uploading to GitHub code scanning or other security dashboards.
+Each issue is reported as a result with a rule ID, such as "paranoia/forbidden-certificate", located at the certificate's file location.
+Required certificates which are absent are located at the configuration file.
+
+In every mode the exit code is the same.
+`)
+	cmd.PersistentFlags().BoolVar(&opts.Explain, "explain", false, "Report whether strict mode would allow, forbid, or not allow each certificate, and why. This is advisory, and does not affect the result, even in permissive mode.")
+	cmd.PersistentFlags().StringVar(&opts.Baseline, "baseline", "", `
+Path of a baseline file, such as baseline.json, recording findings which were previously accepted.
+Findings in the baseline are reported as informational, and only new findings fail validation, so that a policy can be rolled out gradually to images with many existing findings.
+Findings are matched by their kind and certificate fingerprint, so moving an accepted certificate within the image doesn't make it a new finding.
+Partial certificates are matched by their location.
+`)
+	cmd.PersistentFlags().BoolVar(&opts.UpdateBaseline, "update-baseline", false, "Write every finding of this run to the file given by --baseline, replacing its contents, so that they are accepted from now on.")
+	cmd.PersistentFlags().StringVar(&opts.DiffConfig, "diff-config", "", `
+Path or HTTP(S) URL of another configuration file, such as the previous version of the configuration, to validate the same certificates against.
+The findings which the configuration given by *--config* adds and removes compared to it are reported, so that the effect of a configuration change can be checked before it is made.
+This is advisory, and does not affect the result or exit code. It is not supported by the *sarif* output mode.
+`)
+	cmd.PersistentFlags().StringVar(&opts.MetricsFile, "metrics-file", "", `
+Path of a file to write metrics describing the validation result to, in the Prometheus text format.
+The metrics are gauges, such as "paranoia_certificates_found_total", "paranoia_forbidden_total", "paranoia_required_absent_total", and "paranoia_scan_duration_seconds", labelled by image.
+The file is replaced atomically, so it may be read by the node_exporter textfile collector, or pushed to a Pushgateway.
+`)
+	cmd.PersistentFlags().StringVar(&opts.ReportURL, "report-url", "", `
+HTTP(S) URL to post the validation result to after the scan, such as for a central service collecting the results of a fleet of scanners.
+The body is a JSON object with the "image", "imageDigest", "startedAt", "durationSeconds", "filesScanned", and "bytesScanned" of the scan, and the "result", which is the same as the *json* output mode.
+If the `+ReportTokenEnv+` environment variable is set, it is sent as a bearer token.
+A failure to post the result is logged, but does not change the exit code unless *--fail-on-report-error* is given.
+`)
+	cmd.PersistentFlags().DurationVar(&opts.ReportTimeout, "report-timeout", 30*time.Second, "Time allowed for posting the validation result to the URL given by --report-url.")
+	cmd.PersistentFlags().BoolVar(&opts.FailOnReportError, "fail-on-report-error", false, "Fail the command if the validation result can't be posted to the URL given by --report-url, even if validation passed.")
+	cmd.PersistentFlags().StringVar(&opts.Now, "now", "", `
+Validate as of the given time instead of the current time, such as "2025-07-01" or "2025-07-01T12:00:00Z".
+Certificate expiry and validity, the expiry of allow and forbid entries, and the update times of CRLs are all checked against it.
+This answers questions such as whether an image will still pass validation next month.
+`)
+	cmd.PersistentFlags().StringArrayVar(&opts.AnchorPaths, "anchor-path", nil, `
+Glob pattern of the paths of trust stores, such as /etc/ssl/certs, whose certificates are trusted as roots.
+Certificates found in these paths fail validation if they are not valid certificate authorities, such as a leaf certificate mistakenly added to a trusted roots directory.
+A pattern matching a directory matches every file below it.
+May be given multiple times, and is added to the "anchorPaths" of the configuration.
+`)
+	cmd.PersistentFlags().BoolVar(&opts.FailOnPartial, "fail-on-partial", false, `
+Fail validation if any partial certificate is found, reporting the location and reason of each.
+Partials are data which appears to be a certificate, but is incomplete or invalid, and files which a parser failed to read.
+They can be false positives, so by default they don't fail validation, but high-assurance environments may want to investigate every one.
+`)
+	cmd.PersistentFlags().StringSliceVar(&opts.FailOn, "fail-on", nil, `
+Kinds of finding which fail validation, such as "forbidden,required" or "forbidden,not-allowed".
+Every kind of finding is still reported, but only those given fail validation and affect the exit code.
+The kinds of finding are the same as for *--exit-code-map*, and *weak* may be given for both *weakSignature* and *weakKey*.
+*required* includes *requiredAnyOf*, as both are required certificates which are absent.
+If not given, every kind of finding fails validation.
+`)
+	cmd.PersistentFlags().BoolVar(&opts.AllowExpired, "allow-expired", false, `
+Report expired certificates, found when "checkExpiry" is set, without them failing validation, such as when auditing an old image whose certificates have all expired.
+This separates whether the image complies with the policy from whether its certificates are current.
+Every other kind of finding still fails validation, or with *--fail-on*, the kinds it gives, which must not include *expired*.
+To check an old image as it was when its certificates were current, use *--now* instead.
+`)
+	cmd.PersistentFlags().StringToIntVar(&opts.ExitCodes, "exit-code-map", nil, `
+Exit codes to use for each kind of finding which fails validation, such as "forbidden=1,required=2,notAllowed=3".
+The kinds of finding are *forbidden*, *revoked*, *required*, *requiredAnyOf*, *notAllowed*, *expired*, *notYetValid*, *overlongValidity*, *weakSignature*, *weakKey*, *unhandledCriticalExtension*, *invalidAnchor*, *unverifiableChain*, and *partial*, which is only found with *--fail-on-partial*.
+Each may also be given in kebab-case, such as *not-allowed* or *required-any-of*.
+When validation fails with several kinds of finding, the exit code of the most severe kind is used, in the order above.
+A kind of finding with an exit code of 0 does not fail the command.
+Kinds of finding which are not given exit with code 1.
+`)
 	return &opts
 }
+
+func (v *Validation) Validate() error {
+	if err := v.validateExitCodes(); err != nil {
+		return err
+	}
+	if err := v.validateFailOn(); err != nil {
+		return err
+	}
+	if err := v.validateConfigChecksums(); err != nil {
+		return err
+	}
+	if v.SummaryExamples < 0 {
+		return fmt.Errorf("summary examples must not be negative, got %d", v.SummaryExamples)
+	}
+	if v.DiffConfig != "" && v.Output == OutputModeSARIF {
+		return fmt.Errorf("--diff-config is not supported by the %s output mode", OutputModeSARIF)
+	}
+	if len(v.ChainRoots) > 0 && !v.VerifyChains {
+		return fmt.Errorf("--chain-roots requires --verify-chains")
+	}
+	if v.UpdateBaseline && v.Baseline == "" {
+		return fmt.Errorf("--update-baseline requires --baseline")
+	}
+	if v.ReportURL != "" && !strings.HasPrefix(v.ReportURL, "https://") && !strings.HasPrefix(v.ReportURL, "http://") {
+		return fmt.Errorf("invalid --report-url %q, must be a HTTP or HTTPS URL", v.ReportURL)
+	}
+	if v.FailOnReportError && v.ReportURL == "" {
+		return fmt.Errorf("--fail-on-report-error requires --report-url")
+	}
+	if v.ReportTimeout <= 0 {
+		return fmt.Errorf("report timeout must be positive, got %s", v.ReportTimeout)
+	}
+	if v.OCSPTimeout <= 0 {
+		return fmt.Errorf("OCSP timeout must be positive, got %s", v.OCSPTimeout)
+	}
+	if v.OCSPConcurrency <= 0 {
+		return fmt.Errorf("OCSP concurrency must be positive, got %d", v.OCSPConcurrency)
+	}
+	if _, err := v.ValidatorOptions(); err != nil {
+		return err
+	}
+	for _, m := range validationOutputModes {
+		if v.Output == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output mode %q, must be one of %s", v.Output, strings.Join(validationOutputModes, ", "))
+}
+
+// ValidatorOptions returns the options of validators, such as the time to
+// validate as of.
+func (v *Validation) ValidatorOptions() ([]validate.Option, error) {
+	var opts []validate.Option
+	if v.Now != "" {
+		now, err := parseTime(v.Now)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --now %q, must be an RFC 3339 timestamp or a date such as 2025-07-01", v.Now)
+		}
+		opts = append(opts, validate.WithClock(validate.FixedClock(now)))
+	}
+	return opts, nil
+}
+
+// parseTime parses an RFC 3339 timestamp, or a date, which is taken to be
+// midnight UTC.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func (v *Validation) validateConfigChecksums() error {
+	if len(v.ConfigChecksums) == 0 {
+		return nil
+	}
+
+	var remote int
+	for _, c := range v.Configs {
+		if validate.IsRemoteConfig(c) {
+			remote++
+		}
+	}
+	if len(v.ConfigChecksums) != remote {
+		return fmt.Errorf("%d config checksums given, but there are %d configuration URLs; give one checksum for each URL", len(v.ConfigChecksums), remote)
+	}
+
+	for _, c := range v.ConfigChecksums {
+		if _, err := checksum.ParseSHA256(c); err != nil {
+			return fmt.Errorf("invalid config checksum %q: %w", c, err)
+		}
+	}
+	return nil
+}
+
+// ConfigChecksum returns the checksum which the config at the given index in
+// Configs must match, or an empty string if it is a file or no checksums were
+// given.
+func (v *Validation) ConfigChecksum(i int) string {
+	if len(v.ConfigChecksums) == 0 || !validate.IsRemoteConfig(v.Configs[i]) {
+		return ""
+	}
+
+	var remote int
+	for _, c := range v.Configs[:i] {
+		if validate.IsRemoteConfig(c) {
+			remote++
+		}
+	}
+	return v.ConfigChecksums[remote]
+}
+
+func (v *Validation) validateFailOn() error {
+	for _, finding := range v.FailOn {
+		canonical := canonicalFinding(finding)
+		if canonical == validate.FindingExpired && v.AllowExpired {
+			return fmt.Errorf("--fail-on %s conflicts with --allow-expired", finding)
+		}
+		if _, ok := failOnGroups[canonical]; ok || isFinding(canonical) {
+			continue
+		}
+		return fmt.Errorf("invalid finding %q in --fail-on, must be one of %s, or weak, in camelCase or kebab-case such as not-allowed", finding, strings.Join(validate.Findings, ", "))
+	}
+	return nil
+}
+
+// SuppressExitCode returns true if validation failures should not give a
+// non-zero exit code.
+func (v *Validation) SuppressExitCode() bool {
+	return v.ExitZero || v.Quiet
+}
+
+// FailOnFindings returns the kinds of finding which fail validation, with
+// shorthands expanded. If empty, every kind of finding fails validation.
+// With AllowExpired, expired certificates never fail validation.
+func (v *Validation) FailOnFindings() []string {
+	var findings []string
+	for _, finding := range v.FailOn {
+		finding = canonicalFinding(finding)
+		if group, ok := failOnGroups[finding]; ok {
+			findings = append(findings, group...)
+		} else {
+			findings = append(findings, finding)
+		}
+	}
+
+	if v.AllowExpired && len(findings) == 0 {
+		for _, finding := range validate.Findings {
+			if finding != validate.FindingExpired {
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+func isFinding(finding string) bool {
+	for _, f := range validate.Findings {
+		if finding == f {
+			return true
+		}
+	}
+	return false
+}
+
+// FindingExitCodes returns the exit codes of each kind of finding, with
+// kebab-case spellings of kinds of finding given in camelCase.
+func (v *Validation) FindingExitCodes() map[string]int {
+	codes := make(map[string]int, len(v.ExitCodes))
+	for finding, code := range v.ExitCodes {
+		codes[canonicalFinding(finding)] = code
+	}
+	return codes
+}
+
+func (v *Validation) validateExitCodes() error {
+	for finding, code := range v.ExitCodes {
+		if !isFinding(canonicalFinding(finding)) {
+			return fmt.Errorf("invalid finding %q in exit code map, must be one of %s, in camelCase or kebab-case such as not-allowed", finding, strings.Join(validate.Findings, ", "))
+		}
+		if code < 0 || code > 255 {
+			return fmt.Errorf("invalid exit code %d for finding %q, must be between 0 and 255", code, finding)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package options
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func TestValidation_FailOnFindings(t *testing.T) {
+	t.Run("every kind of finding fails validation by default", func(t *testing.T) {
+		assert.Empty(t, (&Validation{}).FailOnFindings())
+	})
+
+	t.Run("shorthands are expanded", func(t *testing.T) {
+		v := &Validation{FailOn: []string{validate.FindingForbidden, "weak"}}
+		assert.Equal(t, []string{validate.FindingForbidden, validate.FindingWeakSignature, validate.FindingWeakKey}, v.FailOnFindings())
+	})
+
+	t.Run("kebab-case kinds of finding are accepted", func(t *testing.T) {
+		v := &Validation{FailOn: []string{"not-allowed", "required-any-of", "weak-key"}}
+		assert.Equal(t, []string{validate.FindingNotAllowed, validate.FindingRequiredAnyOf, validate.FindingWeakKey}, v.FailOnFindings())
+	})
+
+	t.Run("required includes requiredAnyOf", func(t *testing.T) {
+		v := &Validation{FailOn: []string{validate.FindingRequired}}
+		assert.Equal(t, []string{validate.FindingRequired, validate.FindingRequiredAnyOf}, v.FailOnFindings())
+	})
+
+	t.Run("expired findings are dropped with --allow-expired when --fail-on is empty", func(t *testing.T) {
+		findings := (&Validation{AllowExpired: true}).FailOnFindings()
+		assert.NotContains(t, findings, validate.FindingExpired)
+		assert.Len(t, findings, len(validate.Findings)-1)
+	})
+
+	t.Run("--fail-on is unchanged by --allow-expired", func(t *testing.T) {
+		v := &Validation{FailOn: []string{validate.FindingNotAllowed}, AllowExpired: true}
+		assert.Equal(t, []string{validate.FindingNotAllowed}, v.FailOnFindings())
+	})
+}
+
+func TestValidation_validateFailOn(t *testing.T) {
+	t.Run("known kinds of finding and shorthands are accepted", func(t *testing.T) {
+		v := &Validation{FailOn: []string{validate.FindingExpired, "weak", "not-allowed", "notAllowed"}}
+		assert.NoError(t, v.validateFailOn())
+	})
+
+	t.Run("unknown kinds of finding are rejected", func(t *testing.T) {
+		v := &Validation{FailOn: []string{"unknown"}}
+		assert.ErrorContains(t, v.validateFailOn(), `invalid finding "unknown" in --fail-on`)
+		assert.ErrorContains(t, v.validateFailOn(), "kebab-case such as not-allowed")
+	})
+
+	t.Run("expired is rejected with --allow-expired", func(t *testing.T) {
+		v := &Validation{FailOn: []string{validate.FindingForbidden, validate.FindingExpired}, AllowExpired: true}
+		assert.EqualError(t, v.validateFailOn(), "--fail-on expired conflicts with --allow-expired")
+	})
+}
+
+func TestValidation_FindingExitCodes(t *testing.T) {
+	t.Run("kebab-case kinds of finding are accepted", func(t *testing.T) {
+		v := &Validation{ExitCodes: map[string]int{"not-allowed": 3, validate.FindingForbidden: 1}}
+		assert.NoError(t, v.validateExitCodes())
+		assert.Equal(t, map[string]int{validate.FindingNotAllowed: 3, validate.FindingForbidden: 1}, v.FindingExitCodes())
+	})
+
+	t.Run("unknown kinds of finding are rejected", func(t *testing.T) {
+		v := &Validation{ExitCodes: map[string]int{"not-a-finding": 3}}
+		assert.ErrorContains(t, v.validateExitCodes(), `invalid finding "not-a-finding" in exit code map`)
+	})
+}
+
+func TestValidation_AllowExpiredExitCode(t *testing.T) {
+	expiredOnly := func(v *Validation) validate.Result {
+		return validate.Result{
+			ExpiredCertificates: []certificate.Found{{Location: "/etc/ssl/certs/expired.pem"}},
+			FailOn:              v.FailOnFindings(),
+		}
+	}
+
+	t.Run("an expired certificate fails validation by default", func(t *testing.T) {
+		v := &Validation{ExitCodes: map[string]int{validate.FindingExpired: 4}}
+		res := expiredOnly(v)
+		assert.False(t, res.IsPass())
+		assert.Equal(t, 4, res.ExitCode(v.ExitCodes))
+	})
+
+	t.Run("an expired certificate exits with code 0 with --allow-expired", func(t *testing.T) {
+		v := &Validation{AllowExpired: true, ExitCodes: map[string]int{validate.FindingExpired: 4}}
+		res := expiredOnly(v)
+		assert.True(t, res.IsPass())
+		assert.Equal(t, []string{validate.FindingExpired}, res.Kinds())
+		assert.Equal(t, 0, res.ExitCode(v.ExitCodes))
+	})
+}
+
+func TestValidation_Quiet(t *testing.T) {
+	parse := func(t *testing.T, args ...string) *Validation {
+		cmd := &cobra.Command{}
+		v := RegisterValidation(cmd)
+		require.NoError(t, cmd.ParseFlags(args))
+		return v
+	}
+
+	t.Run("the deprecated --quiet suppresses the exit code", func(t *testing.T) {
+		v := parse(t, "--quiet")
+		assert.True(t, v.SuppressExitCode())
+		assert.False(t, v.Silent)
+	})
+
+	t.Run("--exit-zero suppresses the exit code", func(t *testing.T) {
+		assert.True(t, parse(t, "--exit-zero").SuppressExitCode())
+	})
+
+	t.Run("--silent only suppresses output", func(t *testing.T) {
+		v := parse(t, "--silent")
+		assert.True(t, v.Silent)
+		assert.False(t, v.SuppressExitCode())
+	})
+}
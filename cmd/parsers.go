@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func newParsers(_ context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:   "parsers",
+		Short: "List the parsers used to find certificates, and the files they scan",
+		Long: `
+List every parser Paranoia uses to find certificates in images, with the files each parser scans and what it finds in them.
+The name of each parser is recorded on the certificates it finds, as shown by the "parser" column of export.
+Every file is given to every enabled parser which scans it, so a certificate may be found by more than one parser.
+Parsers which are not enabled by default, such as executable, which reads whole binaries and is prone to false positives, are only used when named by --enable-parser.
+`,
+		Example: `
+	$ paranoia parsers
+
+Also scan executables for compiled in certificates, along with every parser enabled by default:
+
+	$ paranoia export --enable-parser pem,pkcs7,jks,pkcs12,nss,certdata,winregistry,manifest,executable example.com/image:v0.1.0
+`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+			columnFmt := color.New(color.FgYellow).SprintfFunc()
+
+			tbl := table.New("Parser", "Enabled", "Scans", "Finds")
+			tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
+			for _, p := range certificate.Parsers() {
+				enabled := "no"
+				if p.EnabledByDefault {
+					enabled = "yes"
+				}
+				tbl.AddRow(p.Name, enabled, p.Targets, p.Description)
+			}
+			tbl.Print()
+			return nil
+		},
+	}
+}
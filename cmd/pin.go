@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jetstack/paranoia/cmd/options"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func newPin(ctx context.Context) *cobra.Command {
+	var pinOpts *options.Pin
+
+	cmd := &cobra.Command{
+		Use:   "pin [flags] host:port",
+		Short: "Print a configuration for the validate command pinning a server's certificate authorities",
+		Long: `
+Connect to a TLS server, capture the certificate chain it presents, and print a configuration file for the validate command pinning its certificate authorities by their SHA256 fingerprints.
+Each entry is commented with the certificate's subject.
+
+The root certificate of the chain is put in the "require" list, so that validation fails on images which can't verify connections to the server.
+Any intermediate certificates are put in the "allow" list.
+The server's own certificate is not pinned, unless it is self-signed.
+
+By default the chain is verified against the system's certificate authorities, and ends with the trusted root, even if the server doesn't present it.
+With --insecure the chain is not verified, and is pinned as presented, so the last certificate presented is required.
+`,
+		Example: `
+Require the certificate authority of an upstream API in scanned images:
+
+	$ paranoia pin api.example.com:443 > .paranoia.yaml
+	$ paranoia validate example.com/image:v0.1.0
+
+Capture the chain of an internal server using a private certificate authority, by its address:
+
+	$ paranoia pin --insecure --servername internal.example.com 10.0.0.1:8443
+`,
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(_ *cobra.Command, _ []string) error {
+			return pinOpts.Validate()
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			addr := args[0]
+			fetcher := validate.ChainFetcher{
+				ServerName: pinOpts.ServerName,
+				Insecure:   pinOpts.Insecure,
+				Timeout:    pinOpts.Timeout,
+			}
+			chain, err := fetcher.Fetch(ctx, addr)
+			if err != nil {
+				return errors.Wrapf(err, "failed to fetch certificate chain of %s", addr)
+			}
+
+			fmt.Printf("# Pinned from the certificate chain of %s\n", addr)
+			enc := yaml.NewEncoder(os.Stdout)
+			enc.SetIndent(2)
+			if err := enc.Encode(validate.PinConfig(chain)); err != nil {
+				return errors.Wrap(err, "failed to marshal config")
+			}
+			return enc.Close()
+		},
+	}
+
+	pinOpts = options.RegisterPin(cmd)
+
+	return cmd
+}
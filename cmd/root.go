@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+
+	"github.com/jetstack/paranoia/cmd/options"
 )
 
 func NewRoot(ctx context.Context) *cobra.Command {
@@ -46,6 +49,8 @@ Certificates in intermediate layers which are removed or replaced in later layer
 Paranoia can also detect "partial" certificates.
 A partial certificate is where Paranoia has detected data that appears to be a certificate but is incomplete or invalid.
 These can be false-positives, but are often worthy of further investigation.
+Files which a parser fails to read, such as a malformed keystore, are also reported as partial certificates, so that one bad file doesn't stop the rest of the image being scanned.
+Use --strict-parse to fail instead.
 
 ## LOCAL IMAGES
 
@@ -56,12 +61,40 @@ Paranoia can also read from STDIN to handle local images that are exported as ta
 To enable this behaviour, use "-" as the image name.
 
 	$ docker save my-local-image:sometag | paranoia export -
+
+Images may also be given with a scheme to read them from elsewhere:
+
+- file://path reads an image tar file, such as one written by "docker save", or by "skopeo copy" to a docker-archive.
+  The layers are read in the order given by the tar file's manifest.json, so each certificate is attributed to the topmost layer containing it, and the tar file's own metadata is never scanned.
+  Image tar files, whether given with file:// or on standard input, may be gzip compressed.
+  They may also be OCI image layouts archived as a tar file, such as one written by "podman save --format oci-archive", which is detected automatically.
+- oci://path[:reference] reads an OCI image layout directory.
+- dir://path scans a directory, such as an unpacked root filesystem.
+- bundle://path scans a single file, such as a PEM bundle of certificates.
+- containerd://reference reads an image from the containerd content store, such as on a Kubernetes node, without exporting it.
+  The socket and namespace are set with --containerd-socket and --containerd-namespace.
+
+	$ paranoia export containerd://docker.io/library/alpine:latest
 `,
 	}
 
+	logOpts := options.RegisterLog(root)
+	root.PersistentPreRunE = func(_ *cobra.Command, _ []string) error {
+		if err := logOpts.Validate(); err != nil {
+			return err
+		}
+		return logOpts.Configure(logrus.StandardLogger())
+	}
+
 	root.AddCommand(newExport(ctx))
 	root.AddCommand(newInspect(ctx))
 	root.AddCommand(newValidation(ctx))
+	root.AddCommand(newValidateConfig(ctx))
+	root.AddCommand(newConfig(ctx))
+	root.AddCommand(newDiff(ctx))
+	root.AddCommand(newPin(ctx))
+	root.AddCommand(newParsers(ctx))
+	root.AddCommand(newBrowse(ctx))
 
 	return root
 }
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jetstack/paranoia/cmd/options"
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/image"
+)
+
+// printScanStats prints a one-line summary of the scan of an image to
+// standard error, so that it doesn't interfere with machine readable output.
+// A scan of no files suggests the input was empty or malformed.
+func printScanStats(imageName string, parsed *certificate.ParsedCertificates) {
+	s := parsed.Stats
+	fmt.Fprintf(os.Stderr, "Scanned %d files (%d bytes) in %s, skipping %d other entries, and found %d certificates and %d partial certificates\n",
+		s.Files, s.Bytes, imageName, s.SkippedFiles, len(parsed.Found), len(parsed.Partials))
+	if s.SkippedLarge > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d files in %s larger than the maximum file size\n", s.SkippedLarge, imageName)
+	}
+}
+
+// progressInterval is how often progress is printed with --progress.
+const progressInterval = 2 * time.Second
+
+// findImageCertificates finds the certificates in an image, printing the
+// progress of the scan to standard error as it runs if --progress is given.
+func findImageCertificates(ctx context.Context, imageName string, imgOpts *options.Image, iOpts []image.Option) (*certificate.ParsedCertificates, error) {
+	if !imgOpts.Progress {
+		return image.FindImageCertificates(ctx, imageName, iOpts...)
+	}
+
+	var progress certificate.Progress
+	stop := progress.Report(progressInterval, func(c certificate.ProgressCounts) {
+		fmt.Fprintf(os.Stderr, "Scanning %s: %d files scanned, %d certificates and %d partial certificates found\n",
+			imageName, c.Files, c.Certificates, c.Partials)
+	})
+	defer stop()
+
+	iOpts = append(append([]image.Option{}, iOpts...), image.WithScanOptions(certificate.WithProgress(&progress)))
+	return image.FindImageCertificates(ctx, imageName, iOpts...)
+}
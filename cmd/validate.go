@@ -4,15 +4,20 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/jetstack/paranoia/cmd/options"
+	"github.com/jetstack/paranoia/internal/certificate"
 	"github.com/jetstack/paranoia/internal/image"
+	"github.com/jetstack/paranoia/internal/output"
 	"github.com/jetstack/paranoia/internal/validate"
 )
 
@@ -32,31 +37,67 @@ If the policy is violated, then Paranoia will output issues to the command line
 ## POLICY
 
 Paranoia can do three different things with certificates in this mode.
-Certificates are generally identified by either their SHA256 or SHA1 fingerprints.
-SHA256 is preferred where possible.
+Certificates are generally identified by either their SHA512, SHA256, or SHA1 fingerprints.
+SHA256 or SHA512 is preferred where possible.
 
 ### Require
 
 If a certificate is required then Paranoia will fail if it is not present in the container.
 As a reminder, this does not guarantee that the program will correctly trust this certificate, just that it is present.
 
+Groups of certificates may also be given, of which at least one must be present, such as redundant trust anchors.
+Paranoia will fail if none of the certificates in a group are present in the container.
+
 ### Allow
 
 Allow a certificate, giving no error if it is found.
-Required certificates are implicitly allowed, there is no need to duplicate the entry.
+Required certificates, including those in groups, are implicitly allowed, there is no need to duplicate the entry.
 
 By default, Paranoia will error on any certificate not explicitly allowed (or required).
 The *--permissive* flag will disable this behaviour, and allow any certificate not explicitly forbidden.
 
+The *--allow-bundle* flag allows every certificate in a trust bundle, such as a known-good roots.pem, as well as those allowed by the configuration file.
+Certificates in the bundle which are not found in the image are reported, but do not fail validation.
+
 ### Forbid
 
 Forbid a certificate.
 Paranoia will always error if it finds a forbidden certificate in a container image.
 
+The *--forbid-feed* flag forbids every certificate in a shared list of distrusted certificates, from a file or HTTP(S) URL, as well as those forbidden by the configuration file.
+The list has one SHA1, SHA256, or SHA512 fingerprint per line, optionally followed by a comment starting with "#".
+Fingerprints given more than once are forbidden once, and a list with an invalid fingerprint is rejected.
+
+### Revoke
+
+The *--crl* flag gives certificate revocation lists (CRLs), from files or HTTP(S) URLs, in PEM or DER form.
+Paranoia will error if it finds a certificate which is revoked by a CRL from its issuer.
+A CRL whose next update time has passed may not list every revoked certificate, so it is not used, and a warning is printed instead.
+
+The *--check-ocsp* flag also queries the OCSP responder of each certificate which gives one, and Paranoia will error if the responder reports the certificate as revoked.
+The issuer of a certificate must be in the image for its responder to be queried.
+Self-signed certificates are not checked.
+When a certificate's revocation status cannot be determined, such as because its responder is unreachable, a warning is printed instead.
+
+### Verify Chains
+
+The *--verify-chains* flag verifies that every certificate which is not self-signed chains to a trusted root, as a TLS client would.
+The certificate authorities found in the image are used as intermediates, and the self-signed certificates found are trusted as roots.
+Further roots may be given with *--chain-roots*, such as a roots.pem which the image's certificates are expected to chain to.
+Paranoia will error if it finds a certificate for which no valid chain can be built, such as an intermediate whose issuer is missing from the bundle.
+Chains are verified as of the current time, or the time given by *--now*, so expired certificates can't be verified.
+
 ## CONFIGURATION FILE
 
 The configuration file is a YAML formatted text file.
 By default Paranoia uses a file named .paranoia.yaml in the working directory, but the *--config* flag can be used to override this.
+The *--config* flag may be given multiple times, such as for a shared baseline policy and team specific overrides.
+The allow, forbid, require, and requireAnyOf lists of each file are combined, and where files disagree on a setting, the strictest is used.
+It is an error for a certificate to be allowed or required by one file, but forbidden by another.
+
+A configuration file may also be fetched from a HTTP or HTTPS URL, such as for a policy managed centrally for a fleet of images.
+The *--config-checksum* flag gives the SHA256 checksum the fetched file must match, so that it cannot be changed without the change being noticed.
+Fetched files are not cached.
 
 This file should contain a "version" key at the root level.
 Presently this should be set to the string "1".
@@ -65,10 +106,99 @@ Future versions of Paranoia may use different values for this key.
 Next it may contain the "require", "allow", and "forbid" keys.
 The behaviour of these keys is described above.
 Each of these keys is a list of certificate entries.
+It may also contain a "requireAnyOf" key, which is a list of groups, each of which is a list of certificate entries.
+At least one certificate of each group must be present.
 
 Each certificate entry may contain the key "comment" with any commentary about the certificate.
-It must contain a "fingerprints" key, with one of "sha1" or "sha256" containing the SHA1 or SHA256 fingerprint of the certificate respectively.
-If both SHA1 and SHA256 fingerprints are given, the SHA1 is ignored.`,
+It must contain a "fingerprints" key, with exactly one of "sha1", "sha256", or "sha512" containing the SHA1, SHA256, or SHA512 fingerprint of the certificate respectively.
+Alternatively the "fingerprints" key may contain "spkiSha256", the SHA256 digest of the certificate's Subject Public Key Info.
+This pins the certificate's key rather than the certificate itself, so continues to match when the certificate is reissued with the same key.
+Fingerprints may be upper or lower case, and may separate bytes with colons or spaces, as copied from browsers and openssl, such as "AB:CD:EF".
+For interoperability with older tools, the "fingerprints" key may instead contain "md5", the MD5 fingerprint of the certificate.
+MD5 is insecure, and should only be used to match hashes supplied by other systems.
+For rapid triage of advisories which give a short fingerprint, the "fingerprints" key may instead contain "sha256Prefix".
+This matches every certificate whose SHA256 fingerprint starts with the given hex prefix.
+Paranoia warns about prefixes shorter than 8 characters, as these are likely to match unrelated certificates.
+The "fingerprints" key may also be a list of such fingerprints, such as those of a root across its reissuances, in which case the entry matches a certificate with any of them.
+Forbidden certificates are reported with the fingerprint which matched.
+
+Instead of a fingerprint, entries may identify certificates by their subject common name.
+The "subjectCN" key matches a common name exactly, and the "subjectCNPattern" key matches a common name against a glob pattern, such as "*.corp.internal".
+Entries may also identify certificates by their issuer, with the "issuerDN" key matching the issuer's distinguished name exactly, such as "CN=Example CA,O=Example,C=US".
+This is useful for forbidding every certificate issued by a compromised certificate authority.
+Together with "issuerDN", the "serialNumber" key identifies a single certificate as its certificate authority does, such as from a vendor advisory.
+Serial numbers are decimal, or hex with a "0x" prefix or bytes separated by colons, such as "0x0a3f" or "0a:3f".
+For more complex policies, the "subjectRegex" key matches the subject's distinguished name against a regular expression.
+Similarly the "sanRegex" key matches when any DNS name, IP address, or email address subject alternative name matches a regular expression.
+Regular expressions are not anchored, so use "^" and "$" to match a whole value.
+The "policyOID" key matches certificates asserting a certificate policy, given as a dotted decimal object identifier such as "2.23.140.1.1" for extended validation.
+A certificate asserting several policies matches if any of them is the given one, and a certificate asserting none never matches.
+This is useful for forbidding certificates issued under a deprecated internal policy.
+When an entry contains several of these keys, a certificate must match all of them.
+An entry cannot contain both a fingerprint and subject or issuer keys.
+When a certificate matches several entries, the entry with the highest precedence decides it, in this order:
+
+1. A forbid entry matching the certificate's fingerprint.
+2. A forbid entry matching the certificate's subject, issuer, or other attributes.
+3. An allow or require entry matching the certificate's fingerprint.
+4. An allow entry matching the certificate's attributes.
+
+So a certificate matching any forbid entry is forbidden, even if it is also allowed by fingerprint.
+Forbidden certificates are reported with the rule which forbade them, and *--explain* reports the rule which decided each certificate.
+However, a configuration which allows or requires a fingerprint that it also forbids is rejected, as this is almost always a mistake.
+A required entry identified this way is satisfied by any certificate which matches it, and is reported by its subject or issuer when none do.
+
+Allow and forbid entries may contain an "expiresAt" key, such as "2024-06-30" or "2024-06-30T12:00:00Z", for temporary exceptions.
+Once an entry has expired it is ignored, so no longer allows or forbids certificates, and a warning is printed so that it can be removed or renewed.
+
+Allow and forbid entries may also contain a "scope" key of "root", "intermediate", "leaf", or "any", to match only certificates acting as such.
+Roots are self-signed certificates, intermediates are other certificate authorities, and leaves are every other certificate, so an entry with a "root" scope can forbid a certificate only where it is trusted as a root.
+
+The configuration file may also contain a "checkExpiry" key.
+When set to true, Paranoia will error on any certificate which has expired.
+The *--allow-expired* flag reports expired certificates without them failing validation, such as when auditing an old image.
+An "expiryWarning" key, such as "720h", may also be given to warn about certificates which will expire within that window.
+
+The configuration file may also contain a "checkNotYetValid" key.
+When set to true, Paranoia will error on any certificate whose validity period has not yet started, which suggests clock skew or a forged certificate.
+
+These checks, and the expiry of entries, are made against the current time, unless the *--now* flag gives another time to validate as of.
+
+The configuration file may also contain a "maxValidityDuration" key, such as "9600h".
+Paranoia will error on any certificate whose validity period, from its "not before" to its "not after" time, is longer than this.
+Long-lived self-signed roots may be exempted by setting "maxValidityExemptSelfSigned" to true.
+
+The configuration file may also contain a "forbidWeakSignatureAlgorithms" key.
+When set to true, Paranoia will error on any certificate signed using a weak algorithm, such as SHA1 or MD5.
+Self-signed certificates are exempt, as their signature is not relied upon, unless "weakSignatureIncludeSelfSigned" is also set to true.
+
+The configuration file may also contain a "minRSAKeySize" key, with the minimum permitted size of RSA keys in bits.
+Similarly an "allowedECDSACurves" key may contain a list of permitted ECDSA curve names, such as "P-256" and "P-384".
+Paranoia will error on any certificate with a public key which doesn't meet these requirements.
+
+The configuration file may also contain a "forbidUnhandledCriticalExtensions" key.
+When set to true, Paranoia will error on any certificate with an extension marked critical which it doesn't understand, listing the extension OIDs.
+Such certificates must be rejected by verifiers which follow RFC 5280, so are unusable by most TLS clients.
+
+The configuration file may also contain a "warnSuspiciousSANs" key.
+When set to true, Paranoia will warn about any certificate with subject alternative names only meaningful on a private network, listing them.
+These are IP addresses in private, loopback, or link-local ranges, such as those of RFC 1918, and names under reserved or internal domains, such as ".local", ".internal", and ".home.arpa".
+Such certificates in a distributable image are often internal certificates which were leaked by mistake.
+The warnings do not fail validation.
+
+The configuration file may also contain a "warnKeyIdAnomalies" key.
+When set to true, Paranoia will warn about certificate authorities without a subject key identifier, and about certificates which share a subject key identifier with a certificate with a different public key.
+Verifiers find a certificate's issuer by its key identifier, so either can break chain building, which is a common trust store construction bug.
+Certificates with the same key, such as a root and its cross-signed copy, may share an identifier.
+The warnings do not fail validation.
+
+The configuration file may also contain an "anchorPaths" key, with a list of glob patterns of the paths of trust stores, such as "/etc/ssl/certs".
+Paranoia will error on any certificate found in these paths which is not a valid certificate authority, as its basic constraints don't mark it as one, or its key usage doesn't permit signing certificates.
+This catches leaf certificates mistakenly added to a trusted roots directory.
+Anchor paths may also be given with --anchor-path.
+
+The configuration file may also contain a "pkcs12Passwords" key, with a list of candidate passwords.
+These are tried in turn when decoding password protected PKCS#12 files found in the image.`,
 		Example: `
 An example configuration file: 
 
@@ -81,6 +211,13 @@ An example configuration file:
 	  - comment: "ISRG X1 Root"
 	    fingerprints:
 	      sha256: 96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6
+	requireAnyOf:
+	  - - comment: "Corporate Root CA 1"
+	      fingerprints:
+	        sha256: 0cb0a1e1a1fcd54c1e8d4a55e0b3ec4e29ab1e3f4f1b4a6c4ff24b2f0c9b3b31
+	    - comment: "Corporate Root CA 2"
+	      fingerprints:
+	        sha256: 5d8a7bd5e1a9c1b0b6bdbd7ab1c6c0f4f1e9b6f3e2a3f1c8ce4fd3b2f8e7d6a5
 	forbid:
 	  - comment: "An internal-only cert"
 	    fingerprints:
@@ -90,24 +227,146 @@ Validating a locally built image, using the implicit .paranoia.yaml configuratio
 
 	$ docker build . -t example.com/image:v0.1.0
 	$ docker save example.com/image:v0.1.0 | paranoia validate -
+
+Validating an image against a central policy, which must match a known checksum:
+
+	$ paranoia validate --config https://example.com/policy/paranoia.yaml --config-checksum 5e2b3f0d0e5ab7c1f1a4c1d4d6f0c1e2b3a4d5e6f708192a3b4c5d6e7f809102 example.com/image:v0.1.0
+
+Checking what strict mode would report, before removing the --permissive flag:
+
+	$ paranoia validate --permissive --explain example.com/image:v0.1.0
+
+Recording metrics for the node_exporter textfile collector, when validating on a schedule:
+
+	$ paranoia validate --metrics-file /var/lib/node_exporter/textfile/paranoia.prom example.com/image:v0.1.0
+
+Posting the result to a central service collecting the results of a fleet of scanners:
+
+	$ PARANOIA_REPORT_TOKEN=... paranoia validate --report-url https://paranoia.example.com/results example.com/image:v0.1.0
+
+Validating an image in a pre-commit hook, printing nothing unless validation fails:
+
+	$ paranoia validate --silent example.com/image:v0.1.0
+
+Checking that an image only contains certificates from a known-good bundle:
+
+	$ paranoia validate --allow-bundle roots.pem example.com/image:v0.1.0
+
+Checking whether an image will still pass validation, with expiry checked, at the start of next month:
+
+	$ paranoia validate --now 2025-07-01 example.com/image:v0.1.0
+
+Forbidding the certificate authorities in a shared list of distrusted certificates:
+
+	$ paranoia validate --forbid-feed https://example.com/distrusted-cas.txt example.com/image:v0.1.0
+
+Checking that every certificate in an image chains to a root in a known-good bundle:
+
+	$ paranoia validate --verify-chains --chain-roots roots.pem example.com/image:v0.1.0
+
+Checking that no certificate authority in an image has been revoked:
+
+	$ paranoia validate --crl https://example.com/root-ca.crl example.com/image:v0.1.0
+
+Auditing an old image, whose certificates have all expired, against the current policy:
+
+	$ paranoia validate --allow-expired example.com/image:v0.1.0
+
+Keeping CI logs readable when a base image adds many certificates which are not allowed:
+
+	$ paranoia validate --summarize example.com/image:v0.1.0
+
+Reporting every issue, but only failing on forbidden or revoked certificates:
+
+	$ paranoia validate --fail-on forbidden,revoked example.com/image:v0.1.0
+
+Checking which findings a change to the configuration would add or remove, before committing it:
+
+	$ git show HEAD:.paranoia.yaml > /tmp/old.yaml
+	$ paranoia validate --diff-config /tmp/old.yaml example.com/image:v0.1.0
+
+Sharing a validation report without revealing internal hostnames:
+
+	$ paranoia validate --redact --output sarif example.com/image:v0.1.0 > report.sarif
+
+Distinguishing forbidden certificates from missing required certificates in CI:
+
+	$ paranoia validate --exit-code-map forbidden=1,required=2,notAllowed=3 example.com/image:v0.1.0
 `,
 		PreRunE: func(_ *cobra.Command, args []string) error {
 			if err := options.MustSingleImageArgs(args); err != nil {
 				return err
 			}
-			return nil
+			return valOpts.Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			validateConfig, err := validate.LoadConfig(valOpts.Config)
+			var configs []validate.Config
+			for i, path := range valOpts.Configs {
+				config, err := loadConfig(ctx, path, valOpts.ConfigChecksum(i))
+				if err != nil {
+					return errors.Wrapf(err, "failed to load validator config %s", path)
+				}
+				configs = append(configs, *config)
+			}
+			bundles, err := loadAllowBundles(ctx, valOpts.AllowBundles)
+			if err != nil {
+				return err
+			}
+			for _, b := range bundles {
+				configs = append(configs, b.Config())
+			}
+			feeds, err := loadForbidFeeds(ctx, valOpts.ForbidFeeds)
 			if err != nil {
-				return errors.Wrap(err, "failed to load validator config")
+				return err
+			}
+			for _, f := range feeds {
+				configs = append(configs, f.Config())
+			}
+
+			validateConfig, err := validate.MergeConfigs(configs...)
+			if err != nil {
+				return errors.Wrap(err, "failed to merge validator configs")
+			}
+			validateConfig.AnchorPaths = append(validateConfig.AnchorPaths, valOpts.AnchorPaths...)
+			for _, w := range validate.ConfigWarnings(&validateConfig) {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
 			}
 
-			validator, err := validate.NewValidator(*validateConfig, valOpts.Permissive)
+			validatorOpts, err := valOpts.ValidatorOptions()
+			if err != nil {
+				return err
+			}
+			var roots []certificate.Found
+			if valOpts.VerifyChains {
+				roots, err = loadChainRoots(ctx, valOpts.ChainRoots)
+				if err != nil {
+					return err
+				}
+				validatorOpts = append(validatorOpts, validate.WithChainVerification(roots))
+			}
+			validator, err := validate.NewValidator(validateConfig, valOpts.Permissive, validatorOpts...)
 			if err != nil {
 				return errors.Wrap(err, "failed to initialise validator")
 			}
-			fmt.Println("Validating certificates with " + validator.DescribeConfig())
+
+			// The baseline is loaded before scanning, so that a missing file
+			// is noticed straight away, unless it's about to be written.
+			var baseline *validate.Baseline
+			if valOpts.Baseline != "" && !valOpts.UpdateBaseline {
+				baseline, err = validate.LoadBaseline(valOpts.Baseline)
+				if err != nil {
+					return errors.Wrapf(err, "failed to load baseline %s", valOpts.Baseline)
+				}
+			}
+
+			var crls []*validate.RevocationList
+			for _, location := range valOpts.CRLs {
+				crl, err := validate.LoadRevocationList(ctx, location)
+				if err != nil {
+					return errors.Wrapf(err, "failed to load CRL %s", location)
+				}
+				crls = append(crls, crl)
+			}
 
 			imageName := args[0]
 
@@ -115,65 +374,166 @@ Validating a locally built image, using the implicit .paranoia.yaml configuratio
 			if err != nil {
 				return errors.Wrap(err, "constructing image options")
 			}
+			iOpts = append(iOpts, image.WithScanOptions(certificate.WithPKCS12Passwords(validateConfig.Pkcs12Passwords)))
+
+			start := time.Now()
 
-			// Validate operates only on full certificates, and ignores partials.
-			parsedCertificates, err := image.FindImageCertificates(context.TODO(), imageName, iOpts...)
+			// Validate operates only on full certificates. Partials are only
+			// findings with --fail-on-partial.
+			parsedCertificates, err := findImageCertificates(ctx, imageName, imgOpts, iOpts)
 			if err != nil {
 				return err
 			}
+			if imgOpts.Verbose {
+				printScanStats(imageName, parsedCertificates)
+			}
+
+			// CRLs are authenticated by their issuers, which may be among
+			// the certificates found, so are only added once they are.
+			issuers := append([]certificate.Found{}, parsedCertificates.Found...)
+			issuers = append(issuers, roots...)
+			for _, b := range bundles {
+				issuers = append(issuers, b.Certificates...)
+			}
+			crls, warnings := validate.AuthenticateRevocationLists(crls, issuers)
+			warnings = append(warnings, validator.AddRevocationLists(crls...)...)
+			for _, w := range warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+			}
+			if valOpts.Output == options.OutputModePretty && !valOpts.Silent {
+				fmt.Println("Validating certificates with " + validator.DescribeConfig())
+			}
 
 			validateRes, err := validator.Validate(parsedCertificates.Found)
 			if err != nil {
 				return err
 			}
+			validateRes.FailOn = valOpts.FailOnFindings()
+			for _, b := range bundles {
+				validateRes.UnseenBundleCertificates = append(validateRes.UnseenBundleCertificates, b.Unseen(parsedCertificates.Found)...)
+			}
+			if valOpts.FailOnPartial {
+				validateRes.PartialCertificates = parsedCertificates.Partials
+			}
 
-			if validateRes.IsPass() {
-				fmt.Printf("Scanned %d certificates in image %s, no issues found.\n", len(parsedCertificates.Found), imageName)
-			} else {
-				fmt.Printf("Scanned %d certificates in image %s, found issues.\n", len(parsedCertificates.Found), imageName)
-				for _, na := range validateRes.NotAllowedCertificates {
-					fmt.Printf("Certificate with SHA256 fingerprint %X in location %s was not allowed\n", na.FingerprintSha256, na.Location)
-				}
-				for _, f := range validateRes.ForbiddenCertificates {
-					sb := strings.Builder{}
-					sb.WriteString("Certificate with ")
-					if f.Entry.Fingerprints.Sha1 != "" {
-						sb.WriteString(fmt.Sprintf("SHA1 %X", f.Certificate.FingerprintSha1))
-					} else if f.Entry.Fingerprints.Sha256 != "" {
-						sb.WriteString(fmt.Sprintf("SHA256 %X", f.Certificate.FingerprintSha256))
-					}
-					sb.WriteString(fmt.Sprintf(" in location %s was forbidden!", f.Certificate.Location))
-					if f.Entry.Comment != "" {
-						sb.WriteString(" Comment: ")
-						sb.WriteString(f.Entry.Comment)
-					} else {
-						sb.WriteString(" No comment was provided.")
-					}
-					fmt.Println(sb.String())
-				}
-				for _, req := range validateRes.RequiredButAbsent {
-					sb := strings.Builder{}
-					sb.WriteString("Certificate with ")
-					if req.Fingerprints.Sha1 != "" {
-						sb.WriteString(fmt.Sprintf("SHA1 %s", req.Fingerprints.Sha1))
-					} else if req.Fingerprints.Sha256 != "" {
-						sb.WriteString(fmt.Sprintf("SHA256 %s", req.Fingerprints.Sha256))
-					}
-					sb.WriteString(" was required, but was not found")
-					if req.Comment != "" {
-						sb.WriteString(" Comment: ")
-						sb.WriteString(req.Comment)
+			var ocspRevoked []validate.RevokedCert
+			if valOpts.CheckOCSP {
+				checker := validate.OCSPChecker{Timeout: valOpts.OCSPTimeout, Concurrency: valOpts.OCSPConcurrency}
+				revoked, unknown := checker.Check(ctx, parsedCertificates.Found)
+				ocspRevoked = revoked
+				validateRes.RevokedCertificates = append(validateRes.RevokedCertificates, revoked...)
+				validateRes.OCSPUnknownCertificates = unknown
+			}
+
+			if valOpts.UpdateBaseline {
+				b := validate.NewBaseline(validateRes)
+				if err := writeBaselineFile(valOpts.Baseline, b); err != nil {
+					return errors.Wrap(err, "failed to write baseline")
+				}
+				fmt.Fprintf(os.Stderr, "Wrote a baseline of %d findings to %s\n", len(b.Findings), valOpts.Baseline)
+				baseline = &b
+			}
+			if baseline != nil {
+				validateRes.ApplyBaseline(baseline)
+			}
+
+			var configDiff validate.ResultDiff
+			if valOpts.DiffConfig != "" {
+				diffRes, err := validateWithConfig(ctx, valOpts.DiffConfig, valOpts, validatorOpts, bundles, feeds, crls, parsedCertificates.Found)
+				if err != nil {
+					return err
+				}
+				// OCSP responses and partials don't depend on the config, so
+				// are shared rather than found again.
+				diffRes.RevokedCertificates = append(diffRes.RevokedCertificates, ocspRevoked...)
+				diffRes.PartialCertificates = validateRes.PartialCertificates
+				if baseline != nil {
+					diffRes.ApplyBaseline(baseline)
+				}
+				configDiff = validate.CompareResults(diffRes, validateRes)
+			}
+
+			if valOpts.MetricsFile != "" {
+				if err := writeMetricsFile(valOpts.MetricsFile, imageName, len(parsedCertificates.Found), validateRes, time.Since(start)); err != nil {
+					return errors.Wrap(err, "failed to write metrics file")
+				}
+			}
+
+			var explanations []validate.Explanation
+			if valOpts.Explain {
+				explanations = validator.Explain(parsedCertificates.Found)
+			}
+
+			if valOpts.Redact {
+				certificate.Redact(parsedCertificates.Found)
+				validateRes.Redact()
+			}
+
+			jsonOut := output.NewJSONValidateOutput(imageName, len(parsedCertificates.Found), validateRes)
+			if valOpts.Explain {
+				jsonOut.Explanations = output.NewJSONExplanations(explanations)
+			}
+			if valOpts.DiffConfig != "" {
+				jsonOut.ConfigDiff = output.NewJSONConfigDiff(valOpts.DiffConfig, configDiff)
+			}
+
+			// The result is posted before it is printed, so that it is
+			// collected even if it is silent. Failures are only logged
+			// unless --fail-on-report-error is given, which is checked
+			// once the result has been printed.
+			var reportErr error
+			if valOpts.ReportURL != "" {
+				report := output.NewJSONReport(imageName, parsedCertificates, jsonOut, start, time.Since(start))
+				if err := output.PostReport(ctx, valOpts.ReportURL, os.Getenv(options.ReportTokenEnv), valOpts.ReportTimeout, report); err != nil {
+					if valOpts.FailOnReportError {
+						reportErr = errors.Wrapf(err, "failed to report the result to %s", valOpts.ReportURL)
 					} else {
-						sb.WriteString(" No comment was provided.")
+						fmt.Fprintf(os.Stderr, "Warning: failed to report the result to %s: %s\n", valOpts.ReportURL, err)
 					}
-					fmt.Println(sb.String())
 				}
-				if !valOpts.Quiet {
-					os.Exit(1)
+			}
+
+			if valOpts.Silent && validateRes.IsPass() && len(configDiff.Added) == 0 && len(configDiff.Removed) == 0 {
+				return reportErr
+			}
+
+			switch valOpts.Output {
+			case options.OutputModeJSON:
+				m, err := json.Marshal(jsonOut)
+				if err != nil {
+					return errors.Wrap(err, "failed to marshall output JSON")
+				}
+				fmt.Println(string(m))
+			case options.OutputModeSARIF:
+				m, err := json.MarshalIndent(output.NewSARIFReport(valOpts.Configs[0], validateRes), "", "  ")
+				if err != nil {
+					return errors.Wrap(err, "failed to marshall output SARIF")
+				}
+				fmt.Println(string(m))
+			default:
+				if valOpts.Silent {
+					// The config wasn't described before validation, in
+					// case it passed.
+					fmt.Println("Validating certificates with " + validator.DescribeConfig())
+				}
+				if valOpts.Explain {
+					printExplanations(explanations)
+				}
+				notAllowedExamples := -1
+				if valOpts.Summarize {
+					notAllowedExamples = valOpts.SummaryExamples
+				}
+				printValidateResult(imageName, len(parsedCertificates.Found), validateRes, notAllowedExamples)
+				if valOpts.DiffConfig != "" {
+					printConfigDiff(valOpts.DiffConfig, configDiff)
 				}
 			}
 
-			return nil
+			if code := validateRes.ExitCode(valOpts.FindingExitCodes()); code != 0 && !valOpts.SuppressExitCode() {
+				os.Exit(code)
+			}
+
+			return reportErr
 		},
 	}
 
@@ -183,3 +543,377 @@ Validating a locally built image, using the implicit .paranoia.yaml configuratio
 
 	return cmd
 }
+
+// loadConfig loads a validator config from a file, or fetches it if the path
+// is a HTTP or HTTPS URL.
+func loadConfig(ctx context.Context, path, checksum string) (*validate.Config, error) {
+	if validate.IsRemoteConfig(path) {
+		return validate.FetchConfig(ctx, path, checksum)
+	}
+	return validate.LoadConfig(path)
+}
+
+// loadAllowBundles loads the trust bundles at the given paths.
+func loadAllowBundles(ctx context.Context, paths []string) ([]*validate.AllowBundle, error) {
+	var bundles []*validate.AllowBundle
+	for _, path := range paths {
+		b, err := validate.LoadAllowBundle(ctx, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load allow bundle %s", path)
+		}
+		bundles = append(bundles, b)
+	}
+	return bundles, nil
+}
+
+// loadForbidFeeds loads the forbid feeds at the given locations.
+func loadForbidFeeds(ctx context.Context, locations []string) ([]*validate.ForbidFeed, error) {
+	var feeds []*validate.ForbidFeed
+	for _, location := range locations {
+		f, err := validate.LoadForbidFeed(ctx, location)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load forbid feed %s", location)
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, nil
+}
+
+// loadChainRoots loads the root bundles at the given paths, whose
+// certificates are trusted as roots when verifying chains.
+func loadChainRoots(ctx context.Context, paths []string) ([]certificate.Found, error) {
+	var roots []certificate.Found
+	for _, path := range paths {
+		r, err := validate.LoadRootBundle(ctx, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load chain roots %s", path)
+		}
+		roots = append(roots, r...)
+	}
+	return roots, nil
+}
+
+// validateWithConfigs validates the certificates in strict mode against the
+// merged configs at the given paths.
+func validateWithConfigs(ctx context.Context, paths []string, founds []certificate.Found) (validate.Result, error) {
+	var configs []validate.Config
+	for _, path := range paths {
+		config, err := loadConfig(ctx, path, "")
+		if err != nil {
+			return validate.Result{}, errors.Wrapf(err, "failed to load validator config %s", path)
+		}
+		configs = append(configs, *config)
+	}
+	config, err := validate.MergeConfigs(configs...)
+	if err != nil {
+		return validate.Result{}, errors.Wrap(err, "failed to merge validator configs")
+	}
+	validator, err := validate.NewValidator(config, false)
+	if err != nil {
+		return validate.Result{}, errors.Wrap(err, "failed to initialise validator")
+	}
+	return validator.Validate(founds)
+}
+
+// validateWithConfig validates the certificates against the config at the
+// given path, along with the given allow bundles, forbid feeds and revocation
+// lists, and the validation and validator options which apply to every config.
+func validateWithConfig(ctx context.Context, path string, valOpts *options.Validation, validatorOpts []validate.Option, bundles []*validate.AllowBundle, feeds []*validate.ForbidFeed, crls []*validate.RevocationList, founds []certificate.Found) (validate.Result, error) {
+	loaded, err := loadConfig(ctx, path, "")
+	if err != nil {
+		return validate.Result{}, errors.Wrapf(err, "failed to load validator config %s", path)
+	}
+	configs := []validate.Config{*loaded}
+	for _, b := range bundles {
+		configs = append(configs, b.Config())
+	}
+	for _, f := range feeds {
+		configs = append(configs, f.Config())
+	}
+	config, err := validate.MergeConfigs(configs...)
+	if err != nil {
+		return validate.Result{}, errors.Wrapf(err, "failed to merge allow bundles and forbid feeds with config %s", path)
+	}
+	config.AnchorPaths = append(config.AnchorPaths, valOpts.AnchorPaths...)
+	validator, err := validate.NewValidator(config, valOpts.Permissive, validatorOpts...)
+	if err != nil {
+		return validate.Result{}, errors.Wrapf(err, "failed to initialise validator for config %s", path)
+	}
+	// Problems with the revocation lists were already warned about.
+	validator.AddRevocationLists(crls...)
+	return validator.Validate(founds)
+}
+
+// writeBaselineFile writes the baseline to a file as indented JSON, so that
+// changes to it are easy to review.
+func writeBaselineFile(path string, b validate.Baseline) error {
+	m, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(m, '\n'), 0644)
+}
+
+// writeMetricsFile writes metrics describing the validation result to a file,
+// so that readers never see a partially written file. The file is readable by
+// everyone, as metrics are usually read by a separate collector.
+func writeMetricsFile(path, imageName string, scanned int, res validate.Result, duration time.Duration) error {
+	return output.WriteFileAtomic(path, 0644, func(w io.Writer) error {
+		return output.WriteMetrics(w, imageName, scanned, res, duration)
+	})
+}
+
+// printValidateResult prints the result of validation as human-readable text.
+// When there are more certificates which were not allowed than
+// notAllowedExamples, they are summarised as a count and that many examples.
+// If notAllowedExamples is negative, every certificate is listed.
+func printValidateResult(imageName string, scanned int, res validate.Result, notAllowedExamples int) {
+	for _, e := range res.ExpiringCertificates {
+		fmt.Printf("Warning: certificate with SHA256 fingerprint %X in location %s expires soon, on %s\n",
+			e.FingerprintSha256, describeLocation(e), e.Certificate.NotAfter.Format(time.RFC3339))
+	}
+
+	for _, u := range res.UnsupportedKeyCertificates {
+		fmt.Printf("Warning: certificate in location %s was not checked: %s\n", u.Location, u.Reason)
+	}
+
+	for _, c := range res.SuspiciousSANCertificates {
+		fmt.Printf("Warning: certificate with SHA256 fingerprint %X in location %s has subject alternative names only meaningful on a private network: %s\n",
+			c.Certificate.FingerprintSha256, describeLocation(c.Certificate), strings.Join(c.SANs, ", "))
+	}
+
+	for _, k := range res.KeyIdAnomalyCertificates {
+		fmt.Printf("Warning: certificate with SHA256 fingerprint %X in location %s may break chain building, as %s\n",
+			k.Certificate.FingerprintSha256, describeLocation(k.Certificate), k.Reason)
+	}
+
+	for _, e := range res.ExpiredEntries {
+		sb := strings.Builder{}
+		sb.WriteString(fmt.Sprintf("Warning: %s entry at position %d", e.List, e.Position))
+		if e.Entry.Source != "" {
+			sb.WriteString(fmt.Sprintf(" in %s", e.Entry.Source))
+		}
+		sb.WriteString(fmt.Sprintf(" expired on %s, so was ignored. Remove or renew it.", e.Entry.ExpiresAt.Format(time.RFC3339)))
+		if e.Entry.Comment != "" {
+			sb.WriteString(" Comment: ")
+			sb.WriteString(e.Entry.Comment)
+		}
+		fmt.Println(sb.String())
+	}
+
+	for _, u := range res.OCSPUnknownCertificates {
+		fmt.Printf("Warning: revocation status of certificate with SHA256 fingerprint %X in location %s is unknown, as %s\n",
+			u.Certificate.FingerprintSha256, describeLocation(u.Certificate), u.Reason)
+	}
+
+	for _, u := range res.UnseenBundleCertificates {
+		fmt.Printf("Info: certificate %s with SHA256 fingerprint %X from allow bundle %s was not found in the image\n",
+			u.Certificate.Subject, u.FingerprintSha256, u.Location)
+	}
+
+	if len(res.BaselinedFindings) > 0 {
+		fmt.Printf("Ignoring %d findings accepted by the baseline\n", len(res.BaselinedFindings))
+	}
+
+	if len(res.Kinds()) == 0 {
+		fmt.Printf("Scanned %d certificates in image %s, no issues found.\n", scanned, imageName)
+	} else {
+		if res.IsPass() {
+			fmt.Printf("Scanned %d certificates in image %s, found issues which do not fail validation.\n", scanned, imageName)
+		} else {
+			fmt.Printf("Scanned %d certificates in image %s, found issues.\n", scanned, imageName)
+		}
+		if notAllowedExamples >= 0 && len(res.NotAllowedCertificates) > notAllowedExamples {
+			fmt.Printf("Found %d certificates which were not allowed, such as:\n", len(res.NotAllowedCertificates))
+			for _, na := range res.NotAllowedCertificates[:notAllowedExamples] {
+				fmt.Printf("┣ Certificate with SHA256 fingerprint %X in location %s\n", na.FingerprintSha256, describeLocation(na))
+			}
+			fmt.Printf("┗ and %d more, run without --summarize to list them all\n", len(res.NotAllowedCertificates)-notAllowedExamples)
+		} else {
+			for _, na := range res.NotAllowedCertificates {
+				fmt.Printf("Certificate with SHA256 fingerprint %X in location %s was not allowed\n", na.FingerprintSha256, describeLocation(na))
+			}
+		}
+		for _, f := range res.ForbiddenCertificates {
+			sb := strings.Builder{}
+			sb.WriteString("Certificate with ")
+			// The certificate is described by the fingerprint which matched,
+			// as the entry may list several.
+			if f.Fingerprint.Sha1 != "" {
+				sb.WriteString(fmt.Sprintf("SHA1 %X", f.Certificate.FingerprintSha1))
+			} else if f.Fingerprint.Sha256 != "" {
+				sb.WriteString(fmt.Sprintf("SHA256 %X", f.Certificate.FingerprintSha256))
+			} else if f.Fingerprint.Sha512 != "" {
+				sb.WriteString(fmt.Sprintf("SHA512 %X", f.Certificate.FingerprintSha512))
+			} else if f.Fingerprint.SpkiSha256 != "" {
+				sb.WriteString(fmt.Sprintf("SPKI SHA256 %X", f.Certificate.SpkiSha256))
+			} else if f.Fingerprint.Md5 != "" {
+				sb.WriteString(fmt.Sprintf("MD5 (insecure) %X", f.Certificate.FingerprintMd5))
+			} else if f.Fingerprint.Sha256Prefix != "" {
+				sb.WriteString(fmt.Sprintf("SHA256 %X matching prefix %s", f.Certificate.FingerprintSha256, f.Fingerprint.Sha256Prefix))
+			} else if f.Entry.SerialNumber != "" {
+				sb.WriteString(fmt.Sprintf("serial number %X from issuer %q", f.Certificate.Certificate.SerialNumber, f.Certificate.Certificate.Issuer))
+			} else if f.Entry.IssuerDN != "" {
+				sb.WriteString(fmt.Sprintf("issuer %q", f.Certificate.Certificate.Issuer))
+			} else if f.Entry.SubjectRegex != "" {
+				sb.WriteString(fmt.Sprintf("subject %q", f.Certificate.Certificate.Subject))
+			} else if f.Entry.SANRegex != "" {
+				sb.WriteString(fmt.Sprintf("subject alternative name matching %q", f.Entry.SANRegex))
+			} else if f.Entry.PolicyOID != "" {
+				sb.WriteString(fmt.Sprintf("SHA256 %X asserting certificate policy %s", f.Certificate.FingerprintSha256, f.Entry.PolicyOID))
+			} else {
+				sb.WriteString(fmt.Sprintf("subject CN %q", f.Certificate.Certificate.Subject.CommonName))
+			}
+			sb.WriteString(fmt.Sprintf(" in location %s was forbidden!", describeLocation(f.Certificate)))
+			if f.Entry.Comment != "" {
+				sb.WriteString(" Comment: ")
+				sb.WriteString(f.Entry.Comment)
+			}
+			fmt.Println(sb.String())
+		}
+		for _, r := range res.RevokedCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s was revoked on %s, according to CRL %s\n",
+				r.Certificate.FingerprintSha256, describeLocation(r.Certificate), r.RevokedAt.Format(time.RFC3339), r.Source)
+		}
+		for _, e := range res.ExpiredCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s expired on %s\n",
+				e.FingerprintSha256, describeLocation(e), e.Certificate.NotAfter.Format(time.RFC3339))
+		}
+		for _, n := range res.NotYetValidCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s is not valid until %s\n",
+				n.FingerprintSha256, describeLocation(n), n.Certificate.NotBefore.Format(time.RFC3339))
+		}
+		for _, o := range res.OverlongValidityCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s is valid for %d days, from %s to %s, which is longer than the maximum validity period\n",
+				o.FingerprintSha256, describeLocation(o), validate.ValidityDays(o.Certificate), o.Certificate.NotBefore.Format(time.RFC3339), o.Certificate.NotAfter.Format(time.RFC3339))
+		}
+		for _, w := range res.WeakSignatureCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s is signed with weak signature algorithm %s\n",
+				w.FingerprintSha256, describeLocation(w), w.Certificate.SignatureAlgorithm)
+		}
+		for _, w := range res.WeakKeyCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s has a weak %s public key\n",
+				w.FingerprintSha256, describeLocation(w), w.Certificate.PublicKeyAlgorithm)
+		}
+		for _, u := range res.UnhandledCriticalExtensionCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s has unhandled critical extensions %s\n",
+				u.FingerprintSha256, describeLocation(u), strings.Join(validate.UnhandledCriticalExtensions(u.Certificate), ", "))
+		}
+		for _, a := range res.InvalidAnchorCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s is in a trust store, but is not a valid certificate authority, as its %s\n",
+				a.FingerprintSha256, describeLocation(a), strings.Join(validate.AnchorProblems(a.Certificate), ", and its "))
+		}
+		for _, u := range res.UnverifiableCertificates {
+			fmt.Printf("Certificate with SHA256 fingerprint %X in location %s does not chain to a trusted root, as %s\n",
+				u.Certificate.FingerprintSha256, describeLocation(u.Certificate), u.Reason)
+		}
+		for _, p := range res.PartialCertificates {
+			fmt.Printf("Partial certificate found by the %s parser in location %s: %s\n", p.Parser, p.Location, p.Reason)
+		}
+		for _, req := range res.RequiredButAbsent {
+			sb := strings.Builder{}
+			sb.WriteString("Certificate with ")
+			sb.WriteString(describeEntryFingerprint(req))
+			sb.WriteString(" was required, but was not found")
+			if req.Comment != "" {
+				sb.WriteString(" Comment: ")
+				sb.WriteString(req.Comment)
+			}
+			fmt.Println(sb.String())
+		}
+		for _, group := range res.RequiredGroupsUnsatisfied {
+			fmt.Println("At least one of these certificates was required, but none were found:")
+			for i, req := range group {
+				lead := "┣"
+				if i == len(group)-1 {
+					lead = "┗"
+				}
+				line := fmt.Sprintf("%s Certificate with %s", lead, describeEntryFingerprint(req))
+				if req.Comment != "" {
+					line += " Comment: " + req.Comment
+				}
+				fmt.Println(line)
+			}
+		}
+	}
+}
+
+// printConfigDiff prints the findings which the validation config adds and
+// removes compared to another config as human-readable text.
+func printConfigDiff(config string, diff validate.ResultDiff) {
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		fmt.Printf("Compared to %s, the config doesn't add or remove any findings\n", config)
+		return
+	}
+	fmt.Printf("Compared to %s, the config adds %d and removes %d findings:\n", config, len(diff.Added), len(diff.Removed))
+	for _, f := range diff.Added {
+		fmt.Println("+ " + describeFinding(f))
+	}
+	for _, f := range diff.Removed {
+		fmt.Println("- " + describeFinding(f))
+	}
+}
+
+func describeFinding(f validate.Finding) string {
+	if f.Location == "" {
+		return fmt.Sprintf("%s: %s", f.Kind, f.Certificate)
+	}
+	return fmt.Sprintf("%s: %s in location %s", f.Kind, f.Certificate, f.Location)
+}
+
+// printExplanations prints what strict mode validation would decide for each
+// certificate as human-readable text.
+func printExplanations(explanations []validate.Explanation) {
+	fmt.Println("Explaining what strict mode would decide for each certificate, which does not affect the result:")
+	for _, e := range explanations {
+		fmt.Printf("Certificate with SHA256 fingerprint %X in location %s would be %s: %s\n",
+			e.Certificate.FingerprintSha256, describeLocation(e.Certificate), explainVerdicts[e.Verdict], e.Reason)
+	}
+}
+
+var explainVerdicts = map[string]string{
+	validate.VerdictAllowed:    "allowed",
+	validate.VerdictForbidden:  "forbidden",
+	validate.VerdictNotAllowed: "not allowed",
+}
+
+// describeLocation describes where a certificate was found, including the
+// image layer which introduced it, if known.
+func describeLocation(f certificate.Found) string {
+	if f.LayerDigest == "" {
+		return f.Location
+	}
+	return fmt.Sprintf("%s (layer %s)", f.Location, f.LayerDigest)
+}
+
+// describeEntryFingerprint describes the fingerprints a required certificate
+// entry is identified by, any of which satisfies it, or its attributes if it
+// has no fingerprint.
+func describeEntryFingerprint(req validate.CertificateEntry) string {
+	if len(req.Fingerprints) == 0 {
+		return req.Identity()
+	}
+	alternatives := make([]string, len(req.Fingerprints))
+	for i, f := range req.Fingerprints {
+		alternatives[i] = describeFingerprint(f)
+	}
+	return strings.Join(alternatives, " or ")
+}
+
+// describeFingerprint describes a single fingerprint of an entry.
+func describeFingerprint(f validate.CertificateFingerprints) string {
+	if f.Sha1 != "" {
+		return fmt.Sprintf("SHA1 %s", f.Sha1)
+	} else if f.Sha256 != "" {
+		return fmt.Sprintf("SHA256 %s", f.Sha256)
+	} else if f.Sha512 != "" {
+		return fmt.Sprintf("SHA512 %s", f.Sha512)
+	} else if f.SpkiSha256 != "" {
+		return fmt.Sprintf("SPKI SHA256 %s", f.SpkiSha256)
+	} else if f.Md5 != "" {
+		return fmt.Sprintf("MD5 (insecure) %s", f.Md5)
+	} else if f.Sha256Prefix != "" {
+		return fmt.Sprintf("SHA256 prefix %s", f.Sha256Prefix)
+	}
+	return ""
+}
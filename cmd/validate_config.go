@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func newValidateConfig(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate-config [flags] [config...]",
+		Short: "Check that configuration files for the validate command are well-formed",
+		Long: `
+Check that one or more configuration files for the validate command are well-formed, without scanning an image.
+Every problem found is reported, including the list and position of each malformed entry.
+If any configuration file has problems, then Paranoia will give a non-zero exit code.
+Warnings, such as for SHA256 prefixes short enough to match unrelated certificates, are also reported, but do not affect the exit code.
+
+When more than one configuration file is given, they are also checked to merge without conflicts, as they would with the validate command.
+If no configuration file is given, the .paranoia.yaml file in the working directory is checked.
+Configuration files may also be given as HTTP or HTTPS URLs, which are fetched.
+`,
+		Example: `
+Check the implicit .paranoia.yaml configuration file, such as in a pre-commit hook:
+
+	$ paranoia validate-config
+
+Check a shared baseline policy along with team specific overrides:
+
+	$ paranoia validate-config baseline.yaml team.yaml
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			paths := args
+			if len(paths) == 0 {
+				paths = []string{".paranoia.yaml"}
+			}
+
+			valid := true
+			var configs []validate.Config
+			for _, path := range paths {
+				config, err := loadConfig(ctx, path, "")
+				if err != nil {
+					valid = false
+					fmt.Printf("%s: failed to load config: %s\n", path, err)
+					continue
+				}
+				configs = append(configs, *config)
+
+				problems := validate.ConfigProblems(config)
+				for _, p := range problems {
+					fmt.Printf("%s: %s\n", path, p)
+				}
+				for _, w := range validate.ConfigWarnings(config) {
+					fmt.Printf("%s: warning: %s\n", path, w)
+				}
+				if len(problems) > 0 {
+					valid = false
+				}
+			}
+
+			if len(configs) > 1 {
+				if _, err := validate.MergeConfigs(configs...); err != nil {
+					valid = false
+					fmt.Printf("Configs cannot be merged: %s\n", err)
+				}
+			}
+
+			if !valid {
+				os.Exit(1)
+			}
+
+			fmt.Printf("Checked %d configuration files, no problems found.\n", len(paths))
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package browse implements a prompt-driven browser over the certificates
+// found by a scan. Commands are read a line at a time, rather than from a
+// full-screen terminal interface, so sessions can also be scripted.
+package browse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/output"
+)
+
+// Sort orders for the list of certificates.
+const (
+	SortFound    = "found"
+	SortExpiry   = "expiry"
+	SortSubject  = "subject"
+	SortLocation = "location"
+)
+
+// SortOrders are all the orders the list of certificates may be sorted by.
+var SortOrders = []string{SortFound, SortExpiry, SortSubject, SortLocation}
+
+const prompt = "browse> "
+
+const help = `Commands:
+  list               List the certificates matching the filter
+  <number>           Show every detail of the certificate with that number
+  filter <text>      Only list certificates whose subject, issuer or location contains the text
+  filter             Clear the filter
+  sort <order>       Sort the list by found (the default), expiry, subject or location
+  help               Show this help
+  quit               Exit the browser
+`
+
+// Browser reads commands from its input and writes the certificates they ask
+// for to its output, until the input ends or it is told to quit.
+type Browser struct {
+	founds []certificate.Found
+	in     *bufio.Scanner
+	out    io.Writer
+
+	filter string
+	order  string
+	// listed are the certificates in the order they were last listed, so
+	// that the numbers shown can be used to select a certificate.
+	listed []certificate.Found
+}
+
+// New returns a Browser over the found certificates. Founds without a parsed
+// certificate can't be described, so are left out.
+func New(founds []certificate.Found, in io.Reader, out io.Writer) *Browser {
+	b := &Browser{
+		in:    bufio.NewScanner(in),
+		out:   out,
+		order: SortFound,
+	}
+	for _, f := range founds {
+		if f.Certificate != nil {
+			b.founds = append(b.founds, f)
+		}
+	}
+	return b
+}
+
+// Run lists the certificates, then handles commands until the input ends or
+// the browser is told to quit.
+func (b *Browser) Run() error {
+	if err := b.list(); err != nil {
+		return err
+	}
+	fmt.Fprint(b.out, help)
+
+	for {
+		fmt.Fprint(b.out, prompt)
+		if !b.in.Scan() {
+			fmt.Fprintln(b.out)
+			return b.in.Err()
+		}
+		quit, err := b.handle(strings.TrimSpace(b.in.Text()))
+		if err != nil {
+			return err
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// handle runs a single command, returning true if the browser should exit.
+func (b *Browser) handle(line string) (bool, error) {
+	command, arg := line, ""
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		command, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch command {
+	case "q", "quit", "exit":
+		return true, nil
+	case "", "l", "list":
+		return false, b.list()
+	case "?", "h", "help":
+		fmt.Fprint(b.out, help)
+		return false, nil
+	case "f", "filter":
+		b.filter = arg
+		return false, b.list()
+	case "s", "sort":
+		if !validOrder(arg) {
+			fmt.Fprintf(b.out, "Unknown sort order %q, must be one of %s\n", arg, strings.Join(SortOrders, ", "))
+			return false, nil
+		}
+		b.order = arg
+		return false, b.list()
+	}
+
+	n, err := strconv.Atoi(command)
+	if err != nil {
+		fmt.Fprintf(b.out, "Unknown command %q, type help for a list of commands\n", command)
+		return false, nil
+	}
+	if n < 1 || n > len(b.listed) {
+		fmt.Fprintf(b.out, "No certificate numbered %d\n", n)
+		return false, nil
+	}
+	return false, b.details(b.listed[n-1])
+}
+
+// list writes a table of the certificates matching the filter, in order.
+func (b *Browser) list() error {
+	b.listed = b.listed[:0]
+	for _, f := range b.founds {
+		if b.matches(f) {
+			b.listed = append(b.listed, f)
+		}
+	}
+	sortFounds(b.listed, b.order)
+
+	if len(b.listed) == 0 {
+		if b.filter != "" {
+			fmt.Fprintf(b.out, "No certificates match %q\n", b.filter)
+		} else {
+			fmt.Fprintln(b.out, "No certificates found")
+		}
+		return nil
+	}
+
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	tbl := table.New("#", "Subject", "Issuer", "Not Before", "Not After", "Location").
+		WithHeaderFormatter(headerFmt).
+		WithFirstColumnFormatter(columnFmt).
+		WithWriter(b.out)
+	for i, f := range b.listed {
+		tbl.AddRow(i+1, f.Certificate.Subject, f.Certificate.Issuer,
+			f.Certificate.NotBefore.Format(time.RFC3339),
+			f.Certificate.NotAfter.Format(time.RFC3339),
+			f.Location)
+	}
+	tbl.Print()
+
+	if b.filter != "" {
+		fmt.Fprintf(b.out, "Showing %d of %d certificates matching %q\n", len(b.listed), len(b.founds), b.filter)
+	} else {
+		fmt.Fprintf(b.out, "Showing %d certificates\n", len(b.listed))
+	}
+	return nil
+}
+
+// details writes every detail of the certificate, along with every location
+// a copy of it was found in.
+func (b *Browser) details(f certificate.Found) error {
+	var copies []certificate.Found
+	for _, c := range b.founds {
+		if c.FingerprintSha256 == f.FingerprintSha256 {
+			copies = append(copies, c)
+		}
+	}
+	return output.WriteDetails(b.out, copies)
+}
+
+// matches returns true if the certificate's subject, issuer or location
+// contains the filter, ignoring case.
+func (b *Browser) matches(f certificate.Found) bool {
+	if b.filter == "" {
+		return true
+	}
+	filter := strings.ToLower(b.filter)
+	for _, s := range []string{f.Certificate.Subject.String(), f.Certificate.Issuer.String(), f.Location} {
+		if strings.Contains(strings.ToLower(s), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+func validOrder(order string) bool {
+	for _, o := range SortOrders {
+		if o == order {
+			return true
+		}
+	}
+	return false
+}
+
+// sortFounds sorts the certificates in place by the given order. Sorting is
+// stable, so certificates which compare equal stay in the order found.
+func sortFounds(founds []certificate.Found, order string) {
+	var less func(a, b certificate.Found) bool
+	switch order {
+	case SortExpiry:
+		less = func(a, b certificate.Found) bool { return a.Certificate.NotAfter.Before(b.Certificate.NotAfter) }
+	case SortSubject:
+		less = func(a, b certificate.Found) bool {
+			return a.Certificate.Subject.String() < b.Certificate.Subject.String()
+		}
+	case SortLocation:
+		less = func(a, b certificate.Found) bool { return a.Location < b.Location }
+	default:
+		return
+	}
+	sort.SliceStable(founds, func(i, j int) bool { return less(founds[i], founds[j]) })
+}
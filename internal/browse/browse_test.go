@@ -0,0 +1,132 @@
+package browse
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func newFound(t *testing.T, location, cn string, notAfter time.Time) certificate.Found {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return certificate.Found{
+		Location:          location,
+		Certificate:       cert,
+		FingerprintSha256: sha256.Sum256(der),
+	}
+}
+
+func TestBrowser(t *testing.T) {
+	later := newFound(t, "/etc/ssl/certs/later.pem", "Later Root", time.Date(2040, 1, 1, 0, 0, 0, 0, time.UTC))
+	sooner := newFound(t, "/etc/ssl/certs/sooner.pem", "Sooner Root", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	copied := later
+	copied.Location = "/usr/share/ca-certificates/later.crt"
+	founds := []certificate.Found{later, sooner, copied, {Location: "/partial"}}
+
+	run := func(t *testing.T, input string) string {
+		var out bytes.Buffer
+		require.NoError(t, New(founds, strings.NewReader(input), &out).Run())
+		return out.String()
+	}
+
+	// listOrder returns the locations listed by the last list in the output,
+	// in order.
+	listOrder := func(out string) []string {
+		listing := out[strings.LastIndex(out, "Subject"):]
+		var locs []string
+		for _, line := range strings.Split(listing, "\n") {
+			for _, l := range []string{later.Location, sooner.Location, copied.Location} {
+				if strings.HasSuffix(strings.TrimSpace(line), l) {
+					locs = append(locs, l)
+				}
+			}
+		}
+		return locs
+	}
+
+	// detailsOf returns the details written in the output.
+	detailsOf := func(out string) string {
+		i := strings.Index(out, prompt+"Location:")
+		require.GreaterOrEqual(t, i, 0, "no details written")
+		return out[i:]
+	}
+
+	t.Run("certificates should be listed in the order found", func(t *testing.T) {
+		out := run(t, "")
+		assert.Equal(t, []string{later.Location, sooner.Location, copied.Location}, listOrder(out))
+		assert.Contains(t, out, "Showing 3 certificates")
+	})
+
+	t.Run("certificates should be sorted by expiry", func(t *testing.T) {
+		out := run(t, "sort expiry\n")
+		assert.Equal(t, []string{sooner.Location, later.Location, copied.Location}, listOrder(out))
+	})
+
+	t.Run("an unknown sort order should be reported", func(t *testing.T) {
+		assert.Contains(t, run(t, "sort size\n"), `Unknown sort order "size"`)
+	})
+
+	t.Run("certificates should be filtered, ignoring case", func(t *testing.T) {
+		out := run(t, "filter SOONER\n")
+		assert.Equal(t, []string{sooner.Location}, listOrder(out))
+		assert.Contains(t, out, `Showing 1 of 3 certificates matching "SOONER"`)
+	})
+
+	t.Run("a filter matching nothing should be reported", func(t *testing.T) {
+		assert.Contains(t, run(t, "filter nothing\n"), `No certificates match "nothing"`)
+	})
+
+	t.Run("clearing the filter should list every certificate", func(t *testing.T) {
+		out := run(t, "filter sooner\nfilter\n")
+		assert.Len(t, listOrder(out), 3)
+	})
+
+	t.Run("selecting a certificate should show its details and every copy", func(t *testing.T) {
+		details := detailsOf(run(t, "1\n"))
+		assert.Contains(t, details, later.Location)
+		assert.Contains(t, details, copied.Location)
+		assert.Contains(t, details, "CN=Later Root")
+	})
+
+	t.Run("numbers should refer to the last list shown", func(t *testing.T) {
+		details := detailsOf(run(t, "filter sooner\n1\n"))
+		assert.Contains(t, details, sooner.Location)
+		assert.NotContains(t, details, later.Location)
+	})
+
+	t.Run("an unknown number should be reported", func(t *testing.T) {
+		assert.Contains(t, run(t, "4\n"), "No certificate numbered 4")
+	})
+
+	t.Run("an unknown command should be reported", func(t *testing.T) {
+		assert.Contains(t, run(t, "frobnicate\n"), `Unknown command "frobnicate"`)
+	})
+
+	t.Run("quit should stop reading commands", func(t *testing.T) {
+		out := run(t, "quit\nfrobnicate\n")
+		assert.NotContains(t, out, "Unknown command")
+	})
+}
@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// certdataName is the file name of Mozilla's trust store, in the NSS source
+// format.
+const certdataName = "certdata.txt"
+
+// certdataTrustPurposes are the trust attributes of NSS trust objects, and
+// the purposes they are recorded as, named as OpenSSL trust settings are.
+var certdataTrustPurposes = []struct {
+	attribute, purpose string
+}{
+	{"CKA_TRUST_SERVER_AUTH", "serverAuth"},
+	{"CKA_TRUST_CLIENT_AUTH", "clientAuth"},
+	{"CKA_TRUST_CODE_SIGNING", "codeSigning"},
+	{"CKA_TRUST_EMAIL_PROTECTION", "emailProtection"},
+}
+
+// Trust values of NSS trust objects. Any other value, such as
+// CKT_NSS_MUST_VERIFY_TRUST, neither trusts nor distrusts the certificate.
+const (
+	certdataTrusted    = "CKT_NSS_TRUSTED_DELEGATOR"
+	certdataNotTrusted = "CKT_NSS_NOT_TRUSTED"
+)
+
+// certdataServerDistrustAfter is the attribute of certificate objects giving
+// the time after which certificates issued by the certificate authority are
+// distrusted for TLS servers. It is CK_FALSE if there is no such time.
+const certdataServerDistrustAfter = "CKA_NSS_SERVER_DISTRUST_AFTER"
+
+// certdataTimeFormat is the format of times in certdata.txt, which are UTC
+// times as in X.509, such as "200930000000Z".
+const certdataTimeFormat = "060102150405Z"
+
+// certdataObject is an object of a certdata.txt file, which holds the
+// attributes from one CKA_CLASS line to the next.
+type certdataObject struct {
+	line  int
+	attrs map[string]string
+	// octal holds the decoded values of MULTILINE_OCTAL attributes.
+	octal map[string][]byte
+}
+
+func (o certdataObject) label() string {
+	label, err := strconv.Unquote(o.attrs["CKA_LABEL"])
+	if err != nil {
+		return ""
+	}
+	return label
+}
+
+type certdata struct{}
+
+func (_ certdata) name() string { return "certdata" }
+
+// scansLocation returns true only for files named certdata.txt, so that
+// other text files are not read.
+func (_ certdata) scansLocation(location string) bool {
+	return path.Base(location) == certdataName
+}
+
+// Find finds X.509 certificates in Mozilla's certdata.txt, the source of the
+// NSS built-in trust store. The location of each certificate is reported as
+// "path!label". The purposes each certificate is trusted or distrusted for
+// are read from its trust object, and the time after which certificates it
+// issues are distrusted for TLS servers from CKA_NSS_SERVER_DISTRUST_AFTER.
+// Trust objects distrusting a certificate which is not in the file, as Mozilla
+// does for certificates which have been explicitly distrusted, are recorded
+// as distrusted certificates so they can be checked.
+func (_ certdata) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
+	file, err := rs()
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := parseCertdata(ctx, file)
+	if err != nil {
+		return &ParsedCertificates{
+			Partials: []Partial{{
+				Location: location,
+				Parser:   "certdata",
+				Reason:   fmt.Sprintf("failed to read certdata.txt: %s", err),
+			}},
+		}, nil
+	}
+
+	parsed := &ParsedCertificates{}
+	certs := make(map[[20]byte]int)
+	var trusts []certdataObject
+	for _, obj := range objects {
+		switch obj.attrs["CKA_CLASS"] {
+		case "CKO_CERTIFICATE":
+			entryLocation := certdataLocation(location, obj)
+			found, err := newFound(entryLocation, "certdata", obj.octal["CKA_VALUE"])
+			if err != nil {
+				parsed.Partials = append(parsed.Partials, Partial{
+					Location: entryLocation,
+					Parser:   "certdata",
+					Reason:   fmt.Sprintf("failed to parse certificate at line %d: %s", obj.line, err),
+				})
+				continue
+			}
+			found.Encoding, found.ContainerFormat = EncodingDER, ContainerFormatCertdata
+			if value, ok := obj.octal[certdataServerDistrustAfter]; ok {
+				found.ServerDistrustAfter, err = time.Parse(certdataTimeFormat, string(value))
+				if err != nil {
+					parsed.Partials = append(parsed.Partials, Partial{
+						Location: entryLocation,
+						Parser:   "certdata",
+						Reason:   fmt.Sprintf("failed to parse %s at line %d: %q is not a time", certdataServerDistrustAfter, obj.line, value),
+					})
+					continue
+				}
+			}
+			certs[found.FingerprintSha1] = len(parsed.Found)
+			parsed.Found = append(parsed.Found, found)
+
+		case "CKO_NSS_TRUST":
+			trusts = append(trusts, obj)
+		}
+	}
+
+	for _, trust := range trusts {
+		var trusted, rejected []string
+		for _, p := range certdataTrustPurposes {
+			switch trust.attrs[p.attribute] {
+			case certdataTrusted:
+				trusted = append(trusted, p.purpose)
+			case certdataNotTrusted:
+				rejected = append(rejected, p.purpose)
+			}
+		}
+
+		var sum [20]byte
+		hash := trust.octal["CKA_CERT_SHA1_HASH"]
+		copy(sum[:], hash)
+		if i, ok := certs[sum]; ok && len(hash) == sha1.Size {
+			parsed.Found[i].TrustedPurposes = append(parsed.Found[i].TrustedPurposes, trusted...)
+			parsed.Found[i].RejectedPurposes = append(parsed.Found[i].RejectedPurposes, rejected...)
+			continue
+		}
+
+		if len(rejected) > 0 {
+			distrusted := Distrusted{
+				Location:         certdataLocation(location, trust),
+				Parser:           "certdata",
+				RejectedPurposes: rejected,
+			}
+			if len(hash) == sha1.Size {
+				distrusted.FingerprintSha1 = sum
+			}
+			parsed.Distrusted = append(parsed.Distrusted, distrusted)
+		}
+	}
+
+	return parsed, nil
+}
+
+func certdataLocation(location string, obj certdataObject) string {
+	if label := obj.label(); label != "" {
+		return location + "!" + label
+	}
+	return location
+}
+
+// parseCertdata reads the objects of a certdata.txt file. Each line holds an
+// attribute name, its type and its value, except for MULTILINE_OCTAL values
+// which follow on the lines up to END.
+func parseCertdata(ctx context.Context, r io.Reader) ([]certdataObject, error) {
+	var (
+		objects []certdataObject
+		scanner = bufio.NewScanner(r)
+		lineNum int
+		began   bool
+	)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNum++
+		if lineNum%1000 == 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !began {
+			began = line == "BEGINDATA"
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: malformed attribute %q", lineNum, line)
+		}
+		attr, typ := fields[0], fields[1]
+
+		if attr == "CKA_CLASS" {
+			objects = append(objects, certdataObject{
+				line:  lineNum,
+				attrs: make(map[string]string),
+				octal: make(map[string][]byte),
+			})
+		}
+		if len(objects) == 0 {
+			return nil, fmt.Errorf("line %d: attribute %s before the first object", lineNum, attr)
+		}
+		obj := objects[len(objects)-1]
+
+		if typ != "MULTILINE_OCTAL" {
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("line %d: attribute %s has no value", lineNum, attr)
+			}
+			obj.attrs[attr] = fields[2]
+			continue
+		}
+
+		start := lineNum
+		var value bytes.Buffer
+		for {
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("line %d: attribute %s has no END", start, attr)
+			}
+			lineNum++
+			octal := strings.TrimSpace(scanner.Text())
+			if octal == "END" {
+				break
+			}
+			if err := decodeOctal(&value, octal); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		}
+		obj.octal[attr] = value.Bytes()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// decodeOctal appends the bytes of a line of octal escapes, such as
+// `\060\202`, to the buffer.
+func decodeOctal(buf *bytes.Buffer, line string) error {
+	for len(line) > 0 {
+		if len(line) < 4 || line[0] != '\\' {
+			return fmt.Errorf("malformed octal escape %q", line)
+		}
+		b, err := strconv.ParseUint(line[1:4], 8, 8)
+		if err != nil {
+			return fmt.Errorf("malformed octal escape %q", line[:4])
+		}
+		buf.WriteByte(byte(b))
+		line = line[4:]
+	}
+	return nil
+}
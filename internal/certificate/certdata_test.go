@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_certdata(t *testing.T) {
+	// testdata/certdata.txt holds the certificates from testdata/test-1,
+	// trusted for serverAuth, with no trust and distrusted for TLS servers
+	// after 2020-09-30, and distrusted for serverAuth, and a trust object
+	// distrusting a certificate which is not in the file.
+	data := mustReadFile(t, "testdata/certdata.txt")
+
+	distrustedCA := Distrusted{
+		Location:         "certdata.txt!Distrusted CA",
+		Parser:           "certdata",
+		FingerprintSha1:  [20]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19},
+		RejectedPurposes: []string{"serverAuth", "emailProtection"},
+	}
+
+	type trust struct {
+		trusted, rejected []string
+	}
+
+	tests := map[string]struct {
+		data              []byte
+		expTrust          map[string]trust
+		expPartialReasons []string
+		expDistrustAfter  map[string]time.Time
+		expDistrusted     []Distrusted
+	}{
+		"certdata.txt should parse": {
+			data: data,
+			expTrust: map[string]trust{
+				"certdata.txt!GeoTrust Global CA":           {trusted: []string{"serverAuth", "emailProtection"}},
+				"certdata.txt!Google Internet Authority G2": {},
+				"certdata.txt!www.google.com":               {rejected: []string{"serverAuth"}},
+			},
+			expDistrustAfter: map[string]time.Time{
+				"certdata.txt!Google Internet Authority G2": time.Date(2020, time.September, 30, 0, 0, 0, 0, time.UTC),
+			},
+			expDistrusted: []Distrusted{distrustedCA},
+		},
+		"malformed distrust after times should be recorded as partial": {
+			data: bytes.Replace(data, []byte(`\062\060\060\071\063\060\060\060\060\060\060\060\132`), []byte(`\062\060`), 1),
+			expPartialReasons: []string{
+				`failed to parse CKA_NSS_SERVER_DISTRUST_AFTER at line 99: "20" is not a time`,
+			},
+			expTrust: map[string]trust{
+				"certdata.txt!GeoTrust Global CA": {trusted: []string{"serverAuth", "emailProtection"}},
+				"certdata.txt!www.google.com":     {rejected: []string{"serverAuth"}},
+			},
+			expDistrusted: []Distrusted{distrustedCA},
+		},
+		"unterminated values should be recorded as partial": {
+			data: []byte("BEGINDATA\nCKA_CLASS CK_OBJECT_CLASS CKO_CERTIFICATE\nCKA_VALUE MULTILINE_OCTAL\n\\060\\202\n"),
+			expPartialReasons: []string{
+				"failed to read certdata.txt: line 3: attribute CKA_VALUE has no END",
+			},
+		},
+		"malformed octal should be recorded as partial": {
+			data: []byte("BEGINDATA\nCKA_CLASS CK_OBJECT_CLASS CKO_CERTIFICATE\nCKA_VALUE MULTILINE_OCTAL\n\\060\\9\nEND\n"),
+			expPartialReasons: []string{
+				`failed to read certdata.txt: line 4: malformed octal escape "\\9"`,
+			},
+		},
+		"files without data should be ignored": {
+			data: mustReadFile(t, "testdata/test-1"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsedCerts, err := (certdata{}).Find(context.TODO(), "certdata.txt", func() (io.ReadSeeker, error) {
+				return bytes.NewReader(test.data), nil
+			})
+			require.NoError(t, err)
+
+			gotTrust := make(map[string]trust)
+			gotDistrustAfter := make(map[string]time.Time)
+			for _, r := range parsedCerts.Found {
+				assert.Equal(t, "certdata", r.Parser)
+				assert.Equal(t, EncodingDER, r.Encoding)
+				assert.Equal(t, ContainerFormatCertdata, r.ContainerFormat)
+				gotTrust[r.Location] = trust{trusted: r.TrustedPurposes, rejected: r.RejectedPurposes}
+				if !r.ServerDistrustAfter.IsZero() {
+					gotDistrustAfter[r.Location] = r.ServerDistrustAfter
+				}
+			}
+			if test.expTrust == nil {
+				test.expTrust = map[string]trust{}
+			}
+			assert.Equal(t, test.expTrust, gotTrust)
+			if test.expDistrustAfter == nil {
+				test.expDistrustAfter = map[string]time.Time{}
+			}
+			assert.Equal(t, test.expDistrustAfter, gotDistrustAfter)
+			assert.Equal(t, test.expDistrusted, parsedCerts.Distrusted)
+
+			var partialsReasons []string
+			for _, r := range parsedCerts.Partials {
+				partialsReasons = append(partialsReasons, r.Reason)
+			}
+			assert.ElementsMatch(t, test.expPartialReasons, partialsReasons)
+		})
+	}
+
+	t.Run("only certdata.txt is scanned", func(t *testing.T) {
+		assert.True(t, (certdata{}).scansLocation("/usr/share/ca-certificates/mozilla/certdata.txt"))
+		assert.False(t, (certdata{}).scansLocation("/etc/ssl/certs/ca-certificates.crt"))
+	})
+}
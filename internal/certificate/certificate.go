@@ -6,13 +6,27 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/md5"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 // Found is a single X.509 certificate which was found by a parser inside the
@@ -24,17 +38,98 @@ type Found struct {
 	// Parser is the name of the parser which discovered the certificate.
 	Parser string
 
+	// Encoding is how the data holding the certificate is encoded in its file,
+	// such as EncodingPEM or EncodingDER.
+	Encoding string
+
+	// ContainerFormat is the format of the structure the certificate was
+	// found in, such as ContainerFormatPKCS7, or ContainerFormatX509 for a
+	// certificate on its own.
+	ContainerFormat string
+
 	// Certificate is the parsed certificate. May be nil if the parser failed to
 	// decode a found certificate.
 	Certificate *x509.Certificate
 
+	// FingerprintMd5 is the MD5 fingerprint of the certificate. MD5 is
+	// insecure, and this is only intended for matching against externally
+	// supplied hashes.
+	FingerprintMd5 [16]byte
+
 	// Fingerprint is the SHA-1 fingerprint of the certificate.
 	FingerprintSha1 [20]byte
 
 	// Fingerprint is the SHA-256 fingerprint of the certificate.
 	FingerprintSha256 [32]byte
+
+	// Fingerprint is the SHA-512 fingerprint of the certificate.
+	FingerprintSha512 [64]byte
+
+	// SpkiSha256 is the SHA-256 digest of the certificate's DER encoded
+	// SubjectPublicKeyInfo. Unlike the fingerprints, this remains the same
+	// when a certificate is reissued with the same key.
+	SpkiSha256 [32]byte
+
+	// KeyAlgorithm is the algorithm of the certificate's public key, such as
+	// "RSA", "ECDSA" or "Ed25519".
+	KeyAlgorithm string
+
+	// KeySizeBits is the size of the certificate's public key in bits; the
+	// modulus size of RSA keys, and the curve size of ECDSA keys. Zero if the
+	// size of the key is not known.
+	KeySizeBits int
+
+	// SelfSigned is true if the certificate is issued by itself, as root
+	// certificates are. See IsSelfSigned.
+	SelfSigned bool
+
+	// IsCA is true if the certificate's basic constraints mark it as a
+	// certificate authority, which may issue other certificates.
+	IsCA bool
+
+	// LayerDigest is the digest of the image layer which introduced the
+	// certificate's file. Empty if the certificate wasn't found in an image
+	// layer.
+	LayerDigest string
+
+	// TrustedPurposes and RejectedPurposes are the purposes the certificate
+	// is explicitly trusted or rejected for, when it was found with OpenSSL
+	// trust settings. Purposes are named after their extended key usage, such
+	// as "serverAuth", or are dotted OIDs if unknown.
+	TrustedPurposes  []string
+	RejectedPurposes []string
+
+	// ServerDistrustAfter is the time after which certificates issued by
+	// this certificate authority are distrusted for TLS servers, when it was
+	// found in a trust store recording one, such as Mozilla's certdata.txt.
+	// It is zero if there is no such time.
+	ServerDistrustAfter time.Time
 }
 
+// Encodings of the data holding a found certificate.
+const (
+	EncodingPEM = "PEM"
+	EncodingDER = "DER"
+	// EncodingBase64 is base64 encoded PEM or DER, such as a string value
+	// in a YAML or JSON file.
+	EncodingBase64 = "base64"
+)
+
+// Formats of the structures holding a found certificate.
+const (
+	ContainerFormatX509       = "X.509"
+	ContainerFormatPKCS7      = "PKCS#7"
+	ContainerFormatPKCS12     = "PKCS#12"
+	ContainerFormatJKS        = "JKS"
+	ContainerFormatNSS        = "NSS"
+	ContainerFormatCertdata   = "certdata"
+	ContainerFormatExecutable = "executable"
+	ContainerFormatYAML       = "YAML"
+	ContainerFormatJSON       = "JSON"
+
+	ContainerFormatWindowsRegistry = "Windows registry"
+)
+
 // Partial is a "partial" certificate. Usually the result of parsing something that looks like a certificate but isn't
 // valid, or some other anomaly. These are often worthy of further investigation, but aren't compatible with Paranoia's
 // various certificate operations.
@@ -50,6 +145,90 @@ type Partial struct {
 	Reason string
 }
 
+// Distrusted is a certificate which a trust store explicitly distrusts, but
+// which is not itself in the trust store, such as the certificates Mozilla
+// distrusts in certdata.txt. Only the certificate's fingerprint is known.
+type Distrusted struct {
+	// Location is the filepath location of the trust store, and the label of
+	// the distrust if it has one.
+	Location string
+
+	// Parser is the name of the parser which discovered the distrust.
+	Parser string
+
+	// FingerprintSha1 is the SHA1 fingerprint of the distrusted certificate.
+	// It is zero if the trust store doesn't give it.
+	FingerprintSha1 [20]byte
+
+	// RejectedPurposes are the purposes the certificate is distrusted for,
+	// such as "serverAuth".
+	RejectedPurposes []string
+}
+
+// newFound parses the given DER encoded certificate, and returns a Found
+// with the certificate and its fingerprints populated.
+func newFound(location, parser string, der []byte) (Found, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return Found{}, err
+	}
+
+	return Found{
+		Location:          location,
+		Parser:            parser,
+		Certificate:       cert,
+		FingerprintMd5:    md5.Sum(der),
+		FingerprintSha1:   sha1.Sum(der),
+		FingerprintSha256: sha256.Sum256(der),
+		FingerprintSha512: sha512.Sum512(der),
+		SpkiSha256:        sha256.Sum256(cert.RawSubjectPublicKeyInfo),
+		KeyAlgorithm:      cert.PublicKeyAlgorithm.String(),
+		KeySizeBits:       KeySizeBits(cert.PublicKey),
+		SelfSigned:        IsSelfSigned(cert),
+		IsCA:              cert.BasicConstraintsValid && cert.IsCA,
+	}, nil
+}
+
+// KeySizeBits returns the size in bits of the given public key, or zero if the
+// type of key is not known.
+func KeySizeBits(pub crypto.PublicKey) int {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return pub.N.BitLen()
+	case *ecdsa.PublicKey:
+		return pub.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return len(pub) * 8
+	default:
+		return 0
+	}
+}
+
+// IsSelfSigned returns true if the given certificate is issued by itself; its
+// issuer is its own subject, and its signature verifies with its own public
+// key. Signatures using insecure algorithms, such as MD5, are still verified.
+func IsSelfSigned(cert *x509.Certificate) bool {
+	if cert == nil || !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+
+	err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature)
+	if errors.As(err, new(x509.InsecureAlgorithmError)) && cert.SignatureAlgorithm == x509.MD5WithRSA {
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		digest := md5.Sum(cert.RawTBSCertificate)
+		err = rsa.VerifyPKCS1v15(pub, crypto.MD5, digest[:], cert.Signature)
+	}
+
+	return err == nil
+}
+
+// defaultSpillThreshold is the default size above which files are read from
+// disk by parsers, rather than being held in memory.
+const defaultSpillThreshold = 1 << 30
+
 type rseekerOpener func() (io.ReadSeeker, error)
 
 type ParsedCertificates struct {
@@ -58,105 +237,455 @@ type ParsedCertificates struct {
 	// Partials is a slice of any partial certificates we've found. This might be fragments of certificates in memory
 	// or other anomalies.
 	Partials []Partial
+	// Distrusted are the certificates which trust stores explicitly
+	// distrust, but which are not themselves in the trust store. These are
+	// not partials, as nothing failed to be parsed.
+	Distrusted []Distrusted
+	// SkippedLayers is the number of image layers which were not scanned, as
+	// they were identical to a layer which was already scanned.
+	SkippedLayers int
+	// Stats are statistics about the files which were scanned.
+	Stats ScanStats
+	// ImageDigest is the digest of the manifest of the image which was
+	// scanned, such as "sha256:...". It is empty if what was scanned wasn't
+	// an image, such as a directory.
+	ImageDigest string
+	// Symlinks are the symbolic links found in the scanned tars, from their
+	// location to the location of their target. They are only recorded if
+	// symbolic links are resolved.
+	Symlinks map[string]string
+}
+
+// ScanStats are statistics about a scan, so that a scan which examined
+// nothing, such as of empty or malformed input, can be noticed.
+type ScanStats struct {
+	// Files is the number of files which were scanned.
+	Files int
+	// SkippedFiles is the number of entries which were not scanned, as they
+	// were not regular files, or were filtered out.
+	SkippedFiles int
+	// SkippedLarge is the number of files which were not scanned, as they
+	// were larger than the maximum file size.
+	SkippedLarge int
+	// Bytes is the total size of the files which were scanned.
+	Bytes int64
+}
+
+// Add adds the statistics of another scan, such as of another image layer.
+func (s *ScanStats) Add(t ScanStats) {
+	s.Files += t.Files
+	s.SkippedFiles += t.SkippedFiles
+	s.SkippedLarge += t.SkippedLarge
+	s.Bytes += t.Bytes
+}
+
+// Sort orders the certificates by their location, parser, and SHA-256
+// fingerprint, and the partials by their location, parser, and reason, so that
+// results are the same on every scan, however files were scheduled between
+// workers.
+func (p *ParsedCertificates) Sort() {
+	sort.SliceStable(p.Found, func(i, j int) bool {
+		a, b := p.Found[i], p.Found[j]
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		if a.Parser != b.Parser {
+			return a.Parser < b.Parser
+		}
+		return bytes.Compare(a.FingerprintSha256[:], b.FingerprintSha256[:]) < 0
+	})
+	sort.SliceStable(p.Partials, func(i, j int) bool {
+		a, b := p.Partials[i], p.Partials[j]
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		if a.Parser != b.Parser {
+			return a.Parser < b.Parser
+		}
+		return a.Reason < b.Reason
+	})
+	sort.SliceStable(p.Distrusted, func(i, j int) bool {
+		a, b := p.Distrusted[i], p.Distrusted[j]
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return bytes.Compare(a.FingerprintSha1[:], b.FingerprintSha1[:]) < 0
+	})
 }
 
 func (p *ParsedCertificates) appendParsed(q *ParsedCertificates) {
 	p.Found = append(p.Found, q.Found...)
 	p.Partials = append(p.Partials, q.Partials...)
+	p.Distrusted = append(p.Distrusted, q.Distrusted...)
+	p.SkippedLayers += q.SkippedLayers
+	p.Stats.Add(q.Stats)
 }
 
 // parser is the interface implemented by X.509 certificate parsers.
 type parser interface {
 	Find(context.Context, string, rseekerOpener) (*ParsedCertificates, error)
+	// name returns the name of the parser, as recorded on the certificates
+	// it finds.
+	name() string
+}
+
+// locationFilter is optionally implemented by parsers which only scan files
+// at particular locations, such as files with a well-known name. Other files
+// are skipped without being read.
+type locationFilter interface {
+	scansLocation(location string) bool
 }
 
 // FindCertificates will scan a container image, given as a file handler to a TAR file, for certificates and return them.
-func FindCertificates(ctx context.Context, imageTar io.Reader) (*ParsedCertificates, error) {
-	var (
-		parsers = []parser{pem{}}
-		parsed  = &ParsedCertificates{}
-	)
+// The TAR file may be a gzip or zstd compressed layer blob, which is decompressed transparently.
+// Files which a parser fails to scan are recorded as partial certificates, unless WithStrictParse is given, so an error is only
+// returned if the TAR file itself can't be read.
+func FindCertificates(ctx context.Context, imageTar io.Reader, opts ...Option) (*ParsedCertificates, error) {
+	o := makeOptions(opts...)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	imageTar, closeTar, err := decompress(imageTar)
+	if err != nil {
+		return nil, err
+	}
+	defer closeTar()
 
+	// Entries are read from the tar sequentially, and each is then scanned by
+	// the pool while the next is read.
+	pool := newScanPool(ctx, o)
 	tz := tar.NewReader(imageTar)
+	var stats ScanStats
+	symlinks := make(map[string]string)
 
 	for {
+		// If context has been cancelled, or scanning a file failed, exit
+		// scanning.
+		select {
+		case <-pool.ctx.Done():
+			pool.fail(pool.ctx.Err())
+			return pool.wait()
+		default:
+		}
+
 		header, err := tz.Next()
 		if err == io.EOF {
 			break
 		}
 
 		if err != nil {
-			return nil, err
+			pool.fail(err)
+			return pool.wait()
+		}
+
+		location := filepath.Join("/", header.Name)
+		logger := o.logger.WithField("location", location)
+
+		if o.headerFilter != nil && !o.headerFilter(header) {
+			logger.Debug("Skipping file, as it was filtered out")
+			stats.SkippedFiles++
+			continue
+		}
+
+		// Symbolic links are recorded, so that certificates can be found at
+		// their location once every file has been scanned.
+		if header.Typeflag == tar.TypeSymlink && o.resolveSymlinks && o.pathFilter.scans(location) {
+			logger.WithField("target", header.Linkname).Debug("Recording symbolic link")
+			symlinks[location] = symlinkTarget(location, header.Linkname)
+			stats.SkippedFiles++
+			continue
 		}
 
 		// If file is not a regular file, ignore.
 		if header.Typeflag != tar.TypeReg {
+			logger.Debug("Skipping file, as it isn't a regular file")
+			stats.SkippedFiles++
 			continue
 		}
 
-		opener, oCleanup, err := openerForFile(ctx, header, tz)
-		if err != nil {
-			return nil, err
+		if !o.pathFilter.scans(location) {
+			logger.Debug("Skipping file, as it doesn't match the path filter")
+			stats.SkippedFiles++
+			continue
 		}
 
-		var (
-			wg   sync.WaitGroup
-			lock sync.Mutex
-			errs []string
-		)
+		// Files which are too large are skipped before they are read, so
+		// that they are never spilled to disk.
+		if o.tooLarge(header.Size) {
+			logger.WithField("size", header.Size).Debug("Skipping file, as it is larger than the maximum file size")
+			stats.SkippedLarge++
+			continue
+		}
+		stats.Files++
+		stats.Bytes += header.Size
 
-		wg.Add(len(parsers))
+		opener, oCleanup, err := openerForFile(pool.ctx, header, tz, o.spillThreshold)
+		if err != nil {
+			pool.fail(err)
+			return pool.wait()
+		}
 
-		// Run all parsers.
-		for _, p := range parsers {
-			go func(p parser) {
-				defer wg.Done()
-				parserParsed, err := p.Find(ctx, filepath.Join("/", header.Name), opener)
-				lock.Lock()
-				defer lock.Unlock()
-				if err != nil {
-					errs = append(errs, err.Error())
-				}
-				parsed.appendParsed(parserParsed)
-			}(p)
+		if err := pool.add(location, opener, oCleanup); err != nil {
+			pool.fail(err)
+			return pool.wait()
 		}
+	}
 
-		wg.Wait()
+	parsed, err := pool.wait()
+	if err != nil {
+		return nil, err
+	}
 
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
+	for i := range parsed.Found {
+		parsed.Found[i].LayerDigest = o.layerDigest
+	}
+	parsed.Stats = stats
+	if o.resolveSymlinks {
+		parsed.Symlinks = symlinks
+		parsed.ResolveSymlinks()
+	}
+	parsed.Sort()
+
+	logger := o.logger
+	if o.layerDigest != "" {
+		logger = logger.WithField("layer", o.layerDigest)
+	}
+	logScanned(logger, parsed)
+
+	return parsed, nil
+}
+
+// maxSymlinkHops is the number of symbolic links followed when resolving a
+// path, beyond which the links are assumed to form a loop. This is the same
+// limit as Linux.
+const maxSymlinkHops = 40
+
+// symlinkTarget returns the absolute location of the target of the symbolic
+// link at the given location.
+func symlinkTarget(location, linkname string) string {
+	if path.IsAbs(linkname) {
+		return path.Clean(linkname)
+	}
+	return path.Join(path.Dir(location), linkname)
+}
+
+// resolveSymlinks returns the location the given location refers to, once
+// every symbolic link in it is followed. False is returned if the links form
+// a loop.
+func resolveSymlinks(symlinks map[string]string, location string) (string, bool) {
+	for hops := 0; ; {
+		resolved := true
+		// Find the shortest prefix of the location which is a link, since
+		// the links below it are only meaningful once it is resolved.
+		for i := 1; i <= len(location); i++ {
+			if i < len(location) && location[i] != '/' {
+				continue
+			}
+			target, ok := symlinks[location[:i]]
+			if !ok {
+				continue
+			}
+			if hops++; hops > maxSymlinkHops {
+				return "", false
+			}
+			location = path.Join(target, location[i:])
+			resolved = false
+			break
 		}
+		if resolved {
+			return location, true
+		}
+	}
+}
 
-		if err := oCleanup(); err != nil {
-			errs = append(errs, err.Error())
+// ResolveSymlinks adds a copy of every certificate found at the target of a
+// symbolic link, or below it if the target is a directory, at the location of
+// the link. Certificates already found at a location are not added again, so
+// this may be called again once more links are known, such as those of other
+// image layers. Links which form a loop are ignored.
+func (p *ParsedCertificates) ResolveSymlinks() {
+	type key struct {
+		location    string
+		parser      string
+		fingerprint [32]byte
+	}
+	existing := make(map[key]bool, len(p.Found))
+	for _, f := range p.Found {
+		existing[key{f.Location, f.Parser, f.FingerprintSha256}] = true
+	}
+
+	links := make([]string, 0, len(p.Symlinks))
+	for link := range p.Symlinks {
+		links = append(links, link)
+	}
+	sort.Strings(links)
+
+	found := p.Found
+	for _, link := range links {
+		target, ok := resolveSymlinks(p.Symlinks, link)
+		if !ok {
+			continue
 		}
+		for _, f := range found {
+			if f.Location != target && !strings.HasPrefix(f.Location, target+"/") {
+				continue
+			}
+			alias := f
+			alias.Location = link + strings.TrimPrefix(f.Location, target)
+			k := key{alias.Location, alias.Parser, alias.FingerprintSha256}
+			if existing[k] {
+				continue
+			}
+			existing[k] = true
+			p.Found = append(p.Found, alias)
+		}
+	}
+}
+
+// logScanned logs the statistics of a finished scan.
+func logScanned(logger logrus.FieldLogger, parsed *ParsedCertificates) {
+	logger.WithFields(logrus.Fields{
+		"files":        parsed.Stats.Files,
+		"skippedFiles": parsed.Stats.SkippedFiles,
+		"certificates": len(parsed.Found),
+		"partials":     len(parsed.Partials),
+	}).Info("Finished scanning")
+}
 
-		if len(errs) > 0 {
-			return parsed, fmt.Errorf("parser error finding certificates: %s", strings.Join(errs, "; "))
+// findInFile runs all of the given parsers concurrently over a single file,
+// and returns everything they found. Results are ordered by parser name, so
+// that they are the same between runs. Each parser error is logged as a
+// warning, and recorded as a partial certificate, so that one malformed file
+// doesn't abandon the scan. If strict, parser errors are instead returned.
+func findInFile(ctx context.Context, parsers []parser, location string, opener rseekerOpener, logger logrus.FieldLogger, strict bool) (*ParsedCertificates, error) {
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		errs   []string
+		parsed = &ParsedCertificates{}
+	)
+
+	// Reads by parsers fail once the context is done, so that parsing large
+	// files is abandoned promptly on cancellation.
+	opener = contextOpener(ctx, opener)
+
+	logger = logger.WithField("location", location)
+	logger.Debug("Scanning file")
+
+	// Run all parsers which scan the file.
+	for _, p := range parsers {
+		if f, ok := p.(locationFilter); ok && !f.scansLocation(location) {
+			continue
 		}
+		wg.Add(1)
+		go func(p parser) {
+			defer wg.Done()
+			parserLogger := logger.WithField("parser", p.name())
+			parserParsed, err := p.Find(ctx, location, opener)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				// Errors caused by cancellation aren't the file's fault.
+				if ctx.Err() == nil {
+					parserLogger.WithError(err).Warn("Parser failed to scan file")
+				}
+				if strict {
+					errs = append(errs, err.Error())
+				} else {
+					parsed.Partials = append(parsed.Partials, Partial{
+						Location: location,
+						Parser:   p.name(),
+						Reason:   fmt.Sprintf("parser failed to scan file: %s", err),
+					})
+				}
+				return
+			}
+			for _, f := range parserParsed.Found {
+				parserLogger.WithField("subject", f.Certificate.Subject.String()).Debug("Found certificate")
+			}
+			for _, p := range parserParsed.Partials {
+				parserLogger.WithField("reason", p.Reason).Debug("Found partial certificate")
+			}
+			parsed.appendParsed(parserParsed)
+		}(p)
+	}
+
+	wg.Wait()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
 	}
 
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("parser error finding certificates: %s", strings.Join(errs, "; "))
+	}
+
+	sort.SliceStable(parsed.Found, func(i, j int) bool {
+		return parsed.Found[i].Parser < parsed.Found[j].Parser
+	})
+	sort.SliceStable(parsed.Partials, func(i, j int) bool {
+		return parsed.Partials[i].Parser < parsed.Partials[j].Parser
+	})
+
 	return parsed, nil
 }
 
+// readWhole reads the whole of a file which a parser must decode in full, such
+// as a PKCS#12 file, unless it is larger than max bytes, in which case false
+// is returned without reading it all. This keeps the memory used by a scan
+// bounded by the spill threshold, as files larger than it have been spilled
+// to disk. A max of zero or less reads files of any size.
+func readWhole(r io.Reader, max int64) ([]byte, bool, error) {
+	if max <= 0 {
+		data, err := io.ReadAll(r)
+		return data, err == nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(data)) > max {
+		return nil, false, nil
+	}
+	return data, true, nil
+}
+
+// tooLargeReason is the reason recorded on the partial of a file which was
+// too large for a parser to read whole.
+func tooLargeReason(max int64) string {
+	return fmt.Sprintf("file is larger than the spill threshold of %d bytes, so was not decoded", max)
+}
+
 // openerForFile returns an rseekerOpener and clean-up function for the given
-// tarball file. Depending of the size of the file, the ReadSeeker will
-// ordinate from an in-memory buffer, or a temporary file.
-func openerForFile(ctx context.Context, header *tar.Header, reader io.Reader) (rseekerOpener, func() error, error) {
-	// If file is larger than a Gig, write to a temporary file.
-	if header.Size > (1 << 30) {
+// tarball file. Files larger than the spill threshold are read from a
+// temporary file, whereas smaller files are read from an in-memory buffer.
+func openerForFile(ctx context.Context, header *tar.Header, reader io.Reader, spillThreshold int64) (rseekerOpener, func() error, error) {
+	// Reading the file is abandoned if the context is cancelled.
+	reader = &contextReader{ctx: ctx, r: reader}
+
+	// If file is larger than the threshold, write to a temporary file.
+	if header.Size > spillThreshold {
 		tmp, err := os.CreateTemp(os.TempDir(), strings.ReplaceAll(filepath.Clean(header.Name), string(filepath.Separator), "-"))
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to create temporary file: %w", err)
 		}
 
 		if _, err := io.Copy(tmp, reader); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
 			return nil, nil, fmt.Errorf("failed to write image file to temporary file: %w", err)
 		}
 
 		if err := tmp.Close(); err != nil {
+			os.Remove(tmp.Name())
 			return nil, nil, fmt.Errorf("failed to close temporary file: %w", err)
 		}
 
@@ -167,6 +696,9 @@ func openerForFile(ctx context.Context, header *tar.Header, reader io.Reader) (r
 		// Simple in-memory buffer.
 		ff, err := io.ReadAll(reader)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
 			return nil, nil, fmt.Errorf("failed to read image file: %w", err)
 		}
 		return func() (io.ReadSeeker, error) {
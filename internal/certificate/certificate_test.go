@@ -3,15 +3,29 @@ package certificate
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	encpem "encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -24,7 +38,7 @@ func Test_openerForFile(t *testing.T) {
 		rsopener, closer, err := openerForFile(context.TODO(), &tar.Header{
 			Name: name,
 			Size: 999999999999999999,
-		}, buf)
+		}, buf, defaultSpillThreshold)
 		require.NoError(t, err)
 
 		dir, err := os.ReadDir(os.TempDir())
@@ -60,7 +74,7 @@ func Test_openerForFile(t *testing.T) {
 		rsopener, closer, err := openerForFile(context.TODO(), &tar.Header{
 			Name: name,
 			Size: 10,
-		}, buf)
+		}, buf, defaultSpillThreshold)
 		require.NoError(t, err)
 
 		dir, err := os.ReadDir(os.TempDir())
@@ -84,4 +98,522 @@ func Test_openerForFile(t *testing.T) {
 		assert.NoError(t, closer())
 		assert.NoFileExists(t, filename)
 	})
+
+	t.Run("the spill threshold decides whether a file is written to disk", func(t *testing.T) {
+		const threshold = 1024
+		for name, test := range map[string]struct {
+			size     int64
+			wantDisk bool
+		}{
+			"just over the threshold is written to disk": {size: threshold + 1, wantDisk: true},
+			"at the threshold is held in memory":         {size: threshold, wantDisk: false},
+		} {
+			t.Run(name, func(t *testing.T) {
+				name := fmt.Sprintf("spill-file-%d", time.Now().UnixNano())
+				content := bytes.Repeat([]byte("a"), int(test.size))
+				rsopener, closer, err := openerForFile(context.TODO(), &tar.Header{
+					Name: name,
+					Size: test.size,
+				}, bytes.NewReader(content), threshold)
+				require.NoError(t, err)
+				defer closer()
+
+				dir, err := os.ReadDir(os.TempDir())
+				require.NoError(t, err)
+				var onDisk bool
+				for _, f := range dir {
+					if strings.Contains(f.Name(), name) {
+						onDisk = true
+					}
+				}
+				assert.Equal(t, test.wantDisk, onDisk)
+
+				rs, err := rsopener()
+				require.NoError(t, err)
+				b, err := io.ReadAll(rs)
+				require.NoError(t, err)
+				assert.Equal(t, content, b)
+				if f, ok := rs.(*os.File); ok {
+					f.Close()
+				}
+			})
+		}
+	})
+}
+
+func TestFindCertificates_SpillThreshold(t *testing.T) {
+	for _, threshold := range []int64{0, -1} {
+		_, err := FindCertificates(context.TODO(), bytes.NewReader(nil), WithSpillThreshold(threshold))
+		assert.ErrorContains(t, err, "spill threshold must be positive")
+
+		_, err = FindCertificatesInDir(context.TODO(), t.TempDir(), WithSpillThreshold(threshold))
+		assert.ErrorContains(t, err, "spill threshold must be positive")
+	}
+}
+
+func TestFindCertificates_SpillThresholdLimitsWholeReads(t *testing.T) {
+	root := t.TempDir()
+	for name, testdata := range map[string]string{
+		"bundle.p7b": "testdata/pkcs7-der",
+		"cert9.db":   "testdata/cert9.db",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), mustReadFile(t, testdata), 0o644))
+	}
+
+	parsed, err := FindCertificatesInDir(context.TODO(), root)
+	require.NoError(t, err)
+	assert.NotEmpty(t, parsed.Found)
+	for _, p := range parsed.Partials {
+		assert.NotEqual(t, tooLargeReason(defaultSpillThreshold), p.Reason)
+	}
+
+	// Files larger than the spill threshold which must be decoded whole are
+	// partials, rather than being read into memory.
+	parsed, err = FindCertificatesInDir(context.TODO(), root, WithSpillThreshold(64))
+	require.NoError(t, err)
+	assert.Empty(t, parsed.Found)
+	assert.Equal(t, []Partial{
+		{Location: "bundle.p7b", Parser: "pkcs7", Reason: tooLargeReason(64)},
+		{Location: "cert9.db", Parser: "nss", Reason: tooLargeReason(64)},
+	}, parsed.Partials)
+}
+
+func Test_readWhole(t *testing.T) {
+	data := []byte("0123456789")
+	for max, exp := range map[int64]bool{0: true, 9: false, 10: true, 11: true} {
+		got, ok, err := readWhole(bytes.NewReader(data), max)
+		require.NoError(t, err)
+		assert.Equal(t, exp, ok, max)
+		if exp {
+			assert.Equal(t, data, got, max)
+		}
+	}
+}
+
+func TestFindCertificates_Concurrency(t *testing.T) {
+	for _, concurrency := range []int{0, -1} {
+		_, err := FindCertificates(context.TODO(), bytes.NewReader(nil), WithConcurrency(concurrency))
+		assert.ErrorContains(t, err, "concurrency must be positive")
+
+		_, err = FindCertificatesInDir(context.TODO(), t.TempDir(), WithConcurrency(concurrency))
+		assert.ErrorContains(t, err, "concurrency must be positive")
+	}
+
+	tarball := mustMakeTar(t, 50)
+
+	sequential, err := FindCertificates(context.TODO(), bytes.NewReader(tarball), WithConcurrency(1))
+	require.NoError(t, err)
+	require.Len(t, sequential.Found, 150)
+	assert.Equal(t, 50, sequential.Stats.Files)
+	assert.Equal(t, 0, sequential.Stats.SkippedFiles)
+	chain, err := os.ReadFile("testdata/test-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(50*len(chain)), sequential.Stats.Bytes)
+	for i, f := range sequential.Found {
+		assert.Equal(t, fmt.Sprintf("/etc/ssl/certs/%03d.pem", i/3), f.Location)
+	}
+
+	for i := 0; i < 5; i++ {
+		concurrent, err := FindCertificates(context.TODO(), bytes.NewReader(tarball), WithConcurrency(8))
+		require.NoError(t, err)
+		assert.Equal(t, sequential, concurrent, "expected concurrent results to be in the same order as sequential results")
+	}
+}
+
+func TestFindCertificates_Ordering(t *testing.T) {
+	chain, err := os.ReadFile("testdata/test-1")
+	require.NoError(t, err)
+
+	// Files are written in the reverse of the order they are reported in.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 20; i > 0; i-- {
+		data := chain
+		if i%5 == 0 {
+			data = []byte("-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydGlmaWNhdGU=\n-----END CERTIFICATE-----\n")
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     fmt.Sprintf("etc/ssl/certs/%03d.pem", i),
+			Mode:     0644,
+			Size:     int64(len(data)),
+		}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	first, err := FindCertificates(context.TODO(), bytes.NewReader(buf.Bytes()), WithConcurrency(8))
+	require.NoError(t, err)
+	require.Len(t, first.Found, 48)
+	require.Len(t, first.Partials, 4)
+
+	assert.True(t, sort.SliceIsSorted(first.Found, func(i, j int) bool {
+		a, b := first.Found[i], first.Found[j]
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return bytes.Compare(a.FingerprintSha256[:], b.FingerprintSha256[:]) < 0
+	}), "expected certificates to be sorted by location and fingerprint")
+	assert.Equal(t, "/etc/ssl/certs/001.pem", first.Found[0].Location)
+	assert.Equal(t, "/etc/ssl/certs/005.pem", first.Partials[0].Location)
+
+	for i := 0; i < 10; i++ {
+		again, err := FindCertificates(context.TODO(), bytes.NewReader(buf.Bytes()), WithConcurrency(8))
+		require.NoError(t, err)
+		assert.Equal(t, first, again, "expected every scan to report results in the same order")
+	}
+}
+
+func TestParsedCertificates_Sort(t *testing.T) {
+	parsed := &ParsedCertificates{
+		Found: []Found{
+			{Location: "/b", Parser: "pem", FingerprintSha256: [32]byte{1}},
+			{Location: "/a", Parser: "pkcs7", FingerprintSha256: [32]byte{1}},
+			{Location: "/b", Parser: "pem", FingerprintSha256: [32]byte{0}},
+			{Location: "/a", Parser: "pem", FingerprintSha256: [32]byte{2}},
+		},
+		Partials: []Partial{
+			{Location: "/b", Parser: "pem", Reason: "b"},
+			{Location: "/b", Parser: "pem", Reason: "a"},
+			{Location: "/a", Parser: "pkcs7", Reason: "c"},
+		},
+	}
+	parsed.Sort()
+
+	assert.Equal(t, []Found{
+		{Location: "/a", Parser: "pem", FingerprintSha256: [32]byte{2}},
+		{Location: "/a", Parser: "pkcs7", FingerprintSha256: [32]byte{1}},
+		{Location: "/b", Parser: "pem", FingerprintSha256: [32]byte{0}},
+		{Location: "/b", Parser: "pem", FingerprintSha256: [32]byte{1}},
+	}, parsed.Found)
+	assert.Equal(t, []Partial{
+		{Location: "/a", Parser: "pkcs7", Reason: "c"},
+		{Location: "/b", Parser: "pem", Reason: "a"},
+		{Location: "/b", Parser: "pem", Reason: "b"},
+	}, parsed.Partials)
+}
+
+func TestFindCertificates_MaxFileSize(t *testing.T) {
+	_, err := FindCertificates(context.TODO(), bytes.NewReader(nil), WithMaxFileSize(-1))
+	assert.ErrorContains(t, err, "max file size must not be negative")
+
+	chain := mustReadFile(t, "testdata/test-1")
+	tarball := mustMakeTar(t, 2)
+
+	parsed, err := FindCertificates(context.TODO(), bytes.NewReader(tarball), WithMaxFileSize(int64(len(chain))))
+	require.NoError(t, err)
+	assert.Len(t, parsed.Found, 6)
+	assert.Equal(t, 2, parsed.Stats.Files)
+	assert.Equal(t, 0, parsed.Stats.SkippedLarge)
+
+	parsed, err = FindCertificates(context.TODO(), bytes.NewReader(tarball), WithMaxFileSize(int64(len(chain)-1)))
+	require.NoError(t, err)
+	assert.Empty(t, parsed.Found)
+	assert.Equal(t, 0, parsed.Stats.Files)
+	assert.Equal(t, 2, parsed.Stats.SkippedLarge)
+	assert.Equal(t, int64(0), parsed.Stats.Bytes)
+}
+
+func TestFindCertificates_Compressed(t *testing.T) {
+	tarball := mustMakeTar(t, 2)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err := gw.Write(tarball)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	var zst bytes.Buffer
+	zw, err := zstd.NewWriter(&zst)
+	require.NoError(t, err)
+	_, err = zw.Write(tarball)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	expected, err := FindCertificates(context.TODO(), bytes.NewReader(tarball))
+	require.NoError(t, err)
+	require.Len(t, expected.Found, 6)
+
+	for name, blob := range map[string][]byte{"gzip": gz.Bytes(), "zstd": zst.Bytes()} {
+		t.Run(name+" layers should be decompressed", func(t *testing.T) {
+			parsed, err := FindCertificates(context.TODO(), bytes.NewReader(blob))
+			require.NoError(t, err)
+			assert.Equal(t, expected, parsed)
+		})
+	}
+
+	t.Run("unsupported compression should give an error", func(t *testing.T) {
+		_, err := FindCertificates(context.TODO(), bytes.NewReader(append([]byte("BZh91AY&SY"), tarball...)))
+		assert.EqualError(t, err, "unsupported bzip2 compressed layer, only gzip and zstd are supported")
+	})
+
+	t.Run("corrupt compressed layers should give an error", func(t *testing.T) {
+		_, err := FindCertificates(context.TODO(), bytes.NewReader(gz.Bytes()[:20]))
+		assert.Error(t, err)
+	})
+}
+
+func TestFindCertificates_Logging(t *testing.T) {
+	chain, err := os.ReadFile("testdata/test-1")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, h := range []*tar.Header{
+		{Typeflag: tar.TypeReg, Name: "etc/ssl/cert.pem", Size: int64(len(chain))},
+		{Typeflag: tar.TypeReg, Name: "tmp/cert.pem", Size: int64(len(chain))},
+		{Typeflag: tar.TypeSymlink, Name: "etc/ssl/link.pem", Linkname: "cert.pem"},
+	} {
+		require.NoError(t, tw.WriteHeader(h))
+		if h.Typeflag == tar.TypeReg {
+			_, err := tw.Write(chain)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+
+	logger, hook := logtest.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	_, err = FindCertificates(context.TODO(), &buf, WithLogger(logger), WithPathFilter(nil, []string{"/tmp"}))
+	require.NoError(t, err)
+
+	messages := make(map[string][]string)
+	for _, e := range hook.AllEntries() {
+		location, _ := e.Data["location"].(string)
+		messages[location] = append(messages[location], e.Message)
+	}
+	assert.Equal(t, []string{"Skipping file, as it doesn't match the path filter"}, messages["/tmp/cert.pem"])
+	assert.Equal(t, []string{"Skipping file, as it isn't a regular file"}, messages["/etc/ssl/link.pem"])
+	assert.Contains(t, messages["/etc/ssl/cert.pem"], "Scanning file")
+	assert.Contains(t, messages["/etc/ssl/cert.pem"], "Found certificate")
+	assert.Equal(t, []string{"Finished scanning"}, messages[""])
+}
+
+func TestFindCertificates_ResolveSymlinks(t *testing.T) {
+	chain, err := os.ReadFile("testdata/test-1")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, h := range []*tar.Header{
+		{Typeflag: tar.TypeReg, Name: "usr/share/ca-certificates/cert.pem", Size: int64(len(chain))},
+		{Typeflag: tar.TypeSymlink, Name: "etc/ssl/certs/cert.pem", Linkname: "/usr/share/ca-certificates/cert.pem"},
+		{Typeflag: tar.TypeSymlink, Name: "etc/ssl/certs/a1b2c3d4.0", Linkname: "cert.pem"},
+		{Typeflag: tar.TypeSymlink, Name: "etc/pki", Linkname: "../usr/share"},
+		{Typeflag: tar.TypeSymlink, Name: "loop/a", Linkname: "b"},
+		{Typeflag: tar.TypeSymlink, Name: "loop/b", Linkname: "a"},
+	} {
+		require.NoError(t, tw.WriteHeader(h))
+		if h.Typeflag == tar.TypeReg {
+			_, err := tw.Write(chain)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	tarball := buf.Bytes()
+
+	locations := func(parsed *ParsedCertificates) map[string]int {
+		l := make(map[string]int)
+		for _, f := range parsed.Found {
+			l[f.Location]++
+		}
+		return l
+	}
+
+	parsed, err := FindCertificates(context.TODO(), bytes.NewReader(tarball), WithResolveSymlinks(true))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"/usr/share/ca-certificates/cert.pem": 3,
+		"/etc/ssl/certs/cert.pem":             3,
+		"/etc/ssl/certs/a1b2c3d4.0":           3,
+		"/etc/pki/ca-certificates/cert.pem":   3,
+	}, locations(parsed))
+
+	t.Run("Resolving again adds nothing", func(t *testing.T) {
+		found := len(parsed.Found)
+		parsed.ResolveSymlinks()
+		assert.Len(t, parsed.Found, found)
+	})
+
+	t.Run("Links are skipped by default", func(t *testing.T) {
+		parsed, err := FindCertificates(context.TODO(), bytes.NewReader(tarball))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"/usr/share/ca-certificates/cert.pem": 3}, locations(parsed))
+		assert.Nil(t, parsed.Symlinks)
+	})
+}
+
+// failingParser is a parser which fails to scan every file.
+type failingParser struct{}
+
+func (_ failingParser) name() string { return "failing" }
+
+func (_ failingParser) Find(context.Context, string, rseekerOpener) (*ParsedCertificates, error) {
+	return nil, errors.New("corrupt file")
+}
+
+func Test_findInFile_ParserErrors(t *testing.T) {
+	logger, hook := logtest.NewNullLogger()
+	opener := func() (io.ReadSeeker, error) {
+		return bytes.NewReader(mustReadFile(t, "testdata/test-1")), nil
+	}
+
+	parsed, err := findInFile(context.TODO(), []parser{pem{}, failingParser{}}, "/etc/ssl/cert.pem", opener, logger, false)
+	require.NoError(t, err)
+	assert.Len(t, parsed.Found, 3)
+	assert.Equal(t, []Partial{{
+		Location: "/etc/ssl/cert.pem",
+		Parser:   "failing",
+		Reason:   "parser failed to scan file: corrupt file",
+	}}, parsed.Partials)
+
+	require.Len(t, hook.AllEntries(), 1)
+	entry := hook.LastEntry()
+	assert.Equal(t, logrus.WarnLevel, entry.Level)
+	assert.Equal(t, "Parser failed to scan file", entry.Message)
+	assert.Equal(t, logrus.Fields{"location": "/etc/ssl/cert.pem", "parser": "failing", logrus.ErrorKey: errors.New("corrupt file")}, entry.Data)
+
+	t.Run("strict parsing should fail", func(t *testing.T) {
+		_, err := findInFile(context.TODO(), []parser{pem{}, failingParser{}}, "/etc/ssl/cert.pem", opener, logger, true)
+		assert.EqualError(t, err, "parser error finding certificates: corrupt file")
+	})
+}
+
+func BenchmarkFindCertificates(b *testing.B) {
+	tarball := mustMakeTar(b, 500)
+
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := FindCertificates(context.TODO(), bytes.NewReader(tarball), WithConcurrency(concurrency)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// mustMakeTar returns a tarball containing the given number of files, each
+// holding the certificate chain from testdata/test-1.
+func mustMakeTar(t testing.TB, files int) []byte {
+	chain, err := os.ReadFile("testdata/test-1")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i < files; i++ {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     fmt.Sprintf("etc/ssl/certs/%03d.pem", i),
+			Mode:     0644,
+			Size:     int64(len(chain)),
+		}))
+		_, err := tw.Write(chain)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func Test_newFound(t *testing.T) {
+	block, _ := encpem.Decode(mustReadFile(t, "testdata/test-1"))
+	require.NotNil(t, block)
+
+	found, err := newFound("/etc/ssl/cert.pem", "pem", block.Bytes)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/etc/ssl/cert.pem", found.Location)
+	assert.Equal(t, "pem", found.Parser)
+	assert.Equal(t, "CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US", found.Certificate.Subject.String())
+	assert.Equal(t, sha256.Sum256(block.Bytes), found.FingerprintSha256)
+	assert.Equal(t, sha256.Sum256(found.Certificate.RawSubjectPublicKeyInfo), found.SpkiSha256)
+	assert.Equal(t, "RSA", found.KeyAlgorithm)
+	assert.Equal(t, 2048, found.KeySizeBits)
+	assert.True(t, found.SelfSigned)
+	assert.True(t, found.IsCA)
+
+	_, err = newFound("/etc/ssl/cert.pem", "pem", []byte("not a certificate"))
+	assert.Error(t, err)
+}
+
+func TestKeySizeBits(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1024, KeySizeBits(&rsaKey.PublicKey))
+	assert.Equal(t, 384, KeySizeBits(&ecdsaKey.PublicKey))
+	assert.Equal(t, 256, KeySizeBits(ed25519Key))
+	assert.Equal(t, 0, KeySizeBits(nil))
+}
+
+func TestIsSelfSigned(t *testing.T) {
+	var certs []*x509.Certificate
+	for rest := mustReadFile(t, "testdata/test-1"); ; {
+		var block *encpem.Block
+		block, rest = encpem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		certs = append(certs, cert)
+	}
+	require.Len(t, certs, 3)
+
+	assert.True(t, IsSelfSigned(certs[0]), "expected SHA1 signed GeoTrust root to be self-signed")
+	assert.False(t, IsSelfSigned(certs[1]), "expected intermediate to not be self-signed")
+	assert.False(t, IsSelfSigned(certs[2]), "expected leaf to not be self-signed")
+	assert.False(t, IsSelfSigned(nil))
+}
+
+// cancellingReader reads zeros, and cancels a context after a number of
+// bytes have been read.
+type cancellingReader struct {
+	cancel func()
+	after  int64
+	read   int64
+}
+
+func (c *cancellingReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	c.read += int64(len(p))
+	if c.read > c.after {
+		c.cancel()
+	}
+	return len(p), nil
+}
+
+func TestFindCertificates_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A tarball with a single entry too large to be held in memory, whose
+	// content is generated as it is read.
+	name := fmt.Sprintf("paranoia-cancel-%d", time.Now().UnixNano())
+	var header bytes.Buffer
+	require.NoError(t, tar.NewWriter(&header).WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     defaultSpillThreshold + 1,
+	}))
+	tarball := io.MultiReader(&header, &cancellingReader{cancel: cancel, after: 8 << 20})
+
+	start := time.Now()
+	parsed, err := FindCertificates(ctx, tarball)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, parsed)
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	dir, err := os.ReadDir(os.TempDir())
+	require.NoError(t, err)
+	for _, f := range dir {
+		assert.NotContains(t, f.Name(), name, "expected temporary file to be removed")
+	}
 }
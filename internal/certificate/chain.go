@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import "bytes"
+
+// Chain is a chain of found certificates, linked from each certificate to
+// its issuer.
+type Chain struct {
+	// Certificates are the certificates in the chain, starting with the
+	// certificate furthest from the root. The chain ends at a self-signed
+	// root, or at the last certificate whose issuer could not be found.
+	Certificates []Found
+
+	// Orphaned is true if the last certificate in the chain is not
+	// self-signed, and its issuer could not be found.
+	Orphaned bool
+}
+
+// Root returns the self-signed root of the chain, or nil if the chain is
+// orphaned.
+func (c Chain) Root() *Found {
+	if c.Orphaned || len(c.Certificates) == 0 {
+		return nil
+	}
+	return &c.Certificates[len(c.Certificates)-1]
+}
+
+// Intermediates returns the certificate authorities in the chain which are
+// not self-signed roots.
+func (c Chain) Intermediates() []Found {
+	var intermediates []Found
+	for _, f := range c.Certificates {
+		if isIntermediate(f) {
+			intermediates = append(intermediates, f)
+		}
+	}
+	return intermediates
+}
+
+// Orphan returns the last certificate of an orphaned chain if it's an
+// intermediate, since an intermediate without its issuer is often the sign
+// of a misconfigured bundle. Otherwise nil is returned.
+func (c Chain) Orphan() *Found {
+	if !c.Orphaned || len(c.Certificates) == 0 {
+		return nil
+	}
+	last := c.Certificates[len(c.Certificates)-1]
+	if !isIntermediate(last) {
+		return nil
+	}
+	return &last
+}
+
+// BuildChains links the given certificates to their issuers, and returns a
+// chain for each certificate which doesn't issue any of the others. Issuers
+// are matched by their subject, and by their subject key ID where both
+// certificates have key IDs. Certificates found more than once, by SHA-256
+// fingerprint, are only included once, and certificates which failed to
+// parse are ignored.
+func BuildChains(founds []Found) []Chain {
+	var certs []Found
+	seen := make(map[[32]byte]bool)
+	for _, f := range founds {
+		if f.Certificate == nil || seen[f.FingerprintSha256] {
+			continue
+		}
+		seen[f.FingerprintSha256] = true
+		certs = append(certs, f)
+	}
+
+	issuers := make(map[int]int)
+	isIssuer := make(map[int]bool)
+	for i, f := range certs {
+		if IsSelfSigned(f.Certificate) {
+			continue
+		}
+		for j, candidate := range certs {
+			if i != j && issuedBy(f, candidate) {
+				issuers[i] = j
+				isIssuer[j] = true
+				break
+			}
+		}
+	}
+
+	var (
+		chains  []Chain
+		covered = make(map[[32]byte]bool)
+	)
+	addChain := func(i int) {
+		chain := buildChain(certs, issuers, i)
+		for _, f := range chain.Certificates {
+			covered[f.FingerprintSha256] = true
+		}
+		chains = append(chains, chain)
+	}
+	for i := range certs {
+		if !isIssuer[i] {
+			addChain(i)
+		}
+	}
+
+	// Certificates which only issue each other, such as a pair of
+	// cross-signed certificates, are never the start of a chain. Include a
+	// chain for any such certificates which would otherwise be missed.
+	for i, f := range certs {
+		if !covered[f.FingerprintSha256] {
+			addChain(i)
+		}
+	}
+
+	return chains
+}
+
+// buildChain follows the issuers from the certificate at the given index,
+// stopping if a certificate repeats.
+func buildChain(certs []Found, issuers map[int]int, i int) Chain {
+	var chain Chain
+	visited := make(map[int]bool)
+	for {
+		visited[i] = true
+		chain.Certificates = append(chain.Certificates, certs[i])
+		next, ok := issuers[i]
+		if !ok {
+			chain.Orphaned = !IsSelfSigned(certs[i].Certificate)
+			return chain
+		}
+		if visited[next] {
+			// A loop of certificates issuing each other has no root.
+			chain.Orphaned = true
+			return chain
+		}
+		i = next
+	}
+}
+
+// issuedBy returns true if the certificate appears to be issued by the
+// candidate issuer.
+func issuedBy(f, candidate Found) bool {
+	cert, issuer := f.Certificate, candidate.Certificate
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	if len(cert.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 {
+		return bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId)
+	}
+	return true
+}
+
+// isIntermediate returns true if the certificate is a certificate authority,
+// but not a self-signed root.
+func isIntermediate(f Found) bool {
+	return f.Certificate != nil && f.Certificate.IsCA && !IsSelfSigned(f.Certificate)
+}
@@ -0,0 +1,68 @@
+package certificate
+
+import (
+	encpem "encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildChains(t *testing.T) {
+	// testdata/test-1 contains a root, an intermediate issued by the root,
+	// and a leaf issued by the intermediate, in that order.
+	var certs []Found
+	for rest := mustReadFile(t, "testdata/test-1"); ; {
+		var block *encpem.Block
+		block, rest = encpem.Decode(rest)
+		if block == nil {
+			break
+		}
+		f, err := newFound("/etc/ssl/certs/test-1", "pem", block.Bytes)
+		require.NoError(t, err)
+		certs = append(certs, f)
+	}
+	require.Len(t, certs, 3)
+	root, intermediate, leaf := certs[0], certs[1], certs[2]
+
+	t.Run("Complete chain", func(t *testing.T) {
+		chains := BuildChains([]Found{root, leaf, intermediate})
+		require.Len(t, chains, 1)
+		assert.Equal(t, []Found{leaf, intermediate, root}, chains[0].Certificates)
+		assert.False(t, chains[0].Orphaned)
+		assert.Equal(t, &root, chains[0].Root())
+		assert.Equal(t, []Found{intermediate}, chains[0].Intermediates())
+		assert.Nil(t, chains[0].Orphan())
+	})
+
+	t.Run("Intermediate without its issuer is an orphan", func(t *testing.T) {
+		chains := BuildChains([]Found{intermediate, leaf})
+		require.Len(t, chains, 1)
+		assert.Equal(t, []Found{leaf, intermediate}, chains[0].Certificates)
+		assert.True(t, chains[0].Orphaned)
+		assert.Nil(t, chains[0].Root())
+		assert.Equal(t, &intermediate, chains[0].Orphan())
+	})
+
+	t.Run("Leaf without its issuer is not an orphan intermediate", func(t *testing.T) {
+		chains := BuildChains([]Found{leaf, root})
+		require.Len(t, chains, 2)
+		assert.Equal(t, []Found{leaf}, chains[0].Certificates)
+		assert.True(t, chains[0].Orphaned)
+		assert.Nil(t, chains[0].Orphan())
+		assert.Equal(t, []Found{root}, chains[1].Certificates)
+		assert.False(t, chains[1].Orphaned)
+	})
+
+	t.Run("Duplicates and unparsed certificates are ignored", func(t *testing.T) {
+		copied := root
+		copied.Location = "/usr/share/ca-certificates/root.crt"
+		chains := BuildChains([]Found{root, copied, {Location: "/broken.pem"}})
+		require.Len(t, chains, 1)
+		assert.Equal(t, []Found{root}, chains[0].Certificates)
+	})
+
+	t.Run("No certificates", func(t *testing.T) {
+		assert.Empty(t, BuildChains(nil))
+	})
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// unsupportedMagic are the signatures of compression formats used for layer
+// blobs which can't be read, so which give a clear error rather than failing
+// to read as a tar.
+var unsupportedMagic = map[string][]byte{
+	"bzip2": []byte("BZh"),
+	"xz":    {0xfd, '7', 'z', 'X', 'Z', 0x00},
+}
+
+// decompress returns a reader of the contents of r, decompressing it if it is
+// gzip or zstd compressed, as layer blobs are. Other data is returned as is.
+// The returned function releases the resources of the decompressor.
+func decompress(r io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	// Short input can't be compressed, and is left for the tar reader to
+	// report.
+	magic, _ := br.Peek(6)
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read gzip compressed layer: %w", err)
+		}
+		return gz, func() { gz.Close() }, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read zstd compressed layer: %w", err)
+		}
+		return zr, zr.Close, nil
+	}
+
+	for name, m := range unsupportedMagic {
+		if bytes.HasPrefix(magic, m) {
+			return nil, nil, fmt.Errorf("unsupported %s compressed layer, only gzip and zstd are supported", name)
+		}
+	}
+	return br, func() {}, nil
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader is an io.Reader which fails with the context's error once the
+// context is done, so that long reads are abandoned promptly on cancellation.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// contextReadSeeker is an io.ReadSeeker which fails reads with the context's
+// error once the context is done.
+type contextReadSeeker struct {
+	ctx context.Context
+	io.ReadSeeker
+}
+
+func (c *contextReadSeeker) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.ReadSeeker.Read(p)
+}
+
+// contextOpener wraps an rseekerOpener, such that reads from the files it
+// opens fail once the context is done.
+func contextOpener(ctx context.Context, opener rseekerOpener) rseekerOpener {
+	return func() (io.ReadSeeker, error) {
+		rs, err := opener()
+		if err != nil {
+			return nil, err
+		}
+		return &contextReadSeeker{ctx: ctx, ReadSeeker: rs}, nil
+	}
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+// Diff is the difference between two sets of found certificates, where
+// certificates are identified by their SHA-256 fingerprint. Each certificate
+// appears at most once, in the order it was first found, regardless of how
+// many copies of it there are.
+type Diff struct {
+	// OnlyFirst are the certificates only found in the first set.
+	OnlyFirst []Found
+	// OnlySecond are the certificates only found in the second set.
+	OnlySecond []Found
+	// Common are the certificates found in both sets, as they were found in
+	// the first set.
+	Common []Found
+}
+
+// Compare returns the difference between the two given sets of found
+// certificates.
+func Compare(first, second []Found) Diff {
+	inFirst := fingerprintSet(first)
+	inSecond := fingerprintSet(second)
+
+	var diff Diff
+	seen := make(map[[32]byte]bool)
+	for _, f := range first {
+		if seen[f.FingerprintSha256] {
+			continue
+		}
+		seen[f.FingerprintSha256] = true
+		if inSecond[f.FingerprintSha256] {
+			diff.Common = append(diff.Common, f)
+		} else {
+			diff.OnlyFirst = append(diff.OnlyFirst, f)
+		}
+	}
+	for _, f := range second {
+		if seen[f.FingerprintSha256] {
+			continue
+		}
+		seen[f.FingerprintSha256] = true
+		if !inFirst[f.FingerprintSha256] {
+			diff.OnlySecond = append(diff.OnlySecond, f)
+		}
+	}
+	return diff
+}
+
+func fingerprintSet(founds []Found) map[[32]byte]bool {
+	set := make(map[[32]byte]bool, len(founds))
+	for _, f := range founds {
+		set[f.FingerprintSha256] = true
+	}
+	return set
+}
@@ -0,0 +1,33 @@
+package certificate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	a := Found{Location: "/etc/ssl/certs/a.pem", FingerprintSha256: [32]byte{1}}
+	aCopy := Found{Location: "/etc/ssl/certs/bundle.pem", FingerprintSha256: [32]byte{1}}
+	b := Found{Location: "/etc/ssl/certs/b.pem", FingerprintSha256: [32]byte{2}}
+	c := Found{Location: "/etc/ssl/certs/c.pem", FingerprintSha256: [32]byte{3}}
+	d := Found{Location: "/etc/ssl/certs/d.pem", FingerprintSha256: [32]byte{4}}
+
+	t.Run("Splits certificates by which sets they are in", func(t *testing.T) {
+		diff := Compare([]Found{a, b, aCopy}, []Found{c, aCopy, d, c})
+		assert.Equal(t, Diff{
+			OnlyFirst:  []Found{b},
+			OnlySecond: []Found{c, d},
+			Common:     []Found{a},
+		}, diff)
+	})
+
+	t.Run("Identical sets have only common certificates", func(t *testing.T) {
+		diff := Compare([]Found{a, b}, []Found{b, a})
+		assert.Equal(t, Diff{Common: []Found{a, b}}, diff)
+	})
+
+	t.Run("Empty sets have no difference", func(t *testing.T) {
+		assert.Equal(t, Diff{}, Compare(nil, nil))
+	})
+}
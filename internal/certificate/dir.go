@@ -0,0 +1,194 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// defaultSkipDirs are the directories, relative to the scanned root, which
+// are skipped when scanning a directory. These hold virtual filesystems on a
+// live system, which are not useful to scan and may never finish reading.
+var defaultSkipDirs = []string{"/proc", "/sys", "/dev"}
+
+// FindCertificatesInDir will scan the filesystem tree rooted at the given
+// directory, such as an unpacked root filesystem or a mounted volume, for
+// certificates and return them. Locations are reported relative to root.
+// Symbolic links are not followed.
+func FindCertificatesInDir(ctx context.Context, root string, opts ...Option) (*ParsedCertificates, error) {
+	o := makeOptions(opts...)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	skipDirs := defaultSkipDirs
+	if o.skipDirs != nil {
+		skipDirs = o.skipDirs
+	}
+	skip := make(map[string]bool, len(skipDirs))
+	for _, dir := range skipDirs {
+		skip[filepath.Clean(filepath.Join("/", dir))] = true
+	}
+
+	pool := newScanPool(ctx, o)
+	var stats ScanStats
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-pool.ctx.Done():
+			return pool.ctx.Err()
+		default:
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		logger := o.logger.WithField("location", filepath.ToSlash(rel))
+
+		if d.IsDir() {
+			if skip[filepath.Join("/", rel)] || (rel != "." && o.pathFilter.excludes(filepath.ToSlash(rel))) {
+				logger.Debug("Skipping directory")
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// If file is not a regular file, ignore.
+		if !d.Type().IsRegular() {
+			logger.Debug("Skipping file, as it isn't a regular file")
+			stats.SkippedFiles++
+			return nil
+		}
+
+		if !o.pathFilter.scans(filepath.ToSlash(rel)) {
+			logger.Debug("Skipping file, as it doesn't match the path filter")
+			stats.SkippedFiles++
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if o.tooLarge(info.Size()) {
+			logger.WithField("size", info.Size()).Debug("Skipping file, as it is larger than the maximum file size")
+			stats.SkippedLarge++
+			return nil
+		}
+		stats.Files++
+		stats.Bytes += info.Size()
+
+		opener, oCleanup, err := openerForPath(path, info.Size(), o.spillThreshold)
+		if err != nil {
+			return err
+		}
+
+		return pool.add(filepath.ToSlash(rel), opener, oCleanup)
+	})
+	if err != nil {
+		pool.fail(err)
+	}
+
+	parsed, err := pool.wait()
+	if err != nil {
+		return nil, err
+	}
+	parsed.Stats = stats
+	parsed.Sort()
+	logScanned(o.logger.WithField("root", root), parsed)
+	return parsed, nil
+}
+
+// FindCertificatesInFile will scan a single file, such as a PEM bundle, for
+// certificates and return them. Locations are reported as the given path.
+func FindCertificatesInFile(ctx context.Context, path string, opts ...Option) (*ParsedCertificates, error) {
+	o := makeOptions(opts...)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	pool := newScanPool(ctx, o)
+	opener, oCleanup, err := openerForPath(path, info.Size(), o.spillThreshold)
+	if err != nil {
+		pool.fail(err)
+	} else if err := pool.add(filepath.ToSlash(path), opener, oCleanup); err != nil {
+		pool.fail(err)
+	}
+
+	parsed, err := pool.wait()
+	if err != nil {
+		return nil, err
+	}
+	parsed.Stats = ScanStats{Files: 1, Bytes: info.Size()}
+	parsed.Sort()
+	logScanned(o.logger.WithField("file", path), parsed)
+	return parsed, nil
+}
+
+// openerForPath returns an rseekerOpener and clean-up function for the file
+// at the given path. Files no larger than the spill threshold are read into an
+// in-memory buffer, whereas larger files are opened from disk by each parser.
+// Files opened from disk are closed by the clean-up function.
+func openerForPath(path string, size, spillThreshold int64) (rseekerOpener, func() error, error) {
+	if size > spillThreshold {
+		var (
+			lock  sync.Mutex
+			files []*os.File
+		)
+
+		opener := func() (io.ReadSeeker, error) {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			files = append(files, f)
+			return f, nil
+		}
+
+		cleanup := func() error {
+			lock.Lock()
+			defer lock.Unlock()
+			var err error
+			for _, f := range files {
+				if cErr := f.Close(); cErr != nil && err == nil {
+					err = fmt.Errorf("failed to close file: %w", cErr)
+				}
+			}
+			return err
+		}
+
+		return opener, cleanup, nil
+	}
+
+	// Simple in-memory buffer.
+	ff, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return func() (io.ReadSeeker, error) {
+		return bytes.NewReader(ff), nil
+	}, func() error { return nil }, nil
+}
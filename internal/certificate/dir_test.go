@@ -0,0 +1,116 @@
+package certificate
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCertificatesInDir(t *testing.T) {
+	root := t.TempDir()
+	cert := mustReadFile(t, "testdata/test-2")
+	writeFile := func(name string, data []byte) {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), data, 0o644))
+	}
+	writeFile("etc/ssl/certs/ca.pem", cert)
+	writeFile("proc/1/cert.pem", cert)
+	writeFile("sys/cert.pem", cert)
+	writeFile("usr/share/readme.txt", []byte("not a certificate"))
+	require.NoError(t, os.Symlink(filepath.Join(root, "etc/ssl/certs/ca.pem"), filepath.Join(root, "etc/ssl/link.pem")))
+
+	locations := func(t *testing.T, opts ...Option) []string {
+		parsed, err := FindCertificatesInDir(context.TODO(), root, opts...)
+		require.NoError(t, err)
+		var (
+			locs []string
+			seen = make(map[string]bool)
+		)
+		for _, f := range parsed.Found {
+			if !seen[f.Location] {
+				seen[f.Location] = true
+				locs = append(locs, f.Location)
+			}
+		}
+		return locs
+	}
+
+	t.Run("default skipped directories and symlinks should be ignored", func(t *testing.T) {
+		assert.Equal(t, []string{"etc/ssl/certs/ca.pem"}, locations(t))
+	})
+
+	t.Run("skipped directories should be configurable", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"etc/ssl/certs/ca.pem", "proc/1/cert.pem"}, locations(t, WithSkipDirs([]string{"sys"})))
+	})
+
+	t.Run("files larger than the maximum file size should be skipped", func(t *testing.T) {
+		assert.Empty(t, locations(t, WithMaxFileSize(int64(len(cert)-1))))
+	})
+
+	t.Run("a cancelled context should return an error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		_, err := FindCertificatesInDir(ctx, root)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("a missing directory should return an error", func(t *testing.T) {
+		_, err := FindCertificatesInDir(context.TODO(), filepath.Join(root, "missing"))
+		assert.Error(t, err)
+	})
+}
+
+func TestFindCertificatesInFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.pem")
+	require.NoError(t, os.WriteFile(path, mustReadFile(t, "testdata/test-2"), 0o644))
+
+	t.Run("certificates in the file should be found at its path", func(t *testing.T) {
+		parsed, err := FindCertificatesInFile(context.TODO(), path)
+		require.NoError(t, err)
+		require.NotEmpty(t, parsed.Found)
+		for _, f := range parsed.Found {
+			assert.Equal(t, filepath.ToSlash(path), f.Location)
+		}
+		assert.Equal(t, 1, parsed.Stats.Files)
+	})
+
+	t.Run("a directory should return an error", func(t *testing.T) {
+		_, err := FindCertificatesInFile(context.TODO(), filepath.Dir(path))
+		assert.Error(t, err)
+	})
+
+	t.Run("a missing file should return an error", func(t *testing.T) {
+		_, err := FindCertificatesInFile(context.TODO(), filepath.Join(filepath.Dir(path), "missing"))
+		assert.Error(t, err)
+	})
+}
+
+func Test_openerForPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, []byte("hello-world"), 0o644))
+
+	for name, size := range map[string]int64{
+		"a small file should be read from memory": 11,
+		"a large file should be read from disk":   defaultSpillThreshold + 1,
+	} {
+		t.Run(name, func(t *testing.T) {
+			opener, cleanup, err := openerForPath(path, size, defaultSpillThreshold)
+			require.NoError(t, err)
+
+			for i := 0; i < 2; i++ {
+				rs, err := opener()
+				require.NoError(t, err)
+				b, err := io.ReadAll(rs)
+				require.NoError(t, err)
+				assert.Equal(t, []byte("hello-world"), b)
+			}
+
+			assert.NoError(t, cleanup())
+		})
+	}
+}
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+// Duplicates groups the found certificates by their SHA-256 fingerprint, and
+// returns the locations of every certificate found more than once, keyed by
+// fingerprint. Locations are in the order the certificates were found, and a
+// location is repeated if a single file contains the same certificate more
+// than once.
+func (p *ParsedCertificates) Duplicates() map[[32]byte][]string {
+	locations := make(map[[32]byte][]string)
+	for _, f := range p.Found {
+		locations[f.FingerprintSha256] = append(locations[f.FingerprintSha256], f.Location)
+	}
+
+	duplicates := make(map[[32]byte][]string)
+	for fingerprint, l := range locations {
+		if len(l) > 1 {
+			duplicates[fingerprint] = l
+		}
+	}
+	return duplicates
+}
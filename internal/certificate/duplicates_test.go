@@ -0,0 +1,29 @@
+package certificate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsedCertificates_Duplicates(t *testing.T) {
+	a := [32]byte{1}
+	b := [32]byte{2}
+	c := [32]byte{3}
+
+	parsed := &ParsedCertificates{
+		Found: []Found{
+			{Location: "/etc/ssl/certs/ca-certificates.crt", FingerprintSha256: a},
+			{Location: "/etc/ssl/certs/ca-certificates.crt", FingerprintSha256: b},
+			{Location: "/etc/ssl/certs/a.pem", FingerprintSha256: a},
+			{Location: "/etc/ssl/certs/c.pem", FingerprintSha256: c},
+			{Location: "/usr/share/ca-certificates/a.crt", FingerprintSha256: a},
+		},
+	}
+
+	assert.Equal(t, map[[32]byte][]string{
+		a: {"/etc/ssl/certs/ca-certificates.crt", "/etc/ssl/certs/a.pem", "/usr/share/ca-certificates/a.crt"},
+	}, parsed.Duplicates())
+
+	assert.Empty(t, (&ParsedCertificates{}).Duplicates())
+}
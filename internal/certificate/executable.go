@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+)
+
+const (
+	// executableMinCertSize is the smallest DER certificate which is considered
+	// when scanning executables. Real certificates are rarely smaller, and
+	// ignoring smaller candidates keeps partials caused by byte sequences
+	// which happen to look like certificates low.
+	executableMinCertSize = 300
+
+	// executableHeadSize is the number of bytes read to identify executables.
+	executableHeadSize = 4
+
+	// executableWindowSize is the number of bytes of an executable which are
+	// held in memory at once while it is scanned.
+	executableWindowSize = 4 << 20
+
+	// executableMaxCertSize is the size of the largest candidate certificate;
+	// a SEQUENCE header with a two byte length, and its contents.
+	executableMaxCertSize = 4 + 0xffff
+)
+
+// executableMagics are the leading bytes of executable formats: ELF, PE, and
+// 32-bit, 64-bit, and universal Mach-O in either byte order.
+var executableMagics = [][]byte{
+	[]byte("\x7fELF"),
+	[]byte("MZ"),
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+}
+
+// executableCertPrefix is the start of the TBSCertificate of an X.509 v3
+// certificate; a SEQUENCE with a two byte length, followed by the explicitly
+// tagged version 2 (v3).
+var executableCertPrefix = []byte{0x30, 0x82}
+
+var executableVersion = []byte{0xa0, 0x03, 0x02, 0x01, 0x02}
+
+type executable struct{}
+
+func (_ executable) name() string { return "executable" }
+
+// Find finds DER encoded X.509 certificates embedded in executables, such as
+// trust anchors compiled into static binaries. Files are identified by their
+// leading bytes, so files which are not executables are skipped without being
+// read in full. Candidate certificates are only reported if they parse and
+// have a valid validity period.
+func (_ executable) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
+	file, err := rs()
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, executableHeadSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if !isExecutable(head[:n]) {
+		return &ParsedCertificates{}, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+
+	// Executables are scanned in windows, rather than read in full, so that
+	// large binaries aren't held in memory. Consecutive windows overlap by
+	// the size of the largest candidate, so that certificates spanning the
+	// end of a window are found in the next.
+	var (
+		parsed = &ParsedCertificates{}
+		buf    = make([]byte, 0, executableWindowSize+executableMaxCertSize)
+		base   int64
+	)
+	for {
+		n, err := io.ReadFull(file, buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return nil, err
+		}
+
+		// Unless this is the last window, only candidates which start far
+		// enough from its end to fit in it are considered.
+		limit := len(buf)
+		if !eof {
+			limit -= executableMaxCertSize
+		}
+		offset, err := findExecutableCertificates(ctx, location, buf, limit, base, parsed)
+		if err != nil {
+			return nil, err
+		}
+		if eof {
+			break
+		}
+
+		base += int64(offset)
+		buf = buf[:copy(buf, buf[offset:])]
+	}
+
+	return parsed, nil
+}
+
+// findExecutableCertificates adds the certificates, and partials, found in
+// the data to parsed, considering candidates which start before limit. Base is
+// the offset of the data in its file, so that partials report where they are.
+// The offset in the data from which scanning should continue is returned.
+func findExecutableCertificates(ctx context.Context, location string, data []byte, limit int, base int64, parsed *ParsedCertificates) (int, error) {
+	offset := 0
+	for offset < limit {
+		// If context has been cancelled, exit scanning.
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		default:
+		}
+
+		i := bytes.Index(data[offset:], executableCertPrefix)
+		if i < 0 || offset+i >= limit {
+			break
+		}
+		offset += i
+
+		size, ok := executableCandidateSize(data[offset:])
+		if !ok {
+			offset++
+			continue
+		}
+		der := data[offset : offset+size]
+
+		found, err := newFound(location, "executable", der)
+		if err != nil {
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: location,
+				Parser:   "executable",
+				Reason:   fmt.Sprintf("failed to parse certificate at offset %d: %s", base+int64(offset), err),
+			})
+			offset++
+			continue
+		}
+		found.Encoding, found.ContainerFormat = EncodingDER, ContainerFormatExecutable
+
+		if !found.Certificate.NotBefore.Before(found.Certificate.NotAfter) {
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: location,
+				Parser:   "executable",
+				Reason:   fmt.Sprintf("certificate at offset %d has an invalid validity period", base+int64(offset)),
+			})
+			offset++
+			continue
+		}
+
+		parsed.Found = append(parsed.Found, found)
+		offset += size
+	}
+
+	// Scanning continues from the first byte which wasn't considered, or the
+	// end of the last certificate found, if it was beyond it.
+	if offset < limit {
+		offset = limit
+	}
+	return offset, nil
+}
+
+// isExecutable returns true if the given data begins with the magic bytes of
+// a known executable format.
+func isExecutable(head []byte) bool {
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// executableCandidateSize returns the size of the DER certificate which may start
+// at the beginning of the given data. Candidates must be a SEQUENCE of at
+// least executableMinCertSize bytes, which fits in the data, and contains a v3
+// TBSCertificate.
+func executableCandidateSize(data []byte) (int, bool) {
+	// SEQUENCE and two byte length, followed by the same for the
+	// TBSCertificate, and its version.
+	if len(data) < 8+len(executableVersion) {
+		return 0, false
+	}
+
+	size := 4 + (int(data[2])<<8 | int(data[3]))
+	if size < executableMinCertSize || size > len(data) {
+		return 0, false
+	}
+
+	if !bytes.HasPrefix(data[4:], executableCertPrefix) || !bytes.HasPrefix(data[8:], executableVersion) {
+		return 0, false
+	}
+
+	return size, true
+}
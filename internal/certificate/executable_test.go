@@ -0,0 +1,99 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	encpem "encoding/pem"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_executable(t *testing.T) {
+	var ders [][]byte
+	for rest := mustReadFile(t, "testdata/test-1"); ; {
+		var block *encpem.Block
+		block, rest = encpem.Decode(rest)
+		if block == nil {
+			break
+		}
+		ders = append(ders, block.Bytes)
+	}
+	require.Len(t, ders, 3)
+
+	padding := bytes.Repeat([]byte{0x30, 0x82, 0x00}, 100)
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	// A candidate with a plausible header, but garbage contents.
+	garbage := append(append([]byte{}, ders[1][:13]...), make([]byte, len(ders[1])-13)...)
+
+	tests := map[string]struct {
+		data              []byte
+		expSubjects       []string
+		expPartialOffsets []int
+	}{
+		"certificates embedded in an ELF binary should be found": {
+			data: join([]byte("\x7fELF"), padding, ders[0], padding, ders[2]),
+			expSubjects: []string{
+				"CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US",
+				"CN=www.google.com,O=Google Inc,L=Mountain View,ST=California,C=US",
+			},
+		},
+		"certificates embedded in a PE binary should be found": {
+			data: join([]byte("MZ"), ders[1]),
+			expSubjects: []string{
+				"CN=Google Internet Authority G2,O=Google Inc,C=US",
+			},
+		},
+		"candidates which fail to parse should be a partial": {
+			data:              join([]byte("\x7fELF"), garbage, ders[0]),
+			expSubjects:       []string{"CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US"},
+			expPartialOffsets: []int{4},
+		},
+		"certificates spanning the windows an executable is scanned in should be found": {
+			data: join([]byte("\x7fELF"), make([]byte, executableWindowSize-4-len(ders[0])/2), ders[0],
+				make([]byte, executableWindowSize), garbage, ders[2]),
+			expSubjects: []string{
+				"CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US",
+				"CN=www.google.com,O=Google Inc,L=Mountain View,ST=California,C=US",
+			},
+			expPartialOffsets: []int{2*executableWindowSize - len(ders[0])/2 + len(ders[0])},
+		},
+		"truncated certificates should be ignored": {
+			data: join([]byte("\x7fELF"), ders[0][:len(ders[0])-1]),
+		},
+		"non-executable files should be ignored": {
+			data: join([]byte("not an executable"), ders[0]),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsedCerts, err := (executable{}).Find(context.TODO(), "test-location", func() (io.ReadSeeker, error) {
+				return bytes.NewReader(test.data), nil
+			})
+			require.NoError(t, err)
+
+			var subjects []string
+			for _, r := range parsedCerts.Found {
+				assert.Equal(t, "test-location", r.Location)
+				assert.Equal(t, "executable", r.Parser)
+				assert.Equal(t, EncodingDER, r.Encoding)
+				assert.Equal(t, ContainerFormatExecutable, r.ContainerFormat)
+				subjects = append(subjects, r.Certificate.Subject.String())
+			}
+			assert.ElementsMatch(t, test.expSubjects, subjects)
+
+			require.Len(t, parsedCerts.Partials, len(test.expPartialOffsets))
+			for i, r := range parsedCerts.Partials {
+				assert.Equal(t, "test-location", r.Location)
+				assert.Contains(t, r.Reason, fmt.Sprintf("failed to parse certificate at offset %d:", test.expPartialOffsets[i]))
+			}
+		})
+	}
+}
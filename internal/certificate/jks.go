@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// jksMagic is the magic number at the start of every Java KeyStore file.
+	jksMagic uint32 = 0xFEEDFEED
+
+	jksTagPrivateKey  uint32 = 1
+	jksTagTrustedCert uint32 = 2
+)
+
+type jks struct{}
+
+func (_ jks) name() string { return "jks" }
+
+// Find finds X.509 certificates stored as trusted certificate entries inside
+// Java KeyStore files, such as the "cacerts" file shipped with most JREs.
+// Private key entries are password protected, so are skipped and recorded as
+// partials. The location of each certificate is reported as "path!alias".
+func (_ jks) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
+	file, err := rs()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &jksReader{r: bufio.NewReader(file)}
+
+	magic := r.uint32()
+	if r.err != nil || magic != jksMagic {
+		return &ParsedCertificates{}, nil
+	}
+
+	version := r.uint32()
+	count := r.uint32()
+	if r.err != nil {
+		return jksPartial(location, fmt.Sprintf("failed to read JKS header: %s", r.err)), nil
+	}
+	if version != 1 && version != 2 {
+		return jksPartial(location, fmt.Sprintf("unsupported JKS version %d", version)), nil
+	}
+
+	parsed := &ParsedCertificates{}
+	for i := uint32(0); i < count; i++ {
+		// If context has been cancelled, exit scanning.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		tag := r.uint32()
+		alias := r.utf()
+		_ = r.uint64() // creation timestamp
+		if r.err != nil {
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: location,
+				Parser:   "jks",
+				Reason:   fmt.Sprintf("failed to read JKS entry %d: %s", i, r.err),
+			})
+			break
+		}
+
+		entryLocation := location + "!" + alias
+
+		switch tag {
+		case jksTagTrustedCert:
+			der := r.certificate(version)
+			if r.err != nil {
+				parsed.Partials = append(parsed.Partials, Partial{
+					Location: entryLocation,
+					Parser:   "jks",
+					Reason:   fmt.Sprintf("failed to read JKS trusted certificate entry: %s", r.err),
+				})
+				return parsed, nil
+			}
+
+			found, err := newFound(entryLocation, "jks", der)
+			if err != nil {
+				parsed.Partials = append(parsed.Partials, Partial{
+					Location: entryLocation,
+					Parser:   "jks",
+					Reason:   fmt.Sprintf("failed to parse JKS certificate: %s", err),
+				})
+				continue
+			}
+			found.Encoding, found.ContainerFormat = EncodingDER, ContainerFormatJKS
+			parsed.Found = append(parsed.Found, found)
+
+		case jksTagPrivateKey:
+			// Skip over the encrypted key and its certificate chain.
+			r.skip(int64(r.uint32()))
+			chainLen := r.uint32()
+			for j := uint32(0); j < chainLen && r.err == nil; j++ {
+				r.certificate(version)
+			}
+			if r.err != nil {
+				parsed.Partials = append(parsed.Partials, Partial{
+					Location: entryLocation,
+					Parser:   "jks",
+					Reason:   fmt.Sprintf("failed to read JKS private key entry: %s", r.err),
+				})
+				return parsed, nil
+			}
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: entryLocation,
+				Parser:   "jks",
+				Reason:   "JKS private key entry is password protected and was not inspected",
+			})
+
+		default:
+			// Entry lengths are not recorded, so an unknown entry makes the
+			// rest of the keystore unreadable.
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: entryLocation,
+				Parser:   "jks",
+				Reason:   fmt.Sprintf("unknown JKS entry type %d", tag),
+			})
+			return parsed, nil
+		}
+	}
+
+	return parsed, nil
+}
+
+// jksReader reads the big-endian primitives used by the JKS format. The first
+// error encountered is recorded, and all subsequent reads become no-ops.
+type jksReader struct {
+	r   io.Reader
+	err error
+}
+
+func (j *jksReader) read(n int) []byte {
+	if j.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(j.r, b); err != nil {
+		j.err = err
+		return nil
+	}
+	return b
+}
+
+func (j *jksReader) skip(n int64) {
+	if j.err != nil {
+		return
+	}
+	if _, err := io.CopyN(io.Discard, j.r, n); err != nil {
+		j.err = err
+	}
+}
+
+func (j *jksReader) uint16() uint16 {
+	b := j.read(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (j *jksReader) uint32() uint32 {
+	b := j.read(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func (j *jksReader) uint64() uint64 {
+	b := j.read(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// utf reads a Java "modified UTF-8" string, prefixed by its length.
+func (j *jksReader) utf() string {
+	return string(j.read(int(j.uint16())))
+}
+
+// certificate reads a single encoded certificate. Version 2 keystores prefix
+// each certificate with its type, which must be X.509.
+func (j *jksReader) certificate(version uint32) []byte {
+	if version == 2 {
+		if typ := j.utf(); j.err == nil && typ != "X.509" {
+			j.err = fmt.Errorf("unsupported certificate type %q", typ)
+			return nil
+		}
+	}
+
+	length := j.uint32()
+	// Guard against allocating huge buffers for corrupt lengths.
+	if j.err == nil && length > 1<<24 {
+		j.err = fmt.Errorf("certificate length %d is too large", length)
+		return nil
+	}
+
+	return j.read(int(length))
+}
+
+func jksPartial(location, reason string) *ParsedCertificates {
+	return &ParsedCertificates{
+		Partials: []Partial{{
+			Location: location,
+			Parser:   "jks",
+			Reason:   reason,
+		}},
+	}
+}
@@ -0,0 +1,146 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	encpem "encoding/pem"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_jks(t *testing.T) {
+	var ders [][]byte
+	for rest := mustReadFile(t, "testdata/test-1"); ; {
+		var block *encpem.Block
+		block, rest = encpem.Decode(rest)
+		if block == nil {
+			break
+		}
+		ders = append(ders, block.Bytes)
+	}
+	require.Len(t, ders, 3)
+
+	tests := map[string]struct {
+		data              []byte
+		expLocations      []string
+		expPartialReasons []string
+	}{
+		"version 2 keystore with trusted certificates should parse": {
+			data: buildJKS(2, []jksTestEntry{
+				{tag: jksTagTrustedCert, alias: "geotrust", certs: ders[:1]},
+				{tag: jksTagTrustedCert, alias: "google", certs: ders[1:2]},
+			}),
+			expLocations: []string{"cacerts!geotrust", "cacerts!google"},
+		},
+		"version 1 keystore with trusted certificates should parse": {
+			data: buildJKS(1, []jksTestEntry{
+				{tag: jksTagTrustedCert, alias: "geotrust", certs: ders[:1]},
+			}),
+			expLocations: []string{"cacerts!geotrust"},
+		},
+		"private key entries should be skipped and recorded as partials": {
+			data: buildJKS(2, []jksTestEntry{
+				{tag: jksTagPrivateKey, alias: "mykey", key: []byte("encrypted"), certs: ders[2:]},
+				{tag: jksTagTrustedCert, alias: "geotrust", certs: ders[:1]},
+			}),
+			expLocations: []string{"cacerts!geotrust"},
+			expPartialReasons: []string{
+				"JKS private key entry is password protected and was not inspected",
+			},
+		},
+		"invalid certificate should be recorded as partial": {
+			data: buildJKS(2, []jksTestEntry{
+				{tag: jksTagTrustedCert, alias: "broken", certs: [][]byte{[]byte("not a certificate")}},
+				{tag: jksTagTrustedCert, alias: "geotrust", certs: ders[:1]},
+			}),
+			expLocations: []string{"cacerts!geotrust"},
+			expPartialReasons: []string{
+				"failed to parse JKS certificate: x509: malformed certificate",
+			},
+		},
+		"truncated keystore should be recorded as partial": {
+			data: buildJKS(2, []jksTestEntry{
+				{tag: jksTagTrustedCert, alias: "geotrust", certs: ders[:1]},
+			})[:100],
+			expPartialReasons: []string{
+				"failed to read JKS trusted certificate entry: unexpected EOF",
+			},
+		},
+		"non JKS files should be ignored": {
+			data: mustReadFile(t, "testdata/test-1"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsedCerts, err := (jks{}).Find(context.TODO(), "cacerts", func() (io.ReadSeeker, error) {
+				return bytes.NewReader(test.data), nil
+			})
+			require.NoError(t, err)
+
+			var locations []string
+			for _, r := range parsedCerts.Found {
+				assert.Equal(t, "jks", r.Parser)
+				assert.Equal(t, EncodingDER, r.Encoding)
+				assert.Equal(t, ContainerFormatJKS, r.ContainerFormat)
+				locations = append(locations, r.Location)
+			}
+			assert.ElementsMatch(t, test.expLocations, locations)
+
+			var partialsReasons []string
+			for _, r := range parsedCerts.Partials {
+				partialsReasons = append(partialsReasons, r.Reason)
+			}
+			assert.ElementsMatch(t, test.expPartialReasons, partialsReasons)
+		})
+	}
+}
+
+type jksTestEntry struct {
+	tag   uint32
+	alias string
+	key   []byte
+	certs [][]byte
+}
+
+// buildJKS builds a Java KeyStore of the given version. The trailing integrity
+// digest is not computed, since it is not checked by the parser.
+func buildJKS(version uint32, entries []jksTestEntry) []byte {
+	var buf bytes.Buffer
+	write := func(v interface{}) { _ = binary.Write(&buf, binary.BigEndian, v) }
+	writeUTF := func(s string) {
+		write(uint16(len(s)))
+		buf.WriteString(s)
+	}
+	writeCert := func(der []byte) {
+		if version == 2 {
+			writeUTF("X.509")
+		}
+		write(uint32(len(der)))
+		buf.Write(der)
+	}
+
+	write(jksMagic)
+	write(version)
+	write(uint32(len(entries)))
+	for _, e := range entries {
+		write(e.tag)
+		writeUTF(e.alias)
+		write(uint64(0))
+		if e.tag == jksTagPrivateKey {
+			write(uint32(len(e.key)))
+			buf.Write(e.key)
+			write(uint32(len(e.certs)))
+		}
+		for _, der := range e.certs {
+			writeCert(der)
+		}
+	}
+	buf.Write(make([]byte, 20))
+
+	return buf.Bytes()
+}
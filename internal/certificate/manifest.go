@@ -0,0 +1,224 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	encpem "encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// manifestMaxSize is the size of the largest YAML or JSON file which is
+	// decoded, as the whole structure is held in memory.
+	manifestMaxSize = 16 << 20
+
+	// manifestMinValueLength is the length of the shortest string value
+	// which is decoded, as certificates are much longer than this.
+	manifestMinValueLength = 256
+)
+
+// manifestPointerEscaper escapes keys for use in JSON pointers.
+var manifestPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+type manifest struct{}
+
+func (_ manifest) name() string { return "manifest" }
+
+// scansLocation returns true for YAML and JSON files, identified by their
+// extension.
+func (_ manifest) scansLocation(location string) bool {
+	switch strings.ToLower(path.Ext(location)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Find finds X.509 certificates which are base64 encoded in string values of
+// YAML and JSON files, such as CA bundles in Kubernetes secrets and Helm
+// values. The base64 data may hold PEM or DER encoded certificates. The
+// location of each certificate is reported as "path#pointer", where pointer
+// is a JSON pointer to the value, such as "secret.yaml#/data/ca.crt". For YAML
+// files with several documents, the pointer starts with the index of the
+// document, such as "manifests.yaml#1/data/ca.crt". Values which are not
+// certificates are ignored.
+func (_ manifest) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
+	file, err := rs()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, manifestMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > manifestMaxSize {
+		return &ParsedCertificates{}, nil
+	}
+
+	format := ContainerFormatYAML
+	if strings.EqualFold(path.Ext(location), ".json") {
+		format = ContainerFormatJSON
+	}
+
+	// Files which can't be decoded are not reported, as a file with a YAML or
+	// JSON extension that isn't valid is no indication of a certificate.
+	docs, ok := decodeManifest(format, data)
+	if !ok {
+		return &ParsedCertificates{}, nil
+	}
+
+	parsed := &ParsedCertificates{}
+	for i, doc := range docs {
+		var prefix string
+		if len(docs) > 1 {
+			prefix = strconv.Itoa(i)
+		}
+		err := walkManifest(ctx, doc, prefix, func(pointer, value string) {
+			findInManifestValue(location+"#"+pointer, format, value, parsed)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return parsed, nil
+}
+
+// decodeManifest decodes the documents of a YAML or JSON file.
+func decodeManifest(format string, data []byte) ([]interface{}, bool) {
+	if format == ContainerFormatJSON {
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, false
+		}
+		return []interface{}{doc}, true
+	}
+
+	var docs []interface{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, true
+		}
+		if err != nil {
+			return nil, false
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// walkManifest calls fn with every string value in the decoded structure, and
+// the JSON pointer to it. Mapping keys are walked in sorted order, so results
+// are stable.
+func walkManifest(ctx context.Context, node interface{}, pointer string, fn func(pointer, value string)) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	switch n := node.(type) {
+	case string:
+		fn(pointer, n)
+	case []interface{}:
+		for i, v := range n {
+			if err := walkManifest(ctx, v, pointer+"/"+strconv.Itoa(i), fn); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := walkManifest(ctx, n[k], pointer+"/"+manifestPointerEscaper.Replace(k), fn); err != nil {
+				return err
+			}
+		}
+	case map[interface{}]interface{}:
+		// YAML mappings with keys which aren't strings.
+		keys := make([]string, 0, len(n))
+		values := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			key := fmt.Sprint(k)
+			keys = append(keys, key)
+			values[key] = v
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := walkManifest(ctx, values[k], pointer+"/"+manifestPointerEscaper.Replace(k), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findInManifestValue adds the certificates held by a base64 encoded string
+// value to parsed. Values which are not base64, or don't decode to
+// certificates, are ignored.
+func findInManifestValue(location, format, value string, parsed *ParsedCertificates) {
+	if len(value) < manifestMinValueLength {
+		return
+	}
+
+	// Long values are often folded over several lines.
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil {
+		return
+	}
+
+	if !bytes.Contains(decoded, []byte("-----BEGIN CERTIFICATE-----")) {
+		if _, err := x509.ParseCertificate(decoded); err != nil {
+			return
+		}
+		found, err := newFound(location, "manifest", decoded)
+		if err != nil {
+			return
+		}
+		found.Encoding, found.ContainerFormat = EncodingBase64, format
+		parsed.Found = append(parsed.Found, found)
+		return
+	}
+
+	for rest := decoded; ; {
+		var block *encpem.Block
+		block, rest = encpem.Decode(rest)
+		if block == nil {
+			return
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		found, err := newFound(location, "manifest", block.Bytes)
+		if err != nil {
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: location,
+				Parser:   "manifest",
+				Reason:   fmt.Sprintf("failed to parse base64 encoded PEM certificate: %s", err),
+			})
+			continue
+		}
+		found.Encoding, found.ContainerFormat = EncodingBase64, format
+		parsed.Found = append(parsed.Found, found)
+	}
+}
@@ -0,0 +1,107 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	encpem "encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_manifest(t *testing.T) {
+	chain := mustReadFile(t, "testdata/test-1")
+	block, _ := encpem.Decode(chain)
+	require.NotNil(t, block)
+
+	pemChain := base64.StdEncoding.EncodeToString(chain)
+	der := base64.StdEncoding.EncodeToString(block.Bytes)
+	notCert := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("not a certificate "), 30))
+
+	// Long values are often folded when written as YAML block scalars.
+	var folded strings.Builder
+	for i := 0; i < len(der); i += 76 {
+		end := i + 76
+		if end > len(der) {
+			end = len(der)
+		}
+		folded.WriteString("    " + der[i:end] + "\n")
+	}
+
+	tests := map[string]struct {
+		location        string
+		data            string
+		expFormat       string
+		expLocations    []string
+		expPartialCount int
+	}{
+		"base64 PEM bundles in a Kubernetes secret should be found": {
+			location:  "secret.yaml",
+			expFormat: ContainerFormatYAML,
+			data: fmt.Sprintf(`apiVersion: v1
+kind: Secret
+data:
+  ca.crt: %s
+  token: %s
+`, pemChain, notCert),
+			expLocations: []string{"secret.yaml#/data/ca.crt", "secret.yaml#/data/ca.crt", "secret.yaml#/data/ca.crt"},
+		},
+		"base64 DER in folded values should be found": {
+			location:     "values.yml",
+			expFormat:    ContainerFormatYAML,
+			data:         "tls:\n  caBundle: >-\n" + folded.String(),
+			expLocations: []string{"values.yml#/tls/caBundle"},
+		},
+		"documents should be identified in multi-document YAML files": {
+			location:     "manifests.yaml",
+			expFormat:    ContainerFormatYAML,
+			data:         fmt.Sprintf("kind: ConfigMap\n---\nwebhooks:\n  - clientConfig:\n      caBundle: %s\n", der),
+			expLocations: []string{"manifests.yaml#1/webhooks/0/clientConfig/caBundle"},
+		},
+		"keys should be escaped in JSON files": {
+			location:     "config.json",
+			expFormat:    ContainerFormatJSON,
+			data:         fmt.Sprintf(`{"certs": {"a/b~c": "%s"}}`, der),
+			expLocations: []string{"config.json#/certs/a~1b~0c"},
+		},
+		"base64 values which are not certificates should be ignored": {
+			location: "secret.yaml",
+			data:     fmt.Sprintf("data:\n  token: %s\n", notCert),
+		},
+		"invalid files should be ignored": {
+			location: "broken.json",
+			data:     fmt.Sprintf(`{"ca": "%s"`, der),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsedCerts, err := (manifest{}).Find(context.TODO(), test.location, func() (io.ReadSeeker, error) {
+				return strings.NewReader(test.data), nil
+			})
+			require.NoError(t, err)
+
+			var locations []string
+			for _, r := range parsedCerts.Found {
+				assert.Equal(t, "manifest", r.Parser)
+				assert.Equal(t, EncodingBase64, r.Encoding)
+				assert.Equal(t, test.expFormat, r.ContainerFormat)
+				locations = append(locations, r.Location)
+			}
+			assert.Equal(t, test.expLocations, locations)
+			assert.Len(t, parsedCerts.Partials, test.expPartialCount)
+		})
+	}
+
+	t.Run("only YAML and JSON files are scanned", func(t *testing.T) {
+		assert.True(t, manifest{}.scansLocation("/etc/app/values.YAML"))
+		assert.True(t, manifest{}.scansLocation("/etc/app/values.yml"))
+		assert.True(t, manifest{}.scansLocation("/etc/app/config.json"))
+		assert.False(t, manifest{}.scansLocation("/etc/ssl/certs/ca-certificates.crt"))
+	})
+}
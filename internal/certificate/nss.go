@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path"
+)
+
+const (
+	// nssCertDBName is the file name of NSS SQLite certificate databases.
+	// The matching key4.db holds private keys, so is not scanned.
+	nssCertDBName = "cert9.db"
+
+	// nssPublicTable is the table holding the public objects of an NSS
+	// database, including certificates and trust records.
+	nssPublicTable = "nssPublic"
+
+	nssClassCertificate uint32 = 0x00000001 // CKO_CERTIFICATE
+	nssClassTrust       uint32 = 0xce534353 // CKO_NSS_TRUST
+)
+
+// Columns of the nssPublic table are named after the PKCS#11 attribute they
+// hold, as "a" followed by the attribute type in hex.
+const (
+	nssColumnClass    = "a0"        // CKA_CLASS
+	nssColumnLabel    = "a3"        // CKA_LABEL
+	nssColumnValue    = "a11"       // CKA_VALUE
+	nssColumnCertSHA1 = "ace5363b4" // CKA_CERT_SHA1_HASH
+)
+
+// nssNull is the value NSS stores for attributes which are present, but empty.
+var nssNull = []byte{0xa5, 0x00, 0x5a}
+
+type nss struct {
+	// maxReadSize is the size of the largest file which is read to be
+	// decoded. If zero, files of any size are read.
+	maxReadSize int64
+}
+
+func (_ nss) name() string { return "nss" }
+
+// scansLocation returns true only for NSS certificate databases, which are
+// identified by name, so that other files are not read.
+func (_ nss) scansLocation(location string) bool {
+	return path.Base(location) == nssCertDBName
+}
+
+// Find finds X.509 certificates stored in NSS SQLite certificate databases
+// (cert9.db), as used by Firefox and some enterprise images. The location of
+// each certificate is reported as "path!label". Trust records referencing a
+// certificate which is not in the database are recorded as partials.
+func (n nss) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
+	file, err := rs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the header before reading the whole database, so that files
+	// which aren't databases, or are too large, are reported as such.
+	head := make([]byte, len(sqliteMagic))
+	if _, err := io.ReadFull(file, head); err != nil || !isSQLite(head) {
+		return &ParsedCertificates{}, nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	data, ok, err := readWhole(file, n.maxReadSize)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nssPartial(location, tooLargeReason(n.maxReadSize)), nil
+	}
+
+	db, err := newSQLiteDB(data)
+	if err != nil {
+		return nssPartial(location, fmt.Sprintf("failed to read NSS database: %s", err)), nil
+	}
+
+	table, ok, err := db.table(nssPublicTable)
+	if err != nil {
+		return nssPartial(location, fmt.Sprintf("failed to read NSS database: %s", err)), nil
+	}
+	if !ok {
+		return nssPartial(location, "NSS database has no public object table"), nil
+	}
+
+	rows, err := db.rows(table.rootPage)
+	if err != nil {
+		return nssPartial(location, fmt.Sprintf("failed to read NSS database objects: %s", err)), nil
+	}
+
+	columns := make(map[string]int)
+	for i, c := range table.columns {
+		columns[c] = i
+	}
+	attr := func(row []interface{}, column string) []byte {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return nil
+		}
+		b, _ := row[i].([]byte)
+		if bytes.Equal(b, nssNull) {
+			return nil
+		}
+		return b
+	}
+
+	var (
+		parsed     = &ParsedCertificates{}
+		certSHA1s  = make(map[[20]byte]bool)
+		trustSHA1s [][]byte
+		trustNames []string
+	)
+	for _, row := range rows {
+		// If context has been cancelled, exit scanning.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		class := attr(row, nssColumnClass)
+		if len(class) != 4 {
+			continue
+		}
+
+		entryLocation := location
+		if label := attr(row, nssColumnLabel); len(label) > 0 {
+			entryLocation = location + "!" + string(label)
+		}
+
+		switch binary.BigEndian.Uint32(class) {
+		case nssClassCertificate:
+			der := attr(row, nssColumnValue)
+			certSHA1s[sha1.Sum(der)] = true
+
+			found, err := newFound(entryLocation, "nss", der)
+			if err != nil {
+				parsed.Partials = append(parsed.Partials, Partial{
+					Location: entryLocation,
+					Parser:   "nss",
+					Reason:   fmt.Sprintf("failed to parse NSS certificate: %s", err),
+				})
+				continue
+			}
+			found.Encoding, found.ContainerFormat = EncodingDER, ContainerFormatNSS
+			parsed.Found = append(parsed.Found, found)
+
+		case nssClassTrust:
+			if hash := attr(row, nssColumnCertSHA1); len(hash) == sha1.Size {
+				trustSHA1s = append(trustSHA1s, hash)
+				trustNames = append(trustNames, entryLocation)
+			}
+		}
+	}
+
+	for i, hash := range trustSHA1s {
+		var sum [20]byte
+		copy(sum[:], hash)
+		if !certSHA1s[sum] {
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: trustNames[i],
+				Parser:   "nss",
+				Reason:   fmt.Sprintf("NSS trust record references certificate with SHA1 fingerprint %X, which is not in the database", sum),
+			})
+		}
+	}
+
+	return parsed, nil
+}
+
+func nssPartial(location, reason string) *ParsedCertificates {
+	return &ParsedCertificates{
+		Partials: []Partial{{
+			Location: location,
+			Parser:   "nss",
+			Reason:   reason,
+		}},
+	}
+}
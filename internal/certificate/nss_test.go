@@ -0,0 +1,88 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_nss(t *testing.T) {
+	// testdata/cert9.db was created with SQLite using the nssPublic table
+	// layout of NSS, and a small page size so that certificates spill onto
+	// overflow pages. It contains the certificates from testdata/test-1, with
+	// trust records for each, and a trust record for a missing certificate.
+	db := mustReadFile(t, "testdata/cert9.db")
+	// testdata/nss-cert9.db was created by NSS, importing the certificates
+	// from testdata/test-1 with their subject common names as nicknames, and
+	// trust set for each.
+	nssDB := mustReadFile(t, "testdata/nss-cert9.db")
+
+	tests := map[string]struct {
+		data              []byte
+		expLocations      []string
+		expPartialReasons []string
+	}{
+		"certificate database should parse": {
+			data: db,
+			expLocations: []string{
+				"cert9.db!GeoTrust Global CA",
+				"cert9.db!Google Internet Authority G2",
+				"cert9.db!www.google.com",
+			},
+			expPartialReasons: []string{
+				"NSS trust record references certificate with SHA1 fingerprint 000102030405060708090A0B0C0D0E0F10111213, which is not in the database",
+			},
+		},
+		"certificate database created by NSS should parse": {
+			data: nssDB,
+			expLocations: []string{
+				"cert9.db!GeoTrust Global CA",
+				"cert9.db!Google Internet Authority G2",
+				"cert9.db!www.google.com",
+			},
+		},
+		"truncated database should be recorded as partial": {
+			data: db[:2048],
+			expPartialReasons: []string{
+				"failed to read NSS database objects: page 6 is out of range",
+			},
+		},
+		"non SQLite files should be ignored": {
+			data: mustReadFile(t, "testdata/test-1"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsedCerts, err := (nss{}).Find(context.TODO(), "cert9.db", func() (io.ReadSeeker, error) {
+				return bytes.NewReader(test.data), nil
+			})
+			require.NoError(t, err)
+
+			var locations []string
+			for _, r := range parsedCerts.Found {
+				assert.Equal(t, "nss", r.Parser)
+				assert.Equal(t, EncodingDER, r.Encoding)
+				assert.Equal(t, ContainerFormatNSS, r.ContainerFormat)
+				locations = append(locations, r.Location)
+			}
+			assert.ElementsMatch(t, test.expLocations, locations)
+
+			var partialsReasons []string
+			for _, r := range parsedCerts.Partials {
+				partialsReasons = append(partialsReasons, r.Reason)
+			}
+			assert.ElementsMatch(t, test.expPartialReasons, partialsReasons)
+		})
+	}
+
+	t.Run("only certificate databases are scanned", func(t *testing.T) {
+		assert.True(t, (nss{}).scansLocation("/etc/pki/nssdb/cert9.db"))
+		assert.False(t, (nss{}).scansLocation("/etc/pki/nssdb/key4.db"))
+		assert.False(t, (nss{}).scansLocation("/etc/ssl/certs/ca-certificates.crt"))
+	})
+}
@@ -0,0 +1,251 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Option is a functional option that configures certificate scanning.
+type Option func(*options)
+
+type options struct {
+	pkcs12Passwords []string
+	skipDirs        []string
+	headerFilter    func(*tar.Header) bool
+	pathFilter      pathFilter
+	layerDigest     string
+	spillThreshold  int64
+	maxFileSize     int64
+	concurrency     int
+	logger          logrus.FieldLogger
+	strictParse     bool
+	resolveSymlinks bool
+	enableParsers   []string
+	disableParsers  []string
+	progress        *Progress
+}
+
+func makeOptions(opts ...Option) *options {
+	o := &options{spillThreshold: defaultSpillThreshold, concurrency: runtime.GOMAXPROCS(0), logger: discardLogger()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// validate returns an error if the options are invalid.
+func (o *options) validate() error {
+	if o.spillThreshold <= 0 {
+		return fmt.Errorf("spill threshold must be positive, got %d", o.spillThreshold)
+	}
+	if o.maxFileSize < 0 {
+		return fmt.Errorf("max file size must not be negative, got %d", o.maxFileSize)
+	}
+	if o.concurrency <= 0 {
+		return fmt.Errorf("concurrency must be positive, got %d", o.concurrency)
+	}
+	if err := o.validateParsers(); err != nil {
+		return err
+	}
+	return o.pathFilter.validate()
+}
+
+// tooLarge returns true if a file of the given size is larger than the
+// maximum file size, so shouldn't be scanned.
+func (o *options) tooLarge(size int64) bool {
+	return o.maxFileSize > 0 && size > o.maxFileSize
+}
+
+// ValidateOptions returns an error if the given options are invalid, so that
+// mistakes, such as unknown parser names, are noticed before an image is
+// fetched to be scanned.
+func ValidateOptions(opts ...Option) error {
+	return makeOptions(opts...).validate()
+}
+
+// validateParsers returns an error if any of the enabled or disabled parsers
+// are not in the registry, or if no parsers are left to scan with.
+func (o *options) validateParsers() error {
+	known := make(map[string]bool, len(parserRegistry))
+	var names []string
+	for _, p := range parserRegistry {
+		known[p.Name] = true
+		names = append(names, p.Name)
+	}
+	for _, name := range append(append([]string{}, o.enableParsers...), o.disableParsers...) {
+		if !known[name] {
+			return fmt.Errorf("unknown parser %q, expected one of %s", name, strings.Join(names, ", "))
+		}
+	}
+	if len(o.parsers()) == 0 {
+		return fmt.Errorf("every parser is disabled, so no certificates can be found")
+	}
+	return nil
+}
+
+// parsers returns the set of parsers to scan files with, from the registry.
+func (o *options) parsers() []parser {
+	var parsers []parser
+	for _, p := range parserRegistry {
+		if o.parserEnabled(p.ParserInfo) {
+			parsers = append(parsers, p.new(o))
+		}
+	}
+	return parsers
+}
+
+// parserEnabled returns true if the given parser should be scanned with.
+// If any parsers are enabled, only those are scanned with, rather than the
+// parsers which are enabled by default. Disabled parsers are never scanned
+// with.
+func (o *options) parserEnabled(p ParserInfo) bool {
+	for _, name := range o.disableParsers {
+		if name == p.Name {
+			return false
+		}
+	}
+	if len(o.enableParsers) == 0 {
+		return p.EnabledByDefault
+	}
+	for _, name := range o.enableParsers {
+		if name == p.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPKCS12Passwords is a functional option that configures the candidate
+// passwords tried when decoding password protected PKCS#12 files. An empty
+// password is always tried.
+func WithPKCS12Passwords(passwords []string) Option {
+	return func(o *options) {
+		o.pkcs12Passwords = append(o.pkcs12Passwords, passwords...)
+	}
+}
+
+// WithSkipDirs is a functional option that configures the directories which
+// are skipped when scanning a directory, relative to the scanned root, such
+// as "/proc". This replaces the default list of skipped directories.
+func WithSkipDirs(dirs []string) Option {
+	return func(o *options) {
+		o.skipDirs = append([]string{}, dirs...)
+	}
+}
+
+// WithTarHeaderFilter is a functional option that configures a filter called
+// with every header in a scanned tar, in order. Files for which the filter
+// returns false are not scanned.
+func WithTarHeaderFilter(filter func(*tar.Header) bool) Option {
+	return func(o *options) {
+		o.headerFilter = filter
+	}
+}
+
+// WithPathFilter is a functional option that configures the files which are
+// scanned by their path, such as "/etc/ssl". If include is not empty, only
+// files matching one of its glob patterns are scanned. Files matching one of
+// the exclude patterns are never scanned. Patterns are in the syntax of
+// path.Match, and also match every file below a matching directory.
+func WithPathFilter(include, exclude []string) Option {
+	return func(o *options) {
+		o.pathFilter = pathFilter{
+			include: append([]string{}, include...),
+			exclude: append([]string{}, exclude...),
+		}
+	}
+}
+
+// WithLayerDigest is a functional option that configures the digest of the
+// image layer being scanned, which is recorded on every certificate found.
+func WithLayerDigest(digest string) Option {
+	return func(o *options) {
+		o.layerDigest = digest
+	}
+}
+
+// WithSpillThreshold is a functional option that configures the size, in
+// bytes, above which files are written to a temporary file while they are
+// scanned, rather than being held in memory. Defaults to 1 GiB. Parsers which
+// decode whole files, such as PKCS#12 files and NSS databases, don't read
+// files larger than this, recording a partial instead, so that the memory
+// used by a scan is bounded.
+func WithSpillThreshold(bytes int64) Option {
+	return func(o *options) {
+		o.spillThreshold = bytes
+	}
+}
+
+// WithMaxFileSize is a functional option that configures the size, in bytes,
+// above which files are skipped without being scanned, such as large
+// databases or media which will never contain certificates worth finding.
+// Defaults to zero, which scans files of any size.
+func WithMaxFileSize(bytes int64) Option {
+	return func(o *options) {
+		o.maxFileSize = bytes
+	}
+}
+
+// WithConcurrency is a functional option that configures the number of files
+// which are scanned concurrently. Each file being scanned may be held in
+// memory, up to the spill threshold. Defaults to the number of CPUs usable.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// WithLogger is a functional option that configures the logger diagnostics
+// are written to, such as why a file was skipped, and each parser error.
+// Defaults to discarding them.
+func WithLogger(logger logrus.FieldLogger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithStrictParse is a functional option that configures scanning to fail
+// when a parser fails to scan any file. By default, such failures are
+// recorded as partial certificates, and scanning continues.
+func WithStrictParse(strict bool) Option {
+	return func(o *options) {
+		o.strictParse = strict
+	}
+}
+
+// WithResolveSymlinks is a functional option that configures the symbolic
+// links in scanned tars to be resolved, such that a certificate is also found
+// at the location of every link to its file, or to a directory containing it.
+// Links are otherwise skipped, like every other file which isn't a regular
+// file.
+func WithResolveSymlinks(resolve bool) Option {
+	return func(o *options) {
+		o.resolveSymlinks = resolve
+	}
+}
+
+// WithParsers is a functional option that configures the parsers files are
+// scanned with, by name. If enable is not empty, only the parsers it names
+// are scanned with, rather than the parsers which are enabled by default.
+// Parsers named by disable are never scanned with. It is an error to name a
+// parser which doesn't exist, or to disable every parser.
+func WithParsers(enable, disable []string) Option {
+	return func(o *options) {
+		o.enableParsers = append([]string{}, enable...)
+		o.disableParsers = append([]string{}, disable...)
+	}
+}
+
+func discardLogger() logrus.FieldLogger {
+	logger := logrus.New()
+	logger.Out = io.Discard
+	return logger
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+// ParserInfo describes a parser, so that users can discover what is scanned.
+type ParserInfo struct {
+	// Name is the name of the parser, as recorded on the certificates it
+	// finds.
+	Name string
+	// Targets describes the files the parser scans, and how they are
+	// recognised, such as by their extension or leading bytes.
+	Targets string
+	// Description is a one-line description of what the parser finds.
+	Description string
+	// EnabledByDefault is true if the parser scans files without being
+	// enabled by an option.
+	EnabledByDefault bool
+}
+
+// registeredParser is a parser known to the scanner, with a constructor
+// configuring it from the scan's options.
+type registeredParser struct {
+	ParserInfo
+	new func(o *options) parser
+}
+
+// parserRegistry holds every parser, in the order they are listed. Parsers
+// added here are scanned with, and are listed by Parsers.
+var parserRegistry = []registeredParser{
+	{
+		ParserInfo: ParserInfo{
+			Name:             "pem",
+			Targets:          "every file, searched for PEM blocks",
+			Description:      "PEM encoded certificates, including OpenSSL trusted certificates, such as CA bundles",
+			EnabledByDefault: true,
+		},
+		new: func(*options) parser { return pem{} },
+	},
+	{
+		ParserInfo: ParserInfo{
+			Name:             "pkcs7",
+			Targets:          "files starting with a DER or PEM encoded PKCS#7 structure, such as .p7b and .p7c files",
+			Description:      "certificates in PKCS#7 SignedData bundles",
+			EnabledByDefault: true,
+		},
+		new: func(o *options) parser { return pkcs7{maxReadSize: o.spillThreshold} },
+	},
+	{
+		ParserInfo: ParserInfo{
+			Name:             "jks",
+			Targets:          "files starting with the Java KeyStore magic number, such as cacerts",
+			Description:      "trusted certificate entries of Java KeyStores",
+			EnabledByDefault: true,
+		},
+		new: func(*options) parser { return jks{} },
+	},
+	{
+		ParserInfo: ParserInfo{
+			Name:             "pkcs12",
+			Targets:          "files starting with a DER encoded PKCS#12 structure, such as .p12 and .pfx files",
+			Description:      "certificate bags of PKCS#12 files, decrypted with the configured passwords",
+			EnabledByDefault: true,
+		},
+		new: func(o *options) parser { return pkcs12{passwords: o.pkcs12Passwords, maxReadSize: o.spillThreshold} },
+	},
+	{
+		ParserInfo: ParserInfo{
+			Name:             "nss",
+			Targets:          "files named " + nssCertDBName,
+			Description:      "certificates in NSS SQLite certificate databases, as used by Firefox",
+			EnabledByDefault: true,
+		},
+		new: func(o *options) parser { return nss{maxReadSize: o.spillThreshold} },
+	},
+	{
+		ParserInfo: ParserInfo{
+			Name:             "certdata",
+			Targets:          "files named " + certdataName,
+			Description:      "certificates in Mozilla's certdata.txt, with the purposes they are trusted and distrusted for",
+			EnabledByDefault: true,
+		},
+		new: func(*options) parser { return certdata{} },
+	},
+	{
+		ParserInfo: ParserInfo{
+			Name:             "winregistry",
+			Targets:          "files starting with the Windows registry hive magic number",
+			Description:      "certificate stores of Windows registry hives, as used by Windows images",
+			EnabledByDefault: true,
+		},
+		new: func(o *options) parser { return winRegistry{maxReadSize: o.spillThreshold} },
+	},
+	{
+		ParserInfo: ParserInfo{
+			Name:             "manifest",
+			Targets:          "files with a .yaml, .yml or .json extension",
+			Description:      "base64 encoded certificates in string values, such as in Kubernetes secrets",
+			EnabledByDefault: true,
+		},
+		new: func(*options) parser { return manifest{} },
+	},
+	{
+		ParserInfo: ParserInfo{
+			Name:             "executable",
+			Targets:          "files starting with the ELF, PE or Mach-O magic number",
+			Description:      "DER encoded certificates compiled into executables",
+			EnabledByDefault: false,
+		},
+		new: func(*options) parser { return executable{} },
+	},
+}
+
+// Parsers returns a description of every parser, in the order they are
+// registered.
+func Parsers() []ParserInfo {
+	infos := make([]ParserInfo, 0, len(parserRegistry))
+	for _, p := range parserRegistry {
+		infos = append(infos, p.ParserInfo)
+	}
+	return infos
+}
@@ -0,0 +1,47 @@
+package certificate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsers(t *testing.T) {
+	var names []string
+	for _, p := range makeOptions().parsers() {
+		names = append(names, p.name())
+	}
+
+	// Every parser scanned with is listed, under the name it records.
+	var listed []string
+	for _, p := range Parsers() {
+		assert.NotEmpty(t, p.Targets, p.Name)
+		assert.NotEmpty(t, p.Description, p.Name)
+		if p.EnabledByDefault {
+			listed = append(listed, p.Name)
+		}
+	}
+	assert.Equal(t, names, listed)
+}
+
+func TestWithParsers(t *testing.T) {
+	names := func(opts ...Option) []string {
+		var names []string
+		for _, p := range makeOptions(opts...).parsers() {
+			names = append(names, p.name())
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"pem", "pkcs7"}, names(WithParsers([]string{"pkcs7", "pem"}, nil)))
+	assert.NotContains(t, names(), "executable")
+	assert.Equal(t, []string{"pem", "executable"}, names(WithParsers([]string{"executable", "pem"}, nil)))
+	assert.NotContains(t, names(WithParsers(nil, []string{"nss"})), "nss")
+	assert.Equal(t, []string{"pem"}, names(WithParsers([]string{"pem", "pkcs7"}, []string{"pkcs7"})))
+
+	assert.EqualError(t, ValidateOptions(WithParsers(nil, []string{"binary"})),
+		`unknown parser "binary", expected one of pem, pkcs7, jks, pkcs12, nss, certdata, winregistry, manifest, executable`)
+	assert.EqualError(t, ValidateOptions(WithParsers([]string{"pem"}, []string{"pem"})),
+		"every parser is disabled, so no certificates can be found")
+	assert.NoError(t, ValidateOptions(WithParsers([]string{"pem"}, nil)))
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"fmt"
+	"path"
+)
+
+// pathFilter selects the files which are scanned by their path, using glob
+// patterns in the syntax of path.Match. A pattern matches a file if it
+// matches the file's path, or the path of any directory containing it, so
+// "/etc/ssl" matches every file below /etc/ssl.
+type pathFilter struct {
+	include []string
+	exclude []string
+}
+
+// validate returns an error if any of the patterns are malformed.
+func (f pathFilter) validate() error {
+	for _, pattern := range append(append([]string{}, f.include...), f.exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid path pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// scans returns true if the file at the given path, which is relative to the
+// root of the image, should be scanned. Excludes take precedence over
+// includes, and every file is included if there are no includes.
+func (f pathFilter) scans(p string) bool {
+	if f.excludes(p) {
+		return false
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if MatchPath(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludes returns true if the file or directory at the given path is
+// excluded, so that directories can be skipped without being walked.
+func (f pathFilter) excludes(p string) bool {
+	for _, pattern := range f.exclude {
+		if MatchPath(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath returns true if the glob pattern, in the syntax of path.Match,
+// matches the path, or the path of any directory containing it. Both are
+// normalised to a cleaned, "/" prefixed form first, so that paths relative to
+// the root of an image match absolute patterns such as "/etc/ssl".
+func MatchPath(pattern, p string) bool {
+	pattern = path.Join("/", pattern)
+	for p = path.Join("/", p); p != "/"; p = path.Dir(p) {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
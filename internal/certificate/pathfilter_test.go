@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pathFilter(t *testing.T) {
+	tests := map[string]struct {
+		filter  pathFilter
+		scanned []string
+		skipped []string
+	}{
+		"no patterns should scan every file": {
+			scanned: []string{"/etc/ssl/cert.pem", "/usr/share/ca.crt"},
+		},
+		"includes should match files and the files below directories": {
+			filter:  pathFilter{include: []string{"/etc/ssl", "*.crt"}},
+			scanned: []string{"/etc/ssl/cert.pem", "/etc/ssl/certs/ca.pem", "/ca.crt"},
+			skipped: []string{"/etc/ssl-old/cert.pem", "/usr/share/ca.crt"},
+		},
+		"excludes should take precedence over includes": {
+			filter:  pathFilter{include: []string{"/usr"}, exclude: []string{"/usr/share/*/testdata"}},
+			scanned: []string{"/usr/share/app/cert.pem", "/usr/lib/cert.pem"},
+			skipped: []string{"/usr/share/app/testdata/cert.pem", "/etc/ssl/cert.pem"},
+		},
+		"patterns should be normalised": {
+			filter:  pathFilter{include: []string{"etc/ssl/"}},
+			scanned: []string{"etc/ssl/cert.pem", "/etc/ssl/cert.pem"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, p := range test.scanned {
+				assert.True(t, test.filter.scans(p), "expected %s to be scanned", p)
+			}
+			for _, p := range test.skipped {
+				assert.False(t, test.filter.scans(p), "expected %s to be skipped", p)
+			}
+		})
+	}
+}
+
+func TestFindCertificates_PathFilter(t *testing.T) {
+	tarball := mustMakeTar(t, 3)
+
+	parsed, err := FindCertificates(context.TODO(), bytes.NewReader(tarball), WithPathFilter([]string{"/etc/ssl/certs/00[01].pem"}, []string{"/etc/ssl/certs/001.pem"}))
+	require.NoError(t, err)
+	var locations []string
+	for _, f := range parsed.Found {
+		locations = append(locations, f.Location)
+	}
+	assert.Equal(t, []string{"/etc/ssl/certs/000.pem", "/etc/ssl/certs/000.pem", "/etc/ssl/certs/000.pem"}, locations)
+	assert.Equal(t, 1, parsed.Stats.Files)
+	assert.Equal(t, 2, parsed.Stats.SkippedFiles)
+
+	_, err = FindCertificates(context.TODO(), bytes.NewReader(tarball), WithPathFilter([]string{"[invalid"}, nil))
+	assert.ErrorContains(t, err, `invalid path pattern "[invalid"`)
+}
+
+func TestFindCertificatesInDir_PathFilter(t *testing.T) {
+	chain, err := os.ReadFile("testdata/test-1")
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	for _, p := range []string{"etc/ssl/cert.pem", "opt/app/testdata/cert.pem"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(p)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, p), chain, 0644))
+	}
+
+	parsed, err := FindCertificatesInDir(context.TODO(), root, WithPathFilter(nil, []string{"/opt/*/testdata"}))
+	require.NoError(t, err)
+	require.Len(t, parsed.Found, 3)
+	for _, f := range parsed.Found {
+		assert.Equal(t, "etc/ssl/cert.pem", f.Location)
+	}
+	assert.Equal(t, ScanStats{Files: 1, Bytes: int64(len(chain))}, parsed.Stats)
+}
@@ -5,9 +5,7 @@ package certificate
 import (
 	"bytes"
 	"context"
-	"crypto/sha1"
-	"crypto/sha256"
-	"crypto/x509"
+	"encoding/asn1"
 	encpem "encoding/pem"
 	"errors"
 	"fmt"
@@ -16,15 +14,24 @@ import (
 
 type pem struct{}
 
+func (_ pem) name() string { return "pem" }
+
 // Find finds X.509 PEM encoded certificates in the given reader. It does this
 // by greping through the input and attempting to find the PEM Certificate
 // header. Once found, it attempts to find the end footer. Even if the end
 // footer is not found, a Certificate is still recorded, but marked as not
-// correctly decoded.
+// correctly decoded. Other PEM blocks, such as private keys, are skipped, and
+// a certificate missing its footer ends at the start of the next block, so
+// the blocks after it are still found. OpenSSL "TRUSTED CERTIFICATE" blocks are
+// also found, and the trust settings appended to their certificate are
+// recorded.
 func (_ pem) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
 	ignored := []byte{'\n', '\t', '\r', ' ', '\f', '\v', '\b', '\x00', '"', '\''}
-	pemStart := []byte("-----BEGIN CERTIFICATE-----")
-	pemEnd := []byte("-----END CERTIFICATE-----")
+	pemStarts := [][]byte{[]byte("-----BEGIN CERTIFICATE-----"), []byte("-----BEGIN TRUSTED CERTIFICATE-----")}
+	pemEnds := [][]byte{[]byte("-----END CERTIFICATE-----"), []byte("-----END TRUSTED CERTIFICATE-----")}
+	// pemNext is the start of any PEM block, such as a private key, which
+	// ends a certificate missing its footer.
+	pemNext := []byte("-----BEGIN")
 
 	file, err := rs()
 	if err != nil {
@@ -69,25 +76,32 @@ func (_ pem) Find(ctx context.Context, location string, rs rseekerOpener) (*Pars
 			continue
 		}
 
-		if token[0] == pemStart[len(current)] {
-			// If the scanned token matches the current PEM start token, we append to
-			// the current.
-			current = append(current, token[0])
-		} else {
-			// Did not match, reset current to empty.
+		// If the scanned token matches one of the PEM start tokens, we append to
+		// the current. If it did not match, reset current to empty.
+		current = append(current, token[0])
+		marker := pemMarker(pemStarts, current)
+		if marker < 0 {
 			current = current[:0]
+			continue
 		}
+		pemStart, pemEnd := pemStarts[marker], pemEnds[marker]
 
-		if len(current) == 10 {
-			// Make sure we add the space character from PEM start since those get
-			// ignored.
+		// Make sure we add the space characters from PEM start since those get
+		// ignored.
+		if len(current) < len(pemStart) && pemStart[len(current)] == ' ' {
 			current = append(current, ' ')
 		}
 
 		// If we have the PEM header, then we can start to scan for the footer.
 		if len(current) == len(pemStart) {
-			// footer is the buffer we use to match on the PEM footer.
-			var footer []byte
+			var (
+				// footer is the buffer we use to match on the PEM footer.
+				footer []byte
+				// next is the buffer we use to match on the start of the next
+				// PEM block, and nextOffset is the offset it starts at.
+				next       []byte
+				nextOffset int64
+			)
 
 			for {
 				// Check errors and return/break appropriately.
@@ -110,7 +124,7 @@ func (_ pem) Find(ctx context.Context, location string, rs rseekerOpener) (*Pars
 				if bytes.Contains(ignored, token) {
 					// Append if we haven't reached the footer yet, or need to add the
 					// space character.
-					if len(footer) == 0 || len(footer) == 9 {
+					if len(footer) == 0 || footer[len(footer)-1] == ' ' {
 						current = append(current, token[0])
 					}
 					continue
@@ -126,11 +140,30 @@ func (_ pem) Find(ctx context.Context, location string, rs rseekerOpener) (*Pars
 					footer = footer[:0]
 				}
 
-				// Add in the space character we ignore.
-				if len(footer) == 8 {
+				// Add in the space characters we ignore.
+				if len(footer) < len(pemEnd) && pemEnd[len(footer)] == ' ' {
 					footer = append(footer, ' ')
 				}
 
+				// Check for the start of another PEM block, which means this
+				// certificate has no footer.
+				if token[0] != pemNext[len(next)] {
+					next = next[:0]
+				}
+				if token[0] == pemNext[len(next)] {
+					if len(next) == 0 {
+						offset, err := file.Seek(0, io.SeekCurrent)
+						if err != nil {
+							return nil, fmt.Errorf("failed to seek: %w", err)
+						}
+						nextOffset = offset - 1
+					}
+					next = append(next, token[0])
+				}
+				if len(next) == len(pemNext) {
+					break
+				}
+
 				// If the length of footer matches the PEM footer, then we have scanned
 				// a certificate.
 				if len(footer) == len(pemEnd) {
@@ -143,11 +176,9 @@ func (_ pem) Find(ctx context.Context, location string, rs rseekerOpener) (*Pars
 			// to the end of the file, or we matched on the footer.
 
 			var (
-				valid    = false
-				reason   string
-				cert     *x509.Certificate
-				fpsha1   [20]byte
-				fpsha256 [32]byte
+				valid  = false
+				reason string
+				found  Found
 			)
 
 			// If we did match on the footer, then attempt to decode the actual
@@ -157,16 +188,29 @@ func (_ pem) Find(ctx context.Context, location string, rs rseekerOpener) (*Pars
 
 				if block == nil {
 					reason = fmt.Sprintf("a block of data looks like a PEM certificate, but cannot be decoded")
+				} else if block.Type == "TRUSTED CERTIFICATE" {
+					found, err = newTrustedFound(location, block.Bytes)
+					if err != nil {
+						reason = fmt.Sprintf("failed to parse PEM trusted certificate: %s", err)
+					} else {
+						valid = true
+					}
 				} else {
-					cert, err = x509.ParseCertificate(block.Bytes)
+					found, err = newFound(location, "pem", block.Bytes)
 					if err != nil {
 						reason = fmt.Sprintf("failed to parse PEM certificate: %s", err)
 					} else {
-						fpsha1 = sha1.Sum(block.Bytes)
-						fpsha256 = sha256.Sum256(block.Bytes)
+						found.Encoding, found.ContainerFormat = EncodingPEM, ContainerFormatX509
 						valid = true
 					}
 				}
+			} else if len(next) == len(pemNext) {
+				// Another PEM block started before the footer, so rescan from
+				// its start, so that it isn't lost with this certificate.
+				reason = "found start of PEM encoded certificate, but could not find end"
+				if _, err := file.Seek(nextOffset, io.SeekStart); err != nil {
+					return nil, fmt.Errorf("failed to seek: %w", err)
+				}
 			} else {
 				// If we didn't actually decode an entire certificate, then set an
 				// appropriate reason, and reset the file so we can re-scan.
@@ -178,13 +222,7 @@ func (_ pem) Find(ctx context.Context, location string, rs rseekerOpener) (*Pars
 
 			// Capture result.
 			if valid {
-				results = append(results, Found{
-					Location:          location,
-					Parser:            "pem",
-					Certificate:       cert,
-					FingerprintSha1:   fpsha1,
-					FingerprintSha256: fpsha256,
-				})
+				results = append(results, found)
 			} else {
 				partials = append(partials, Partial{
 					Location: location,
@@ -201,3 +239,78 @@ func (_ pem) Find(ctx context.Context, location string, rs rseekerOpener) (*Pars
 		Partials: partials,
 	}, nil
 }
+
+// pemMarker returns the index of the first marker which begins with buf, or
+// -1 if there is none.
+func pemMarker(markers [][]byte, buf []byte) int {
+	for i, m := range markers {
+		if bytes.HasPrefix(m, buf) {
+			return i
+		}
+	}
+	return -1
+}
+
+// certAux is OpenSSL's X509_CERT_AUX structure, which holds the trust settings
+// appended to the certificate of a TRUSTED CERTIFICATE block.
+type certAux struct {
+	Trust  []asn1.ObjectIdentifier `asn1:"optional"`
+	Reject []asn1.ObjectIdentifier `asn1:"optional,tag:0"`
+	Alias  string                  `asn1:"optional,utf8"`
+	KeyID  []byte                  `asn1:"optional"`
+	Other  asn1.RawValue           `asn1:"optional,tag:1"`
+}
+
+// trustPurposes are the names of the extended key usages which OpenSSL trust
+// settings are given for.
+var trustPurposes = map[string]string{
+	"2.5.29.37.0":       "anyExtendedKeyUsage",
+	"1.3.6.1.5.5.7.3.1": "serverAuth",
+	"1.3.6.1.5.5.7.3.2": "clientAuth",
+	"1.3.6.1.5.5.7.3.3": "codeSigning",
+	"1.3.6.1.5.5.7.3.4": "emailProtection",
+	"1.3.6.1.5.5.7.3.8": "timeStamping",
+	"1.3.6.1.5.5.7.3.9": "OCSPSigning",
+}
+
+// newTrustedFound returns the certificate of an OpenSSL TRUSTED CERTIFICATE
+// block, which is a DER encoded certificate followed by its trust settings.
+func newTrustedFound(location string, der []byte) (Found, error) {
+	var cert asn1.RawValue
+	trailer, err := asn1.Unmarshal(der, &cert)
+	if err != nil {
+		return Found{}, err
+	}
+
+	found, err := newFound(location, "pem", cert.FullBytes)
+	if err != nil {
+		return Found{}, err
+	}
+	found.Encoding, found.ContainerFormat = EncodingPEM, ContainerFormatX509
+
+	if len(trailer) == 0 {
+		return found, nil
+	}
+	var aux certAux
+	if rest, err := asn1.Unmarshal(trailer, &aux); err != nil {
+		return Found{}, fmt.Errorf("failed to parse trust settings: %w", err)
+	} else if len(rest) > 0 {
+		return Found{}, errors.New("trailing data after trust settings")
+	}
+	found.TrustedPurposes = purposeNames(aux.Trust)
+	found.RejectedPurposes = purposeNames(aux.Reject)
+
+	return found, nil
+}
+
+func purposeNames(oids []asn1.ObjectIdentifier) []string {
+	var names []string
+	for _, oid := range oids {
+		name, ok := trustPurposes[oid.String()]
+		if !ok {
+			name = oid.String()
+		}
+		names = append(names, name)
+	}
+	return names
+}
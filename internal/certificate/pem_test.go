@@ -42,7 +42,8 @@ func Test_x509pem(t *testing.T) {
 				"CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US",
 			},
 			expPartialReasons: []string{
-				"a block of data looks like a PEM certificate, but cannot be decoded",
+				"found start of PEM encoded certificate, but could not find end",
+				"failed to parse PEM certificate: x509: malformed certificate",
 			},
 		},
 		"malformed certificates should still be reported": {
@@ -55,6 +56,17 @@ func Test_x509pem(t *testing.T) {
 				"failed to parse PEM certificate: x509: malformed certificate",
 			},
 		},
+		"non-certificate blocks and trailing garbage should be skipped": {
+			file: "testdata/messy-bundle",
+			expSubjects: []string{
+				"CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US",
+				"CN=www.google.com,O=Google Inc,L=Mountain View,ST=California,C=US",
+			},
+			expPartialReasons: []string{
+				"found start of PEM encoded certificate, but could not find end",
+				"found start of PEM encoded certificate, but could not find end",
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -75,6 +87,8 @@ func Test_x509pem(t *testing.T) {
 			var subjects []string
 			for _, r := range parsedCerts.Found {
 				assert.Equal(t, test.file, r.Location)
+				assert.Equal(t, EncodingPEM, r.Encoding)
+				assert.Equal(t, ContainerFormatX509, r.ContainerFormat)
 				subjects = append(subjects, r.Certificate.Subject.String())
 			}
 			assert.ElementsMatch(t, test.expSubjects, subjects)
@@ -88,3 +102,29 @@ func Test_x509pem(t *testing.T) {
 		})
 	}
 }
+
+func Test_x509pem_trusted(t *testing.T) {
+	b := mustReadFile(t, "testdata/trusted-1")
+	parsedCerts, err := (pem{}).Find(context.TODO(), "testdata/trusted-1", func() (io.ReadSeeker, error) {
+		return bytes.NewReader(b), nil
+	})
+	require.NoError(t, err)
+	require.Empty(t, parsedCerts.Partials)
+	require.Len(t, parsedCerts.Found, 1)
+
+	found := parsedCerts.Found[0]
+	assert.Equal(t, "CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US", found.Certificate.Subject.String())
+	assert.Equal(t, EncodingPEM, found.Encoding)
+	assert.Equal(t, ContainerFormatX509, found.ContainerFormat)
+	assert.Equal(t, []string{"serverAuth", "emailProtection"}, found.TrustedPurposes)
+	assert.Equal(t, []string{"clientAuth"}, found.RejectedPurposes)
+
+	// The trust settings must not be part of the certificate, so it has the
+	// same fingerprint as when found without them.
+	plain, err := (pem{}).Find(context.TODO(), "testdata/test-1", func() (io.ReadSeeker, error) {
+		return bytes.NewReader(mustReadFile(t, "testdata/test-1")), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, plain.Found[0].FingerprintSha256, found.FingerprintSha256)
+	assert.Empty(t, plain.Found[0].TrustedPurposes)
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+
+	gopkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+type pkcs12 struct {
+	// passwords are the candidate passwords tried when decoding, in addition
+	// to the empty password.
+	passwords []string
+
+	// maxReadSize is the size of the largest file which is read to be
+	// decoded. If zero, files of any size are read.
+	maxReadSize int64
+}
+
+func (_ pkcs12) name() string { return "pkcs12" }
+
+// Find finds X.509 certificates inside PKCS#12 (.p12 or .pfx) files. Only
+// certificate bags are extracted; private keys are ignored. As most PKCS#12
+// files are password protected, each of the configured candidate passwords is
+// tried in turn. If none of them work, a partial is recorded.
+func (p pkcs12) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
+	file, err := rs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Read enough of the file to identify whether it is PKCS#12.
+	head := make([]byte, 64)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if !isPKCS12DER(head[:n]) {
+		return &ParsedCertificates{}, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	data, ok, err := readWhole(file, p.maxReadSize)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &ParsedCertificates{
+			Partials: []Partial{{
+				Location: location,
+				Parser:   "pkcs12",
+				Reason:   tooLargeReason(p.maxReadSize),
+			}},
+		}, nil
+	}
+
+	var (
+		certs  []*x509.Certificate
+		reason = "encrypted, no matching password"
+	)
+	for _, password := range append([]string{""}, p.passwords...) {
+		// If context has been cancelled, exit decoding.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		certs, err = decodePKCS12Certificates(data, password)
+		if err == nil {
+			break
+		}
+		if !isPKCS12PasswordError(err) {
+			reason = fmt.Sprintf("failed to decode PKCS#12 file: %s", err)
+			break
+		}
+	}
+	if err != nil {
+		return &ParsedCertificates{
+			Partials: []Partial{{
+				Location: location,
+				Parser:   "pkcs12",
+				Reason:   reason,
+			}},
+		}, nil
+	}
+
+	parsed := &ParsedCertificates{}
+	for _, cert := range certs {
+		found, err := newFound(location, "pkcs12", cert.Raw)
+		if err != nil {
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: location,
+				Parser:   "pkcs12",
+				Reason:   fmt.Sprintf("failed to parse PKCS#12 certificate: %s", err),
+			})
+			continue
+		}
+		found.Encoding, found.ContainerFormat = EncodingDER, ContainerFormatPKCS12
+		parsed.Found = append(parsed.Found, found)
+	}
+
+	return parsed, nil
+}
+
+// decodePKCS12Certificates decodes all certificates from the given PKCS#12
+// data. Files holding a private key and its chain, as well as trust stores
+// holding only certificates, are supported.
+func decodePKCS12Certificates(data []byte, password string) ([]*x509.Certificate, error) {
+	_, cert, caCerts, err := gopkcs12.DecodeChain(data, password)
+	if err == nil {
+		return append([]*x509.Certificate{cert}, caCerts...), nil
+	}
+	if isPKCS12PasswordError(err) {
+		return nil, err
+	}
+
+	return gopkcs12.DecodeTrustStore(data, password)
+}
+
+func isPKCS12PasswordError(err error) bool {
+	return errors.Is(err, gopkcs12.ErrIncorrectPassword) || errors.Is(err, gopkcs12.ErrDecryption)
+}
+
+// isPKCS12DER returns true if the given data begins with a PKCS#12 PFX
+// structure; a version 3 SEQUENCE wrapping a PKCS#7 content info.
+func isPKCS12DER(data []byte) bool {
+	contents, ok := derSequenceContents(data)
+	if !ok {
+		return false
+	}
+
+	var version int
+	rest, err := asn1.Unmarshal(contents, &version)
+	if err != nil || version != 3 {
+		return false
+	}
+
+	return isPKCS7DER(rest)
+}
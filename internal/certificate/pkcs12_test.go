@@ -0,0 +1,98 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gopkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+func Test_pkcs12(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "paranoia-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	withKey, err := gopkcs12.Modern.Encode(key, cert, nil, "s3cret")
+	require.NoError(t, err)
+	trustStore, err := gopkcs12.Modern.EncodeTrustStore([]*x509.Certificate{cert}, "changeit")
+	require.NoError(t, err)
+	passwordless, err := gopkcs12.Passwordless.EncodeTrustStore([]*x509.Certificate{cert}, "")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		data              []byte
+		passwords         []string
+		expSubjects       []string
+		expPartialReasons []string
+	}{
+		"key and certificate with matching password should parse": {
+			data:        withKey,
+			passwords:   []string{"changeit", "s3cret"},
+			expSubjects: []string{"CN=paranoia-test"},
+		},
+		"trust store with matching password should parse": {
+			data:        trustStore,
+			passwords:   []string{"changeit"},
+			expSubjects: []string{"CN=paranoia-test"},
+		},
+		"passwordless trust store should parse without passwords": {
+			data:        passwordless,
+			expSubjects: []string{"CN=paranoia-test"},
+		},
+		"no matching password should be a partial": {
+			data:      withKey,
+			passwords: []string{"changeit"},
+			expPartialReasons: []string{
+				"encrypted, no matching password",
+			},
+		},
+		"non PKCS#12 files should be ignored": {
+			data: mustReadFile(t, "testdata/pkcs7-der"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsedCerts, err := (pkcs12{passwords: test.passwords}).Find(context.TODO(), "test-location", func() (io.ReadSeeker, error) {
+				return bytes.NewReader(test.data), nil
+			})
+			require.NoError(t, err)
+
+			var subjects []string
+			for _, r := range parsedCerts.Found {
+				assert.Equal(t, "test-location", r.Location)
+				assert.Equal(t, "pkcs12", r.Parser)
+				assert.Equal(t, EncodingDER, r.Encoding)
+				assert.Equal(t, ContainerFormatPKCS12, r.ContainerFormat)
+				subjects = append(subjects, r.Certificate.Subject.String())
+			}
+			assert.ElementsMatch(t, test.expSubjects, subjects)
+
+			var partialsReasons []string
+			for _, r := range parsedCerts.Partials {
+				partialsReasons = append(partialsReasons, r.Reason)
+			}
+			assert.ElementsMatch(t, test.expPartialReasons, partialsReasons)
+		})
+	}
+}
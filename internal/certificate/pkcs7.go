@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	encpem "encoding/pem"
+	"fmt"
+	"io"
+)
+
+var (
+	// oidPKCS7 is the arc under which all PKCS#7 content types live.
+	oidPKCS7 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7}
+	// oidPKCS7SignedData is the content type of a PKCS#7 SignedData structure,
+	// which is the only content type carrying a certificate bundle.
+	oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+)
+
+// pkcs7ContentInfo is the outer ContentInfo structure of a PKCS#7 message, as
+// defined in RFC 2315 section 7.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// pkcs7SignedData is the SignedData structure, as defined in RFC 2315 section
+// 9.1. Only the certificates are of interest, so all other fields are left
+// raw.
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+type pkcs7 struct {
+	// maxReadSize is the size of the largest file which is read to be
+	// decoded. If zero, files of any size are read.
+	maxReadSize int64
+}
+
+func (_ pkcs7) name() string { return "pkcs7" }
+
+// Find finds X.509 certificates inside PKCS#7 SignedData bundles (typically
+// .p7b or .p7c files), either DER encoded or wrapped in a PEM "PKCS7" block.
+// Files are identified by their leading bytes, so files which are not PKCS#7
+// are skipped without being read in full.
+func (p pkcs7) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
+	file, err := rs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Read enough of the file to identify whether it is PKCS#7.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	var isPEM bool
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(head), []byte("-----BEGIN PKCS7-----")):
+		isPEM = true
+	case isPKCS7DER(head):
+	default:
+		return &ParsedCertificates{}, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	data, ok, err := readWhole(file, p.maxReadSize)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return pkcs7Partial(location, tooLargeReason(p.maxReadSize)), nil
+	}
+
+	// If context has been cancelled, exit before decoding.
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if isPEM {
+		block, _ := encpem.Decode(data)
+		if block == nil {
+			return pkcs7Partial(location, "a block of data looks like a PEM PKCS#7 bundle, but cannot be decoded"), nil
+		}
+		data = block.Bytes
+	}
+
+	var info pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return pkcs7Partial(location, fmt.Sprintf("failed to parse PKCS#7 content info: %s", err)), nil
+	}
+
+	if !info.ContentType.Equal(oidPKCS7SignedData) {
+		return pkcs7Partial(location, fmt.Sprintf("unexpected PKCS#7 content type %s, expected SignedData", info.ContentType)), nil
+	}
+
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		return pkcs7Partial(location, fmt.Sprintf("failed to parse PKCS#7 signed data: %s", err)), nil
+	}
+
+	encoding := EncodingDER
+	if isPEM {
+		encoding = EncodingPEM
+	}
+
+	parsed := &ParsedCertificates{}
+	for rest := sd.Certificates.Bytes; len(rest) > 0; {
+		var raw asn1.RawValue
+		rest, err = asn1.Unmarshal(rest, &raw)
+		if err != nil {
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: location,
+				Parser:   "pkcs7",
+				Reason:   fmt.Sprintf("failed to read PKCS#7 certificate: %s", err),
+			})
+			break
+		}
+
+		found, err := newFound(location, "pkcs7", raw.FullBytes)
+		if err != nil {
+			parsed.Partials = append(parsed.Partials, Partial{
+				Location: location,
+				Parser:   "pkcs7",
+				Reason:   fmt.Sprintf("failed to parse PKCS#7 certificate: %s", err),
+			})
+			continue
+		}
+		found.Encoding, found.ContainerFormat = encoding, ContainerFormatPKCS7
+		parsed.Found = append(parsed.Found, found)
+	}
+
+	return parsed, nil
+}
+
+// isPKCS7DER returns true if the given data begins with a DER SEQUENCE whose
+// first element is an object identifier under the PKCS#7 arc.
+func isPKCS7DER(data []byte) bool {
+	contents, ok := derSequenceContents(data)
+	if !ok {
+		return false
+	}
+
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(contents, &oid); err != nil {
+		return false
+	}
+
+	return len(oid) == len(oidPKCS7)+1 && oid[:len(oidPKCS7)].Equal(oidPKCS7)
+}
+
+// derSequenceContents returns the contents of the DER SEQUENCE at the start of
+// the given data. The data may be a truncated prefix of a larger structure,
+// in which case the contents are truncated too.
+func derSequenceContents(data []byte) ([]byte, bool) {
+	if len(data) < 2 || data[0] != 0x30 {
+		return nil, false
+	}
+
+	offset := 2
+	if data[1]&0x80 != 0 {
+		offset += int(data[1] & 0x7f)
+	}
+	if offset >= len(data) {
+		return nil, false
+	}
+
+	return data[offset:], true
+}
+
+func pkcs7Partial(location, reason string) *ParsedCertificates {
+	return &ParsedCertificates{
+		Partials: []Partial{{
+			Location: location,
+			Parser:   "pkcs7",
+			Reason:   reason,
+		}},
+	}
+}
@@ -0,0 +1,113 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pkcs7(t *testing.T) {
+	emptySignedData, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms []asn1.RawValue `asn1:"set"`
+		ContentInfo      struct{ ContentType asn1.ObjectIdentifier }
+		SignerInfos      []asn1.RawValue `asn1:"set"`
+	}{
+		Version:     1,
+		ContentInfo: struct{ ContentType asn1.ObjectIdentifier }{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}},
+	})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		data              []byte
+		expEncoding       string
+		expSubjects       []string
+		expPartialReasons []string
+	}{
+		"DER encoded bundle should parse": {
+			data:        mustReadFile(t, "testdata/pkcs7-der"),
+			expEncoding: EncodingDER,
+			expSubjects: []string{
+				"CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US",
+				"CN=Google Internet Authority G2,O=Google Inc,C=US",
+				"CN=www.google.com,O=Google Inc,L=Mountain View,ST=California,C=US",
+			},
+		},
+		"PEM encoded bundle should parse": {
+			data:        mustReadFile(t, "testdata/pkcs7-pem"),
+			expEncoding: EncodingPEM,
+			expSubjects: []string{
+				"CN=GeoTrust Global CA,O=GeoTrust Inc.,C=US",
+				"CN=Google Internet Authority G2,O=Google Inc,C=US",
+				"CN=www.google.com,O=Google Inc,L=Mountain View,ST=California,C=US",
+			},
+		},
+		"bundle with no certificates should produce nothing": {
+			data: mustMarshalContentInfo(t, oidPKCS7SignedData, emptySignedData),
+		},
+		"unexpected content type should be a partial": {
+			data: mustMarshalContentInfo(t, asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}, []byte{0x04, 0x01, 0x00}),
+			expPartialReasons: []string{
+				"unexpected PKCS#7 content type 1.2.840.113549.1.7.1, expected SignedData",
+			},
+		},
+		"non PKCS#7 files should be ignored": {
+			data: mustReadFile(t, "testdata/test-1"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsedCerts, err := (pkcs7{}).Find(context.TODO(), "test-location", func() (io.ReadSeeker, error) {
+				return bytes.NewReader(test.data), nil
+			})
+			require.NoError(t, err)
+
+			var subjects []string
+			for _, r := range parsedCerts.Found {
+				assert.Equal(t, "test-location", r.Location)
+				assert.Equal(t, "pkcs7", r.Parser)
+				assert.Equal(t, test.expEncoding, r.Encoding)
+				assert.Equal(t, ContainerFormatPKCS7, r.ContainerFormat)
+				subjects = append(subjects, r.Certificate.Subject.String())
+			}
+			assert.ElementsMatch(t, test.expSubjects, subjects)
+
+			var partialsReasons []string
+			for _, r := range parsedCerts.Partials {
+				assert.Equal(t, "test-location", r.Location)
+				partialsReasons = append(partialsReasons, r.Reason)
+			}
+			assert.ElementsMatch(t, test.expPartialReasons, partialsReasons)
+		})
+	}
+}
+
+func mustReadFile(t *testing.T, name string) []byte {
+	b, err := os.ReadFile(name)
+	require.NoError(t, err)
+	return b
+}
+
+func mustMarshalContentInfo(t *testing.T, contentType asn1.ObjectIdentifier, content []byte) []byte {
+	b, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: contentType,
+		Content: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      content,
+		},
+	})
+	require.NoError(t, err)
+	return b
+}
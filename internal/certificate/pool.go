@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scanPool scans files with a bounded pool of workers, so that many files are
+// parsed concurrently while they are read sequentially, such as from a tar
+// stream. Results are returned in the order files were added.
+type scanPool struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	parsers  []parser
+	logger   logrus.FieldLogger
+	strict   bool
+	progress *Progress
+	jobs     chan scanJob
+	wg       sync.WaitGroup
+
+	lock    sync.Mutex
+	results []*ParsedCertificates
+	err     error
+}
+
+type scanJob struct {
+	index    int
+	location string
+	opener   rseekerOpener
+	cleanup  func() error
+}
+
+// newScanPool starts a pool of workers scanning files as configured by the
+// options. The pool's context is cancelled when the parent is, or when
+// scanning any file fails.
+func newScanPool(parent context.Context, o *options) *scanPool {
+	ctx, cancel := context.WithCancel(parent)
+	p := &scanPool{
+		parent:   parent,
+		ctx:      ctx,
+		cancel:   cancel,
+		parsers:  o.parsers(),
+		logger:   o.logger,
+		strict:   o.strictParse,
+		progress: o.progress,
+		jobs:     make(chan scanJob),
+	}
+
+	for i := 0; i < o.concurrency; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+func (p *scanPool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		fileParsed, err := findInFile(p.ctx, p.parsers, job.location, job.opener, p.logger, p.strict)
+		if cleanupErr := job.cleanup(); err == nil && cleanupErr != nil {
+			err = fmt.Errorf("parser error finding certificates: %w", cleanupErr)
+		}
+		if err != nil {
+			p.fail(err)
+			continue
+		}
+		p.progress.scanned(fileParsed)
+
+		p.lock.Lock()
+		p.results[job.index] = fileParsed
+		p.lock.Unlock()
+	}
+}
+
+// add queues a file to be scanned, blocking until a worker is free. The
+// clean-up function is called once the file has been scanned. An error is
+// returned if scanning has been abandoned, in which case the file is cleaned
+// up without being scanned.
+func (p *scanPool) add(location string, opener rseekerOpener, cleanup func() error) error {
+	p.lock.Lock()
+	index := len(p.results)
+	p.results = append(p.results, nil)
+	p.lock.Unlock()
+
+	select {
+	case p.jobs <- scanJob{index: index, location: location, opener: opener, cleanup: cleanup}:
+		return nil
+	case <-p.ctx.Done():
+		cleanup()
+		return p.ctx.Err()
+	}
+}
+
+// fail abandons scanning with the given error, unless it has already failed.
+func (p *scanPool) fail(err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.err == nil {
+		p.err = err
+		p.cancel()
+	}
+}
+
+// wait waits for every queued file to be scanned, and returns everything
+// found. The first error to occur is returned if scanning failed.
+func (p *scanPool) wait() (*ParsedCertificates, error) {
+	close(p.jobs)
+	p.wg.Wait()
+	defer p.cancel()
+
+	if p.err != nil {
+		return nil, p.err
+	}
+	if err := p.parent.Err(); err != nil {
+		return nil, err
+	}
+
+	parsed := &ParsedCertificates{}
+	for _, r := range p.results {
+		parsed.appendParsed(r)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress counts the files scanned and certificates found as a scan runs,
+// so that a slow scan can be seen to be making progress. Files are scanned
+// concurrently, so the counts are updated atomically, and may be read while
+// the scan runs. The zero value is ready to use, and a Progress may be shared
+// between scans, such as of each layer of an image.
+type Progress struct {
+	files        atomic.Int64
+	certificates atomic.Int64
+	partials     atomic.Int64
+}
+
+// ProgressCounts are the counts of a Progress at a point in time.
+type ProgressCounts struct {
+	// Files is the number of files which have been scanned.
+	Files int64
+	// Certificates is the number of certificates which have been found.
+	Certificates int64
+	// Partials is the number of partial certificates which have been found.
+	Partials int64
+}
+
+// WithProgress is a functional option that configures a Progress to be
+// updated as each file is scanned.
+func WithProgress(p *Progress) Option {
+	return func(o *options) {
+		o.progress = p
+	}
+}
+
+// Counts returns the current counts.
+func (p *Progress) Counts() ProgressCounts {
+	return ProgressCounts{
+		Files:        p.files.Load(),
+		Certificates: p.certificates.Load(),
+		Partials:     p.partials.Load(),
+	}
+}
+
+// scanned records that a file has been scanned, along with everything found
+// in it. It does nothing if the Progress is nil.
+func (p *Progress) scanned(parsed *ParsedCertificates) {
+	if p == nil {
+		return
+	}
+	p.files.Add(1)
+	if parsed != nil {
+		p.certificates.Add(int64(len(parsed.Found)))
+		p.partials.Add(int64(len(parsed.Partials)))
+	}
+}
+
+// Report calls report with the current counts every interval, until the
+// returned function is called. That function calls report once more with the
+// final counts, and returns once reporting has stopped, so report is never
+// called concurrently or after it returns.
+func (p *Progress) Report(interval time.Duration, report func(ProgressCounts)) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ticker.C:
+				report(p.Counts())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			wg.Wait()
+			report(p.Counts())
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	root := t.TempDir()
+	cert := mustReadFile(t, "testdata/test-2")
+	for i := 0; i < 20; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(root, fmt.Sprintf("cert-%d.pem", i)), cert, 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "readme.txt"), []byte("not a certificate"), 0o644))
+
+	t.Run("counts should match the scan when files are scanned concurrently", func(t *testing.T) {
+		var progress Progress
+		parsed, err := FindCertificatesInDir(context.TODO(), root, WithConcurrency(4), WithProgress(&progress))
+		require.NoError(t, err)
+		assert.Equal(t, ProgressCounts{
+			Files:        int64(parsed.Stats.Files),
+			Certificates: int64(len(parsed.Found)),
+			Partials:     int64(len(parsed.Partials)),
+		}, progress.Counts())
+		assert.Equal(t, int64(21), progress.Counts().Files)
+	})
+
+	t.Run("counts should accumulate across scans", func(t *testing.T) {
+		var progress Progress
+		for i := 0; i < 2; i++ {
+			_, err := FindCertificatesInFile(context.TODO(), filepath.Join(root, "cert-0.pem"), WithProgress(&progress))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int64(2), progress.Counts().Files)
+	})
+
+	t.Run("stopping a report should report the final counts once", func(t *testing.T) {
+		var (
+			progress Progress
+			reports  []ProgressCounts
+		)
+		stop := progress.Report(time.Hour, func(c ProgressCounts) {
+			reports = append(reports, c)
+		})
+		progress.scanned(&ParsedCertificates{Found: make([]Found, 2)})
+		stop()
+		stop()
+		assert.Equal(t, []ProgressCounts{{Files: 1, Certificates: 2}}, reports)
+	})
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+)
+
+// Redacted replaces the values hidden by Redact.
+const Redacted = "[redacted]"
+
+// Redact hides the subjects, issuers and subject alternative names of the
+// found certificates, such as internal hostnames, so that output can be
+// shared without revealing them. Issuers are hidden too, as the subject of an
+// internal certificate authority is the issuer of every certificate it
+// signed. DNS name, email address and URI subject alternative names are each
+// replaced with Redacted, and IP addresses are removed. Fingerprints are unchanged, so that certificates can
+// still be correlated.
+//
+// Certificates are redacted in place, so every result sharing them is
+// redacted too. This should only be done once they have been validated, as
+// entries may match their subjects. The raw certificate is unchanged.
+func Redact(founds []Found) {
+	for _, f := range founds {
+		if f.Certificate != nil {
+			redactCertificate(f.Certificate)
+		}
+	}
+}
+
+func redactCertificate(cert *x509.Certificate) {
+	redacted := pkix.Name{CommonName: Redacted}
+	cert.Issuer = redacted
+	cert.Subject = redacted
+
+	for i := range cert.DNSNames {
+		cert.DNSNames[i] = Redacted
+	}
+	for i := range cert.EmailAddresses {
+		cert.EmailAddresses[i] = Redacted
+	}
+	for i := range cert.URIs {
+		cert.URIs[i] = &url.URL{Opaque: Redacted}
+	}
+	cert.IPAddresses = nil
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	uri, _ := url.Parse("spiffe://corp.internal/billing")
+	leaf := &x509.Certificate{
+		RawSubject:     []byte("leaf"),
+		RawIssuer:      []byte("issuer"),
+		Subject:        pkix.Name{CommonName: "billing.corp.internal", Organization: []string{"Example"}},
+		Issuer:         pkix.Name{CommonName: "Example Issuing CA"},
+		DNSNames:       []string{"billing.corp.internal", "billing"},
+		EmailAddresses: []string{"ops@corp.internal"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		URIs:           []*url.URL{uri},
+	}
+	selfSigned := &x509.Certificate{
+		RawSubject: []byte("self"),
+		RawIssuer:  []byte("self"),
+		Subject:    pkix.Name{CommonName: "jenkins.corp.internal"},
+		Issuer:     pkix.Name{CommonName: "jenkins.corp.internal"},
+	}
+	founds := []Found{
+		{Location: "/app/leaf.pem", Certificate: leaf, FingerprintSha256: [32]byte{1}},
+		{Location: "/app/self.pem", Certificate: selfSigned, FingerprintSha256: [32]byte{2}},
+		{Location: "/app/partial.pem"},
+	}
+
+	Redact(founds)
+
+	assert.Equal(t, "CN=[redacted]", leaf.Subject.String())
+	assert.Equal(t, "CN=[redacted]", leaf.Issuer.String(), "expected the issuer of a certificate which is not self-signed to be hidden")
+	assert.Equal(t, []string{Redacted, Redacted}, leaf.DNSNames)
+	assert.Equal(t, []string{Redacted}, leaf.EmailAddresses)
+	assert.Empty(t, leaf.IPAddresses)
+	assert.Equal(t, Redacted, leaf.URIs[0].String())
+	assert.Equal(t, "CN=[redacted]", selfSigned.Subject.String())
+	assert.Equal(t, "CN=[redacted]", selfSigned.Issuer.String())
+	assert.Equal(t, [32]byte{1}, founds[0].FingerprintSha256)
+}
@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// regfMagic is the signature at the start of every Windows registry hive.
+var regfMagic = []byte("regf")
+
+const (
+	// regfBaseBlockSize is the size of the header of a hive. Cell offsets are
+	// relative to the end of the header.
+	regfBaseBlockSize = 4096
+
+	// regfKeyCompName and regfValueCompName are the flags marking key and
+	// value names stored as Latin-1, rather than UTF-16.
+	regfKeyCompName   = 0x0020
+	regfValueCompName = 0x0001
+
+	// regfBigDataSegment is the size of each segment of values larger than
+	// regfBigDataSegment, which are split over several cells in hives of
+	// version 1.4 and later.
+	regfBigDataSegment = 16344
+
+	// regfMaxDepth is the maximum depth of nested subkey lists which will be
+	// read, guarding against malformed hives.
+	regfMaxDepth = 32
+)
+
+// regfHive is a minimal, read-only reader of Windows registry hive files held
+// in memory. It supports only what is needed to find keys by name and read
+// their values, and does not apply transaction logs.
+type regfHive struct {
+	data  []byte
+	minor uint32
+}
+
+func isRegf(data []byte) bool {
+	return bytes.HasPrefix(data, regfMagic)
+}
+
+func newRegfHive(data []byte) (*regfHive, error) {
+	if len(data) < regfBaseBlockSize || !isRegf(data) {
+		return nil, errors.New("not a Windows registry hive")
+	}
+	if major := binary.LittleEndian.Uint32(data[0x14:]); major != 1 {
+		return nil, fmt.Errorf("unsupported hive version %d", major)
+	}
+	return &regfHive{data: data, minor: binary.LittleEndian.Uint32(data[0x18:])}, nil
+}
+
+// regfKey is a key in a hive.
+type regfKey struct {
+	name string
+	node []byte
+}
+
+// root returns the root key of the hive.
+func (h *regfHive) root() (regfKey, error) {
+	return h.key(binary.LittleEndian.Uint32(h.data[0x24:]))
+}
+
+// cell returns the data of the allocated cell at the given offset.
+func (h *regfHive) cell(offset uint32) ([]byte, error) {
+	start := regfBaseBlockSize + int64(offset)
+	if start+4 > int64(len(h.data)) {
+		return nil, fmt.Errorf("cell %#x is out of range", offset)
+	}
+	// Allocated cells have a negative size, which includes the size itself.
+	size := -int64(int32(binary.LittleEndian.Uint32(h.data[start:])))
+	if size < 4 || start+size > int64(len(h.data)) {
+		return nil, fmt.Errorf("cell %#x is unallocated or out of range", offset)
+	}
+	return h.data[start+4 : start+size], nil
+}
+
+func (h *regfHive) key(offset uint32) (regfKey, error) {
+	node, err := h.cell(offset)
+	if err != nil {
+		return regfKey{}, err
+	}
+	if len(node) < 76 || !bytes.HasPrefix(node, []byte("nk")) {
+		return regfKey{}, fmt.Errorf("cell %#x is not a key", offset)
+	}
+	nameLen := int(binary.LittleEndian.Uint16(node[72:]))
+	if 76+nameLen > len(node) {
+		return regfKey{}, fmt.Errorf("key %#x name is out of range", offset)
+	}
+	flags := binary.LittleEndian.Uint16(node[2:])
+	return regfKey{
+		name: regfName(node[76:76+nameLen], flags&regfKeyCompName != 0),
+		node: node,
+	}, nil
+}
+
+// subkeys returns every subkey of the key.
+func (h *regfHive) subkeys(k regfKey) ([]regfKey, error) {
+	if binary.LittleEndian.Uint32(k.node[20:]) == 0 {
+		return nil, nil
+	}
+	var offsets []uint32
+	if err := h.subkeyList(binary.LittleEndian.Uint32(k.node[28:]), 0, &offsets); err != nil {
+		return nil, fmt.Errorf("failed to read subkeys of %q: %w", k.name, err)
+	}
+
+	keys := make([]regfKey, 0, len(offsets))
+	for _, offset := range offsets {
+		key, err := h.key(offset)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// subkeyList appends the offsets of the keys in the subkey list at the given
+// offset, following index roots to the lists they reference.
+func (h *regfHive) subkeyList(offset uint32, depth int, offsets *[]uint32) error {
+	if depth > regfMaxDepth {
+		return errors.New("subkey lists are too deep")
+	}
+	list, err := h.cell(offset)
+	if err != nil {
+		return err
+	}
+	if len(list) < 4 {
+		return fmt.Errorf("subkey list %#x is too small", offset)
+	}
+	count := int(binary.LittleEndian.Uint16(list[2:]))
+
+	// Fast leaf and hash leaf lists hold a hint with each offset, while index
+	// leaf and index root lists hold offsets alone.
+	stride := 4
+	switch string(list[:2]) {
+	case "lf", "lh":
+		stride = 8
+	case "li", "ri":
+	default:
+		return fmt.Errorf("cell %#x is not a subkey list", offset)
+	}
+	if 4+count*stride > len(list) {
+		return fmt.Errorf("subkey list %#x is out of range", offset)
+	}
+
+	for i := 0; i < count; i++ {
+		o := binary.LittleEndian.Uint32(list[4+i*stride:])
+		if string(list[:2]) == "ri" {
+			if err := h.subkeyList(o, depth+1, offsets); err != nil {
+				return err
+			}
+			continue
+		}
+		*offsets = append(*offsets, o)
+	}
+	return nil
+}
+
+// subkey returns the subkey of the key with the given name, which like
+// Windows is matched ignoring case.
+func (h *regfHive) subkey(k regfKey, name string) (regfKey, bool, error) {
+	keys, err := h.subkeys(k)
+	if err != nil {
+		return regfKey{}, false, err
+	}
+	for _, key := range keys {
+		if strings.EqualFold(key.name, name) {
+			return key, true, nil
+		}
+	}
+	return regfKey{}, false, nil
+}
+
+// value returns the data of the value of the key with the given name, which
+// is matched ignoring case.
+func (h *regfHive) value(k regfKey, name string) ([]byte, bool, error) {
+	count := int(binary.LittleEndian.Uint32(k.node[36:]))
+	if count == 0 {
+		return nil, false, nil
+	}
+	list, err := h.cell(binary.LittleEndian.Uint32(k.node[40:]))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read values of %q: %w", k.name, err)
+	}
+	if count*4 > len(list) {
+		return nil, false, fmt.Errorf("value list of %q is out of range", k.name)
+	}
+
+	for i := 0; i < count; i++ {
+		vk, err := h.cell(binary.LittleEndian.Uint32(list[i*4:]))
+		if err != nil {
+			return nil, false, err
+		}
+		if len(vk) < 20 || !bytes.HasPrefix(vk, []byte("vk")) {
+			return nil, false, fmt.Errorf("value %d of %q is not a value", i, k.name)
+		}
+		nameLen := int(binary.LittleEndian.Uint16(vk[2:]))
+		if 20+nameLen > len(vk) {
+			return nil, false, fmt.Errorf("value %d of %q name is out of range", i, k.name)
+		}
+		flags := binary.LittleEndian.Uint16(vk[16:])
+		if !strings.EqualFold(regfName(vk[20:20+nameLen], flags&regfValueCompName != 0), name) {
+			continue
+		}
+
+		data, err := h.valueData(vk)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read value %q of %q: %w", name, k.name, err)
+		}
+		return data, true, nil
+	}
+
+	return nil, false, nil
+}
+
+func (h *regfHive) valueData(vk []byte) ([]byte, error) {
+	size := binary.LittleEndian.Uint32(vk[4:])
+	offset := binary.LittleEndian.Uint32(vk[8:])
+
+	// Data of four bytes or fewer is stored in place of its offset.
+	if size&0x80000000 != 0 {
+		size &^= 0x80000000
+		if size > 4 {
+			return nil, fmt.Errorf("resident data size %d is too large", size)
+		}
+		return vk[8 : 8+size], nil
+	}
+
+	cell, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+	if size <= regfBigDataSegment || h.minor < 4 {
+		if int64(size) > int64(len(cell)) {
+			return nil, fmt.Errorf("data size %d is out of range", size)
+		}
+		return cell[:size], nil
+	}
+
+	// Larger data is split into segments, listed by a big data cell.
+	if len(cell) < 8 || !bytes.HasPrefix(cell, []byte("db")) {
+		return nil, errors.New("malformed big data cell")
+	}
+	count := int(binary.LittleEndian.Uint16(cell[2:]))
+	segments, err := h.cell(binary.LittleEndian.Uint32(cell[4:]))
+	if err != nil {
+		return nil, err
+	}
+	if count*4 > len(segments) {
+		return nil, errors.New("big data segment list is out of range")
+	}
+
+	data := make([]byte, 0, size)
+	for i := 0; i < count && uint32(len(data)) < size; i++ {
+		segment, err := h.cell(binary.LittleEndian.Uint32(segments[i*4:]))
+		if err != nil {
+			return nil, err
+		}
+		n := size - uint32(len(data))
+		if n > regfBigDataSegment {
+			n = regfBigDataSegment
+		}
+		if int64(n) > int64(len(segment)) {
+			return nil, errors.New("big data segment is out of range")
+		}
+		data = append(data, segment[:n]...)
+	}
+	if uint32(len(data)) != size {
+		return nil, fmt.Errorf("big data has %d bytes, expected %d", len(data), size)
+	}
+	return data, nil
+}
+
+// regfName decodes a key or value name, which is either Latin-1 or UTF-16.
+func regfName(b []byte, latin1 bool) string {
+	if latin1 {
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Roots returns the self-signed root certificates found, such as to build a
+// minimal trust bundle of just the trust anchors. Each certificate is
+// returned once, at the first location it was found, and they are ordered by
+// SHA-256 fingerprint, so that the result is the same however the certificates
+// were found.
+func Roots(founds []Found) []Found {
+	var (
+		roots []Found
+		seen  = make(map[[32]byte]bool)
+	)
+	for _, f := range founds {
+		if f.Certificate == nil || !f.SelfSigned || seen[f.FingerprintSha256] {
+			continue
+		}
+		seen[f.FingerprintSha256] = true
+		roots = append(roots, f)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		return bytes.Compare(roots[i].FingerprintSha256[:], roots[j].FingerprintSha256[:]) < 0
+	})
+	return roots
+}
@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoots(t *testing.T) {
+	a := [32]byte{1}
+	b := [32]byte{2}
+	c := [32]byte{3}
+	cert := &x509.Certificate{}
+
+	founds := []Found{
+		{Location: "/etc/ssl/certs/ca-certificates.crt", Certificate: cert, FingerprintSha256: b, SelfSigned: true},
+		{Location: "/etc/ssl/certs/ca-certificates.crt", Certificate: cert, FingerprintSha256: c},
+		{Location: "/etc/ssl/certs/a.pem", Certificate: cert, FingerprintSha256: a, SelfSigned: true},
+		{Location: "/etc/ssl/certs/b.pem", Certificate: cert, FingerprintSha256: b, SelfSigned: true},
+	}
+
+	assert.Equal(t, []Found{founds[2], founds[0]}, Roots(founds))
+	assert.Empty(t, Roots(founds[1:2]))
+}
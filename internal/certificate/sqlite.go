@@ -0,0 +1,370 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// sqliteMagic is the header string at the start of every SQLite 3 database.
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+const (
+	sqlitePageInteriorTable = 0x05
+	sqlitePageLeafTable     = 0x0d
+
+	// sqliteMaxDepth is the maximum depth of table b-trees which will be
+	// read, guarding against malformed databases.
+	sqliteMaxDepth = 32
+)
+
+// sqliteDB is a minimal, read-only reader of SQLite 3 database files held in
+// memory. It supports only what is needed to read every row of a table, and
+// does not read uncommitted data from write-ahead logs. SQLite libraries for
+// Go either require cgo or translate the whole of SQLite, so the table b-trees
+// are read directly to keep paranoia a small, static binary. It is tested
+// against a database written by NSS itself, as well as one written by SQLite
+// with small pages so that overflow pages are read.
+type sqliteDB struct {
+	data       []byte
+	pageSize   int
+	usableSize int
+}
+
+func isSQLite(data []byte) bool {
+	return bytes.HasPrefix(data, sqliteMagic)
+}
+
+func newSQLiteDB(data []byte) (*sqliteDB, error) {
+	if len(data) < 100 || !isSQLite(data) {
+		return nil, errors.New("not a SQLite 3 database")
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size %d", pageSize)
+	}
+
+	usableSize := pageSize - int(data[20])
+	if usableSize < 480 {
+		return nil, fmt.Errorf("invalid SQLite usable page size %d", usableSize)
+	}
+
+	return &sqliteDB{data: data, pageSize: pageSize, usableSize: usableSize}, nil
+}
+
+// sqliteTable is a table in the database schema.
+type sqliteTable struct {
+	rootPage int
+	columns  []string
+}
+
+// table returns the table with the given name from the database schema.
+func (db *sqliteDB) table(name string) (sqliteTable, bool, error) {
+	// The schema is stored in a table rooted at page 1, with columns type,
+	// name, tbl_name, rootpage, and sql.
+	rows, err := db.rows(1)
+	if err != nil {
+		return sqliteTable{}, false, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	for _, row := range rows {
+		if len(row) < 5 {
+			continue
+		}
+		typ, _ := row[0].(string)
+		tblName, _ := row[1].(string)
+		if typ != "table" || !strings.EqualFold(tblName, name) {
+			continue
+		}
+		rootPage, _ := row[3].(int64)
+		sql, _ := row[4].(string)
+		return sqliteTable{rootPage: int(rootPage), columns: sqliteColumns(sql)}, true, nil
+	}
+
+	return sqliteTable{}, false, nil
+}
+
+// sqliteColumns returns the column names from a CREATE TABLE statement. Only
+// simple column definitions are supported; table constraints are returned as
+// if they were columns, which is harmless as no record has values for them.
+func sqliteColumns(sql string) []string {
+	start, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	var columns []string
+	for _, def := range strings.Split(sql[start+1:end], ",") {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		columns = append(columns, strings.Trim(fields[0], "\"`[]"))
+	}
+	return columns
+}
+
+// rows returns the values of every row in the table b-tree rooted at the
+// given page. Values are nil, int64, float64, string, or []byte.
+func (db *sqliteDB) rows(rootPage int) ([][]interface{}, error) {
+	var rows [][]interface{}
+	visited := make(map[int]bool)
+	err := db.walk(rootPage, 0, visited, func(payload []byte) error {
+		row, err := sqliteRecord(payload)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
+
+func (db *sqliteDB) page(n int) ([]byte, error) {
+	offset := (n - 1) * db.pageSize
+	if n < 1 || offset+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("page %d is out of range", n)
+	}
+	return db.data[offset : offset+db.pageSize], nil
+}
+
+// walk calls fn with the payload of every cell in the table b-tree rooted at
+// the given page, in rowid order.
+func (db *sqliteDB) walk(n, depth int, visited map[int]bool, fn func([]byte) error) error {
+	if depth > sqliteMaxDepth {
+		return errors.New("table b-tree is too deep")
+	}
+	if visited[n] {
+		return fmt.Errorf("page %d is referenced more than once", n)
+	}
+	visited[n] = true
+
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+
+	// The first page starts with the database header.
+	header := 0
+	if n == 1 {
+		header = 100
+	}
+	if len(page) < header+12 {
+		return fmt.Errorf("page %d is too small", n)
+	}
+
+	pageType := page[header]
+	numCells := int(binary.BigEndian.Uint16(page[header+3 : header+5]))
+
+	var cellPointers int
+	switch pageType {
+	case sqlitePageLeafTable:
+		cellPointers = header + 8
+	case sqlitePageInteriorTable:
+		cellPointers = header + 12
+	default:
+		return fmt.Errorf("page %d has unexpected type %#x", n, pageType)
+	}
+	if cellPointers+numCells*2 > len(page) {
+		return fmt.Errorf("page %d has too many cells", n)
+	}
+
+	for i := 0; i < numCells; i++ {
+		cell := int(binary.BigEndian.Uint16(page[cellPointers+i*2:]))
+		if cell >= db.usableSize {
+			return fmt.Errorf("page %d has a cell out of range", n)
+		}
+
+		if pageType == sqlitePageInteriorTable {
+			if cell+4 > len(page) {
+				return fmt.Errorf("page %d has a cell out of range", n)
+			}
+			child := int(binary.BigEndian.Uint32(page[cell:]))
+			if err := db.walk(child, depth+1, visited, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		payload, err := db.leafPayload(page, cell)
+		if err != nil {
+			return fmt.Errorf("page %d cell %d: %w", n, i, err)
+		}
+		if err := fn(payload); err != nil {
+			return fmt.Errorf("page %d cell %d: %w", n, i, err)
+		}
+	}
+
+	if pageType == sqlitePageInteriorTable {
+		rightMost := int(binary.BigEndian.Uint32(page[header+8:]))
+		return db.walk(rightMost, depth+1, visited, fn)
+	}
+
+	return nil
+}
+
+// leafPayload returns the full payload of the table leaf cell at the given
+// offset, following any overflow pages.
+func (db *sqliteDB) leafPayload(page []byte, cell int) ([]byte, error) {
+	size, n := sqliteVarint(page[cell:])
+	if n == 0 {
+		return nil, errors.New("malformed payload size")
+	}
+	cell += n
+	_, n = sqliteVarint(page[cell:]) // rowid
+	if n == 0 {
+		return nil, errors.New("malformed rowid")
+	}
+	cell += n
+
+	if size > uint64(len(db.data)) {
+		return nil, fmt.Errorf("payload size %d is too large", size)
+	}
+	payloadSize := int(size)
+
+	// The amount of the payload stored on the page itself, as described in
+	// the SQLite file format documentation.
+	u := db.usableSize
+	maxLocal := u - 35
+	local := payloadSize
+	if payloadSize > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (payloadSize-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+
+	if cell+local > len(page) {
+		return nil, errors.New("payload is out of range")
+	}
+	payload := make([]byte, 0, payloadSize)
+	payload = append(payload, page[cell:cell+local]...)
+	if local == payloadSize {
+		return payload, nil
+	}
+
+	if cell+local+4 > len(page) {
+		return nil, errors.New("overflow page pointer is out of range")
+	}
+	next := int(binary.BigEndian.Uint32(page[cell+local:]))
+	visited := make(map[int]bool)
+	for len(payload) < payloadSize {
+		if next == 0 || visited[next] {
+			return nil, errors.New("overflow pages are malformed")
+		}
+		visited[next] = true
+
+		overflow, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		next = int(binary.BigEndian.Uint32(overflow))
+
+		remaining := payloadSize - len(payload)
+		if remaining > u-4 {
+			remaining = u - 4
+		}
+		payload = append(payload, overflow[4:4+remaining]...)
+	}
+
+	return payload, nil
+}
+
+// sqliteRecord decodes a record into its values.
+func sqliteRecord(payload []byte) ([]interface{}, error) {
+	headerSize, n := sqliteVarint(payload)
+	if n == 0 || headerSize > uint64(len(payload)) {
+		return nil, errors.New("malformed record header")
+	}
+
+	var serialTypes []uint64
+	for offset := n; offset < int(headerSize); {
+		t, n := sqliteVarint(payload[offset:int(headerSize)])
+		if n == 0 {
+			return nil, errors.New("malformed record header")
+		}
+		serialTypes = append(serialTypes, t)
+		offset += n
+	}
+
+	body := payload[headerSize:]
+	values := make([]interface{}, 0, len(serialTypes))
+	for _, t := range serialTypes {
+		var size int
+		switch {
+		case t == 0 || t == 8 || t == 9:
+			size = 0
+		case t >= 1 && t <= 4:
+			size = int(t)
+		case t == 5:
+			size = 6
+		case t == 6 || t == 7:
+			size = 8
+		case t >= 12:
+			size = int((t - 12) / 2)
+		default:
+			return nil, fmt.Errorf("unsupported serial type %d", t)
+		}
+		if size > len(body) {
+			return nil, errors.New("record value is out of range")
+		}
+		v := body[:size]
+		body = body[size:]
+
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t == 8:
+			values = append(values, int64(0))
+		case t == 9:
+			values = append(values, int64(1))
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(v)))
+		case t <= 6:
+			// Sign extend the big-endian two's complement integer.
+			var i int64
+			if v[0]&0x80 != 0 {
+				i = -1
+			}
+			for _, b := range v {
+				i = i<<8 | int64(b)
+			}
+			values = append(values, i)
+		case t%2 == 0:
+			values = append(values, append([]byte(nil), v...))
+		default:
+			values = append(values, string(v))
+		}
+	}
+
+	return values, nil
+}
+
+// sqliteVarint decodes a SQLite variable length integer, returning the value
+// and the number of bytes read, which is zero if the integer is truncated.
+func sqliteVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// winRegistryBlobValue is the value of each certificate key holding the
+	// serialized certificate and its properties.
+	winRegistryBlobValue = "Blob"
+
+	// winRegistryCertProp is the ID of the property holding the DER encoded
+	// certificate (CERT_CERT_PROP_ID).
+	winRegistryCertProp = 0x20
+)
+
+// winRegistryStoreRoots are the keys holding certificate stores, such as ROOT
+// and CA, in the SOFTWARE hive and user NTUSER.DAT hives.
+var winRegistryStoreRoots = [][]string{
+	{"Microsoft", "SystemCertificates"},
+	{"Microsoft", "EnterpriseCertificates"},
+	{"Policies", "Microsoft", "SystemCertificates"},
+	{"Software", "Microsoft", "SystemCertificates"},
+	{"Software", "Policies", "Microsoft", "SystemCertificates"},
+}
+
+type winRegistry struct {
+	// maxReadSize is the size of the largest file which is read to be
+	// decoded. If zero, files of any size are read.
+	maxReadSize int64
+}
+
+func (_ winRegistry) name() string { return "winregistry" }
+
+// Find finds X.509 certificates in the certificate stores of Windows registry
+// hives, as used by Windows container images. The location of each
+// certificate is reported as "path!key", where the key includes the store
+// name, such as "Microsoft\SystemCertificates\ROOT\Certificates\<SHA1>".
+// Certificates whose serialized data cannot be read are recorded as partials.
+func (w winRegistry) Find(ctx context.Context, location string, rs rseekerOpener) (*ParsedCertificates, error) {
+	file, err := rs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the signature before reading the whole file, since most files
+	// are not hives.
+	magic := make([]byte, len(regfMagic))
+	if _, err := io.ReadFull(file, magic); err != nil || !isRegf(magic) {
+		return &ParsedCertificates{}, nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+	data, ok, err := readWhole(file, w.maxReadSize)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &ParsedCertificates{
+			Partials: []Partial{{
+				Location: location,
+				Parser:   "winregistry",
+				Reason:   tooLargeReason(w.maxReadSize),
+			}},
+		}, nil
+	}
+
+	parsed, err := winRegistryCertificates(ctx, data, location)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return &ParsedCertificates{
+			Partials: []Partial{{
+				Location: location,
+				Parser:   "winregistry",
+				Reason:   fmt.Sprintf("failed to read Windows registry hive: %s", err),
+			}},
+		}, nil
+	}
+
+	return parsed, nil
+}
+
+// winRegistryCertificates returns the certificates in every store of the
+// hive.
+func winRegistryCertificates(ctx context.Context, data []byte, location string) (*ParsedCertificates, error) {
+	hive, err := newRegfHive(data)
+	if err != nil {
+		return nil, err
+	}
+	root, err := hive.root()
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &ParsedCertificates{}
+	for _, path := range winRegistryStoreRoots {
+		storeRoot, ok, err := winRegistryKey(hive, root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		stores, err := hive.subkeys(storeRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, store := range stores {
+			certs, ok, err := hive.subkey(store, "Certificates")
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			keys, err := hive.subkeys(certs)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, key := range keys {
+				// If context has been cancelled, exit scanning.
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				default:
+				}
+
+				keyPath := strings.Join(append(append([]string{}, path...), store.name, certs.name, key.name), `\`)
+				entryLocation := location + "!" + keyPath
+				found, err := winRegistryFound(hive, key, entryLocation)
+				if err != nil {
+					parsed.Partials = append(parsed.Partials, Partial{
+						Location: entryLocation,
+						Parser:   "winregistry",
+						Reason:   fmt.Sprintf("failed to parse Windows registry certificate: %s", err),
+					})
+					continue
+				}
+				parsed.Found = append(parsed.Found, found)
+			}
+		}
+	}
+
+	return parsed, nil
+}
+
+// winRegistryKey returns the key at the given path below the given key.
+func winRegistryKey(hive *regfHive, key regfKey, path []string) (regfKey, bool, error) {
+	for _, name := range path {
+		var (
+			ok  bool
+			err error
+		)
+		key, ok, err = hive.subkey(key, name)
+		if err != nil || !ok {
+			return regfKey{}, false, err
+		}
+	}
+	return key, true, nil
+}
+
+// winRegistryFound returns the certificate in the serialized certificate held
+// by the blob value of the key. Serialized certificates are a list of
+// properties, each a property ID, a reserved field, and the length of the
+// data which follows, all little endian uint32s.
+func winRegistryFound(hive *regfHive, key regfKey, location string) (Found, error) {
+	blob, ok, err := hive.value(key, winRegistryBlobValue)
+	if err != nil {
+		return Found{}, err
+	}
+	if !ok {
+		return Found{}, errors.New("key has no Blob value")
+	}
+
+	for len(blob) > 0 {
+		if len(blob) < 12 {
+			return Found{}, errors.New("truncated certificate property")
+		}
+		id := binary.LittleEndian.Uint32(blob)
+		size := binary.LittleEndian.Uint32(blob[8:])
+		if uint64(size) > uint64(len(blob)-12) {
+			return Found{}, fmt.Errorf("certificate property %#x is out of range", id)
+		}
+		data := blob[12 : 12+size]
+		blob = blob[12+size:]
+
+		if id != winRegistryCertProp {
+			continue
+		}
+		found, err := newFound(location, "winregistry", data)
+		if err != nil {
+			return Found{}, err
+		}
+		found.Encoding, found.ContainerFormat = EncodingDER, ContainerFormatWindowsRegistry
+		return found, nil
+	}
+
+	return Found{}, errors.New("serialized certificate has no certificate property")
+}
@@ -0,0 +1,243 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	encpem "encoding/pem"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRegfKey is a key of a hive built by buildTestRegf.
+type testRegfKey struct {
+	name    string
+	values  map[string][]byte
+	subkeys []testRegfKey
+}
+
+// buildTestRegf builds a version 1.5 Windows registry hive holding the given
+// root key. Keys with more than one subkey have their subkeys split over an
+// index root, and large values are split into big data segments.
+func buildTestRegf(root testRegfKey) []byte {
+	// Cell offsets are relative to the first hive bin, which starts with a
+	// header.
+	bins := append([]byte("hbin"), make([]byte, 28)...)
+	cell := func(data []byte) uint32 {
+		offset := uint32(len(bins))
+		size := (4 + len(data) + 7) &^ 7
+		bins = binary.LittleEndian.AppendUint32(bins, uint32(-int32(size)))
+		bins = append(bins, data...)
+		bins = append(bins, make([]byte, size-4-len(data))...)
+		return offset
+	}
+	list := func(sig string, offsets []uint32, stride int) uint32 {
+		data := append([]byte(sig), 0, 0)
+		binary.LittleEndian.PutUint16(data[2:], uint16(len(offsets)))
+		for _, o := range offsets {
+			data = binary.LittleEndian.AppendUint32(data, o)
+			data = append(data, make([]byte, stride-4)...)
+		}
+		return cell(data)
+	}
+
+	var key func(k testRegfKey) uint32
+	key = func(k testRegfKey) uint32 {
+		var subkeys []uint32
+		for _, s := range k.subkeys {
+			subkeys = append(subkeys, key(s))
+		}
+		var subkeyList uint32
+		switch {
+		case len(subkeys) == 1:
+			subkeyList = list("lf", subkeys, 8)
+		case len(subkeys) > 1:
+			half := len(subkeys) / 2
+			subkeyList = list("ri", []uint32{list("li", subkeys[:half], 4), list("lh", subkeys[half:], 8)}, 4)
+		}
+
+		var values []byte
+		for name, data := range k.values {
+			vk := append([]byte("vk"), make([]byte, 18)...)
+			binary.LittleEndian.PutUint16(vk[2:], uint16(len(name)))
+			binary.LittleEndian.PutUint32(vk[4:], uint32(len(data)))
+			binary.LittleEndian.PutUint32(vk[12:], 3) // REG_BINARY
+			binary.LittleEndian.PutUint16(vk[16:], regfValueCompName)
+			vk = append(vk, name...)
+			if len(data) > regfBigDataSegment {
+				var segments []uint32
+				for rest := data; len(rest) > 0; {
+					n := len(rest)
+					if n > regfBigDataSegment {
+						n = regfBigDataSegment
+					}
+					segments = append(segments, cell(rest[:n]))
+					rest = rest[n:]
+				}
+				db := append([]byte("db"), 0, 0)
+				binary.LittleEndian.PutUint16(db[2:], uint16(len(segments)))
+				var list []byte
+				for _, s := range segments {
+					list = binary.LittleEndian.AppendUint32(list, s)
+				}
+				db = binary.LittleEndian.AppendUint32(db, cell(list))
+				binary.LittleEndian.PutUint32(vk[8:], cell(db))
+			} else {
+				binary.LittleEndian.PutUint32(vk[8:], cell(data))
+			}
+			values = binary.LittleEndian.AppendUint32(values, cell(vk))
+		}
+
+		nk := append([]byte("nk"), make([]byte, 74)...)
+		binary.LittleEndian.PutUint16(nk[2:], regfKeyCompName)
+		binary.LittleEndian.PutUint32(nk[20:], uint32(len(subkeys)))
+		binary.LittleEndian.PutUint32(nk[28:], subkeyList)
+		binary.LittleEndian.PutUint32(nk[36:], uint32(len(k.values)))
+		if len(values) > 0 {
+			binary.LittleEndian.PutUint32(nk[40:], cell(values))
+		}
+		binary.LittleEndian.PutUint16(nk[72:], uint16(len(k.name)))
+		return cell(append(nk, k.name...))
+	}
+	rootOffset := key(root)
+
+	base := make([]byte, regfBaseBlockSize)
+	copy(base, regfMagic)
+	binary.LittleEndian.PutUint32(base[0x14:], 1)
+	binary.LittleEndian.PutUint32(base[0x18:], 5)
+	binary.LittleEndian.PutUint32(base[0x24:], rootOffset)
+	binary.LittleEndian.PutUint32(base[0x28:], uint32(len(bins)))
+	return append(base, bins...)
+}
+
+// testSerializedCert returns a serialized certificate, as stored in the Blob
+// value of certificate keys, holding the given properties.
+func testSerializedCert(props map[uint32][]byte) []byte {
+	var b []byte
+	for _, id := range []uint32{0x03, 0x0b, winRegistryCertProp} {
+		data, ok := props[id]
+		if !ok {
+			continue
+		}
+		b = binary.LittleEndian.AppendUint32(b, id)
+		b = binary.LittleEndian.AppendUint32(b, 1)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+		b = append(b, data...)
+	}
+	return b
+}
+
+func Test_winRegistry(t *testing.T) {
+	var ders [][]byte
+	for rest := mustReadFile(t, "testdata/test-1"); ; {
+		var block *encpem.Block
+		block, rest = encpem.Decode(rest)
+		if block == nil {
+			break
+		}
+		ders = append(ders, block.Bytes)
+	}
+	require.Len(t, ders, 3)
+
+	certKey := func(name string, props map[uint32][]byte) testRegfKey {
+		return testRegfKey{name: name, values: map[string][]byte{"Blob": testSerializedCert(props)}}
+	}
+	hive := buildTestRegf(testRegfKey{
+		name: "ROOT",
+		subkeys: []testRegfKey{
+			{name: "Microsoft", subkeys: []testRegfKey{
+				{name: "SystemCertificates", subkeys: []testRegfKey{
+					{name: "ROOT", subkeys: []testRegfKey{
+						{name: "Certificates", subkeys: []testRegfKey{
+							certKey("ROOT-1", map[uint32][]byte{0x03: make([]byte, 20), winRegistryCertProp: ders[0]}),
+							// A large property, so that the value is split
+							// into big data segments.
+							certKey("ROOT-2", map[uint32][]byte{0x0b: make([]byte, 40000), winRegistryCertProp: ders[1]}),
+							{name: "ROOT-3"},
+						}},
+					}},
+					{name: "CA", subkeys: []testRegfKey{
+						{name: "certificates", subkeys: []testRegfKey{
+							certKey("CA-1", map[uint32][]byte{winRegistryCertProp: ders[2]}),
+							certKey("CA-2", map[uint32][]byte{0x03: make([]byte, 20)}),
+						}},
+					}},
+					{name: "Disallowed"},
+				}},
+			}},
+			{name: "Policies", subkeys: []testRegfKey{
+				{name: "Microsoft", subkeys: []testRegfKey{
+					{name: "SystemCertificates", subkeys: []testRegfKey{
+						{name: "Root", subkeys: []testRegfKey{
+							{name: "Certificates", subkeys: []testRegfKey{
+								certKey("POLICY-1", map[uint32][]byte{winRegistryCertProp: ders[0]}),
+							}},
+						}},
+					}},
+				}},
+			}},
+		},
+	})
+
+	tests := map[string]struct {
+		data              []byte
+		expLocations      []string
+		expPartials       []string
+		expPartialReasons []string
+	}{
+		"certificate stores should be found": {
+			data: hive,
+			expLocations: []string{
+				`SOFTWARE!Microsoft\SystemCertificates\ROOT\Certificates\ROOT-1`,
+				`SOFTWARE!Microsoft\SystemCertificates\ROOT\Certificates\ROOT-2`,
+				`SOFTWARE!Microsoft\SystemCertificates\CA\certificates\CA-1`,
+				`SOFTWARE!Policies\Microsoft\SystemCertificates\Root\Certificates\POLICY-1`,
+			},
+			expPartials: []string{
+				`SOFTWARE!Microsoft\SystemCertificates\ROOT\Certificates\ROOT-3`,
+				`SOFTWARE!Microsoft\SystemCertificates\CA\certificates\CA-2`,
+			},
+			expPartialReasons: []string{
+				"failed to parse Windows registry certificate: key has no Blob value",
+				"failed to parse Windows registry certificate: serialized certificate has no certificate property",
+			},
+		},
+		"truncated hive should be recorded as partial": {
+			data:              hive[:regfBaseBlockSize+64],
+			expPartials:       []string{"SOFTWARE"},
+			expPartialReasons: []string{"failed to read Windows registry hive: cell 0xb4f8 is out of range"},
+		},
+		"non hive files should be ignored": {
+			data: mustReadFile(t, "testdata/test-1"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsedCerts, err := (winRegistry{}).Find(context.TODO(), "SOFTWARE", func() (io.ReadSeeker, error) {
+				return bytes.NewReader(test.data), nil
+			})
+			require.NoError(t, err)
+
+			var locations []string
+			for _, r := range parsedCerts.Found {
+				assert.Equal(t, "winregistry", r.Parser)
+				assert.Equal(t, EncodingDER, r.Encoding)
+				assert.Equal(t, ContainerFormatWindowsRegistry, r.ContainerFormat)
+				locations = append(locations, r.Location)
+			}
+			assert.ElementsMatch(t, test.expLocations, locations)
+
+			var partials, reasons []string
+			for _, p := range parsedCerts.Partials {
+				partials = append(partials, p.Location)
+				reasons = append(reasons, p.Reason)
+			}
+			assert.ElementsMatch(t, test.expPartials, partials)
+			assert.ElementsMatch(t, test.expPartialReasons, reasons)
+		})
+	}
+}
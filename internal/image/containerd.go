@@ -0,0 +1,312 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	contentapi "github.com/containerd/containerd/api/services/content/v1"
+	imagesapi "github.com/containerd/containerd/api/services/images/v1"
+	containerdtypes "github.com/containerd/containerd/api/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	crapi "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+const (
+	// DefaultContainerdSocket is the path of the containerd socket on most
+	// nodes.
+	DefaultContainerdSocket = "/run/containerd/containerd.sock"
+
+	// DefaultContainerdNamespace is the containerd namespace holding images
+	// pulled by Kubernetes through the CRI plugin.
+	DefaultContainerdNamespace = "k8s.io"
+
+	// maxContainerdManifest is the largest manifest or config blob read from
+	// the content store.
+	maxContainerdManifest = 16 << 20
+
+	// containerdNamespaceHeader is the gRPC metadata key containerd reads
+	// the namespace of a request from.
+	containerdNamespaceHeader = "containerd-namespace"
+)
+
+// FindCertificatesInContainerd will read the image with the given reference
+// from the content store of the containerd listening on the given socket,
+// scan for X.509 certificates, and return the result. Layers are read
+// directly from the content store, so neither Docker nor a registry is
+// required.
+func FindCertificatesInContainerd(ctx context.Context, socket, ref string, opts ...Option) (*certificate.ParsedCertificates, error) {
+	o := makeOptions(opts...)
+
+	if socket == "" {
+		socket = DefaultContainerdSocket
+	}
+	namespace := o.containerdNamespace
+	if namespace == "" {
+		namespace = DefaultContainerdNamespace
+	}
+
+	if _, err := os.Stat(socket); err != nil {
+		return nil, fmt.Errorf("containerd socket %s not found: %w", socket, err)
+	}
+
+	c, err := newContainerdClient(socket, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	desc, err := c.image(ctx, strings.TrimSpace(ref))
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := c.resolveImage(ctx, desc, o.platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return findCertificatesInImage(ctx, img, o)
+}
+
+// containerdClient is a client of the containerd content and images
+// services, supporting only what is needed to read images from the content
+// store.
+type containerdClient struct {
+	conn      *grpc.ClientConn
+	content   contentapi.ContentClient
+	images    imagesapi.ImagesClient
+	namespace string
+}
+
+func newContainerdClient(socket, namespace string) (*containerdClient, error) {
+	conn, err := grpc.Dial("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to containerd socket %s: %w", socket, err)
+	}
+	return &containerdClient{
+		conn:      conn,
+		content:   contentapi.NewContentClient(conn),
+		images:    imagesapi.NewImagesClient(conn),
+		namespace: namespace,
+	}, nil
+}
+
+func (c *containerdClient) close() error {
+	return c.conn.Close()
+}
+
+// withNamespace returns a context which sends the namespace with each call
+// made to containerd.
+func (c *containerdClient) withNamespace(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, containerdNamespaceHeader, c.namespace)
+}
+
+// image returns the descriptor of the image with the given name. Names are
+// stored fully qualified by containerd, so the normalised name is tried if the
+// name as given is not found, such that "alpine" finds
+// "docker.io/library/alpine:latest".
+func (c *containerdClient) image(ctx context.Context, ref string) (crapi.Descriptor, error) {
+	names := []string{ref}
+	if parsed, err := name.ParseReference(ref); err == nil {
+		normalised := parsed.Name()
+		if strings.HasPrefix(normalised, name.DefaultRegistry+"/") {
+			normalised = "docker.io/" + strings.TrimPrefix(normalised, name.DefaultRegistry+"/")
+		}
+		if normalised != ref {
+			names = append(names, normalised)
+		}
+	}
+
+	for _, n := range names {
+		resp, err := c.images.Get(c.withNamespace(ctx), &imagesapi.GetImageRequest{Name: n})
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return crapi.Descriptor{}, fmt.Errorf("failed to get image %q from containerd: %w", n, err)
+		}
+		return parseDescriptor(resp.GetImage().GetTarget())
+	}
+
+	return crapi.Descriptor{}, fmt.Errorf("image %q not found in containerd namespace %q", ref, c.namespace)
+}
+
+// resolveImage returns the image for the given descriptor. If the descriptor
+// is an index, the image matching the given platform is returned.
+func (c *containerdClient) resolveImage(ctx context.Context, desc crapi.Descriptor, platform *crapi.Platform) (crapi.Image, error) {
+	switch {
+	case desc.MediaType.IsImage():
+		manifest, err := c.readBlob(ctx, desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		return partial.CompressedToImage(&containerdImage{
+			ctx:       ctx,
+			client:    c,
+			manifest:  manifest,
+			mediaType: desc.MediaType,
+		})
+	case desc.MediaType.IsIndex():
+		b, err := c.readBlob(ctx, desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := crapi.ParseIndexManifest(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image index %s: %w", desc.Digest, err)
+		}
+		d, err := platformManifest(manifest, desc.Digest, platform)
+		if err != nil {
+			return nil, err
+		}
+		return c.resolveImage(ctx, d, platform)
+	default:
+		return nil, fmt.Errorf("unsupported media type %q for manifest %s", desc.MediaType, desc.Digest)
+	}
+}
+
+// readBlob reads the whole of a manifest or config blob from the content
+// store.
+func (c *containerdClient) readBlob(ctx context.Context, digest crapi.Hash) ([]byte, error) {
+	var buf bytes.Buffer
+	err := c.read(ctx, digest, func(data []byte) error {
+		if buf.Len()+len(data) > maxContainerdManifest {
+			return fmt.Errorf("blob %s is larger than %d bytes", digest, maxContainerdManifest)
+		}
+		buf.Write(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// read calls fn with each chunk of the blob with the given digest, in order.
+func (c *containerdClient) read(ctx context.Context, digest crapi.Hash, fn func([]byte) error) error {
+	stream, err := c.content.Read(c.withNamespace(ctx), &contentapi.ReadContentRequest{Digest: digest.String()})
+	if err != nil {
+		return readContentError(digest, err)
+	}
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return readContentError(digest, err)
+		}
+		if err := fn(resp.GetData()); err != nil {
+			return err
+		}
+	}
+}
+
+func readContentError(digest crapi.Hash, err error) error {
+	if status.Code(err) == codes.NotFound {
+		return fmt.Errorf("content %s not found in containerd content store", digest)
+	}
+	return fmt.Errorf("failed to read content %s from containerd: %w", digest, err)
+}
+
+// parseDescriptor converts a containerd descriptor to a registry descriptor.
+func parseDescriptor(target *containerdtypes.Descriptor) (crapi.Descriptor, error) {
+	if target.GetDigest() == "" {
+		return crapi.Descriptor{}, errors.New("image descriptor has no digest")
+	}
+	h, err := crapi.NewHash(target.GetDigest())
+	if err != nil {
+		return crapi.Descriptor{}, fmt.Errorf("failed to decode image descriptor: %w", err)
+	}
+	return crapi.Descriptor{
+		MediaType: types.MediaType(target.GetMediaType()),
+		Digest:    h,
+		Size:      target.GetSize(),
+	}, nil
+}
+
+// containerdImage is an image read from the containerd content store.
+type containerdImage struct {
+	ctx       context.Context
+	client    *containerdClient
+	manifest  []byte
+	mediaType types.MediaType
+}
+
+func (i *containerdImage) RawManifest() ([]byte, error) {
+	return i.manifest, nil
+}
+
+func (i *containerdImage) MediaType() (types.MediaType, error) {
+	return i.mediaType, nil
+}
+
+func (i *containerdImage) RawConfigFile() ([]byte, error) {
+	manifest, err := crapi.ParseManifest(bytes.NewReader(i.manifest))
+	if err != nil {
+		return nil, err
+	}
+	return i.client.readBlob(i.ctx, manifest.Config.Digest)
+}
+
+func (i *containerdImage) LayerByDigest(h crapi.Hash) (partial.CompressedLayer, error) {
+	manifest, err := crapi.ParseManifest(bytes.NewReader(i.manifest))
+	if err != nil {
+		return nil, err
+	}
+	descs := make([]crapi.Descriptor, 0, len(manifest.Layers)+1)
+	descs = append(descs, manifest.Layers...)
+	descs = append(descs, manifest.Config)
+	for _, desc := range descs {
+		if desc.Digest == h {
+			return &containerdLayer{image: i, desc: desc}, nil
+		}
+	}
+	return nil, fmt.Errorf("layer %s not found in image manifest", h)
+}
+
+// containerdLayer is a layer read from the containerd content store.
+type containerdLayer struct {
+	image *containerdImage
+	desc  crapi.Descriptor
+}
+
+func (l *containerdLayer) Digest() (crapi.Hash, error) {
+	return l.desc.Digest, nil
+}
+
+func (l *containerdLayer) Size() (int64, error) {
+	return l.desc.Size, nil
+}
+
+func (l *containerdLayer) MediaType() (types.MediaType, error) {
+	return l.desc.MediaType, nil
+}
+
+// Compressed streams the layer from the content store as it is read.
+func (l *containerdLayer) Compressed() (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(l.image.client.read(l.image.ctx, l.desc.Digest, func(data []byte) error {
+			_, err := pw.Write(data)
+			return err
+		}))
+	}()
+	return pr, nil
+}
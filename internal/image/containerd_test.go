@@ -0,0 +1,280 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	contentapi "github.com/containerd/containerd/api/services/content/v1"
+	imagesapi "github.com/containerd/containerd/api/services/images/v1"
+	containerdtypes "github.com/containerd/containerd/api/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// fakeContainerd serves the parts of the containerd images and content
+// services used to read images, from the given images and blobs.
+type fakeContainerd struct {
+	namespace string
+	images    map[string]v1.Descriptor
+	blobs     map[string][]byte
+}
+
+func (f *fakeContainerd) inNamespace(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	namespaces := md.Get(containerdNamespaceHeader)
+	return len(namespaces) == 1 && namespaces[0] == f.namespace
+}
+
+// fakeImages serves the images service of a fakeContainerd.
+type fakeImages struct {
+	imagesapi.UnimplementedImagesServer
+	*fakeContainerd
+}
+
+func (f fakeImages) Get(ctx context.Context, req *imagesapi.GetImageRequest) (*imagesapi.GetImageResponse, error) {
+	desc, ok := f.images[req.Name]
+	if !ok || !f.inNamespace(ctx) {
+		return nil, status.Errorf(codes.NotFound, "image %q not found", req.Name)
+	}
+	return &imagesapi.GetImageResponse{
+		Image: &imagesapi.Image{
+			Name: req.Name,
+			Target: &containerdtypes.Descriptor{
+				MediaType: string(desc.MediaType),
+				Digest:    desc.Digest.String(),
+				Size:      desc.Size,
+			},
+		},
+	}, nil
+}
+
+// fakeContent serves the content service of a fakeContainerd.
+type fakeContent struct {
+	contentapi.UnimplementedContentServer
+	*fakeContainerd
+}
+
+func (f fakeContent) Read(req *contentapi.ReadContentRequest, srv contentapi.Content_ReadServer) error {
+	blob, ok := f.blobs[req.Digest]
+	if !ok || !f.inNamespace(srv.Context()) {
+		return status.Errorf(codes.NotFound, "content %s not found", req.Digest)
+	}
+	// Stream the blob in small chunks, as containerd does.
+	for offset := 0; offset < len(blob); offset += 1024 {
+		end := offset + 1024
+		if end > len(blob) {
+			end = len(blob)
+		}
+		if err := srv.Send(&contentapi.ReadContentResponse{Offset: int64(offset), Data: blob[offset:end]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serveContainerd serves the fake containerd on a socket, returning its path.
+func serveContainerd(t *testing.T, f *fakeContainerd) string {
+	// Socket paths are limited in length, so may not be in the test's
+	// temporary directory.
+	dir, err := os.MkdirTemp("", "containerd")
+	if err != nil {
+		t.Fatalf("unexpected error creating directory: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "containerd.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("unexpected error listening: %s", err)
+	}
+	srv := grpc.NewServer()
+	imagesapi.RegisterImagesServer(srv, fakeImages{fakeContainerd: f})
+	contentapi.RegisterContentServer(srv, fakeContent{fakeContainerd: f})
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+
+	return socket
+}
+
+// addImage adds the manifest, config, and layers of the image to the blobs.
+func addImage(t *testing.T, blobs map[string][]byte, img v1.Image) v1.Descriptor {
+	manifest, err := img.RawManifest()
+	if err != nil {
+		t.Fatalf("unexpected error getting manifest: %s", err)
+	}
+	config, err := img.RawConfigFile()
+	if err != nil {
+		t.Fatalf("unexpected error getting config: %s", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting digest: %s", err)
+	}
+	configName, err := img.ConfigName()
+	if err != nil {
+		t.Fatalf("unexpected error getting config name: %s", err)
+	}
+	blobs[digest.String()] = manifest
+	blobs[configName.String()] = config
+
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatalf("unexpected error getting layers: %s", err)
+	}
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			t.Fatalf("unexpected error getting layer digest: %s", err)
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			t.Fatalf("unexpected error reading layer: %s", err)
+		}
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("unexpected error reading layer: %s", err)
+		}
+		blobs[digest.String()] = b
+	}
+
+	mediaType, err := img.MediaType()
+	if err != nil {
+		t.Fatalf("unexpected error getting media type: %s", err)
+	}
+	return v1.Descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(manifest))}
+}
+
+func TestFindCertificatesInContainerd(t *testing.T) {
+	blobs := make(map[string][]byte)
+	img := addImage(t, blobs, makeTestImage(t, map[string]string{"image.crt": "testdata/image"}))
+
+	idx := makeTestIndex(
+		t,
+		map[string]v1.Image{
+			"linux/amd64": makeTestImage(t, map[string]string{"linux-amd64.crt": "testdata/linux-amd64"}),
+			"linux/arm64": makeTestImage(t, map[string]string{"linux-arm64.crt": "testdata/linux-arm64"}),
+		},
+	)
+	idxManifest, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatalf("unexpected error getting index manifest: %s", err)
+	}
+	for _, desc := range idxManifest.Manifests {
+		child, err := idx.Image(desc.Digest)
+		if err != nil {
+			t.Fatalf("unexpected error getting image: %s", err)
+		}
+		addImage(t, blobs, child)
+	}
+	rawIdx, err := idx.RawManifest()
+	if err != nil {
+		t.Fatalf("unexpected error getting index: %s", err)
+	}
+	idxDigest, err := idx.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting index digest: %s", err)
+	}
+	idxMediaType, err := idx.MediaType()
+	if err != nil {
+		t.Fatalf("unexpected error getting index media type: %s", err)
+	}
+	blobs[idxDigest.String()] = rawIdx
+
+	socket := serveContainerd(t, &fakeContainerd{
+		namespace: DefaultContainerdNamespace,
+		images: map[string]v1.Descriptor{
+			"docker.io/library/image:latest": img,
+			"example.com/multi-arch:v1":      {MediaType: idxMediaType, Digest: idxDigest, Size: int64(len(rawIdx))},
+			"example.com/missing:v1":         {MediaType: img.MediaType, Digest: v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("0", 64)}},
+		},
+		blobs: blobs,
+	})
+
+	findLocations := func(t *testing.T, ref string, opts ...Option) []certificate.Found {
+		gotCerts, err := FindCertificatesInContainerd(context.TODO(), socket, ref, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error finding certificates: %s", err)
+		}
+		return gotCerts.Found
+	}
+	ignore := cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintMd5", "FingerprintSha1", "FingerprintSha256", "FingerprintSha512", "SpkiSha256", "KeyAlgorithm", "KeySizeBits", "SelfSigned", "IsCA", "LayerDigest")
+	want := func(location string) []certificate.Found {
+		return []certificate.Found{{Location: location, Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509}}
+	}
+
+	testCases := map[string]func(t *testing.T){
+		"an image should be found by its full name": func(t *testing.T) {
+			if diff := cmp.Diff(want("/image.crt"), findLocations(t, "docker.io/library/image:latest"), ignore); diff != "" {
+				t.Errorf("unexpected certificates (-want +got):\n%s", diff)
+			}
+		},
+		"an image should be found by its short name": func(t *testing.T) {
+			if diff := cmp.Diff(want("/image.crt"), findLocations(t, "image"), ignore); diff != "" {
+				t.Errorf("unexpected certificates (-want +got):\n%s", diff)
+			}
+		},
+		"an index should resolve to the default platform": func(t *testing.T) {
+			if diff := cmp.Diff(want("/linux-amd64.crt"), findLocations(t, "example.com/multi-arch:v1"), ignore); diff != "" {
+				t.Errorf("unexpected certificates (-want +got):\n%s", diff)
+			}
+		},
+		"an index should resolve to the given platform": func(t *testing.T) {
+			got := findLocations(t, "example.com/multi-arch:v1", WithPlatform(&v1.Platform{OS: "linux", Architecture: "arm64"}))
+			if diff := cmp.Diff(want("/linux-arm64.crt"), got, ignore); diff != "" {
+				t.Errorf("unexpected certificates (-want +got):\n%s", diff)
+			}
+		},
+		"the containerd scheme should use the configured socket": func(t *testing.T) {
+			gotCerts, err := FindImageCertificates(context.TODO(), "containerd://image", WithContainerd(socket, ""))
+			if err != nil {
+				t.Fatalf("unexpected error finding certificates: %s", err)
+			}
+			if diff := cmp.Diff(want("/image.crt"), gotCerts.Found, ignore); diff != "" {
+				t.Errorf("unexpected certificates (-want +got):\n%s", diff)
+			}
+		},
+		"an unknown image should give an error": func(t *testing.T) {
+			_, err := FindCertificatesInContainerd(context.TODO(), socket, "example.com/unknown:v1")
+			if err == nil || !strings.Contains(err.Error(), `image "example.com/unknown:v1" not found in containerd namespace "k8s.io"`) {
+				t.Errorf("expected image not found error, got %v", err)
+			}
+		},
+		"an image in another namespace should give an error": func(t *testing.T) {
+			_, err := FindCertificatesInContainerd(context.TODO(), socket, "image", WithContainerd("", "moby"))
+			if err == nil || !strings.Contains(err.Error(), `not found in containerd namespace "moby"`) {
+				t.Errorf("expected image not found error, got %v", err)
+			}
+		},
+		"an image without content should give an error": func(t *testing.T) {
+			_, err := FindCertificatesInContainerd(context.TODO(), socket, "example.com/missing:v1")
+			if err == nil || !strings.Contains(err.Error(), "not found in containerd content store") {
+				t.Errorf("expected content not found error, got %v", err)
+			}
+		},
+		"a missing socket should give an error": func(t *testing.T) {
+			missing := filepath.Join(t.TempDir(), "containerd.sock")
+			_, err := FindCertificatesInContainerd(context.TODO(), missing, "image")
+			if err == nil || !strings.Contains(err.Error(), "containerd socket "+missing+" not found") {
+				t.Errorf("expected socket not found error, got %v", err)
+			}
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, tc)
+	}
+}
@@ -3,15 +3,17 @@
 package image
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
-	crapi "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/pkg/errors"
 
 	"github.com/jetstack/paranoia/internal/certificate"
 )
@@ -23,59 +25,132 @@ func FindImageCertificates(ctx context.Context, name string, opts ...Option) (*c
 
 	name = strings.TrimSpace(name)
 
-	var (
-		img crapi.Image
-		err error
-	)
 	switch {
 	case name == "-":
-		var f *os.File
-		f, err = os.CreateTemp(os.TempDir(), "paranoia-")
+		tmp, err := writeTempTarball(os.Stdin)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create temporary file: %w", err)
+			return nil, err
 		}
-		defer os.RemoveAll(f.Name())
+		defer os.RemoveAll(tmp)
 
-		if _, err := io.Copy(f, os.Stdin); err != nil {
-			return nil, fmt.Errorf("failed to write image to temporary file: %w", err)
+		return findCertificatesInTarball(ctx, tmp, o, opts...)
+	case strings.HasPrefix(name, "oci://"):
+		// The reference is optional, and separated from the layout path by the
+		// first colon, such that digests may be used as references.
+		path, ref, _ := strings.Cut(strings.TrimPrefix(name, "oci://"), ":")
+		return FindCertificatesInOCILayout(ctx, path, ref, opts...)
+	case strings.HasPrefix(name, "containerd://"):
+		return FindCertificatesInContainerd(ctx, o.containerdSocket, strings.TrimPrefix(name, "containerd://"), opts...)
+	case strings.HasPrefix(name, "dir://"):
+		return certificate.FindCertificatesInDir(ctx, strings.TrimPrefix(name, "dir://"), o.certOpts...)
+	case strings.HasPrefix(name, "bundle://"):
+		return certificate.FindCertificatesInFile(ctx, strings.TrimPrefix(name, "bundle://"), o.certOpts...)
+	case strings.HasPrefix(name, "file://"):
+		path := strings.TrimPrefix(name, "file://")
+		compressed, err := isGzipFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load image: %w", err)
 		}
-
-		if err := f.Close(); err != nil {
-			return nil, fmt.Errorf("failed to close temporary file: %w", err)
+		if compressed {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load image: %w", err)
+			}
+			path, err = writeTempTarball(f)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			defer os.RemoveAll(path)
 		}
 
-		img, err = crane.Load(f.Name(), o.craneOpts...)
-	case strings.HasPrefix(name, "file://"):
-		img, err = crane.Load(strings.TrimPrefix(name, "file://"), o.craneOpts...)
+		return findCertificatesInTarball(ctx, path, o, opts...)
 	default:
-		img, err = crane.Pull(name, o.craneOpts...)
+		return FindCertificatesInRemoteImage(ctx, name, opts...)
+	}
+}
+
+// FindCertificatesInRemoteImage will pull the image with the given reference
+// directly from its registry, scan for X.509 certificates, and return the
+// result. Registry credentials are taken from the default keychain, such as
+// the Docker config file, so no local Docker daemon is required. Layers are
+// downloaded one at a time, unless WithPullConcurrency is given.
+func FindCertificatesInRemoteImage(ctx context.Context, ref string, opts ...Option) (*certificate.ParsedCertificates, error) {
+	o := makeOptions(opts...)
+
+	craneOpts := append([]crane.Option{
+		crane.WithContext(ctx),
+		crane.WithAuthFromKeychain(authn.DefaultKeychain),
+	}, o.craneOpts...)
+
+	img, err := crane.Pull(strings.TrimSpace(ref), craneOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull image: %w", err)
+	}
+
+	// Otherwise, layers are downloaded one at a time, as they are scanned.
+	if o.pullConcurrency > 1 {
+		var cleanup func()
+		img, cleanup, err = prefetchImage(ctx, img, o.pullConcurrency)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
 	}
+
+	return findCertificatesInImage(ctx, img, o)
+}
+
+// gzipMagic are the leading bytes of gzip compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isGzipFile returns true if the file at the given path is gzip compressed,
+// such as the output of "docker save | gzip".
+func isGzipFile(path string) (bool, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load image: %w", err)
+		return false, err
 	}
+	defer f.Close()
 
-	var exportErr error
-	exportDone := make(chan struct{})
-	r, w := io.Pipe()
-	defer r.Close()
-	defer w.Close()
+	magic := make([]byte, len(gzipMagic))
+	n, err := io.ReadFull(f, magic)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	return bytes.Equal(magic[:n], gzipMagic), nil
+}
 
-	go func() {
-		if err := crane.Export(img, w); err != nil {
-			exportErr = err
+// writeTempTarball writes the image tarball read from r to a temporary file,
+// as tarballs are read more than once when loaded, and returns its name. A
+// gzip compressed tarball is decompressed as it is written.
+func writeTempTarball(r io.Reader) (string, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return "", fmt.Errorf("failed to read gzip compressed image: %w", err)
 		}
-		close(exportDone)
-	}()
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
 
-	parsedCertificates, err := certificate.FindCertificates(context.TODO(), r)
+	f, err := os.CreateTemp(os.TempDir(), "paranoia-")
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to search for certificates in container image")
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
 	}
 
-	<-exportDone
-	if exportErr != nil {
-		return nil, errors.Wrap(err, "error when exporting image")
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write image to temporary file: %w", err)
 	}
 
-	return parsedCertificates, nil
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	return f.Name(), nil
 }
@@ -4,12 +4,15 @@ package image
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -81,12 +84,14 @@ func TestFindImageCertificates_Platform(t *testing.T) {
 			wantCerts := &certificate.ParsedCertificates{
 				Found: []certificate.Found{
 					{
-						Location: "/linux-amd64.crt",
-						Parser:   "pem",
+						Location:        "/linux-amd64.crt",
+						Parser:          "pem",
+						Encoding:        certificate.EncodingPEM,
+						ContainerFormat: certificate.ContainerFormatX509,
 					},
 				},
 			}
-			if diff := cmp.Diff(wantCerts, gotCerts, cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintSha1", "FingerprintSha256")); diff != "" {
+			if diff := cmp.Diff(wantCerts, gotCerts, cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintMd5", "FingerprintSha1", "FingerprintSha256", "FingerprintSha512", "SpkiSha256", "KeyAlgorithm", "KeySizeBits", "SelfSigned", "IsCA", "LayerDigest"), cmpopts.IgnoreFields(certificate.ParsedCertificates{}, "Stats", "ImageDigest")); diff != "" {
 				t.Fatalf("unexpected certificates:\n%s", diff)
 			}
 		},
@@ -103,12 +108,14 @@ func TestFindImageCertificates_Platform(t *testing.T) {
 			wantCerts := &certificate.ParsedCertificates{
 				Found: []certificate.Found{
 					{
-						Location: "/linux-arm64.crt",
-						Parser:   "pem",
+						Location:        "/linux-arm64.crt",
+						Parser:          "pem",
+						Encoding:        certificate.EncodingPEM,
+						ContainerFormat: certificate.ContainerFormatX509,
 					},
 				},
 			}
-			if diff := cmp.Diff(wantCerts, gotCerts, cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintSha1", "FingerprintSha256")); diff != "" {
+			if diff := cmp.Diff(wantCerts, gotCerts, cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintMd5", "FingerprintSha1", "FingerprintSha256", "FingerprintSha512", "SpkiSha256", "KeyAlgorithm", "KeySizeBits", "SelfSigned", "IsCA", "LayerDigest"), cmpopts.IgnoreFields(certificate.ParsedCertificates{}, "Stats", "ImageDigest")); diff != "" {
 				t.Fatalf("unexpected certificates:\n%s", diff)
 			}
 
@@ -135,12 +142,14 @@ func TestFindImageCertificates_Platform(t *testing.T) {
 			wantCerts := &certificate.ParsedCertificates{
 				Found: []certificate.Found{
 					{
-						Location: "/image.crt",
-						Parser:   "pem",
+						Location:        "/image.crt",
+						Parser:          "pem",
+						Encoding:        certificate.EncodingPEM,
+						ContainerFormat: certificate.ContainerFormatX509,
 					},
 				},
 			}
-			if diff := cmp.Diff(wantCerts, gotCerts, cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintSha1", "FingerprintSha256")); diff != "" {
+			if diff := cmp.Diff(wantCerts, gotCerts, cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintMd5", "FingerprintSha1", "FingerprintSha256", "FingerprintSha512", "SpkiSha256", "KeyAlgorithm", "KeySizeBits", "SelfSigned", "IsCA", "LayerDigest"), cmpopts.IgnoreFields(certificate.ParsedCertificates{}, "Stats", "ImageDigest")); diff != "" {
 				t.Fatalf("unexpected certificates:\n%s", diff)
 			}
 		},
@@ -151,6 +160,109 @@ func TestFindImageCertificates_Platform(t *testing.T) {
 	}
 }
 
+func TestFindCertificatesInRemoteImage(t *testing.T) {
+	host := setupRegistry(t)
+
+	img := makeTestImage(
+		t,
+		map[string]string{
+			"image.crt": "testdata/image",
+		},
+	)
+	imgTag := fmt.Sprintf("%s/%s:%s", host, "repo", "remote")
+	imgRef, err := name.ParseReference(imgTag)
+	if err != nil {
+		t.Fatalf("unexpected error parsing reference: %s", err)
+	}
+	if err := remote.Write(imgRef, img); err != nil {
+		t.Fatalf("unexpected error writing image: %s", err)
+	}
+
+	testCases := map[string]func(t *testing.T){
+		"certificates are found in a pulled image": func(t *testing.T) {
+			gotCerts, err := FindCertificatesInRemoteImage(context.TODO(), imgTag)
+			if err != nil {
+				t.Fatalf("unexpected error finding certificates: %s", err)
+			}
+
+			wantCerts := &certificate.ParsedCertificates{
+				Found: []certificate.Found{
+					{
+						Location:        "/image.crt",
+						Parser:          "pem",
+						Encoding:        certificate.EncodingPEM,
+						ContainerFormat: certificate.ContainerFormatX509,
+					},
+				},
+			}
+			if diff := cmp.Diff(wantCerts, gotCerts, cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintMd5", "FingerprintSha1", "FingerprintSha256", "FingerprintSha512", "SpkiSha256", "KeyAlgorithm", "KeySizeBits", "SelfSigned", "IsCA", "LayerDigest"), cmpopts.IgnoreFields(certificate.ParsedCertificates{}, "Stats", "ImageDigest")); diff != "" {
+				t.Fatalf("unexpected certificates:\n%s", diff)
+			}
+		},
+		"layers may be downloaded concurrently": func(t *testing.T) {
+			gotCerts, err := FindCertificatesInRemoteImage(context.TODO(), imgTag, WithPullConcurrency(4))
+			if err != nil {
+				t.Fatalf("unexpected error finding certificates: %s", err)
+			}
+			if len(gotCerts.Found) != 1 || gotCerts.Found[0].Location != "/image.crt" {
+				t.Fatalf("expected a certificate at /image.crt, got %+v", gotCerts.Found)
+			}
+		},
+		"an image which doesn't exist should return an error": func(t *testing.T) {
+			if _, err := FindCertificatesInRemoteImage(context.TODO(), fmt.Sprintf("%s/%s:%s", host, "repo", "missing")); err == nil {
+				t.Fatalf("expected error but got nil")
+			}
+		},
+		"a cancelled context should return an error": func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			if _, err := FindCertificatesInRemoteImage(ctx, imgTag); err == nil {
+				t.Fatalf("expected error but got nil")
+			}
+		},
+	}
+
+	for n, fn := range testCases {
+		t.Run(n, fn)
+	}
+}
+
+func TestFindImageCertificates_GzipTarball(t *testing.T) {
+	img := makeTestImage(t, map[string]string{"image.crt": "testdata/image"})
+
+	dir := t.TempDir()
+	tarPath := filepath.Join(dir, "image.tar")
+	if err := crane.Save(img, "example.com/image:latest", tarPath); err != nil {
+		t.Fatalf("unexpected error saving image: %s", err)
+	}
+	data, err := os.ReadFile(tarPath)
+	if err != nil {
+		t.Fatalf("unexpected error reading tarball: %s", err)
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("unexpected error compressing tarball: %s", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("unexpected error compressing tarball: %s", err)
+	}
+	gzPath := filepath.Join(dir, "image.tar.gz")
+	if err := os.WriteFile(gzPath, compressed.Bytes(), 0644); err != nil {
+		t.Fatalf("unexpected error writing tarball: %s", err)
+	}
+
+	for _, path := range []string{tarPath, gzPath} {
+		gotCerts, err := FindImageCertificates(context.TODO(), "file://"+path)
+		if err != nil {
+			t.Fatalf("unexpected error finding certificates in %s: %s", path, err)
+		}
+		if len(gotCerts.Found) != 1 || gotCerts.Found[0].Location != "/image.crt" {
+			t.Errorf("expected a certificate at /image.crt in %s, got %+v", path, gotCerts.Found)
+		}
+	}
+}
+
 func makeTestImage(t *testing.T, fileMap map[string]string) v1.Image {
 	m := map[string][]byte{}
 	for path, f := range fileMap {
@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	crapi "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+const (
+	// whiteoutPrefix marks a file as deleted from the layers below.
+	whiteoutPrefix = ".wh."
+	// whiteoutOpaque marks a directory's contents as deleted from the layers
+	// below.
+	whiteoutOpaque = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+// findCertificatesInImage scans each of the layers of the given image for
+// X.509 certificates. Layers are scanned from the top down, such that only
+// files which are present in the image's final filesystem are scanned, and
+// each certificate is attributed to the topmost layer containing its file.
+// Layers identical to a layer higher in the image are skipped, since every
+// file they contain is replaced by the higher copy.
+func findCertificatesInImage(ctx context.Context, img crapi.Image, o *options) (*certificate.ParsedCertificates, error) {
+	imageDigest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image digest: %w", err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image layers: %w", err)
+	}
+
+	var (
+		filter  = newLayerFilter()
+		scanned = make(map[crapi.Hash]bool)
+		parsed  = &certificate.ParsedCertificates{ImageDigest: imageDigest.String()}
+	)
+	for i := len(layers) - 1; i >= 0; i-- {
+		digest, err := layers[i].Digest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layer digest: %w", err)
+		}
+		if scanned[digest] {
+			parsed.SkippedLayers++
+			continue
+		}
+		scanned[digest] = true
+
+		layerParsed, err := findCertificatesInLayer(ctx, layers[i], digest, filter, o)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Found = append(parsed.Found, layerParsed.Found...)
+		parsed.Partials = append(parsed.Partials, layerParsed.Partials...)
+		parsed.Distrusted = append(parsed.Distrusted, layerParsed.Distrusted...)
+		parsed.Stats.Add(layerParsed.Stats)
+		for link, target := range layerParsed.Symlinks {
+			if parsed.Symlinks == nil {
+				parsed.Symlinks = make(map[string]string)
+			}
+			parsed.Symlinks[link] = target
+		}
+		filter.endLayer()
+	}
+
+	// Links may refer to files in other layers, so are resolved again once
+	// every layer has been scanned.
+	if parsed.Symlinks != nil {
+		parsed.ResolveSymlinks()
+	}
+	parsed.Sort()
+
+	return parsed, nil
+}
+
+func findCertificatesInLayer(ctx context.Context, layer crapi.Layer, digest crapi.Hash, filter *layerFilter, o *options) (*certificate.ParsedCertificates, error) {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layer %s: %w", digest, err)
+	}
+	defer rc.Close()
+
+	opts := append([]certificate.Option{}, o.certOpts...)
+	opts = append(opts, certificate.WithTarHeaderFilter(filter.include), certificate.WithLayerDigest(digest.String()))
+
+	parsed, err := certificate.FindCertificates(ctx, rc, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for certificates in layer %s: %w", digest, err)
+	}
+
+	return parsed, nil
+}
+
+// layerFilter filters the files of image layers, as they are read from the
+// top layer down, to only those present in the image's final filesystem.
+// Files are excluded if they were replaced by or deleted in a higher layer.
+type layerFilter struct {
+	// seen holds every path seen in a higher layer, or the current layer.
+	// The value is true if the path hides everything beneath it in lower
+	// layers; such as files, deleted files, and opaque directories.
+	seen map[string]bool
+	// opaque holds the directories marked opaque in the current layer. These
+	// only hide the contents of lower layers, so are not applied to seen
+	// until the layer ends.
+	opaque []string
+}
+
+func newLayerFilter() *layerFilter {
+	return &layerFilter{seen: make(map[string]bool)}
+}
+
+// include returns true if the given file of the current layer is present in
+// the final filesystem.
+func (f *layerFilter) include(header *tar.Header) bool {
+	name := path.Clean("/" + header.Name)
+	dir, base := path.Split(name)
+
+	if base == whiteoutOpaque {
+		f.opaque = append(f.opaque, path.Clean(dir))
+		return false
+	}
+
+	deleted := strings.HasPrefix(base, whiteoutPrefix)
+	if deleted {
+		name = path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix))
+	}
+
+	if _, ok := f.seen[name]; ok || f.hidden(name) {
+		return false
+	}
+
+	f.seen[name] = deleted || header.Typeflag != tar.TypeDir
+
+	return !deleted
+}
+
+// hidden returns true if a parent directory of the given path is hidden by a
+// higher layer.
+func (f *layerFilter) hidden(name string) bool {
+	for dir := path.Dir(name); dir != "/"; dir = path.Dir(dir) {
+		if f.seen[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// endLayer is called once all files of the current layer have been filtered.
+func (f *layerFilter) endLayer() {
+	for _, dir := range f.opaque {
+		f.seen[dir] = true
+	}
+	f.opaque = nil
+}
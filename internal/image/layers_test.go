@@ -0,0 +1,223 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestFindCertificatesInImage_Layers(t *testing.T) {
+	readFile := func(name string) []byte {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error reading file: %s", err)
+		}
+		return data
+	}
+
+	lower, err := crane.Layer(map[string][]byte{
+		"etc/replaced.crt": readFile("testdata/linux-arm64"),
+		"etc/deleted.crt":  readFile("testdata/linux-amd64"),
+		"opt/hidden.crt":   readFile("testdata/linux-arm64"),
+		"usr/lower.crt":    readFile("testdata/image"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating layer: %s", err)
+	}
+	upper, err := crane.Layer(map[string][]byte{
+		"etc/replaced.crt":    readFile("testdata/image"),
+		"etc/.wh.deleted.crt": {},
+		"opt/.wh..wh..opq":    {},
+		"opt/upper.crt":       readFile("testdata/linux-amd64"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating layer: %s", err)
+	}
+
+	img, err := mutate.AppendLayers(empty.Image, lower, upper)
+	if err != nil {
+		t.Fatalf("unexpected error creating image: %s", err)
+	}
+
+	digest := func(l v1.Layer) string {
+		d, err := l.Digest()
+		if err != nil {
+			t.Fatalf("unexpected error getting layer digest: %s", err)
+		}
+		return d.String()
+	}
+
+	gotCerts, err := findCertificatesInImage(context.TODO(), img, makeOptions())
+	if err != nil {
+		t.Fatalf("unexpected error finding certificates: %s", err)
+	}
+
+	imageDigest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting image digest: %s", err)
+	}
+
+	wantCerts := &certificate.ParsedCertificates{
+		Found: []certificate.Found{
+			{Location: "/etc/replaced.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509, LayerDigest: digest(upper)},
+			{Location: "/opt/upper.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509, LayerDigest: digest(upper)},
+			{Location: "/usr/lower.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509, LayerDigest: digest(lower)},
+		},
+		ImageDigest: imageDigest.String(),
+	}
+	if diff := cmp.Diff(wantCerts, gotCerts,
+		cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintMd5", "FingerprintSha1", "FingerprintSha256", "FingerprintSha512", "SpkiSha256", "KeyAlgorithm", "KeySizeBits", "SelfSigned", "IsCA"),
+		cmpopts.IgnoreFields(certificate.ParsedCertificates{}, "Stats"),
+		cmpopts.SortSlices(func(a, b certificate.Found) bool { return a.Location < b.Location }),
+	); diff != "" {
+		t.Fatalf("unexpected certificates:\n%s", diff)
+	}
+
+	// Files replaced or deleted by a higher layer, and whiteouts, are skipped.
+	if gotCerts.Stats.Files != 3 || gotCerts.Stats.SkippedFiles != 5 {
+		t.Errorf("expected 3 files scanned and 5 skipped, got %+v", gotCerts.Stats)
+	}
+
+	// The replaced file must be the upper layer's content.
+	for _, f := range gotCerts.Found {
+		if f.Location == "/etc/replaced.crt" && f.Certificate.Subject.String() != findSubject(t, "testdata/image") {
+			t.Fatalf("unexpected certificate for replaced file: %s", f.Certificate.Subject)
+		}
+	}
+}
+
+func TestFindCertificatesInImage_DuplicateLayers(t *testing.T) {
+	readFile := func(name string) []byte {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error reading file: %s", err)
+		}
+		return data
+	}
+
+	base, err := crane.Layer(map[string][]byte{
+		"etc/base.crt": readFile("testdata/image"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating layer: %s", err)
+	}
+	app, err := crane.Layer(map[string][]byte{
+		"opt/app.crt": readFile("testdata/linux-amd64"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating layer: %s", err)
+	}
+
+	// The base layer is added again on top, as happens when images are built
+	// by copying the same content in more than once.
+	img, err := mutate.AppendLayers(empty.Image, base, app, base)
+	if err != nil {
+		t.Fatalf("unexpected error creating image: %s", err)
+	}
+
+	baseDigest, err := base.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting layer digest: %s", err)
+	}
+	appDigest, err := app.Digest()
+	if err != nil {
+		t.Fatalf("unexpected error getting layer digest: %s", err)
+	}
+
+	gotCerts, err := findCertificatesInImage(context.TODO(), img, makeOptions())
+	if err != nil {
+		t.Fatalf("unexpected error finding certificates: %s", err)
+	}
+
+	wantCerts := &certificate.ParsedCertificates{
+		Found: []certificate.Found{
+			{Location: "/etc/base.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509, LayerDigest: baseDigest.String()},
+			{Location: "/opt/app.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509, LayerDigest: appDigest.String()},
+		},
+		SkippedLayers: 1,
+	}
+	if diff := cmp.Diff(wantCerts, gotCerts,
+		cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintMd5", "FingerprintSha1", "FingerprintSha256", "FingerprintSha512", "SpkiSha256", "KeyAlgorithm", "KeySizeBits", "SelfSigned", "IsCA"),
+		cmpopts.IgnoreFields(certificate.ParsedCertificates{}, "Stats", "ImageDigest"),
+		cmpopts.SortSlices(func(a, b certificate.Found) bool { return a.Location < b.Location }),
+	); diff != "" {
+		t.Fatalf("unexpected certificates:\n%s", diff)
+	}
+
+	// Files in the skipped layer are not counted.
+	if gotCerts.Stats.Files != 2 {
+		t.Errorf("expected 2 files scanned, got %+v", gotCerts.Stats)
+	}
+}
+
+func TestFindCertificatesInImage_Symlinks(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/image")
+	if err != nil {
+		t.Fatalf("unexpected error reading file: %s", err)
+	}
+
+	base, err := crane.Layer(map[string][]byte{
+		"usr/share/ca-certificates/ca.crt": data,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating layer: %s", err)
+	}
+
+	// The link is in a higher layer than the file it refers to.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "etc/ssl/certs/ca.crt", Linkname: "/usr/share/ca-certificates/ca.crt"}); err != nil {
+		t.Fatalf("unexpected error writing tar: %s", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unexpected error writing tar: %s", err)
+	}
+	links, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating layer: %s", err)
+	}
+
+	img, err := mutate.AppendLayers(empty.Image, base, links)
+	if err != nil {
+		t.Fatalf("unexpected error creating image: %s", err)
+	}
+
+	gotCerts, err := findCertificatesInImage(context.TODO(), img, makeOptions(WithScanOptions(certificate.WithResolveSymlinks(true))))
+	if err != nil {
+		t.Fatalf("unexpected error finding certificates: %s", err)
+	}
+
+	var locations []string
+	for _, f := range gotCerts.Found {
+		locations = append(locations, f.Location)
+	}
+	if diff := cmp.Diff([]string{"/etc/ssl/certs/ca.crt", "/usr/share/ca-certificates/ca.crt"}, locations); diff != "" {
+		t.Fatalf("unexpected certificate locations:\n%s", diff)
+	}
+}
+
+func findSubject(t *testing.T, name string) string {
+	img := makeTestImage(t, map[string]string{"cert.crt": name})
+	certs, err := findCertificatesInImage(context.TODO(), img, makeOptions())
+	if err != nil || len(certs.Found) != 1 {
+		t.Fatalf("unexpected error finding certificate in %s: %v", name, err)
+	}
+	return certs.Found[0].Certificate.Subject.String()
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	crapi "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// ociRefNameAnnotation is the annotation on an OCI layout's index which holds
+// the reference, typically the tag, of each manifest.
+const ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+
+// defaultPlatform is the platform resolved from a multi-arch image when no
+// platform is given, matching the behaviour when pulling from a registry.
+var defaultPlatform = crapi.Platform{OS: "linux", Architecture: "amd64"}
+
+// FindCertificatesInOCILayout will load an image from the OCI image layout
+// directory at the given path, scan for X.509 certificates, and return the
+// result. The ref selects the manifest by its "org.opencontainers.image.ref.name"
+// annotation or its digest. If ref is empty and the layout holds a single
+// manifest, that manifest is used.
+func FindCertificatesInOCILayout(ctx context.Context, path, ref string, opts ...Option) (*certificate.ParsedCertificates, error) {
+	o := makeOptions(opts...)
+
+	idx, err := layout.ImageIndexFromPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI layout: %w", err)
+	}
+
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI layout index: %w", err)
+	}
+
+	desc, err := selectLayoutManifest(manifest, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := layoutImage(idx, desc, o.platform)
+	if err != nil {
+		return nil, err
+	}
+
+	return findCertificatesInImage(ctx, img, o)
+}
+
+// selectLayoutManifest returns the descriptor in the given OCI layout index
+// matching ref. An error listing the available references is returned if
+// no single manifest matches.
+func selectLayoutManifest(manifest *crapi.IndexManifest, ref string) (crapi.Descriptor, error) {
+	if ref == "" && len(manifest.Manifests) == 1 {
+		return manifest.Manifests[0], nil
+	}
+
+	if ref != "" {
+		for _, desc := range manifest.Manifests {
+			if desc.Annotations[ociRefNameAnnotation] == ref || desc.Digest.String() == ref {
+				return desc, nil
+			}
+		}
+	}
+
+	var refs []string
+	for _, desc := range manifest.Manifests {
+		if name, ok := desc.Annotations[ociRefNameAnnotation]; ok {
+			refs = append(refs, name)
+		} else {
+			refs = append(refs, desc.Digest.String())
+		}
+	}
+
+	if ref == "" {
+		return crapi.Descriptor{}, fmt.Errorf("OCI layout contains %d manifests, a reference must be given, available references: %s",
+			len(manifest.Manifests), strings.Join(refs, ", "))
+	}
+	return crapi.Descriptor{}, fmt.Errorf("reference %q not found in OCI layout, available references: %s", ref, strings.Join(refs, ", "))
+}
+
+// layoutImage returns the image for the given descriptor. If the descriptor
+// is itself an index, the image matching the given platform is returned.
+func layoutImage(idx crapi.ImageIndex, desc crapi.Descriptor, platform *crapi.Platform) (crapi.Image, error) {
+	switch {
+	case desc.MediaType.IsImage():
+		return idx.Image(desc.Digest)
+	case desc.MediaType.IsIndex():
+		child, err := idx.ImageIndex(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image index %s: %w", desc.Digest, err)
+		}
+
+		manifest, err := child.IndexManifest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image index %s: %w", desc.Digest, err)
+		}
+
+		d, err := platformManifest(manifest, desc.Digest, platform)
+		if err != nil {
+			return nil, err
+		}
+		return layoutImage(child, d, platform)
+	default:
+		return nil, fmt.Errorf("unsupported media type %q for manifest %s", desc.MediaType, desc.Digest)
+	}
+}
+
+// platformManifest returns the descriptor in the image index with the given
+// digest which matches the platform, or the default platform if nil.
+func platformManifest(manifest *crapi.IndexManifest, digest crapi.Hash, platform *crapi.Platform) (crapi.Descriptor, error) {
+	if platform == nil {
+		platform = &defaultPlatform
+	}
+	for _, d := range manifest.Manifests {
+		p := defaultPlatform
+		if d.Platform != nil {
+			p = *d.Platform
+		}
+		if p.OS == platform.OS && p.Architecture == platform.Architecture &&
+			(platform.Variant == "" || p.Variant == platform.Variant) {
+			return d, nil
+		}
+	}
+	return crapi.Descriptor{}, fmt.Errorf("no image with platform %s in image index %s", platform, digest)
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestFindCertificatesInOCILayout(t *testing.T) {
+	img := makeTestImage(t, map[string]string{"image.crt": "testdata/image"})
+	idx := makeTestIndex(
+		t,
+		map[string]v1.Image{
+			"linux/amd64": makeTestImage(t, map[string]string{"linux-amd64.crt": "testdata/linux-amd64"}),
+			"linux/arm64": makeTestImage(t, map[string]string{"linux-arm64.crt": "testdata/linux-arm64"}),
+		},
+	)
+
+	single := t.TempDir()
+	p, err := layout.Write(single, empty.Index)
+	if err != nil {
+		t.Fatalf("unexpected error writing layout: %s", err)
+	}
+	if err := p.AppendImage(img); err != nil {
+		t.Fatalf("unexpected error appending image: %s", err)
+	}
+
+	multi := t.TempDir()
+	p, err = layout.Write(multi, empty.Index)
+	if err != nil {
+		t.Fatalf("unexpected error writing layout: %s", err)
+	}
+	if err := p.AppendImage(img, layout.WithAnnotations(map[string]string{ociRefNameAnnotation: "image"})); err != nil {
+		t.Fatalf("unexpected error appending image: %s", err)
+	}
+	if err := p.AppendIndex(idx, layout.WithAnnotations(map[string]string{ociRefNameAnnotation: "multi-arch"})); err != nil {
+		t.Fatalf("unexpected error appending index: %s", err)
+	}
+
+	findLocations := func(t *testing.T, path, ref string, opts ...Option) []certificate.Found {
+		gotCerts, err := FindCertificatesInOCILayout(context.TODO(), path, ref, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error finding certificates: %s", err)
+		}
+		return gotCerts.Found
+	}
+	ignore := cmpopts.IgnoreFields(certificate.Found{}, "Certificate", "FingerprintMd5", "FingerprintSha1", "FingerprintSha256", "FingerprintSha512", "SpkiSha256", "KeyAlgorithm", "KeySizeBits", "SelfSigned", "IsCA", "LayerDigest")
+
+	testCases := map[string]func(t *testing.T){
+		"a single manifest should be used when no reference is given": func(t *testing.T) {
+			want := []certificate.Found{{Location: "/image.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509}}
+			if diff := cmp.Diff(want, findLocations(t, single, ""), ignore); diff != "" {
+				t.Fatalf("unexpected certificates:\n%s", diff)
+			}
+		},
+		"the manifest should be selected by its reference": func(t *testing.T) {
+			want := []certificate.Found{{Location: "/image.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509}}
+			if diff := cmp.Diff(want, findLocations(t, multi, "image"), ignore); diff != "" {
+				t.Fatalf("unexpected certificates:\n%s", diff)
+			}
+		},
+		"an index should default to linux/amd64": func(t *testing.T) {
+			want := []certificate.Found{{Location: "/linux-amd64.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509}}
+			if diff := cmp.Diff(want, findLocations(t, multi, "multi-arch"), ignore); diff != "" {
+				t.Fatalf("unexpected certificates:\n%s", diff)
+			}
+		},
+		"an index should resolve the given platform": func(t *testing.T) {
+			platform, err := v1.ParsePlatform("linux/arm64")
+			if err != nil {
+				t.Fatalf("unexpected error parsing platform: %s", err)
+			}
+			want := []certificate.Found{{Location: "/linux-arm64.crt", Parser: "pem", Encoding: certificate.EncodingPEM, ContainerFormat: certificate.ContainerFormatX509}}
+			if diff := cmp.Diff(want, findLocations(t, multi, "multi-arch", WithPlatform(platform)), ignore); diff != "" {
+				t.Fatalf("unexpected certificates:\n%s", diff)
+			}
+		},
+		"multiple manifests without a reference should return an error listing references": func(t *testing.T) {
+			_, err := FindCertificatesInOCILayout(context.TODO(), multi, "")
+			if err == nil {
+				t.Fatalf("expected error but got nil")
+			}
+			if !strings.Contains(err.Error(), "image, multi-arch") {
+				t.Fatalf("expected error to list references, got: %s", err)
+			}
+		},
+		"an unknown reference should return an error": func(t *testing.T) {
+			if _, err := FindCertificatesInOCILayout(context.TODO(), multi, "missing"); err == nil {
+				t.Fatalf("expected error but got nil")
+			}
+		},
+	}
+
+	for n, fn := range testCases {
+		t.Run(n, fn)
+	}
+}
@@ -3,13 +3,22 @@ package image
 import (
 	"github.com/google/go-containerregistry/pkg/crane"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/jetstack/paranoia/internal/certificate"
 )
 
 // Option is a functional option that configures image operations
 type Option func(*options)
 
 type options struct {
+	platform  *v1.Platform
 	craneOpts []crane.Option
+	certOpts  []certificate.Option
+
+	containerdSocket    string
+	containerdNamespace string
+
+	pullConcurrency int
 }
 
 func makeOptions(opts ...Option) *options {
@@ -26,7 +35,37 @@ func makeOptions(opts ...Option) *options {
 func WithPlatform(platform *v1.Platform) Option {
 	return func(o *options) {
 		if platform != nil {
+			o.platform = platform
 			o.craneOpts = append(o.craneOpts, crane.WithPlatform(platform))
 		}
 	}
 }
+
+// WithScanOptions is a functional option that configures how the image is
+// scanned for certificates.
+func WithScanOptions(opts ...certificate.Option) Option {
+	return func(o *options) {
+		o.certOpts = append(o.certOpts, opts...)
+	}
+}
+
+// WithContainerd is a functional option that configures the socket and
+// namespace images are read from when given with the containerd:// scheme.
+// Empty values are replaced with the defaults.
+func WithContainerd(socket, namespace string) Option {
+	return func(o *options) {
+		o.containerdSocket = socket
+		o.containerdNamespace = namespace
+	}
+}
+
+// WithPullConcurrency is a functional option that configures the number of
+// layers of remote images which are downloaded at once. If more than one,
+// layers are downloaded ahead of being scanned, to temporary files, so up to
+// the whole compressed image may be held on disk. Otherwise, each layer is
+// streamed from the registry as it is scanned.
+func WithPullConcurrency(n int) Option {
+	return func(o *options) {
+		o.pullConcurrency = n
+	}
+}
@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	crapi "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// prefetchedImage is an image whose layers are downloaded ahead of being
+// scanned, so that several layers are downloaded at once.
+type prefetchedImage struct {
+	crapi.Image
+	layers []crapi.Layer
+}
+
+func (i *prefetchedImage) Layers() ([]crapi.Layer, error) {
+	return i.layers, nil
+}
+
+// prefetchedLayer is a layer which is downloaded to a temporary file in the
+// background. Reading the layer waits for the download to finish.
+type prefetchedLayer struct {
+	crapi.Layer
+	digest crapi.Hash
+	done   chan struct{}
+	path   string
+	err    error
+}
+
+// Uncompressed returns the downloaded layer blob as it was stored in the
+// registry, which may still be compressed. Layers are decompressed
+// transparently when they are scanned.
+func (l *prefetchedLayer) Uncompressed() (io.ReadCloser, error) {
+	<-l.done
+	if l.err != nil {
+		return nil, l.err
+	}
+	return os.Open(l.path)
+}
+
+// prefetchImage returns the image with its layers downloaded to temporary
+// files in the background, at most concurrency at a time, from the top layer
+// down as they are scanned. Layers with the same digest are only downloaded
+// once. Downloads stop once the context is done. The returned function waits
+// for the downloads to stop, and removes the temporary files.
+func prefetchImage(ctx context.Context, img crapi.Image, concurrency int) (crapi.Image, func(), error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get image layers: %w", err)
+	}
+
+	// Layers to download, from the top layer down.
+	var pending []*prefetchedLayer
+	fetched := make(map[crapi.Hash]*prefetchedLayer)
+	prefetch := make([]crapi.Layer, len(layers))
+	for i := len(layers) - 1; i >= 0; i-- {
+		digest, err := layers[i].Digest()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get layer digest: %w", err)
+		}
+		if l, ok := fetched[digest]; ok {
+			prefetch[i] = l
+			continue
+		}
+		l := &prefetchedLayer{Layer: layers[i], digest: digest, done: make(chan struct{})}
+		fetched[digest] = l
+		prefetch[i] = l
+		pending = append(pending, l)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var (
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, concurrency)
+		files []string
+		lock  sync.Mutex
+	)
+	cleanup := func() {
+		cancel()
+		wg.Wait()
+		for _, f := range files {
+			os.Remove(f)
+		}
+	}
+
+	// Downloads are started in order as the semaphore is acquired, so that
+	// lower layers never take a slot ahead of the layers above them.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, l := range pending {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				for _, l := range pending[i:] {
+					l.err = ctx.Err()
+					close(l.done)
+				}
+				return
+			}
+
+			wg.Add(1)
+			go func(l *prefetchedLayer) {
+				defer wg.Done()
+				defer close(l.done)
+				defer func() { <-sem }()
+
+				path, err := downloadLayer(ctx, l.Layer)
+				if path != "" {
+					lock.Lock()
+					files = append(files, path)
+					lock.Unlock()
+				}
+				if err != nil {
+					l.err = fmt.Errorf("failed to download layer %s: %w", l.digest, err)
+					return
+				}
+				l.path = path
+			}(l)
+		}
+	}()
+
+	return &prefetchedImage{Image: img, layers: prefetch}, cleanup, nil
+}
+
+// downloadLayer writes the compressed blob of the layer to a temporary file,
+// and returns its name. The name is returned even on failure, if the file
+// was created, so that it can be removed.
+func downloadLayer(ctx context.Context, layer crapi.Layer) (string, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	f, err := os.CreateTemp(os.TempDir(), "paranoia-layer-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	if _, err := io.Copy(f, &contextReader{ctx: ctx, r: rc}); err != nil {
+		f.Close()
+		return f.Name(), err
+	}
+	return f.Name(), f.Close()
+}
+
+// contextReader is a reader which fails once its context is done, so that a
+// download is abandoned promptly on cancellation.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// orderedLayer records the order in which layers are downloaded.
+type orderedLayer struct {
+	v1.Layer
+	name  string
+	lock  *sync.Mutex
+	order *[]string
+}
+
+func (l *orderedLayer) Compressed() (io.ReadCloser, error) {
+	l.lock.Lock()
+	*l.order = append(*l.order, l.name)
+	l.lock.Unlock()
+	return l.Layer.Compressed()
+}
+
+func TestPrefetchImage(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/image")
+	if err != nil {
+		t.Fatalf("unexpected error reading file: %s", err)
+	}
+	lower, err := crane.Layer(map[string][]byte{"etc/lower.crt": data})
+	if err != nil {
+		t.Fatalf("unexpected error creating layer: %s", err)
+	}
+	upper, err := crane.Layer(map[string][]byte{"etc/upper.crt": data})
+	if err != nil {
+		t.Fatalf("unexpected error creating layer: %s", err)
+	}
+	img, err := mutate.AppendLayers(empty.Image, lower, upper, lower)
+	if err != nil {
+		t.Fatalf("unexpected error creating image: %s", err)
+	}
+
+	want, err := findCertificatesInImage(context.TODO(), img, makeOptions())
+	if err != nil {
+		t.Fatalf("unexpected error finding certificates: %s", err)
+	}
+
+	prefetched, cleanup, err := prefetchImage(context.TODO(), img, 2)
+	if err != nil {
+		t.Fatalf("unexpected error prefetching image: %s", err)
+	}
+	defer cleanup()
+	got, err := findCertificatesInImage(context.TODO(), prefetched, makeOptions())
+	if err != nil {
+		t.Fatalf("unexpected error finding certificates: %s", err)
+	}
+
+	if len(got.Found) != len(want.Found) {
+		t.Fatalf("expected %d certificates, got %d", len(want.Found), len(got.Found))
+	}
+	for i := range want.Found {
+		if want.Found[i].Location != got.Found[i].Location || want.Found[i].LayerDigest != got.Found[i].LayerDigest {
+			t.Errorf("expected certificate at %s in layer %s, got %s in layer %s", want.Found[i].Location, want.Found[i].LayerDigest, got.Found[i].Location, got.Found[i].LayerDigest)
+		}
+	}
+	if got.SkippedLayers != 1 {
+		t.Errorf("expected the duplicate layer to be skipped, got %d skipped", got.SkippedLayers)
+	}
+
+	t.Run("a cancelled context should stop downloads", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		prefetched, cleanup, err := prefetchImage(ctx, img, 1)
+		if err != nil {
+			t.Fatalf("unexpected error prefetching image: %s", err)
+		}
+		defer cleanup()
+		if _, err := findCertificatesInImage(context.TODO(), prefetched, makeOptions()); err == nil {
+			t.Fatalf("expected error but got nil")
+		}
+	})
+
+	t.Run("layers should be downloaded from the top layer down", func(t *testing.T) {
+		var (
+			lock   sync.Mutex
+			order  []string
+			layers []v1.Layer
+		)
+		for _, name := range []string{"a", "b", "c", "d"} {
+			layer, err := crane.Layer(map[string][]byte{"etc/" + name + ".crt": data})
+			if err != nil {
+				t.Fatalf("unexpected error creating layer: %s", err)
+			}
+			layers = append(layers, &orderedLayer{Layer: layer, name: name, lock: &lock, order: &order})
+		}
+		img, err := mutate.AppendLayers(empty.Image, layers...)
+		if err != nil {
+			t.Fatalf("unexpected error creating image: %s", err)
+		}
+
+		prefetched, cleanup, err := prefetchImage(context.TODO(), img, 1)
+		if err != nil {
+			t.Fatalf("unexpected error prefetching image: %s", err)
+		}
+		defer cleanup()
+		if _, err := findCertificatesInImage(context.TODO(), prefetched, makeOptions()); err != nil {
+			t.Fatalf("unexpected error finding certificates: %s", err)
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		want := []string{"d", "c", "b", "a"}
+		if len(order) != len(want) {
+			t.Fatalf("expected downloads %v, got %v", want, order)
+		}
+		for i := range want {
+			if order[i] != want[i] {
+				t.Fatalf("expected downloads %v, got %v", want, order)
+			}
+		}
+	})
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// The formats of image tarballs.
+const (
+	// tarballDocker is a tarball written by "docker save", or by "skopeo
+	// copy" to a docker-archive, with a manifest.json file listing its images
+	// and the paths of their layers within the tarball, bottom first. Only
+	// the layers it lists are scanned, in that order, wherever they are in
+	// the tarball and whether or not they are links.
+	tarballDocker = "docker"
+	// tarballOCI is an OCI image layout archived as a tarball, such as one
+	// written by "podman save --format oci-archive".
+	tarballOCI = "oci"
+)
+
+// findCertificatesInTarball loads the image from the uncompressed tarball at
+// the given path, detecting whether it was written by "docker save" or is an
+// archived OCI image layout, then scans it for X.509 certificates.
+func findCertificatesInTarball(ctx context.Context, tarball string, o *options, opts ...Option) (*certificate.ParsedCertificates, error) {
+	format, err := tarballFormat(tarball)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image: %w", err)
+	}
+
+	if format == tarballOCI {
+		dir, err := extractTarball(tarball)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load image: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		return FindCertificatesInOCILayout(ctx, dir, "", opts...)
+	}
+
+	img, err := crane.Load(tarball, o.craneOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image: %w", err)
+	}
+	return findCertificatesInImage(ctx, img, o)
+}
+
+// tarballFormat returns the format of the image tarball at the given path.
+// Tarballs written by recent versions of Docker are both, in which case the
+// Docker manifest is preferred. Tarballs of neither format are assumed to be
+// written by Docker, so that loading them reports what is missing.
+func tarballFormat(tarball string) (string, error) {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var ociLayout bool
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read image tarball: %w", err)
+		}
+
+		switch path.Clean(header.Name) {
+		case "manifest.json":
+			return tarballDocker, nil
+		case "oci-layout":
+			ociLayout = true
+		}
+	}
+
+	if ociLayout {
+		return tarballOCI, nil
+	}
+	return tarballDocker, nil
+}
+
+// extractTarball extracts the directories and regular files of the tarball
+// at the given path into a new temporary directory, and returns its name.
+// Entries which would be written outside of the directory are an error.
+func extractTarball(tarball string) (string, error) {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	dir, err := os.MkdirTemp(os.TempDir(), "paranoia-layout-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	if err := extractTar(tar.NewReader(f), dir); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+func extractTar(tr *tar.Reader, dir string) error {
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read image tarball: %w", err)
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path %q in image tarball", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			out, err := os.Create(target)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return fmt.Errorf("failed to extract %s from image tarball: %w", header.Name, err)
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
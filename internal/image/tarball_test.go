@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+)
+
+func TestFindImageCertificates_Stdin(t *testing.T) {
+	img := makeTestImage(t, map[string]string{"image.crt": "testdata/image"})
+	dir := t.TempDir()
+
+	dockerPath := filepath.Join(dir, "image.tar")
+	if err := crane.Save(img, "example.com/image:latest", dockerPath); err != nil {
+		t.Fatalf("unexpected error saving image: %s", err)
+	}
+	docker, err := os.ReadFile(dockerPath)
+	if err != nil {
+		t.Fatalf("unexpected error reading tarball: %s", err)
+	}
+
+	layoutDir := filepath.Join(dir, "layout")
+	p, err := layout.Write(layoutDir, empty.Index)
+	if err != nil {
+		t.Fatalf("unexpected error writing layout: %s", err)
+	}
+	if err := p.AppendImage(img); err != nil {
+		t.Fatalf("unexpected error appending image: %s", err)
+	}
+	oci := tarDir(t, layoutDir)
+
+	testCases := map[string][]byte{
+		"docker save output":            docker,
+		"gzip compressed docker output": gzipBytes(t, docker),
+		"OCI layout archive":            oci,
+		"gzip compressed OCI archive":   gzipBytes(t, oci),
+	}
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			gotCerts, err := findWithStdin(t, data)
+			if err != nil {
+				t.Fatalf("unexpected error finding certificates: %s", err)
+			}
+			if len(gotCerts) != 1 || gotCerts[0] != "/image.crt" {
+				t.Errorf("expected a certificate at /image.crt, got %v", gotCerts)
+			}
+		})
+	}
+
+	t.Run("an OCI layout archive should be read from a file", func(t *testing.T) {
+		path := filepath.Join(dir, "oci.tar")
+		if err := os.WriteFile(path, oci, 0o644); err != nil {
+			t.Fatalf("unexpected error writing tarball: %s", err)
+		}
+		gotCerts, err := FindImageCertificates(context.TODO(), "file://"+path)
+		if err != nil {
+			t.Fatalf("unexpected error finding certificates: %s", err)
+		}
+		if len(gotCerts.Found) != 1 || gotCerts.Found[0].Location != "/image.crt" {
+			t.Errorf("expected a certificate at /image.crt, got %+v", gotCerts.Found)
+		}
+	})
+
+	t.Run("input which isn't a tarball should return an error", func(t *testing.T) {
+		if _, err := findWithStdin(t, []byte("not an image")); err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+}
+
+func Test_extractTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0o644}); err != nil {
+		t.Fatalf("unexpected error writing tarball: %s", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unexpected error writing tarball: %s", err)
+	}
+
+	dir := t.TempDir()
+	if err := extractTar(tar.NewReader(&buf), filepath.Join(dir, "out")); err == nil {
+		t.Error("expected an error extracting a path outside the directory, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape")); err == nil {
+		t.Error("expected no file to be written outside the directory")
+	}
+}
+
+// findWithStdin pipes the data to standard input, as if written by another
+// command in a pipeline, and finds the certificates in the image read from
+// it. The locations of the certificates found are returned.
+func findWithStdin(t *testing.T, data []byte) ([]string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unexpected error creating pipe: %s", err)
+	}
+	defer r.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	go func() {
+		w.Write(data)
+		w.Close()
+	}()
+
+	parsed, err := FindImageCertificates(context.TODO(), "-")
+	if err != nil {
+		return nil, err
+	}
+	var locations []string
+	for _, f := range parsed.Found {
+		locations = append(locations, f.Location)
+	}
+	return locations, nil
+}
+
+// tarDir returns a tarball of the files in the directory, named relative to
+// it.
+func tarDir(t *testing.T, dir string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error archiving %s: %s", dir, err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unexpected error archiving %s: %s", dir, err)
+	}
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("unexpected error compressing: %s", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("unexpected error compressing: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFindImageCertificates_SkopeoArchive(t *testing.T) {
+	// testdata/skopeo-archive.tar is laid out as "skopeo copy" writes a
+	// docker-archive. Unlike "docker save", each layer is an uncompressed
+	// tarball named by its diff ID at the top of the archive, and the legacy
+	// per-layer directories link to them. Its lower layer has
+	// testdata/linux-arm64 at /etc/replaced.crt and testdata/image at
+	// /usr/lower.crt, and its upper layer replaces /etc/replaced.crt with
+	// testdata/linux-amd64. It can be regenerated from an image with those
+	// layers with:
+	//
+	//	skopeo copy docker://example.com/image:latest docker-archive:skopeo-archive.tar:example.com/image:latest
+	gotCerts, err := FindImageCertificates(context.TODO(), "file://testdata/skopeo-archive.tar")
+	if err != nil {
+		t.Fatalf("unexpected error finding certificates: %s", err)
+	}
+
+	want := map[string]string{
+		"/etc/replaced.crt": "sha256:fb9aae10f98f7f669b63c85cbbb3b08d1f1d419fca8b605413f42e5c3ecf5d74",
+		"/usr/lower.crt":    "sha256:d695662de6852e326a164bb124ccc762b21e84d62881215c04c359403d563586",
+	}
+	got := make(map[string]string)
+	for _, f := range gotCerts.Found {
+		got[f.Location] = f.LayerDigest
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected certificates, or layers they were attributed to:\n%s", diff)
+	}
+	for _, f := range gotCerts.Found {
+		if f.Location == "/etc/replaced.crt" && f.Certificate.Subject.String() != findSubject(t, "testdata/linux-amd64") {
+			t.Errorf("expected the replaced file to be the upper layer's, got %s", f.Certificate.Subject)
+		}
+	}
+}
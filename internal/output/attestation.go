@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+const (
+	// InTotoStatementType is the type of in-toto statements, which bind a
+	// predicate to the artifacts it is about.
+	InTotoStatementType = "https://in-toto.io/Statement/v1"
+
+	// AttestationPredicateType identifies the predicate describing the
+	// certificates found in an image. It only changes if the predicate
+	// changes incompatibly.
+	AttestationPredicateType = "https://github.com/jetstack/paranoia/attestation/certificates/v1"
+)
+
+// InTotoStatement is an in-toto statement about the certificates found in an
+// image, which may be signed, such as with "cosign attest".
+type InTotoStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []InTotoSubject      `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     AttestationPredicate `json:"predicate"`
+}
+
+type InTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// AttestationPredicate summarises the certificates found in an image. It
+// holds nothing which varies between scans of the same image, such as the
+// time of the scan, so that attestations are reproducible.
+type AttestationPredicate struct {
+	Scanner      AttestationScanner       `json:"scanner"`
+	Summary      AttestationSummary       `json:"summary"`
+	Certificates []AttestationCertificate `json:"certificates"`
+	Partials     []JSONPartialCertificate `json:"partials"`
+	Validation   *AttestationValidation   `json:"validation,omitempty"`
+}
+
+type AttestationScanner struct {
+	URI string `json:"uri"`
+}
+
+type AttestationSummary struct {
+	Certificates int `json:"certificates"`
+	Partials     int `json:"partials"`
+}
+
+type AttestationCertificate struct {
+	FileLocation      string `json:"fileLocation"`
+	Subject           string `json:"subject"`
+	NotAfter          string `json:"notAfter"`
+	FingerprintSHA256 string `json:"fingerprintSHA256"`
+	LayerDigest       string `json:"layerDigest,omitempty"`
+}
+
+// AttestationValidation is the result of validating the certificates against
+// a configuration.
+type AttestationValidation struct {
+	Pass bool `json:"pass"`
+	// Failures are the kinds of finding which fail validation, in order of
+	// decreasing severity.
+	Failures []string `json:"failures"`
+	// Findings are the number of findings of each kind, whether or not they
+	// fail validation.
+	Findings map[string]int `json:"findings"`
+}
+
+// NewAttestationValidation summarises a validation result for an attestation.
+func NewAttestationValidation(res validate.Result) *AttestationValidation {
+	v := &AttestationValidation{
+		Pass:     res.IsPass(),
+		Failures: append([]string{}, res.Failures()...),
+		Findings: map[string]int{},
+	}
+	for _, f := range res.ListFindings() {
+		v.Findings[f.Kind]++
+	}
+	return v
+}
+
+// NewAttestation returns an in-toto statement about the certificates found in
+// an image, whose subject is the image's manifest digest. The certificates
+// and partials are sorted, so that the statement is the same for every scan
+// of the image. An error is returned if the image's digest isn't known, such
+// as when a directory was scanned.
+func NewAttestation(image string, parsed *certificate.ParsedCertificates, validation *AttestationValidation) (InTotoStatement, error) {
+	algorithm, digest, ok := strings.Cut(parsed.ImageDigest, ":")
+	if !ok {
+		return InTotoStatement{}, errors.New("the image digest is unknown, so an attestation cannot refer to it")
+	}
+
+	predicate := AttestationPredicate{
+		Scanner: AttestationScanner{URI: "https://github.com/jetstack/paranoia"},
+		Summary: AttestationSummary{
+			Certificates: len(parsed.Found),
+			Partials:     len(parsed.Partials),
+		},
+		Certificates: []AttestationCertificate{},
+		Partials:     []JSONPartialCertificate{},
+		Validation:   validation,
+	}
+	for _, f := range parsed.Found {
+		predicate.Certificates = append(predicate.Certificates, AttestationCertificate{
+			FileLocation:      f.Location,
+			Subject:           f.Certificate.Subject.String(),
+			NotAfter:          f.Certificate.NotAfter.UTC().Format(time.RFC3339),
+			FingerprintSHA256: hex.EncodeToString(f.FingerprintSha256[:]),
+			LayerDigest:       f.LayerDigest,
+		})
+	}
+	sort.Slice(predicate.Certificates, func(i, j int) bool {
+		a, b := predicate.Certificates[i], predicate.Certificates[j]
+		if a.FileLocation != b.FileLocation {
+			return a.FileLocation < b.FileLocation
+		}
+		return a.FingerprintSHA256 < b.FingerprintSHA256
+	})
+	for _, p := range parsed.Partials {
+		predicate.Partials = append(predicate.Partials, JSONPartialCertificate{
+			FileLocation: p.Location,
+			Parser:       p.Parser,
+			Reason:       p.Reason,
+		})
+	}
+	sort.Slice(predicate.Partials, func(i, j int) bool {
+		a, b := predicate.Partials[i], predicate.Partials[j]
+		if a.FileLocation != b.FileLocation {
+			return a.FileLocation < b.FileLocation
+		}
+		if a.Parser != b.Parser {
+			return a.Parser < b.Parser
+		}
+		return a.Reason < b.Reason
+	})
+
+	return InTotoStatement{
+		Type:          InTotoStatementType,
+		Subject:       []InTotoSubject{{Name: image, Digest: map[string]string{algorithm: digest}}},
+		PredicateType: AttestationPredicateType,
+		Predicate:     predicate,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func TestNewAttestation(t *testing.T) {
+	found := func(location string, fingerprint byte) certificate.Found {
+		return certificate.Found{
+			Location: location,
+			Certificate: &x509.Certificate{
+				Subject:  pkix.Name{CommonName: "Example Root"},
+				NotAfter: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			FingerprintSha256: [32]byte{fingerprint},
+		}
+	}
+	parsed := &certificate.ParsedCertificates{
+		Found: []certificate.Found{
+			found("usr/share/ca.pem", 0xab),
+			found("etc/ssl/certs/ca.pem", 0xcd),
+			found("etc/ssl/certs/ca.pem", 0x01),
+		},
+		Partials: []certificate.Partial{
+			{Location: "opt/app.jar", Parser: "jks", Reason: "truncated"},
+			{Location: "etc/ssl/certs/bad.pem", Parser: "pem", Reason: "truncated"},
+		},
+		ImageDigest: "sha256:cd00",
+	}
+
+	validation := NewAttestationValidation(validate.Result{
+		NotAllowedCertificates: parsed.Found[:2],
+		ExpiringCertificates:   parsed.Found[:1],
+	})
+	statement, err := NewAttestation("example.com/image:v0.1.0", parsed, validation)
+	require.NoError(t, err)
+
+	m, err := json.Marshal(statement)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"_type": "https://in-toto.io/Statement/v1",
+		"subject": [{"name": "example.com/image:v0.1.0", "digest": {"sha256": "cd00"}}],
+		"predicateType": "https://github.com/jetstack/paranoia/attestation/certificates/v1",
+		"predicate": {
+			"scanner": {"uri": "https://github.com/jetstack/paranoia"},
+			"summary": {"certificates": 3, "partials": 2},
+			"certificates": [
+				{"fileLocation": "etc/ssl/certs/ca.pem", "subject": "CN=Example Root", "notAfter": "2030-01-01T00:00:00Z", "fingerprintSHA256": "0100000000000000000000000000000000000000000000000000000000000000"},
+				{"fileLocation": "etc/ssl/certs/ca.pem", "subject": "CN=Example Root", "notAfter": "2030-01-01T00:00:00Z", "fingerprintSHA256": "cd00000000000000000000000000000000000000000000000000000000000000"},
+				{"fileLocation": "usr/share/ca.pem", "subject": "CN=Example Root", "notAfter": "2030-01-01T00:00:00Z", "fingerprintSHA256": "ab00000000000000000000000000000000000000000000000000000000000000"}
+			],
+			"partials": [
+				{"fileLocation": "etc/ssl/certs/bad.pem", "parser": "pem", "reason": "truncated"},
+				{"fileLocation": "opt/app.jar", "parser": "jks", "reason": "truncated"}
+			],
+			"validation": {"pass": false, "failures": ["notAllowed"], "findings": {"notAllowed": 2}}
+		}
+	}`, string(m))
+
+	t.Run("Scans without an image digest cannot be attested", func(t *testing.T) {
+		_, err := NewAttestation("dir:///rootfs", &certificate.ParsedCertificates{}, nil)
+		assert.Error(t, err)
+	})
+}
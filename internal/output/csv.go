@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+var csvHeader = []string{
+	"location",
+	"parser",
+	"sha1",
+	"sha256",
+	"subjectCN",
+	"issuerCN",
+	"notBefore",
+	"notAfter",
+	"isCA",
+	"keyType",
+	"keySize",
+}
+
+// WriteCSV writes the found certificates as CSV, with a header row followed
+// by a row for each certificate. If includePartials is true, a row is also
+// written for each partial certificate, and a "reason" column is added
+// holding why each partial could not be parsed.
+func WriteCSV(w io.Writer, parsed *certificate.ParsedCertificates, includePartials bool) error {
+	cw := csv.NewWriter(w)
+
+	header := csvHeader
+	if includePartials {
+		header = append(append([]string{}, csvHeader...), "reason")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, f := range parsed.Found {
+		row := make([]string, len(header))
+		row[0] = f.Location
+		row[1] = f.Parser
+		row[2] = hex.EncodeToString(f.FingerprintSha1[:])
+		row[3] = hex.EncodeToString(f.FingerprintSha256[:])
+		if cert := f.Certificate; cert != nil {
+			row[4] = cert.Subject.CommonName
+			row[5] = cert.Issuer.CommonName
+			row[6] = cert.NotBefore.Format(time.RFC3339)
+			row[7] = cert.NotAfter.Format(time.RFC3339)
+			row[8] = strconv.FormatBool(cert.IsCA)
+		}
+		row[9] = f.KeyAlgorithm
+		if f.KeySizeBits > 0 {
+			row[10] = strconv.Itoa(f.KeySizeBits)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	if includePartials {
+		for _, p := range parsed.Partials {
+			row := make([]string, len(header))
+			row[0] = p.Location
+			row[1] = p.Parser
+			row[len(row)-1] = p.Reason
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
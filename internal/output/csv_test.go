@@ -0,0 +1,79 @@
+package output
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestWriteCSV(t *testing.T) {
+	parsed := &certificate.ParsedCertificates{
+		Found: []certificate.Found{{
+			Location: "/etc/ssl/certs/ca-certificates.crt",
+			Parser:   "pem",
+			Certificate: &x509.Certificate{
+				Subject:            pkix.Name{CommonName: `Example, "Quoted" Root`},
+				Issuer:             pkix.Name{CommonName: "Example Root"},
+				NotBefore:          time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				NotAfter:           time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+				IsCA:               true,
+				PublicKeyAlgorithm: x509.ECDSA,
+				PublicKey:          &ecdsa.PublicKey{Curve: elliptic.P384()},
+			},
+			FingerprintSha1:   [20]byte{0xab},
+			FingerprintSha256: [32]byte{0xcd},
+			KeyAlgorithm:      "ECDSA",
+			KeySizeBits:       384,
+		}},
+		Partials: []certificate.Partial{{
+			Location: "/etc/ssl/certs/broken.pem",
+			Parser:   "pem",
+			Reason:   "failed to parse PEM certificate: x509: malformed certificate",
+		}},
+	}
+
+	wantFound := []string{
+		"/etc/ssl/certs/ca-certificates.crt",
+		"pem",
+		"ab00000000000000000000000000000000000000",
+		"cd00000000000000000000000000000000000000000000000000000000000000",
+		`Example, "Quoted" Root`,
+		"Example Root",
+		"2020-01-01T00:00:00Z",
+		"2030-01-01T00:00:00Z",
+		"true",
+		"ECDSA",
+		"384",
+	}
+
+	t.Run("without partials", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteCSV(&buf, parsed, false))
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{csvHeader, wantFound}, records)
+	})
+
+	t.Run("with partials", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteCSV(&buf, parsed, true))
+
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, "reason", records[0][len(records[0])-1])
+		assert.Equal(t, append(wantFound, ""), records[1])
+		assert.Equal(t, []string{"/etc/ssl/certs/broken.pem", "pem", "", "", "", "", "", "", "", "", "", "failed to parse PEM certificate: x509: malformed certificate"}, records[2])
+	})
+}
@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+var keyUsageNames = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "Digital Signature"},
+	{x509.KeyUsageContentCommitment, "Content Commitment"},
+	{x509.KeyUsageKeyEncipherment, "Key Encipherment"},
+	{x509.KeyUsageDataEncipherment, "Data Encipherment"},
+	{x509.KeyUsageKeyAgreement, "Key Agreement"},
+	{x509.KeyUsageCertSign, "Certificate Sign"},
+	{x509.KeyUsageCRLSign, "CRL Sign"},
+	{x509.KeyUsageEncipherOnly, "Encipher Only"},
+	{x509.KeyUsageDecipherOnly, "Decipher Only"},
+}
+
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                            "Any",
+	x509.ExtKeyUsageServerAuth:                     "Server Authentication",
+	x509.ExtKeyUsageClientAuth:                     "Client Authentication",
+	x509.ExtKeyUsageCodeSigning:                    "Code Signing",
+	x509.ExtKeyUsageEmailProtection:                "Email Protection",
+	x509.ExtKeyUsageIPSECEndSystem:                 "IPSec End System",
+	x509.ExtKeyUsageIPSECTunnel:                    "IPSec Tunnel",
+	x509.ExtKeyUsageIPSECUser:                      "IPSec User",
+	x509.ExtKeyUsageTimeStamping:                   "Time Stamping",
+	x509.ExtKeyUsageOCSPSigning:                    "OCSP Signing",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     "Microsoft Server Gated Crypto",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      "Netscape Server Gated Crypto",
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: "Microsoft Commercial Code Signing",
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     "Microsoft Kernel Code Signing",
+}
+
+// WriteDetails writes a human-readable description of every detail of a
+// single certificate. The given certificates must all be copies of the same
+// certificate, found in different locations, and at least one must be given.
+func WriteDetails(w io.Writer, founds []certificate.Found) error {
+	f := founds[0]
+	cert := f.Certificate
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(name, value string) {
+		fmt.Fprintf(tw, "%s:\t%s\n", name, value)
+	}
+
+	for _, l := range founds {
+		location := l.Location
+		if l.LayerDigest != "" {
+			location = fmt.Sprintf("%s (added by layer %s)", l.Location, l.LayerDigest)
+		}
+		row("Location", location)
+		if len(l.TrustedPurposes) > 0 {
+			row("Trusted For", strings.Join(l.TrustedPurposes, ", "))
+		}
+		if len(l.RejectedPurposes) > 0 {
+			row("Rejected For", strings.Join(l.RejectedPurposes, ", "))
+		}
+		if !l.ServerDistrustAfter.IsZero() {
+			row("Server Distrust After", l.ServerDistrustAfter.Format(time.RFC3339))
+		}
+	}
+	row("Subject", cert.Subject.String())
+	row("Issuer", cert.Issuer.String())
+	row("Serial Number", formatSerial(cert.SerialNumber))
+	row("Not Before", cert.NotBefore.Format(time.RFC3339))
+	row("Not After", cert.NotAfter.Format(time.RFC3339))
+	row("Subject Alternative Names", orNone(subjectAltNames(cert)))
+	row("Key Usage", orNone(keyUsages(cert.KeyUsage)))
+	row("Extended Key Usage", orNone(extKeyUsages(cert)))
+	row("Basic Constraints", basicConstraints(cert))
+	row("Self-Signed", strconv.FormatBool(f.SelfSigned))
+	row("Public Key", publicKey(f))
+	row("Signature Algorithm", cert.SignatureAlgorithm.String())
+	row("SHA1 Fingerprint", hex.EncodeToString(f.FingerprintSha1[:]))
+	row("SHA256 Fingerprint", hex.EncodeToString(f.FingerprintSha256[:]))
+
+	return tw.Flush()
+}
+
+func formatSerial(serial *big.Int) string {
+	if serial == nil {
+		return "none"
+	}
+	b := serial.Bytes()
+	if len(b) == 0 {
+		return "00"
+	}
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02x", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+func subjectAltNames(cert *x509.Certificate) []string {
+	var names []string
+	for _, n := range cert.DNSNames {
+		names = append(names, "DNS:"+n)
+	}
+	for _, ip := range cert.IPAddresses {
+		names = append(names, "IP:"+ip.String())
+	}
+	for _, e := range cert.EmailAddresses {
+		names = append(names, "email:"+e)
+	}
+	for _, u := range cert.URIs {
+		names = append(names, "URI:"+u.String())
+	}
+	return names
+}
+
+func keyUsages(usage x509.KeyUsage) []string {
+	var names []string
+	for _, u := range keyUsageNames {
+		if usage&u.usage != 0 {
+			names = append(names, u.name)
+		}
+	}
+	return names
+}
+
+func extKeyUsages(cert *x509.Certificate) []string {
+	var names []string
+	for _, u := range cert.ExtKeyUsage {
+		if name, ok := extKeyUsageNames[u]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("Unknown (%d)", u))
+		}
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		names = append(names, oid.String())
+	}
+	return names
+}
+
+func basicConstraints(cert *x509.Certificate) string {
+	if !cert.BasicConstraintsValid {
+		return "none"
+	}
+	if !cert.IsCA {
+		return "CA: false"
+	}
+	if cert.MaxPathLen > 0 || cert.MaxPathLenZero {
+		return fmt.Sprintf("CA: true, max path length: %d", cert.MaxPathLen)
+	}
+	return "CA: true"
+}
+
+func publicKey(f certificate.Found) string {
+	if f.KeySizeBits > 0 {
+		return fmt.Sprintf("%s (%d bits)", f.KeyAlgorithm, f.KeySizeBits)
+	}
+	return f.KeyAlgorithm
+}
+
+func orNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
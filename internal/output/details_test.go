@@ -0,0 +1,94 @@
+package output
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestWriteDetails(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(0x0102ab),
+		Subject:               pkix.Name{CommonName: "Example Root", Organization: []string{"Example"}},
+		NotBefore:             time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		DNSNames:              []string{"example.com"},
+		IPAddresses:           []net.IP{net.ParseIP("192.0.2.1")},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            1,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	founds := []certificate.Found{
+		{
+			Location:          "/etc/ssl/certs/ca-certificates.crt",
+			Certificate:       cert,
+			FingerprintSha1:   sha1.Sum(der),
+			FingerprintSha256: sha256.Sum256(der),
+			KeyAlgorithm:      "ECDSA",
+			KeySizeBits:       256,
+			SelfSigned:        true,
+			IsCA:              true,
+			LayerDigest:       "sha256:abc",
+		},
+		{
+			Location:          "/usr/share/ca-certificates/example.crt",
+			Certificate:       cert,
+			FingerprintSha1:   sha1.Sum(der),
+			FingerprintSha256: sha256.Sum256(der),
+			KeyAlgorithm:      "ECDSA",
+			KeySizeBits:       256,
+			SelfSigned:        true,
+			IsCA:              true,
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDetails(&buf, founds))
+
+	sha1Sum, sha256Sum := sha1.Sum(der), sha256.Sum256(der)
+	for _, line := range []string{
+		"Location:                   /etc/ssl/certs/ca-certificates.crt (added by layer sha256:abc)\n",
+		"Location:                   /usr/share/ca-certificates/example.crt\n",
+		"Subject:                    CN=Example Root,O=Example\n",
+		"Issuer:                     CN=Example Root,O=Example\n",
+		"Serial Number:              01:02:ab\n",
+		"Not Before:                 2020-01-01T00:00:00Z\n",
+		"Not After:                  2030-01-01T00:00:00Z\n",
+		"Subject Alternative Names:  DNS:example.com, IP:192.0.2.1\n",
+		"Key Usage:                  Certificate Sign, CRL Sign\n",
+		"Extended Key Usage:         Server Authentication\n",
+		"Basic Constraints:          CA: true, max path length: 1\n",
+		"Self-Signed:                true\n",
+		"Public Key:                 ECDSA (256 bits)\n",
+		"Signature Algorithm:        ECDSA-SHA256\n",
+		"SHA1 Fingerprint:           " + hex.EncodeToString(sha1Sum[:]) + "\n",
+		"SHA256 Fingerprint:         " + hex.EncodeToString(sha256Sum[:]) + "\n",
+	} {
+		assert.Contains(t, buf.String(), line)
+	}
+}
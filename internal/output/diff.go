@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import "github.com/jetstack/paranoia/internal/certificate"
+
+type JSONDiffOutput struct {
+	First        string                    `json:"first"`
+	Second       string                    `json:"second"`
+	OnlyInFirst  []JSONValidateCertificate `json:"onlyInFirst"`
+	OnlyInSecond []JSONValidateCertificate `json:"onlyInSecond"`
+	Common       []JSONValidateCertificate `json:"common"`
+}
+
+// NewJSONDiffOutput converts the difference between the certificates found in
+// two images into its JSON output form. Every list is present in the output,
+// even when empty.
+func NewJSONDiffOutput(first, second string, diff certificate.Diff) JSONDiffOutput {
+	return JSONDiffOutput{
+		First:        first,
+		Second:       second,
+		OnlyInFirst:  jsonValidateCertificates(diff.OnlyFirst),
+		OnlyInSecond: jsonValidateCertificates(diff.OnlySecond),
+		Common:       jsonValidateCertificates(diff.Common),
+	}
+}
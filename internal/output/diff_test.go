@@ -0,0 +1,37 @@
+package output
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestNewJSONDiffOutput(t *testing.T) {
+	found := certificate.Found{
+		Location:          "/etc/ssl/certs/ca-certificates.crt",
+		Parser:            "pem",
+		Certificate:       &x509.Certificate{Subject: pkix.Name{CommonName: "Example Root"}},
+		FingerprintSha256: [32]byte{0xcd},
+	}
+
+	out := NewJSONDiffOutput("alpine:3.16", "alpine:3.17", certificate.Diff{OnlySecond: []certificate.Found{found}})
+	assert.Equal(t, "alpine:3.16", out.First)
+	assert.Equal(t, "alpine:3.17", out.Second)
+	require.Len(t, out.OnlyInSecond, 1)
+	assert.Equal(t, "CN=Example Root", out.OnlyInSecond[0].Subject)
+
+	b, err := json.Marshal(out)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &raw))
+	for _, key := range []string{"onlyInFirst", "onlyInSecond", "common"} {
+		assert.NotNilf(t, raw[key], "expected key %q to be present", key)
+	}
+}
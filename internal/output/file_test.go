@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Run("the file is written with the given permissions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bundle.pem")
+		require.NoError(t, WriteFileAtomic(path, 0644, func(w io.Writer) error {
+			_, err := io.WriteString(w, "bundle\n")
+			return err
+		}))
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "bundle\n", string(b))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	})
+
+	t.Run("an existing file is unchanged if writing fails", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "bundle.pem")
+		require.NoError(t, os.WriteFile(path, []byte("old\n"), 0644))
+
+		err := WriteFileAtomic(path, 0644, func(w io.Writer) error {
+			if _, err := io.WriteString(w, "partial"); err != nil {
+				return err
+			}
+			return errors.New("failed to write")
+		})
+		assert.EqualError(t, err, "failed to write")
+
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "old\n", string(b))
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "expected the temporary file to be removed")
+	})
+}
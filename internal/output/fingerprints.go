@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// The digests which fingerprints may be written with.
+const (
+	DigestSHA1   = "sha1"
+	DigestSHA256 = "sha256"
+	DigestSHA512 = "sha512"
+)
+
+// Digests are the digests which fingerprints may be written with.
+var Digests = []string{DigestSHA1, DigestSHA256, DigestSHA512}
+
+// WriteFingerprints writes the lower case hex encoded fingerprint of each found
+// certificate, using the given digest, one per line and with nothing else.
+func WriteFingerprints(w io.Writer, founds []certificate.Found, digest string) error {
+	for _, f := range founds {
+		var fingerprint []byte
+		switch digest {
+		case DigestSHA1:
+			fingerprint = f.FingerprintSha1[:]
+		case DigestSHA256:
+			fingerprint = f.FingerprintSha256[:]
+		case DigestSHA512:
+			fingerprint = f.FingerprintSha512[:]
+		default:
+			return fmt.Errorf("unsupported digest %q", digest)
+		}
+		if _, err := fmt.Fprintf(w, "%x\n", fingerprint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
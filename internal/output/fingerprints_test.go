@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestWriteFingerprints(t *testing.T) {
+	founds := []certificate.Found{
+		{FingerprintSha1: [20]byte{0xab}, FingerprintSha256: [32]byte{0xcd}, FingerprintSha512: [64]byte{0xef}},
+		{FingerprintSha1: [20]byte{0x01}, FingerprintSha256: [32]byte{0x02}, FingerprintSha512: [64]byte{0x03}},
+	}
+
+	for digest, want := range map[string]string{
+		DigestSHA1:   "ab" + strings.Repeat("0", 38) + "\n01" + strings.Repeat("0", 38) + "\n",
+		DigestSHA256: "cd" + strings.Repeat("0", 62) + "\n02" + strings.Repeat("0", 62) + "\n",
+		DigestSHA512: "ef" + strings.Repeat("0", 126) + "\n03" + strings.Repeat("0", 126) + "\n",
+	} {
+		t.Run(digest, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteFingerprints(&buf, founds, digest))
+			assert.Equal(t, want, buf.String())
+		})
+	}
+
+	assert.ErrorContains(t, WriteFingerprints(&bytes.Buffer{}, founds, "md5"), `unsupported digest "md5"`)
+}
@@ -5,17 +5,32 @@ package output
 type JSONOutput struct {
 	Certificates        []JSONCertificate        `json:"certificates"`
 	PartialCertificates []JSONPartialCertificate `json:"partials,omitempty"`
+	Distrusted          []JSONDistrusted         `json:"distrusted,omitempty"`
 }
 
 type JSONCertificate struct {
-	FileLocation      string `json:"fileLocation"`
-	Owner             string `json:"owner"`
-	Parser            string `json:"parser"`
-	Signature         string `json:"signature"`
-	NotBefore         string `json:"notBefore"`
-	NotAfter          string `json:"notAfter"`
-	FingerprintSHA1   string `json:"fingerprintSHA1"`
-	FingerprintSHA256 string `json:"fingerprintSHA256"`
+	FileLocation      string   `json:"fileLocation"`
+	Owner             string   `json:"owner"`
+	Parser            string   `json:"parser"`
+	Encoding          string   `json:"encoding"`
+	ContainerFormat   string   `json:"containerFormat"`
+	Signature         string   `json:"signature"`
+	NotBefore         string   `json:"notBefore"`
+	NotAfter          string   `json:"notAfter"`
+	FingerprintSHA1   string   `json:"fingerprintSHA1"`
+	FingerprintSHA256 string   `json:"fingerprintSHA256"`
+	FingerprintSHA512 string   `json:"fingerprintSHA512"`
+	SpkiSHA256        string   `json:"spkiSHA256"`
+	KeyAlgorithm      string   `json:"keyAlgorithm"`
+	KeySizeBits       int      `json:"keySizeBits,omitempty"`
+	SelfSigned        bool     `json:"selfSigned"`
+	IsCA              bool     `json:"isCA"`
+	LayerDigest       string   `json:"layerDigest,omitempty"`
+	TrustedPurposes   []string `json:"trustedPurposes,omitempty"`
+	RejectedPurposes  []string `json:"rejectedPurposes,omitempty"`
+	// ServerDistrustAfter is the time after which certificates issued by the
+	// certificate are distrusted for TLS servers, if the store sets one.
+	ServerDistrustAfter string `json:"serverDistrustAfter,omitempty"`
 }
 
 type JSONPartialCertificate struct {
@@ -23,3 +38,10 @@ type JSONPartialCertificate struct {
 	Reason       string `json:"reason"`
 	Parser       string `json:"parser"`
 }
+
+type JSONDistrusted struct {
+	FileLocation     string   `json:"fileLocation"`
+	Parser           string   `json:"parser"`
+	FingerprintSHA1  string   `json:"fingerprintSHA1,omitempty"`
+	RejectedPurposes []string `json:"rejectedPurposes"`
+}
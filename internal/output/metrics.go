@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+// metricLabelEscaper escapes label values as required by the Prometheus text
+// format.
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteMetrics writes gauges describing the result of validating the
+// certificates found in an image, in the Prometheus text format. This is
+// suitable for the node_exporter textfile collector, or for pushing to a
+// Pushgateway.
+func WriteMetrics(w io.Writer, image string, scanned int, res validate.Result, duration time.Duration) error {
+	labels := fmt.Sprintf(`{image="%s"}`, metricLabelEscaper.Replace(image))
+
+	pass := 0
+	if res.IsPass() {
+		pass = 1
+	}
+
+	for _, m := range []struct {
+		name  string
+		help  string
+		value interface{}
+	}{
+		{"paranoia_certificates_found_total", "Number of certificates found in the image.", scanned},
+		{"paranoia_forbidden_total", "Number of forbidden certificates found in the image.", len(res.ForbiddenCertificates)},
+		{"paranoia_required_absent_total", "Number of required certificates absent from the image.", len(res.RequiredButAbsent)},
+		{"paranoia_not_allowed_total", "Number of certificates found in the image which are not allowed.", len(res.NotAllowedCertificates)},
+		{"paranoia_expired_total", "Number of expired certificates found in the image.", len(res.ExpiredCertificates)},
+		{"paranoia_validation_pass", "Whether the image passed validation, 1 if it did and 0 if not.", pass},
+		{"paranoia_scan_duration_seconds", "Time taken to find and validate the certificates in the image.", duration.Seconds()},
+	} {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s%s %v\n", m.name, m.help, m.name, m.name, labels, m.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
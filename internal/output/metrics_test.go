@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	res := validate.Result{
+		ForbiddenCertificates: []validate.ForbiddenCert{{}},
+		RequiredButAbsent:     []validate.CertificateEntry{{}, {}},
+		ExpiredCertificates:   []certificate.Found{{}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMetrics(&buf, `example.com/"image":v1`, 12, res, 1500*time.Millisecond))
+
+	assert.Equal(t, `# HELP paranoia_certificates_found_total Number of certificates found in the image.
+# TYPE paranoia_certificates_found_total gauge
+paranoia_certificates_found_total{image="example.com/\"image\":v1"} 12
+# HELP paranoia_forbidden_total Number of forbidden certificates found in the image.
+# TYPE paranoia_forbidden_total gauge
+paranoia_forbidden_total{image="example.com/\"image\":v1"} 1
+# HELP paranoia_required_absent_total Number of required certificates absent from the image.
+# TYPE paranoia_required_absent_total gauge
+paranoia_required_absent_total{image="example.com/\"image\":v1"} 2
+# HELP paranoia_not_allowed_total Number of certificates found in the image which are not allowed.
+# TYPE paranoia_not_allowed_total gauge
+paranoia_not_allowed_total{image="example.com/\"image\":v1"} 0
+# HELP paranoia_expired_total Number of expired certificates found in the image.
+# TYPE paranoia_expired_total gauge
+paranoia_expired_total{image="example.com/\"image\":v1"} 1
+# HELP paranoia_validation_pass Whether the image passed validation, 1 if it did and 0 if not.
+# TYPE paranoia_validation_pass gauge
+paranoia_validation_pass{image="example.com/\"image\":v1"} 0
+# HELP paranoia_scan_duration_seconds Time taken to find and validate the certificates in the image.
+# TYPE paranoia_scan_duration_seconds gauge
+paranoia_scan_duration_seconds{image="example.com/\"image\":v1"} 1.5
+`, buf.String())
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// WritePEMBundle writes the found certificates as a single concatenated PEM
+// bundle, such as for a trust store of another tool. Each certificate is
+// written once, at its first location, preceded by a comment line with its
+// subject and every location it was found in, as PEM decoders ignore text
+// between blocks. Certificates which couldn't be decoded are omitted.
+func WritePEMBundle(w io.Writer, founds []certificate.Found) error {
+	var (
+		order     [][32]byte
+		certs     = make(map[[32]byte]certificate.Found)
+		locations = make(map[[32]byte][]string)
+	)
+	for _, f := range founds {
+		if f.Certificate == nil {
+			continue
+		}
+		if _, ok := certs[f.FingerprintSha256]; !ok {
+			order = append(order, f.FingerprintSha256)
+			certs[f.FingerprintSha256] = f
+		}
+		locations[f.FingerprintSha256] = append(locations[f.FingerprintSha256], f.Location)
+	}
+
+	for _, fp := range order {
+		f := certs[fp]
+		if _, err := fmt.Fprintf(w, "# %s found at %s\n", f.Certificate.Subject, strings.Join(locations[fp], ", ")); err != nil {
+			return err
+		}
+		if err := pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: f.Certificate.Raw}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
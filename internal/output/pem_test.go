@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestWritePEMBundle(t *testing.T) {
+	root := &x509.Certificate{Raw: []byte("root"), Subject: pkix.Name{CommonName: "Example Root"}}
+	other := &x509.Certificate{Raw: []byte("other"), Subject: pkix.Name{CommonName: "Other Root"}}
+	founds := []certificate.Found{
+		{Location: "/usr/share/ca-certificates/root.crt", Certificate: root, FingerprintSha256: [32]byte{0x01}},
+		{Location: "/etc/ssl/certs/other.pem", Certificate: other, FingerprintSha256: [32]byte{0x02}},
+		{Location: "/etc/ssl/certs/a1b2c3d4.0", Certificate: root, FingerprintSha256: [32]byte{0x01}},
+		{Location: "/etc/ssl/certs/broken.pem", FingerprintSha256: [32]byte{0x03}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePEMBundle(&buf, founds))
+
+	var blocks [][]byte
+	rest := buf.Bytes()
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		assert.Equal(t, "CERTIFICATE", block.Type)
+		blocks = append(blocks, block.Bytes)
+	}
+	assert.Equal(t, [][]byte{[]byte("root"), []byte("other")}, blocks, "expected each certificate once, in the order found")
+
+	assert.Contains(t, buf.String(), "# CN=Example Root found at /usr/share/ca-certificates/root.crt, /etc/ssl/certs/a1b2c3d4.0\n-----BEGIN CERTIFICATE-----")
+	assert.Contains(t, buf.String(), "# CN=Other Root found at /etc/ssl/certs/other.pem\n-----BEGIN CERTIFICATE-----")
+}
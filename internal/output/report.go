@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// JSONReport is the validation result of a scan, along with metadata about
+// the scan, as posted to a central service collecting the results of many
+// scanners.
+type JSONReport struct {
+	Image string `json:"image"`
+	// ImageDigest is the digest of the image's manifest, or empty if what
+	// was scanned wasn't an image, such as a directory.
+	ImageDigest     string             `json:"imageDigest,omitempty"`
+	StartedAt       string             `json:"startedAt"`
+	DurationSeconds float64            `json:"durationSeconds"`
+	FilesScanned    int                `json:"filesScanned"`
+	BytesScanned    int64              `json:"bytesScanned"`
+	Result          JSONValidateOutput `json:"result"`
+}
+
+// NewJSONReport returns a report of the validation result of a scan, which
+// started at the given time and took the given duration.
+func NewJSONReport(image string, parsed *certificate.ParsedCertificates, result JSONValidateOutput, started time.Time, duration time.Duration) JSONReport {
+	return JSONReport{
+		Image:           image,
+		ImageDigest:     parsed.ImageDigest,
+		StartedAt:       started.UTC().Format(time.RFC3339),
+		DurationSeconds: duration.Seconds(),
+		FilesScanned:    parsed.Stats.Files,
+		BytesScanned:    parsed.Stats.Bytes,
+		Result:          result,
+	}
+}
+
+// PostReport posts the report as JSON to the HTTP or HTTPS URL, which must
+// respond with a 2xx status within the timeout. If the token is not empty, it
+// is sent as a bearer token.
+func PostReport(ctx context.Context, url, token string, timeout time.Duration, report JSONReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshall report: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting report returned %q, expected a 2xx status", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func TestPostReport(t *testing.T) {
+	parsed := &certificate.ParsedCertificates{
+		ImageDigest: "sha256:abcd",
+		Stats:       certificate.ScanStats{Files: 12, Bytes: 3456},
+	}
+	started := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	report := NewJSONReport("example.com/image:v1", parsed, NewJSONValidateOutput("example.com/image:v1", 3, validate.Result{}), started, 1500*time.Millisecond)
+
+	t.Run("the report should be posted as JSON with the bearer token", func(t *testing.T) {
+		var (
+			got           map[string]interface{}
+			contentType   string
+			authorization string
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			contentType = r.Header.Get("Content-Type")
+			authorization = r.Header.Get("Authorization")
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		require.NoError(t, PostReport(context.TODO(), server.URL, "secret", time.Second, report))
+		assert.Equal(t, "application/json", contentType)
+		assert.Equal(t, "Bearer secret", authorization)
+		assert.Equal(t, "example.com/image:v1", got["image"])
+		assert.Equal(t, "sha256:abcd", got["imageDigest"])
+		assert.Equal(t, "2025-07-01T12:00:00Z", got["startedAt"])
+		assert.Equal(t, 1.5, got["durationSeconds"])
+		assert.Equal(t, float64(12), got["filesScanned"])
+		assert.Equal(t, true, got["result"].(map[string]interface{})["pass"])
+	})
+
+	t.Run("no authorization should be sent without a token", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Authorization"))
+		}))
+		defer server.Close()
+
+		require.NoError(t, PostReport(context.TODO(), server.URL, "", time.Second, report))
+	})
+
+	t.Run("unsuccessful responses should be errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		assert.ErrorContains(t, PostReport(context.TODO(), server.URL, "", time.Second, report), `returned "401 Unauthorized"`)
+	})
+
+	t.Run("slow responses should time out", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		assert.ErrorIs(t, PostReport(context.TODO(), server.URL, "", 50*time.Millisecond, report), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,346 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/util/checksum"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	// sarifFingerprintKey is the partial fingerprint under which the SHA256
+	// fingerprint of a certificate is recorded, used by consumers to
+	// deduplicate results across runs.
+	sarifFingerprintKey = "certificateSha256/v1"
+)
+
+// SARIF rule IDs, one for each kind of validation issue.
+const (
+	SARIFRuleForbidden                  = "paranoia/forbidden-certificate"
+	SARIFRuleRevoked                    = "paranoia/revoked-certificate"
+	SARIFRuleOCSPUnknown                = "paranoia/ocsp-status-unknown"
+	SARIFRuleNotAllowed                 = "paranoia/not-allowed-certificate"
+	SARIFRuleRequired                   = "paranoia/required-certificate-absent"
+	SARIFRuleRequiredGroup              = "paranoia/required-certificate-group-absent"
+	SARIFRuleExpired                    = "paranoia/expired-certificate"
+	SARIFRuleExpiring                   = "paranoia/expiring-certificate"
+	SARIFRuleNotYetValid                = "paranoia/not-yet-valid-certificate"
+	SARIFRuleOverlongValidity           = "paranoia/overlong-validity"
+	SARIFRuleWeakSignature              = "paranoia/weak-signature-algorithm"
+	SARIFRuleWeakKey                    = "paranoia/weak-key"
+	SARIFRuleUnhandledCriticalExtension = "paranoia/unhandled-critical-extension"
+	SARIFRuleInvalidAnchor              = "paranoia/invalid-trust-anchor"
+	SARIFRuleUnverifiableChain          = "paranoia/unverifiable-chain"
+	SARIFRuleSuspiciousSAN              = "paranoia/suspicious-san"
+	SARIFRuleKeyIdAnomaly               = "paranoia/key-id-anomaly"
+	SARIFRulePartial                    = "paranoia/partial-certificate"
+	SARIFRuleExpiredEntry               = "paranoia/expired-config-entry"
+)
+
+var sarifRules = []SARIFRule{
+	{ID: SARIFRuleForbidden, ShortDescription: SARIFMessage{Text: "A forbidden certificate was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleRevoked, ShortDescription: SARIFMessage{Text: "A certificate revoked by its issuer was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleOCSPUnknown, ShortDescription: SARIFMessage{Text: "The revocation status of a certificate found in the image could not be determined using OCSP."}, DefaultConfiguration: SARIFConfiguration{Level: "warning"}},
+	{ID: SARIFRuleNotAllowed, ShortDescription: SARIFMessage{Text: "A certificate which was not allowed was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleRequired, ShortDescription: SARIFMessage{Text: "A required certificate was not found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleRequiredGroup, ShortDescription: SARIFMessage{Text: "None of a group of certificates, of which at least one is required, was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleExpired, ShortDescription: SARIFMessage{Text: "An expired certificate was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleExpiring, ShortDescription: SARIFMessage{Text: "A certificate which expires soon was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "warning"}},
+	{ID: SARIFRuleNotYetValid, ShortDescription: SARIFMessage{Text: "A certificate which is not yet valid was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleOverlongValidity, ShortDescription: SARIFMessage{Text: "A certificate valid for longer than the maximum validity period was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleWeakSignature, ShortDescription: SARIFMessage{Text: "A certificate signed with a weak signature algorithm was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleWeakKey, ShortDescription: SARIFMessage{Text: "A certificate with a weak public key was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleUnhandledCriticalExtension, ShortDescription: SARIFMessage{Text: "A certificate with a critical extension which is not understood was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleInvalidAnchor, ShortDescription: SARIFMessage{Text: "A certificate which is not a valid certificate authority was found in a trust store of the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleUnverifiableChain, ShortDescription: SARIFMessage{Text: "A certificate for which no valid chain to a trusted root could be built was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleSuspiciousSAN, ShortDescription: SARIFMessage{Text: "A certificate for names or addresses only meaningful on a private network was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "warning"}},
+	{ID: SARIFRuleKeyIdAnomaly, ShortDescription: SARIFMessage{Text: "A certificate whose subject key identifier is missing, or shared with a different key, was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "warning"}},
+	{ID: SARIFRulePartial, ShortDescription: SARIFMessage{Text: "Data which appears to be a certificate, but is incomplete or invalid, was found in the image."}, DefaultConfiguration: SARIFConfiguration{Level: "error"}},
+	{ID: SARIFRuleExpiredEntry, ShortDescription: SARIFMessage{Text: "An allow or forbid entry of the configuration has expired, so was ignored."}, DefaultConfiguration: SARIFConfiguration{Level: "warning"}},
+}
+
+type SARIFReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     SARIFMessage       `json:"shortDescription"`
+	DefaultConfiguration SARIFConfiguration `json:"defaultConfiguration"`
+}
+
+type SARIFConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             SARIFMessage      `json:"message"`
+	Locations           []SARIFLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// NewSARIFReport converts the result of validation into a SARIF 2.1.0 report,
+// with a result for each issue. Results about a found certificate are located
+// at the certificate's file location in the image. Required certificates
+// which are absent have no such location, so are instead located at the
+// configuration file which requires them, or the given config path if that
+// isn't known.
+func NewSARIFReport(configPath string, res validate.Result) SARIFReport {
+	var results []SARIFResult
+
+	for _, f := range res.ForbiddenCertificates {
+		msg := fmt.Sprintf("Certificate %q with SHA256 fingerprint %X was forbidden.",
+			f.Certificate.Certificate.Subject.String(), f.Certificate.FingerprintSha256)
+		if f.Entry.Comment != "" {
+			msg += " Comment: " + f.Entry.Comment
+		}
+		results = append(results, sarifCertificateResult(SARIFRuleForbidden, sarifLevel(res, validate.FindingForbidden), msg, f.Certificate))
+	}
+	for _, r := range res.RevokedCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleRevoked, sarifLevel(res, validate.FindingRevoked),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X was revoked on %s, according to CRL %s.", r.Certificate.Certificate.Subject.String(), r.Certificate.FingerprintSha256, r.RevokedAt.Format(time.RFC3339), r.Source), r.Certificate))
+	}
+	for _, u := range res.OCSPUnknownCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleOCSPUnknown, "warning",
+			fmt.Sprintf("The revocation status of certificate %q with SHA256 fingerprint %X is unknown, as %s.", u.Certificate.Certificate.Subject.String(), u.Certificate.FingerprintSha256, u.Reason), u.Certificate))
+	}
+	for _, na := range res.NotAllowedCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleNotAllowed, sarifLevel(res, validate.FindingNotAllowed),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X was not allowed.", na.Certificate.Subject.String(), na.FingerprintSha256), na))
+	}
+	for _, req := range res.RequiredButAbsent {
+		fingerprint := sarifEntryFingerprint(req)
+		msg := fmt.Sprintf("Certificate with %s was required, but was not found.", fingerprint)
+		if req.Comment != "" {
+			msg += " Comment: " + req.Comment
+		}
+		result := SARIFResult{
+			RuleID:    SARIFRuleRequired,
+			Level:     sarifLevel(res, validate.FindingRequired),
+			Message:   SARIFMessage{Text: msg},
+			Locations: sarifLocations(configPath),
+		}
+		if req.Source != "" {
+			result.Locations = sarifLocations(req.Source)
+		}
+		for _, f := range req.Fingerprints {
+			if f.Sha256 != "" {
+				result.PartialFingerprints = map[string]string{sarifFingerprintKey: checksum.Normalize(f.Sha256)}
+				break
+			}
+		}
+		results = append(results, result)
+	}
+	for _, group := range res.RequiredGroupsUnsatisfied {
+		fingerprints := make([]string, len(group))
+		for i, req := range group {
+			fingerprints[i] = sarifEntryFingerprint(req)
+		}
+		result := SARIFResult{
+			RuleID:    SARIFRuleRequiredGroup,
+			Level:     sarifLevel(res, validate.FindingRequiredAnyOf),
+			Message:   SARIFMessage{Text: fmt.Sprintf("At least one of the certificates with %s was required, but none were found.", strings.Join(fingerprints, ", "))},
+			Locations: sarifLocations(configPath),
+		}
+		if len(group) > 0 && group[0].Source != "" {
+			result.Locations = sarifLocations(group[0].Source)
+		}
+		results = append(results, result)
+	}
+	for _, e := range res.ExpiredCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleExpired, sarifLevel(res, validate.FindingExpired),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X expired on %s.", e.Certificate.Subject.String(), e.FingerprintSha256, e.Certificate.NotAfter.Format(time.RFC3339)), e))
+	}
+	for _, e := range res.ExpiringCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleExpiring, "warning",
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X expires soon, on %s.", e.Certificate.Subject.String(), e.FingerprintSha256, e.Certificate.NotAfter.Format(time.RFC3339)), e))
+	}
+	for _, n := range res.NotYetValidCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleNotYetValid, sarifLevel(res, validate.FindingNotYetValid),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X is not valid until %s.", n.Certificate.Subject.String(), n.FingerprintSha256, n.Certificate.NotBefore.Format(time.RFC3339)), n))
+	}
+	for _, o := range res.OverlongValidityCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleOverlongValidity, sarifLevel(res, validate.FindingOverlongValidity),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X is valid for %d days, from %s to %s.", o.Certificate.Subject.String(), o.FingerprintSha256, validate.ValidityDays(o.Certificate), o.Certificate.NotBefore.Format(time.RFC3339), o.Certificate.NotAfter.Format(time.RFC3339)), o))
+	}
+	for _, w := range res.WeakSignatureCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleWeakSignature, sarifLevel(res, validate.FindingWeakSignature),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X is signed with weak signature algorithm %s.", w.Certificate.Subject.String(), w.FingerprintSha256, w.Certificate.SignatureAlgorithm), w))
+	}
+	for _, w := range res.WeakKeyCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleWeakKey, sarifLevel(res, validate.FindingWeakKey),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X has a weak %s public key.", w.Certificate.Subject.String(), w.FingerprintSha256, w.Certificate.PublicKeyAlgorithm), w))
+	}
+	for _, u := range res.UnhandledCriticalExtensionCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleUnhandledCriticalExtension, sarifLevel(res, validate.FindingUnhandledCriticalExtension),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X has unhandled critical extensions %s.", u.Certificate.Subject.String(), u.FingerprintSha256, strings.Join(validate.UnhandledCriticalExtensions(u.Certificate), ", ")), u))
+	}
+	for _, a := range res.InvalidAnchorCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleInvalidAnchor, sarifLevel(res, validate.FindingInvalidAnchor),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X is in a trust store, but is not a valid certificate authority, as its %s.", a.Certificate.Subject.String(), a.FingerprintSha256, strings.Join(validate.AnchorProblems(a.Certificate), ", and its ")), a))
+	}
+	for _, u := range res.UnverifiableCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleUnverifiableChain, sarifLevel(res, validate.FindingUnverifiableChain),
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X does not chain to a trusted root, as %s.", u.Certificate.Certificate.Subject.String(), u.Certificate.FingerprintSha256, u.Reason), u.Certificate))
+	}
+	for _, c := range res.SuspiciousSANCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleSuspiciousSAN, "warning",
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X has subject alternative names only meaningful on a private network: %s.", c.Certificate.Certificate.Subject.String(), c.Certificate.FingerprintSha256, strings.Join(c.SANs, ", ")), c.Certificate))
+	}
+	for _, k := range res.KeyIdAnomalyCertificates {
+		results = append(results, sarifCertificateResult(SARIFRuleKeyIdAnomaly, "warning",
+			fmt.Sprintf("Certificate %q with SHA256 fingerprint %X may break chain building, as %s.", k.Certificate.Certificate.Subject.String(), k.Certificate.FingerprintSha256, k.Reason), k.Certificate))
+	}
+	for _, p := range res.PartialCertificates {
+		results = append(results, SARIFResult{
+			RuleID:    SARIFRulePartial,
+			Level:     sarifLevel(res, validate.FindingPartial),
+			Message:   SARIFMessage{Text: fmt.Sprintf("Partial certificate found by the %s parser: %s.", p.Parser, p.Reason)},
+			Locations: sarifLocations(p.Location),
+		})
+	}
+
+	for _, e := range res.ExpiredEntries {
+		msg := fmt.Sprintf("The %s entry at position %d expired on %s, so was ignored. Remove or renew it.", e.List, e.Position, e.Entry.ExpiresAt.Format(time.RFC3339))
+		if e.Entry.Comment != "" {
+			msg += " Comment: " + e.Entry.Comment
+		}
+		result := SARIFResult{
+			RuleID:    SARIFRuleExpiredEntry,
+			Level:     "warning",
+			Message:   SARIFMessage{Text: msg},
+			Locations: sarifLocations(configPath),
+		}
+		if e.Entry.Source != "" {
+			result.Locations = sarifLocations(e.Entry.Source)
+		}
+		results = append(results, result)
+	}
+
+	// SARIF requires the results to be an array, even when there are none.
+	if results == nil {
+		results = []SARIFResult{}
+	}
+
+	return SARIFReport{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SARIFRun{{
+			Tool: SARIFTool{
+				Driver: SARIFDriver{
+					Name:           "paranoia",
+					InformationURI: "https://github.com/jetstack/paranoia",
+					Rules:          sarifRules,
+				},
+			},
+			Results: results,
+		}},
+	}
+}
+
+// sarifLevel returns the level of results for the given kind of finding,
+// which is a warning if it does not fail validation.
+func sarifLevel(res validate.Result, finding string) string {
+	if res.Fails(finding) {
+		return "error"
+	}
+	return "warning"
+}
+
+func sarifCertificateResult(ruleID, level, msg string, f certificate.Found) SARIFResult {
+	if f.LayerDigest != "" {
+		msg += fmt.Sprintf(" Added by image layer %s.", f.LayerDigest)
+	}
+	return SARIFResult{
+		RuleID:              ruleID,
+		Level:               level,
+		Message:             SARIFMessage{Text: msg},
+		Locations:           sarifLocations(f.Location),
+		PartialFingerprints: map[string]string{sarifFingerprintKey: hex.EncodeToString(f.FingerprintSha256[:])},
+	}
+}
+
+func sarifLocations(uri string) []SARIFLocation {
+	return []SARIFLocation{{
+		PhysicalLocation: SARIFPhysicalLocation{
+			ArtifactLocation: SARIFArtifactLocation{URI: uri},
+		},
+	}}
+}
+
+// sarifEntryFingerprint describes the fingerprints a required certificate
+// entry is identified by, or its attributes if it has no fingerprint.
+func sarifEntryFingerprint(entry validate.CertificateEntry) string {
+	if len(entry.Fingerprints) == 0 {
+		return entry.Identity()
+	}
+	alternatives := make([]string, len(entry.Fingerprints))
+	for i, f := range entry.Fingerprints {
+		alternatives[i] = sarifFingerprint(f)
+	}
+	return strings.Join(alternatives, " or ")
+}
+
+// sarifFingerprint describes a single fingerprint of an entry.
+func sarifFingerprint(f validate.CertificateFingerprints) string {
+	switch {
+	case f.Sha256 != "":
+		return "SHA256 " + f.Sha256
+	case f.Sha512 != "":
+		return "SHA512 " + f.Sha512
+	case f.Sha1 != "":
+		return "SHA1 " + f.Sha1
+	case f.Md5 != "":
+		return "MD5 (insecure) " + f.Md5
+	case f.Sha256Prefix != "":
+		return "SHA256 prefix " + f.Sha256Prefix
+	case f.SpkiSha256 != "":
+		return "SPKI SHA256 " + f.SpkiSha256
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,98 @@
+package output
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func TestNewSARIFReport(t *testing.T) {
+	found := certificate.Found{
+		Location:          "etc/ssl/certs/internal.pem",
+		Parser:            "pem",
+		Certificate:       &x509.Certificate{Subject: pkix.Name{CommonName: "Internal"}},
+		FingerprintSha256: [32]byte{0xcd},
+	}
+
+	report := NewSARIFReport(".paranoia.yaml", validate.Result{
+		ForbiddenCertificates: []validate.ForbiddenCert{{
+			Certificate: found,
+			Entry:       validate.CertificateEntry{Comment: "An internal-only cert"},
+		}},
+		NotAllowedCertificates: []certificate.Found{found},
+		RequiredButAbsent: []validate.CertificateEntry{{
+			Fingerprints: validate.FingerprintList{{Sha256: "AB00"}},
+		}},
+	})
+
+	assert.Equal(t, "2.1.0", report.Version)
+	require.Len(t, report.Runs, 1)
+	results := report.Runs[0].Results
+	require.Len(t, results, 3)
+
+	assert.Equal(t, SARIFRuleForbidden, results[0].RuleID)
+	assert.Equal(t, "error", results[0].Level)
+	assert.Equal(t, `Certificate "CN=Internal" with SHA256 fingerprint CD00000000000000000000000000000000000000000000000000000000000000 was forbidden. Comment: An internal-only cert`, results[0].Message.Text)
+	assert.Equal(t, "etc/ssl/certs/internal.pem", results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, map[string]string{
+		"certificateSha256/v1": "cd00000000000000000000000000000000000000000000000000000000000000",
+	}, results[0].PartialFingerprints)
+
+	assert.Equal(t, SARIFRuleNotAllowed, results[1].RuleID)
+
+	assert.Equal(t, SARIFRuleRequired, results[2].RuleID)
+	assert.Equal(t, "Certificate with SHA256 AB00 was required, but was not found.", results[2].Message.Text)
+	assert.Equal(t, ".paranoia.yaml", results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, map[string]string{"certificateSha256/v1": "ab00"}, results[2].PartialFingerprints)
+}
+
+func TestNewSARIFReport_Pass(t *testing.T) {
+	report := NewSARIFReport(".paranoia.yaml", validate.Result{})
+	require.Len(t, report.Runs, 1)
+	assert.NotNil(t, report.Runs[0].Results)
+	assert.Empty(t, report.Runs[0].Results)
+}
+
+func TestNewSARIFReport_Partials(t *testing.T) {
+	report := NewSARIFReport(".paranoia.yaml", validate.Result{
+		PartialCertificates: []certificate.Partial{{
+			Location: "etc/ssl/certs/truncated.pem",
+			Parser:   "pem",
+			Reason:   "found start of PEM encoded certificate, but could not find end",
+		}},
+	})
+
+	require.Len(t, report.Runs, 1)
+	results := report.Runs[0].Results
+	require.Len(t, results, 1)
+	assert.Equal(t, SARIFRulePartial, results[0].RuleID)
+	assert.Equal(t, "error", results[0].Level)
+	assert.Equal(t, "Partial certificate found by the pem parser: found start of PEM encoded certificate, but could not find end.", results[0].Message.Text)
+	assert.Equal(t, "etc/ssl/certs/truncated.pem", results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Nil(t, results[0].PartialFingerprints)
+}
+
+func TestNewSARIFReport_FailOn(t *testing.T) {
+	found := certificate.Found{
+		Location:    "etc/ssl/certs/internal.pem",
+		Certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "Internal"}},
+	}
+
+	report := NewSARIFReport(".paranoia.yaml", validate.Result{
+		ForbiddenCertificates:  []validate.ForbiddenCert{{Certificate: found}},
+		NotAllowedCertificates: []certificate.Found{found},
+		FailOn:                 []string{validate.FindingForbidden},
+	})
+
+	require.Len(t, report.Runs, 1)
+	results := report.Runs[0].Results
+	require.Len(t, results, 2)
+	assert.Equal(t, "error", results[0].Level)
+	assert.Equal(t, "warning", results[1].Level, "expected findings which do not fail validation to be warnings")
+}
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/rodaine/table"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// OrganizationSummary counts the certificates issued by one organization.
+type OrganizationSummary struct {
+	// Organization is the organization of the certificates' issuer, or empty
+	// if the issuer has no organization.
+	Organization string
+	// Certificates is the number of distinct certificates, which is the sum
+	// of Roots, Intermediates and Leaves.
+	Certificates  int
+	Roots         int
+	Intermediates int
+	Leaves        int
+	// EarliestExpiry is the earliest time at which one of the certificates
+	// expires.
+	EarliestExpiry time.Time
+}
+
+// Summarize groups the found certificates by the organization of their
+// issuer. A certificate found in several locations is counted once. Self-signed
+// certificates are counted as roots, and other certificate authorities as
+// intermediates. Organizations are ordered by the number of certificates they
+// issued, most first.
+func Summarize(founds []certificate.Found) []OrganizationSummary {
+	var (
+		summaries []OrganizationSummary
+		index     = make(map[string]int)
+		seen      = make(map[[32]byte]bool)
+	)
+	for _, f := range founds {
+		if f.Certificate == nil || seen[f.FingerprintSha256] {
+			continue
+		}
+		seen[f.FingerprintSha256] = true
+
+		org := strings.Join(f.Certificate.Issuer.Organization, ", ")
+		i, ok := index[org]
+		if !ok {
+			i = len(summaries)
+			index[org] = i
+			summaries = append(summaries, OrganizationSummary{Organization: org})
+		}
+
+		s := &summaries[i]
+		s.Certificates++
+		switch {
+		case f.SelfSigned:
+			s.Roots++
+		case f.IsCA:
+			s.Intermediates++
+		default:
+			s.Leaves++
+		}
+		if s.EarliestExpiry.IsZero() || f.Certificate.NotAfter.Before(s.EarliestExpiry) {
+			s.EarliestExpiry = f.Certificate.NotAfter
+		}
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Certificates != summaries[j].Certificates {
+			return summaries[i].Certificates > summaries[j].Certificates
+		}
+		return summaries[i].Organization < summaries[j].Organization
+	})
+	return summaries
+}
+
+// WriteSummary writes a table of the found certificates grouped by the
+// organization of their issuer, as given by Summarize.
+func WriteSummary(w io.Writer, founds []certificate.Found) {
+	summaries := Summarize(founds)
+
+	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
+	columnFmt := color.New(color.FgYellow).SprintfFunc()
+	tbl := table.New("Issuer Organization", "Certificates", "Roots", "Intermediates", "Leaves", "Earliest Expiry").
+		WithHeaderFormatter(headerFmt).
+		WithFirstColumnFormatter(columnFmt).
+		WithWriter(w)
+
+	var total int
+	for _, s := range summaries {
+		org := s.Organization
+		if org == "" {
+			org = "(none)"
+		}
+		tbl.AddRow(org, s.Certificates, s.Roots, s.Intermediates, s.Leaves, s.EarliestExpiry.Format(time.RFC3339))
+		total += s.Certificates
+	}
+	tbl.Print()
+
+	fmt.Fprintf(w, "Found %d certificates from %d organizations\n", total, len(summaries))
+}
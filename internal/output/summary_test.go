@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestSummarize(t *testing.T) {
+	expiry := func(year int) time.Time { return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC) }
+	found := func(sha byte, org string, notAfter time.Time, selfSigned, isCA bool) certificate.Found {
+		var issuer pkix.Name
+		if org != "" {
+			issuer.Organization = []string{org}
+		}
+		return certificate.Found{
+			FingerprintSha256: [32]byte{sha},
+			Certificate:       &x509.Certificate{Issuer: issuer, NotAfter: notAfter},
+			SelfSigned:        selfSigned,
+			IsCA:              isCA,
+		}
+	}
+
+	root := found(1, "Example CA", expiry(2040), true, true)
+	founds := []certificate.Found{
+		root,
+		found(2, "Example CA", expiry(2030), false, true),
+		found(3, "Example CA", expiry(2035), false, false),
+		found(4, "Other CA", expiry(2028), true, true),
+		found(5, "", expiry(2026), true, false),
+		// A copy of a certificate in another location is counted once.
+		root,
+		{Location: "partially parsed"},
+	}
+
+	assert.Equal(t, []OrganizationSummary{
+		{Organization: "Example CA", Certificates: 3, Roots: 1, Intermediates: 1, Leaves: 1, EarliestExpiry: expiry(2030)},
+		{Organization: "", Certificates: 1, Roots: 1, EarliestExpiry: expiry(2026)},
+		{Organization: "Other CA", Certificates: 1, Roots: 1, EarliestExpiry: expiry(2028)},
+	}, Summarize(founds))
+
+	var buf bytes.Buffer
+	WriteSummary(&buf, founds)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, []string{"Issuer", "Organization", "Certificates", "Roots", "Intermediates", "Leaves", "Earliest", "Expiry"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"Example", "CA", "3", "1", "1", "1", "2030-01-01T00:00:00Z"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"(none)", "1", "1", "0", "0", "2026-01-01T00:00:00Z"}, strings.Fields(lines[2]))
+	assert.Equal(t, "Found 5 certificates from 3 organizations", lines[4])
+
+	buf.Reset()
+	WriteSummary(&buf, nil)
+	assert.Contains(t, buf.String(), "Found 0 certificates from 0 organizations")
+}
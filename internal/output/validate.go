@@ -0,0 +1,327 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package output
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+type JSONValidateOutput struct {
+	Image                                  string                                      `json:"image"`
+	Scanned                                int                                         `json:"scanned"`
+	Pass                                   bool                                        `json:"pass"`
+	NotAllowedCertificates                 []JSONValidateCertificate                   `json:"notAllowedCertificates"`
+	ForbiddenCertificates                  []JSONForbiddenCertificate                  `json:"forbiddenCertificates"`
+	RequiredButAbsent                      []validate.CertificateEntry                 `json:"requiredButAbsent"`
+	RevokedCertificates                    []JSONRevokedCertificate                    `json:"revokedCertificates"`
+	OCSPUnknownCertificates                []JSONOCSPUnknownCertificate                `json:"ocspUnknownCertificates"`
+	RequiredGroupsUnsatisfied              [][]validate.CertificateEntry               `json:"requiredGroupsUnsatisfied"`
+	ExpiredCertificates                    []JSONValidateCertificate                   `json:"expiredCertificates"`
+	ExpiringCertificates                   []JSONValidateCertificate                   `json:"expiringCertificates"`
+	NotYetValidCertificates                []JSONValidateCertificate                   `json:"notYetValidCertificates"`
+	OverlongValidityCertificates           []JSONOverlongValidityCertificate           `json:"overlongValidityCertificates"`
+	WeakSignatureCertificates              []JSONValidateCertificate                   `json:"weakSignatureCertificates"`
+	WeakKeyCertificates                    []JSONValidateCertificate                   `json:"weakKeyCertificates"`
+	UnhandledCriticalExtensionCertificates []JSONUnhandledCriticalExtensionCertificate `json:"unhandledCriticalExtensionCertificates"`
+	InvalidAnchorCertificates              []JSONInvalidAnchorCertificate              `json:"invalidAnchorCertificates"`
+	UnverifiableCertificates               []JSONUnverifiableCertificate               `json:"unverifiableCertificates"`
+	SuspiciousSANCertificates              []JSONSuspiciousSANCertificate              `json:"suspiciousSANCertificates"`
+	KeyIdAnomalyCertificates               []JSONKeyIdAnomalyCertificate               `json:"keyIdAnomalyCertificates"`
+	UnsupportedKeyCertificates             []JSONPartialCertificate                    `json:"unsupportedKeyCertificates"`
+	PartialCertificates                    []JSONPartialCertificate                    `json:"partialCertificates"`
+	BaselinedFindings                      []JSONFinding                               `json:"baselinedFindings"`
+	UnseenBundleCertificates               []JSONValidateCertificate                   `json:"unseenBundleCertificates"`
+	ExpiredEntries                         []JSONExpiredEntry                          `json:"expiredEntries"`
+	Explanations                           []JSONExplanation                           `json:"explanations,omitempty"`
+	ConfigDiff                             *JSONConfigDiff                             `json:"configDiff,omitempty"`
+}
+
+type JSONValidateCertificate struct {
+	FileLocation      string `json:"fileLocation"`
+	Parser            string `json:"parser"`
+	Encoding          string `json:"encoding"`
+	ContainerFormat   string `json:"containerFormat"`
+	Subject           string `json:"subject"`
+	Issuer            string `json:"issuer"`
+	NotBefore         string `json:"notBefore"`
+	NotAfter          string `json:"notAfter"`
+	FingerprintSHA1   string `json:"fingerprintSHA1"`
+	FingerprintSHA256 string `json:"fingerprintSHA256"`
+	LayerDigest       string `json:"layerDigest,omitempty"`
+}
+
+type JSONRevokedCertificate struct {
+	Certificate JSONValidateCertificate `json:"certificate"`
+	RevokedAt   string                  `json:"revokedAt"`
+	CRL         string                  `json:"crl"`
+}
+
+type JSONOCSPUnknownCertificate struct {
+	Certificate JSONValidateCertificate `json:"certificate"`
+	Reason      string                  `json:"reason"`
+}
+
+type JSONOverlongValidityCertificate struct {
+	Certificate  JSONValidateCertificate `json:"certificate"`
+	ValidityDays int                     `json:"validityDays"`
+}
+
+type JSONUnhandledCriticalExtensionCertificate struct {
+	Certificate JSONValidateCertificate `json:"certificate"`
+	Extensions  []string                `json:"extensions"`
+}
+
+type JSONSuspiciousSANCertificate struct {
+	Certificate JSONValidateCertificate `json:"certificate"`
+	SANs        []string                `json:"sans"`
+}
+
+type JSONKeyIdAnomalyCertificate struct {
+	Certificate JSONValidateCertificate `json:"certificate"`
+	Reason      string                  `json:"reason"`
+}
+
+type JSONInvalidAnchorCertificate struct {
+	Certificate JSONValidateCertificate `json:"certificate"`
+	Problems    []string                `json:"problems"`
+}
+
+type JSONUnverifiableCertificate struct {
+	Certificate JSONValidateCertificate `json:"certificate"`
+	Reason      string                  `json:"reason"`
+}
+
+type JSONExpiredEntry struct {
+	Entry     validate.CertificateEntry `json:"entry"`
+	List      string                    `json:"list"`
+	Position  int                       `json:"position"`
+	Source    string                    `json:"source,omitempty"`
+	ExpiresAt string                    `json:"expiresAt"`
+}
+
+type JSONExplanation struct {
+	Certificate JSONValidateCertificate `json:"certificate"`
+	Verdict     string                  `json:"verdict"`
+	Reason      string                  `json:"reason"`
+	Rule        string                  `json:"rule,omitempty"`
+}
+
+// JSONConfigDiff is the findings which the validation config adds and removes
+// compared to another config.
+type JSONConfigDiff struct {
+	Config  string        `json:"config"`
+	Added   []JSONFinding `json:"added"`
+	Removed []JSONFinding `json:"removed"`
+}
+
+type JSONFinding struct {
+	Kind         string `json:"kind"`
+	FileLocation string `json:"fileLocation,omitempty"`
+	Certificate  string `json:"certificate"`
+}
+
+type JSONForbiddenCertificate struct {
+	Certificate JSONValidateCertificate   `json:"certificate"`
+	Entry       validate.CertificateEntry `json:"entry"`
+	// MatchedFingerprint is the fingerprint of the entry which the
+	// certificate matched, if it was forbidden by fingerprint.
+	MatchedFingerprint *validate.CertificateFingerprints `json:"matchedFingerprint,omitempty"`
+	// Rule is the rule which forbade the certificate, either
+	// "forbidByFingerprint" or "forbidByAttribute".
+	Rule string `json:"rule,omitempty"`
+}
+
+// NewJSONValidateOutput converts the result of validating the certificates
+// found in an image into its JSON output form. Every list is present in the
+// output, even when empty, so consumers may rely on the keys existing.
+func NewJSONValidateOutput(image string, scanned int, res validate.Result) JSONValidateOutput {
+	out := JSONValidateOutput{
+		Image:                                  image,
+		Scanned:                                scanned,
+		Pass:                                   res.IsPass(),
+		NotAllowedCertificates:                 jsonValidateCertificates(res.NotAllowedCertificates),
+		ForbiddenCertificates:                  []JSONForbiddenCertificate{},
+		RequiredButAbsent:                      []validate.CertificateEntry{},
+		RevokedCertificates:                    []JSONRevokedCertificate{},
+		OCSPUnknownCertificates:                []JSONOCSPUnknownCertificate{},
+		RequiredGroupsUnsatisfied:              [][]validate.CertificateEntry{},
+		ExpiredCertificates:                    jsonValidateCertificates(res.ExpiredCertificates),
+		ExpiringCertificates:                   jsonValidateCertificates(res.ExpiringCertificates),
+		NotYetValidCertificates:                jsonValidateCertificates(res.NotYetValidCertificates),
+		OverlongValidityCertificates:           []JSONOverlongValidityCertificate{},
+		WeakSignatureCertificates:              jsonValidateCertificates(res.WeakSignatureCertificates),
+		WeakKeyCertificates:                    jsonValidateCertificates(res.WeakKeyCertificates),
+		UnhandledCriticalExtensionCertificates: []JSONUnhandledCriticalExtensionCertificate{},
+		InvalidAnchorCertificates:              []JSONInvalidAnchorCertificate{},
+		UnverifiableCertificates:               []JSONUnverifiableCertificate{},
+		SuspiciousSANCertificates:              []JSONSuspiciousSANCertificate{},
+		KeyIdAnomalyCertificates:               []JSONKeyIdAnomalyCertificate{},
+		UnsupportedKeyCertificates:             []JSONPartialCertificate{},
+		PartialCertificates:                    []JSONPartialCertificate{},
+		BaselinedFindings:                      jsonFindings(res.BaselinedFindings),
+		UnseenBundleCertificates:               jsonValidateCertificates(res.UnseenBundleCertificates),
+		ExpiredEntries:                         []JSONExpiredEntry{},
+	}
+
+	for _, f := range res.ForbiddenCertificates {
+		jf := JSONForbiddenCertificate{
+			Certificate: jsonValidateCertificate(f.Certificate),
+			Entry:       f.Entry,
+			Rule:        f.Rule,
+		}
+		if f.Fingerprint != (validate.CertificateFingerprints{}) {
+			fp := f.Fingerprint
+			jf.MatchedFingerprint = &fp
+		}
+		out.ForbiddenCertificates = append(out.ForbiddenCertificates, jf)
+	}
+
+	for _, r := range res.RevokedCertificates {
+		out.RevokedCertificates = append(out.RevokedCertificates, JSONRevokedCertificate{
+			Certificate: jsonValidateCertificate(r.Certificate),
+			RevokedAt:   r.RevokedAt.Format(time.RFC3339),
+			CRL:         r.Source,
+		})
+	}
+
+	for _, u := range res.OCSPUnknownCertificates {
+		out.OCSPUnknownCertificates = append(out.OCSPUnknownCertificates, JSONOCSPUnknownCertificate{
+			Certificate: jsonValidateCertificate(u.Certificate),
+			Reason:      u.Reason,
+		})
+	}
+
+	for _, o := range res.OverlongValidityCertificates {
+		out.OverlongValidityCertificates = append(out.OverlongValidityCertificates, JSONOverlongValidityCertificate{
+			Certificate:  jsonValidateCertificate(o),
+			ValidityDays: validate.ValidityDays(o.Certificate),
+		})
+	}
+
+	for _, u := range res.UnhandledCriticalExtensionCertificates {
+		out.UnhandledCriticalExtensionCertificates = append(out.UnhandledCriticalExtensionCertificates, JSONUnhandledCriticalExtensionCertificate{
+			Certificate: jsonValidateCertificate(u),
+			Extensions:  validate.UnhandledCriticalExtensions(u.Certificate),
+		})
+	}
+
+	for _, a := range res.InvalidAnchorCertificates {
+		out.InvalidAnchorCertificates = append(out.InvalidAnchorCertificates, JSONInvalidAnchorCertificate{
+			Certificate: jsonValidateCertificate(a),
+			Problems:    validate.AnchorProblems(a.Certificate),
+		})
+	}
+
+	for _, u := range res.UnverifiableCertificates {
+		out.UnverifiableCertificates = append(out.UnverifiableCertificates, JSONUnverifiableCertificate{
+			Certificate: jsonValidateCertificate(u.Certificate),
+			Reason:      u.Reason,
+		})
+	}
+
+	for _, c := range res.SuspiciousSANCertificates {
+		out.SuspiciousSANCertificates = append(out.SuspiciousSANCertificates, JSONSuspiciousSANCertificate{
+			Certificate: jsonValidateCertificate(c.Certificate),
+			SANs:        c.SANs,
+		})
+	}
+
+	for _, k := range res.KeyIdAnomalyCertificates {
+		out.KeyIdAnomalyCertificates = append(out.KeyIdAnomalyCertificates, JSONKeyIdAnomalyCertificate{
+			Certificate: jsonValidateCertificate(k.Certificate),
+			Reason:      k.Reason,
+		})
+	}
+
+	out.RequiredButAbsent = append(out.RequiredButAbsent, res.RequiredButAbsent...)
+	out.RequiredGroupsUnsatisfied = append(out.RequiredGroupsUnsatisfied, res.RequiredGroupsUnsatisfied...)
+
+	for _, p := range res.UnsupportedKeyCertificates {
+		out.UnsupportedKeyCertificates = append(out.UnsupportedKeyCertificates, JSONPartialCertificate{
+			FileLocation: p.Location,
+			Parser:       p.Parser,
+			Reason:       p.Reason,
+		})
+	}
+
+	for _, p := range res.PartialCertificates {
+		out.PartialCertificates = append(out.PartialCertificates, JSONPartialCertificate{
+			FileLocation: p.Location,
+			Parser:       p.Parser,
+			Reason:       p.Reason,
+		})
+	}
+
+	for _, e := range res.ExpiredEntries {
+		out.ExpiredEntries = append(out.ExpiredEntries, JSONExpiredEntry{
+			Entry:     e.Entry,
+			List:      e.List,
+			Position:  e.Position,
+			Source:    e.Entry.Source,
+			ExpiresAt: e.Entry.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+
+	return out
+}
+
+// NewJSONExplanations converts the explanations of what strict mode
+// validation would decide for each certificate into their JSON output form.
+func NewJSONExplanations(explanations []validate.Explanation) []JSONExplanation {
+	out := []JSONExplanation{}
+	for _, e := range explanations {
+		out = append(out, JSONExplanation{
+			Certificate: jsonValidateCertificate(e.Certificate),
+			Verdict:     e.Verdict,
+			Reason:      e.Reason,
+			Rule:        e.Rule,
+		})
+	}
+	return out
+}
+
+// NewJSONConfigDiff converts the difference between the findings of another
+// config and the validation config into its JSON output form.
+func NewJSONConfigDiff(config string, diff validate.ResultDiff) *JSONConfigDiff {
+	return &JSONConfigDiff{
+		Config:  config,
+		Added:   jsonFindings(diff.Added),
+		Removed: jsonFindings(diff.Removed),
+	}
+}
+
+func jsonFindings(findings []validate.Finding) []JSONFinding {
+	out := []JSONFinding{}
+	for _, f := range findings {
+		out = append(out, JSONFinding{Kind: f.Kind, FileLocation: f.Location, Certificate: f.Certificate})
+	}
+	return out
+}
+
+func jsonValidateCertificates(founds []certificate.Found) []JSONValidateCertificate {
+	certs := []JSONValidateCertificate{}
+	for _, f := range founds {
+		certs = append(certs, jsonValidateCertificate(f))
+	}
+	return certs
+}
+
+func jsonValidateCertificate(f certificate.Found) JSONValidateCertificate {
+	return JSONValidateCertificate{
+		FileLocation:      f.Location,
+		Parser:            f.Parser,
+		Encoding:          f.Encoding,
+		ContainerFormat:   f.ContainerFormat,
+		Subject:           f.Certificate.Subject.String(),
+		Issuer:            f.Certificate.Issuer.String(),
+		NotBefore:         f.Certificate.NotBefore.Format(time.RFC3339),
+		NotAfter:          f.Certificate.NotAfter.Format(time.RFC3339),
+		FingerprintSHA1:   hex.EncodeToString(f.FingerprintSha1[:]),
+		FingerprintSHA256: hex.EncodeToString(f.FingerprintSha256[:]),
+		LayerDigest:       f.LayerDigest,
+	}
+}
@@ -0,0 +1,128 @@
+package output
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/validate"
+)
+
+func TestNewJSONValidateOutput(t *testing.T) {
+	found := certificate.Found{
+		Location:        "etc/ssl/certs/ca-certificates.crt",
+		Parser:          "pem",
+		Encoding:        certificate.EncodingPEM,
+		ContainerFormat: certificate.ContainerFormatX509,
+		Certificate: &x509.Certificate{
+			Subject:   pkix.Name{CommonName: "Example Root"},
+			Issuer:    pkix.Name{CommonName: "Example Root"},
+			NotBefore: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:  time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		FingerprintSha1:   [20]byte{0xab},
+		FingerprintSha256: [32]byte{0xcd},
+	}
+	entry := validate.CertificateEntry{
+		Comment:      "An internal-only cert",
+		Fingerprints: validate.FingerprintList{{Sha256: "cd00"}},
+	}
+
+	t.Run("failing result", func(t *testing.T) {
+		out := NewJSONValidateOutput("example.com/image:v0.1.0", 1, validate.Result{
+			NotAllowedCertificates: []certificate.Found{found},
+			ForbiddenCertificates:  []validate.ForbiddenCert{{Certificate: found, Entry: entry, Fingerprint: entry.Fingerprints[0]}},
+			RequiredButAbsent:      []validate.CertificateEntry{entry},
+		})
+		assert.False(t, out.Pass)
+		assert.Equal(t, "example.com/image:v0.1.0", out.Image)
+		assert.Equal(t, 1, out.Scanned)
+
+		expCert := JSONValidateCertificate{
+			FileLocation:      "etc/ssl/certs/ca-certificates.crt",
+			Parser:            "pem",
+			Encoding:          "PEM",
+			ContainerFormat:   "X.509",
+			Subject:           "CN=Example Root",
+			Issuer:            "CN=Example Root",
+			NotBefore:         "2020-01-01T00:00:00Z",
+			NotAfter:          "2030-01-01T00:00:00Z",
+			FingerprintSHA1:   "ab00000000000000000000000000000000000000",
+			FingerprintSHA256: "cd00000000000000000000000000000000000000000000000000000000000000",
+		}
+		assert.Equal(t, []JSONValidateCertificate{expCert}, out.NotAllowedCertificates)
+		assert.Equal(t, []JSONForbiddenCertificate{{Certificate: expCert, Entry: entry, MatchedFingerprint: &entry.Fingerprints[0]}}, out.ForbiddenCertificates)
+		assert.Equal(t, []validate.CertificateEntry{entry}, out.RequiredButAbsent)
+	})
+
+	t.Run("unseen bundle certificates do not fail validation", func(t *testing.T) {
+		out := NewJSONValidateOutput("image", 0, validate.Result{
+			UnseenBundleCertificates: []certificate.Found{found},
+		})
+		assert.True(t, out.Pass)
+		require.Len(t, out.UnseenBundleCertificates, 1)
+		assert.Equal(t, found.Location, out.UnseenBundleCertificates[0].FileLocation)
+	})
+
+	t.Run("key identifier anomalies are warnings with a reason", func(t *testing.T) {
+		reason := "it is a certificate authority without a subject key identifier"
+		out := NewJSONValidateOutput("image", 1, validate.Result{
+			KeyIdAnomalyCertificates: []validate.KeyIdAnomalyCert{{Certificate: found, Reason: reason}},
+		})
+		assert.True(t, out.Pass)
+		require.Len(t, out.KeyIdAnomalyCertificates, 1)
+		assert.Equal(t, found.Location, out.KeyIdAnomalyCertificates[0].Certificate.FileLocation)
+		assert.Equal(t, reason, out.KeyIdAnomalyCertificates[0].Reason)
+	})
+
+	t.Run("passing result has empty lists", func(t *testing.T) {
+		m, err := json.Marshal(NewJSONValidateOutput("image", 0, validate.Result{}))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"image": "image",
+			"scanned": 0,
+			"pass": true,
+			"notAllowedCertificates": [],
+			"forbiddenCertificates": [],
+			"requiredButAbsent": [],
+			"revokedCertificates": [],
+			"ocspUnknownCertificates": [],
+			"requiredGroupsUnsatisfied": [],
+			"expiredCertificates": [],
+			"expiringCertificates": [],
+			"notYetValidCertificates": [],
+			"overlongValidityCertificates": [],
+			"weakSignatureCertificates": [],
+			"weakKeyCertificates": [],
+			"unhandledCriticalExtensionCertificates": [],
+			"invalidAnchorCertificates": [],
+			"unverifiableCertificates": [],
+			"suspiciousSANCertificates": [],
+			"keyIdAnomalyCertificates": [],
+			"unsupportedKeyCertificates": [],
+			"partialCertificates": [],
+			"baselinedFindings": [],
+			"unseenBundleCertificates": [],
+			"expiredEntries": []
+		}`, string(m))
+	})
+}
+
+func TestNewJSONConfigDiff(t *testing.T) {
+	diff := validate.ResultDiff{
+		Added: []validate.Finding{{Kind: validate.FindingForbidden, Location: "etc/ssl/cert.pem", Certificate: "SHA256 fingerprint abcd"}},
+	}
+	m, err := json.Marshal(NewJSONConfigDiff("old.yaml", diff))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"config": "old.yaml",
+		"added": [{"kind": "forbidden", "fileLocation": "etc/ssl/cert.pem", "certificate": "SHA256 fingerprint abcd"}],
+		"removed": []
+	}`, string(m))
+}
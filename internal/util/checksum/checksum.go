@@ -5,10 +5,42 @@ package checksum
 import (
 	"encoding/hex"
 	"errors"
+	"strings"
+	"unicode"
 )
 
+// Normalize returns a hex encoded checksum in lower case, without the colons
+// and whitespace used to separate its bytes when copied from browsers and
+// openssl, such as "AB:CD:EF".
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ':' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// ParseMD5 parses a hex encoded MD5 checksum, which may be separated as
+// accepted by Normalize. MD5 is insecure, and is only supported for matching
+// against externally supplied hashes.
+func ParseMD5(s string) ([16]byte, error) {
+	b, err := hex.DecodeString(Normalize(s))
+	if err != nil {
+		return [16]byte{}, err
+	}
+	if len(b) != 16 {
+		return [16]byte{}, errors.New("incorrect length for MD5")
+	}
+	var o [16]byte
+	copy(o[:], b[:16])
+	return o, nil
+}
+
+// ParseSHA1 parses a hex encoded SHA1 checksum, which may be separated as
+// accepted by Normalize.
 func ParseSHA1(s string) ([20]byte, error) {
-	b, err := hex.DecodeString(s)
+	b, err := hex.DecodeString(Normalize(s))
 	if err != nil {
 		return [20]byte{}, err
 	}
@@ -20,8 +52,10 @@ func ParseSHA1(s string) ([20]byte, error) {
 	return o, nil
 }
 
+// ParseSHA256 parses a hex encoded SHA256 checksum, which may be separated as
+// accepted by Normalize.
 func ParseSHA256(s string) ([32]byte, error) {
-	b, err := hex.DecodeString(s)
+	b, err := hex.DecodeString(Normalize(s))
 	if err != nil {
 		return [32]byte{}, err
 	}
@@ -33,6 +67,29 @@ func ParseSHA256(s string) ([32]byte, error) {
 	return o, nil
 }
 
+// ParseSHA512 parses a hex encoded SHA512 checksum, which may be separated as
+// accepted by Normalize.
+func ParseSHA512(s string) ([64]byte, error) {
+	b, err := hex.DecodeString(Normalize(s))
+	if err != nil {
+		return [64]byte{}, err
+	}
+	if len(b) != 64 {
+		return [64]byte{}, errors.New("incorrect length for SHA512")
+	}
+	var o [64]byte
+	copy(o[:], b[:64])
+	return o, nil
+}
+
+func MustParseMD5(s string) [16]byte {
+	o, err := ParseMD5(s)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
 func MustParseSHA1(s string) [20]byte {
 	o, err := ParseSHA1(s)
 	if err != nil {
@@ -48,3 +105,11 @@ func MustParseSHA256(s string) [32]byte {
 	}
 	return o
 }
+
+func MustParseSHA512(s string) [64]byte {
+	o, err := ParseSHA512(s)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
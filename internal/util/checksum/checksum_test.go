@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package checksum
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSHA256(t *testing.T) {
+	const plain = "96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6"
+	want := MustParseSHA256(plain)
+
+	for name, s := range map[string]string{
+		"uppercase":       "96BCEC06264976F37460779ACF28C5A7CFE8A3C0AAE11A8FFCEE05C0BDDF08C6",
+		"colon separated": "96:BC:EC:06:26:49:76:F3:74:60:77:9A:CF:28:C5:A7:CF:E8:A3:C0:AA:E1:1A:8F:FC:EE:05:C0:BD:DF:08:C6",
+		"space separated": "96 bc ec 06 26 49 76 f3 74 60 77 9a cf 28 c5 a7 cf e8 a3 c0 aa e1 1a 8f fc ee 05 c0 bd df 08 c6",
+		"mixed":           " 96:bc:EC:06 2649:76f3:7460:779A cf28c5a7\tcfe8a3c0aae11a8f:FC:EE:05:C0:BD:DF:08:C6\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseSHA256(s)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+
+	t.Run("other separators are invalid", func(t *testing.T) {
+		_, err := ParseSHA256("96-bc-ec-06-26-49-76-f3-74-60-77-9a-cf-28-c5-a7-cf-e8-a3-c0-aa-e1-1a-8f-fc-ee-05-c0-bd-df-08-c6")
+		assert.Error(t, err)
+	})
+
+	t.Run("short checksums are invalid", func(t *testing.T) {
+		_, err := ParseSHA256("96:BC:EC:06")
+		assert.EqualError(t, err, "incorrect length for SHA256")
+	})
+}
+
+func TestParse_Separated(t *testing.T) {
+	_, err := ParseMD5("D4:1D:8C:D9:8F:00:B2:04:E9:80:09:98:EC:F8:42:7E")
+	assert.NoError(t, err)
+	_, err = ParseSHA1("4A:E8:40:B2:24:DC:CF:3A:F3:AC:08:27:BE:5F:88:5E:DE:D1:8A:17")
+	assert.NoError(t, err)
+	_, err = ParseSHA512("CF:83:E1:35:7E:EF:B8:BD:F1:54:28:50:D6:6D:80:07:D6:20:E4:05:0B:57:15:DC:83:F4:A9:21:D3:6C:E9:CE:47:D0:D1:3C:5D:85:F2:B0:FF:83:18:D2:87:7E:EC:2F:63:B9:31:BD:47:41:7A:81:A5:38:32:7A:F9:27:DA:3E")
+	assert.NoError(t, err)
+}
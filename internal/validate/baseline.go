@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// BaselineVersion is the version of baseline files which is written, and the
+// only version which is understood.
+const BaselineVersion = "1"
+
+// Baseline is a set of findings which were previously accepted, such as those
+// of a legacy image, so that only new findings fail validation. Findings are
+// matched by their kind and the certificate they are about, but not their
+// location, so that moving an accepted certificate doesn't make it new.
+// Partial certificates have no fingerprint, so are matched by location.
+type Baseline struct {
+	Version  string            `json:"version"`
+	Findings []BaselineFinding `json:"findings"`
+}
+
+type BaselineFinding struct {
+	Kind        string `json:"kind"`
+	Certificate string `json:"certificate"`
+	// Location is only recorded for partial certificates.
+	Location string `json:"fileLocation,omitempty"`
+}
+
+// baselineFinding returns how a finding is recorded in a baseline.
+func baselineFinding(f Finding) BaselineFinding {
+	bf := BaselineFinding{Kind: f.Kind, Certificate: f.Certificate}
+	if f.Kind == FindingPartial {
+		bf.Location = f.Location
+	}
+	return bf
+}
+
+// NewBaseline returns a baseline accepting every finding of the result. The
+// findings are sorted, so that the baseline of the same result is always the
+// same, and changes to it are easy to review.
+func NewBaseline(r Result) Baseline {
+	b := Baseline{Version: BaselineVersion, Findings: []BaselineFinding{}}
+	seen := make(map[BaselineFinding]bool)
+	for _, f := range r.ListFindings() {
+		bf := baselineFinding(f)
+		if !seen[bf] {
+			seen[bf] = true
+			b.Findings = append(b.Findings, bf)
+		}
+	}
+	sort.Slice(b.Findings, func(i, j int) bool {
+		x, y := b.Findings[i], b.Findings[j]
+		if x.Kind != y.Kind {
+			return x.Kind < y.Kind
+		}
+		if x.Certificate != y.Certificate {
+			return x.Certificate < y.Certificate
+		}
+		return x.Location < y.Location
+	})
+	return b
+}
+
+// LoadBaseline loads the baseline file with the given name.
+func LoadBaseline(fileName string) (*Baseline, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(b, &baseline); err != nil {
+		return nil, err
+	}
+	if baseline.Version != BaselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version, expected %s, found %q", BaselineVersion, baseline.Version)
+	}
+	return &baseline, nil
+}
+
+// ApplyBaseline removes the findings of the result which are accepted by the
+// baseline, recording them as baselined findings instead, so that they no
+// longer fail validation.
+func (r *Result) ApplyBaseline(b *Baseline) {
+	accepted := make(map[BaselineFinding]bool, len(b.Findings))
+	for _, f := range b.Findings {
+		accepted[f] = true
+	}
+	// baselined returns true if the finding is accepted, recording it if so.
+	baselined := func(f Finding) bool {
+		if !accepted[baselineFinding(f)] {
+			return false
+		}
+		r.BaselinedFindings = append(r.BaselinedFindings, f)
+		return true
+	}
+	filterFound := func(kind string, founds []certificate.Found) []certificate.Found {
+		var kept []certificate.Found
+		for _, f := range founds {
+			if !baselined(Finding{Kind: kind, Location: f.Location, Certificate: foundFingerprint(f)}) {
+				kept = append(kept, f)
+			}
+		}
+		return kept
+	}
+
+	var forbidden []ForbiddenCert
+	for _, f := range r.ForbiddenCertificates {
+		if !baselined(Finding{Kind: FindingForbidden, Location: f.Certificate.Location, Certificate: foundFingerprint(f.Certificate)}) {
+			forbidden = append(forbidden, f)
+		}
+	}
+	r.ForbiddenCertificates = forbidden
+
+	var revoked []RevokedCert
+	for _, rc := range r.RevokedCertificates {
+		if !baselined(Finding{Kind: FindingRevoked, Location: rc.Certificate.Location, Certificate: foundFingerprint(rc.Certificate)}) {
+			revoked = append(revoked, rc)
+		}
+	}
+	r.RevokedCertificates = revoked
+
+	var required []CertificateEntry
+	for _, e := range r.RequiredButAbsent {
+		if !baselined(Finding{Kind: FindingRequired, Certificate: findingEntry(e)}) {
+			required = append(required, e)
+		}
+	}
+	r.RequiredButAbsent = required
+
+	var groups [][]CertificateEntry
+	for _, group := range r.RequiredGroupsUnsatisfied {
+		if !baselined(Finding{Kind: FindingRequiredAnyOf, Certificate: groupFinding(group)}) {
+			groups = append(groups, group)
+		}
+	}
+	r.RequiredGroupsUnsatisfied = groups
+
+	r.NotAllowedCertificates = filterFound(FindingNotAllowed, r.NotAllowedCertificates)
+	r.ExpiredCertificates = filterFound(FindingExpired, r.ExpiredCertificates)
+	r.NotYetValidCertificates = filterFound(FindingNotYetValid, r.NotYetValidCertificates)
+	r.OverlongValidityCertificates = filterFound(FindingOverlongValidity, r.OverlongValidityCertificates)
+	r.WeakSignatureCertificates = filterFound(FindingWeakSignature, r.WeakSignatureCertificates)
+	r.WeakKeyCertificates = filterFound(FindingWeakKey, r.WeakKeyCertificates)
+	r.UnhandledCriticalExtensionCertificates = filterFound(FindingUnhandledCriticalExtension, r.UnhandledCriticalExtensionCertificates)
+	r.InvalidAnchorCertificates = filterFound(FindingInvalidAnchor, r.InvalidAnchorCertificates)
+
+	var unverifiable []UnverifiableCert
+	for _, u := range r.UnverifiableCertificates {
+		if !baselined(Finding{Kind: FindingUnverifiableChain, Location: u.Certificate.Location, Certificate: foundFingerprint(u.Certificate)}) {
+			unverifiable = append(unverifiable, u)
+		}
+	}
+	r.UnverifiableCertificates = unverifiable
+
+	var partials []certificate.Partial
+	for _, p := range r.PartialCertificates {
+		if !baselined(partialFinding(p)) {
+			partials = append(partials, p)
+		}
+	}
+	r.PartialCertificates = partials
+}
@@ -0,0 +1,87 @@
+package validate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+func TestBaseline(t *testing.T) {
+	forbiddenSHA256 := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+	otherSHA256 := "edfa7caf7f1274d54bacec91e21a5b1a04a7b94bf197f5c92070b8de148d9b37"
+
+	forbidden := certificate.Found{Location: "forbidden", FingerprintSha256: checksum.MustParseSHA256(forbiddenSHA256)}
+	other := certificate.Found{Location: "other", FingerprintSha256: checksum.MustParseSHA256(otherSHA256)}
+	partial := certificate.Partial{Location: "broken.pem", Parser: "pem", Reason: "bad data"}
+
+	validate := func(founds []certificate.Found) Result {
+		validator, err := NewValidator(Config{
+			Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: forbiddenSHA256}}}},
+		}, true)
+		require.NoError(t, err)
+		r, err := validator.Validate(founds)
+		require.NoError(t, err)
+		return r
+	}
+
+	res := validate([]certificate.Found{forbidden})
+	res.PartialCertificates = []certificate.Partial{partial}
+	baseline := NewBaseline(res)
+
+	assert.Equal(t, Baseline{
+		Version: BaselineVersion,
+		Findings: []BaselineFinding{
+			{Kind: FindingForbidden, Certificate: "SHA256 fingerprint " + forbiddenSHA256},
+			{Kind: FindingPartial, Certificate: "partial certificate: bad data", Location: "broken.pem"},
+		},
+	}, baseline)
+
+	t.Run("Accepted findings no longer fail validation", func(t *testing.T) {
+		moved := forbidden
+		moved.Location = "moved"
+		r := validate([]certificate.Found{moved})
+		r.PartialCertificates = []certificate.Partial{partial}
+		r.ApplyBaseline(&baseline)
+		assert.True(t, r.IsPass())
+		assert.Len(t, r.BaselinedFindings, 2)
+	})
+
+	t.Run("New findings still fail validation", func(t *testing.T) {
+		moved := partial
+		moved.Location = "elsewhere.pem"
+		r := validate([]certificate.Found{forbidden, other})
+		r.NotAllowedCertificates = []certificate.Found{other}
+		r.PartialCertificates = []certificate.Partial{moved}
+		r.ApplyBaseline(&baseline)
+		assert.Empty(t, r.ForbiddenCertificates)
+		assert.Equal(t, []certificate.Found{other}, r.NotAllowedCertificates)
+		assert.Equal(t, []certificate.Partial{moved}, r.PartialCertificates)
+		assert.Len(t, r.BaselinedFindings, 1)
+	})
+
+	t.Run("Baselines round trip through a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "baseline.json")
+		data, err := json.Marshal(baseline)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0644))
+
+		loaded, err := LoadBaseline(path)
+		require.NoError(t, err)
+		assert.Equal(t, &baseline, loaded)
+	})
+
+	t.Run("Unknown versions are rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "baseline.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"version": "2", "findings": []}`), 0644))
+
+		_, err := LoadBaseline(path)
+		assert.Error(t, err)
+	})
+}
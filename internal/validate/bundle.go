@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// AllowBundle is a trust bundle, such as a known-good roots.pem, every
+// certificate of which is allowed.
+type AllowBundle struct {
+	// Path is the location of the bundle file.
+	Path string
+
+	// Certificates are the certificates found in the bundle, ordered by
+	// their SHA256 fingerprint rather than their position in the file.
+	Certificates []certificate.Found
+}
+
+// LoadAllowBundle reads every certificate in the bundle file at the given
+// path. The bundle may be in any format which Paranoia finds certificates in,
+// such as a PEM bundle or a PKCS#7 file. An error is returned if the file has
+// no certificates, as an empty bundle is almost always a mistake.
+func LoadAllowBundle(ctx context.Context, path string) (*AllowBundle, error) {
+	parsed, err := certificate.FindCertificatesInFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &AllowBundle{Path: path}
+	for _, f := range parsed.Found {
+		if f.Certificate != nil {
+			b.Certificates = append(b.Certificates, f)
+		}
+	}
+	if len(b.Certificates) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return b, nil
+}
+
+// Config returns a config allowing every certificate in the bundle by its
+// SHA256 fingerprint, so that it can be merged with other configs. The
+// bundle's path is the source of the config and its entries.
+func (b *AllowBundle) Config() Config {
+	c := NewAllowConfig(b.Certificates)
+	c.Source = b.Path
+	for i := range c.Allow {
+		c.Allow[i].Source = b.Path
+	}
+	return c
+}
+
+// Unseen returns the certificates of the bundle which are not among the found
+// certificates, ordered by their SHA256 fingerprint as the bundle's
+// certificates are. Certificates in the bundle more than once are returned
+// once.
+func (b *AllowBundle) Unseen(founds []certificate.Found) []certificate.Found {
+	seen := make(map[[32]byte]bool, len(founds))
+	for _, f := range founds {
+		seen[f.FingerprintSha256] = true
+	}
+
+	var unseen []certificate.Found
+	for _, f := range b.Certificates {
+		if seen[f.FingerprintSha256] {
+			continue
+		}
+		seen[f.FingerprintSha256] = true
+		unseen = append(unseen, f)
+	}
+	return unseen
+}
@@ -0,0 +1,87 @@
+package validate
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestAllowBundle(t *testing.T) {
+	root, _ := generateCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Bundled Root"}}, nil, nil)
+	other, _ := generateCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Other Root"}}, nil, nil)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "roots.pem")
+	var bundle []byte
+	for _, c := range []*x509.Certificate{root, other, root} {
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	require.NoError(t, os.WriteFile(path, bundle, 0o644))
+
+	b, err := LoadAllowBundle(context.TODO(), path)
+	require.NoError(t, err)
+	require.Len(t, b.Certificates, 3)
+
+	t.Run("every certificate in the bundle should be allowed once", func(t *testing.T) {
+		config := b.Config()
+		assert.Equal(t, path, config.Source)
+		require.Len(t, config.Allow, 2)
+		// Certificates found in the same file are ordered by fingerprint.
+		rootSum := sha256.Sum256(root.Raw)
+		var rootEntry CertificateEntry
+		for _, e := range config.Allow {
+			if e.Comment == "Bundled Root" {
+				rootEntry = e
+			}
+		}
+		assert.Equal(t, hex.EncodeToString(rootSum[:]), rootEntry.Fingerprints[0].Sha256)
+		assert.Equal(t, path, rootEntry.Source)
+		assert.True(t, IsConfigValid(&config))
+	})
+
+	t.Run("bundle certificates should be allowed alongside the config", func(t *testing.T) {
+		otherSum := sha256.Sum256(other.Raw)
+		_, err := MergeConfigs(Config{
+			Version: ExpectedVersion,
+			Forbid: []CertificateEntry{{
+				Fingerprints: FingerprintList{{Sha256: hex.EncodeToString(otherSum[:])}},
+			}},
+		}, b.Config())
+		require.Error(t, err, "a bundle certificate forbidden by the config should conflict")
+
+		merged, err := MergeConfigs(Config{Version: ExpectedVersion}, b.Config())
+		require.NoError(t, err)
+		validator, err := NewValidator(merged, false)
+		require.NoError(t, err)
+		res, err := validator.Validate([]certificate.Found{{Certificate: root, FingerprintSha256: sha256.Sum256(root.Raw)}})
+		require.NoError(t, err)
+		assert.True(t, res.IsPass())
+	})
+
+	t.Run("bundle certificates not found should be unseen", func(t *testing.T) {
+		unseen := b.Unseen([]certificate.Found{{FingerprintSha256: sha256.Sum256(root.Raw)}})
+		require.Len(t, unseen, 1)
+		assert.Equal(t, other, unseen[0].Certificate)
+		assert.Equal(t, path, unseen[0].Location)
+
+		assert.Len(t, b.Unseen(nil), 2, "duplicate bundle certificates should be unseen once")
+	})
+
+	t.Run("a file without certificates should return an error", func(t *testing.T) {
+		empty := filepath.Join(dir, "empty.pem")
+		require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o644))
+		_, err := LoadAllowBundle(context.TODO(), empty)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// UnverifiableCert is a certificate for which no valid chain could be built
+// to a trusted root.
+type UnverifiableCert struct {
+	Certificate certificate.Found
+
+	// Reason describes why no chain could be built, such as the issuer being
+	// absent.
+	Reason string
+}
+
+// WithChainVerification is a functional option that configures a validator
+// to verify that every certificate which is not self-signed chains to a root,
+// using the certificate authorities found alongside it as intermediates. The
+// self-signed certificates found are trusted as roots, along with the given
+// roots, such as those of a root bundle.
+func WithChainVerification(roots []certificate.Found) Option {
+	return func(v *Validator) {
+		v.verifyChains = true
+		v.chainRoots = roots
+	}
+}
+
+// LoadRootBundle reads every certificate in the root bundle file at the given
+// path, such as a roots.pem. An error is returned if the file has no
+// certificates, as an empty bundle is almost always a mistake.
+func LoadRootBundle(ctx context.Context, path string) ([]certificate.Found, error) {
+	parsed, err := certificate.FindCertificatesInFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []certificate.Found
+	for _, f := range parsed.Found {
+		if f.Certificate != nil {
+			roots = append(roots, f)
+		}
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return roots, nil
+}
+
+// unverifiableChains returns the certificates, other than self-signed ones,
+// for which no valid chain can be built to one of the self-signed
+// certificates found or the extra roots, as of the given time. Certificates
+// found in several locations are verified once, but reported in each.
+func unverifiableChains(founds, extraRoots []certificate.Found, now time.Time) []UnverifiableCert {
+	roots := x509.NewCertPool()
+	intermediates := x509.NewCertPool()
+	for _, f := range extraRoots {
+		if f.Certificate != nil {
+			roots.AddCert(f.Certificate)
+		}
+	}
+	for _, f := range founds {
+		switch {
+		case f.Certificate == nil:
+		case f.SelfSigned:
+			roots.AddCert(f.Certificate)
+		case f.IsCA:
+			intermediates.AddCert(f.Certificate)
+		}
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	var (
+		unverifiable []UnverifiableCert
+		reasons      = make(map[[32]byte]string)
+	)
+	for _, f := range founds {
+		if f.Certificate == nil || f.SelfSigned {
+			continue
+		}
+		reason, ok := reasons[f.FingerprintSha256]
+		if !ok {
+			if _, err := f.Certificate.Verify(opts); err != nil {
+				reason = chainError(err)
+			}
+			reasons[f.FingerprintSha256] = reason
+		}
+		if reason != "" {
+			unverifiable = append(unverifiable, UnverifiableCert{Certificate: f, Reason: reason})
+		}
+	}
+	return unverifiable
+}
+
+// chainError describes why a chain could not be built, as the errors of the
+// x509 package are phrased for programs rather than people.
+func chainError(err error) string {
+	var (
+		unknown x509.UnknownAuthorityError
+		invalid x509.CertificateInvalidError
+	)
+	switch {
+	case errors.As(err, &unknown):
+		return "its issuer is not among the certificates found or the given roots"
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return "it has expired or is not yet valid, " + invalid.Detail
+	default:
+		return "verification failed: " + strings.TrimPrefix(err.Error(), "x509: ")
+	}
+}
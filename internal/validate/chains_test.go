@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestUnverifiableChains(t *testing.T) {
+	caTemplate := func(cn string) *x509.Certificate {
+		return &x509.Certificate{Subject: pkix.Name{CommonName: cn}, IsCA: true, KeyUsage: x509.KeyUsageCertSign}
+	}
+	root, rootKey := generateCertificate(t, caTemplate("Root"), nil, nil)
+	intermediate, intermediateKey := generateCertificate(t, caTemplate("Intermediate"), root, rootKey)
+	leaf, _ := generateCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "Leaf"}}, intermediate, intermediateKey)
+	otherRoot, otherRootKey := generateCertificate(t, caTemplate("Other Root"), nil, nil)
+	orphan, _ := generateCertificate(t, caTemplate("Orphan"), otherRoot, otherRootKey)
+
+	found := func(location string, cert *x509.Certificate, selfSigned bool) certificate.Found {
+		return certificate.Found{
+			Location:          location,
+			Certificate:       cert,
+			FingerprintSha256: sha256.Sum256(cert.Raw),
+			SelfSigned:        selfSigned,
+			IsCA:              cert.IsCA,
+		}
+	}
+	rootFound := found("/etc/ssl/certs/root.pem", root, true)
+	intermediateFound := found("/etc/ssl/certs/intermediate.pem", intermediate, false)
+	leafFound := found("/app/leaf.pem", leaf, false)
+	orphanFound := found("/etc/ssl/certs/orphan.pem", orphan, false)
+	now := time.Now()
+
+	t.Run("certificates chaining to a root found should be verified", func(t *testing.T) {
+		assert.Empty(t, unverifiableChains([]certificate.Found{rootFound, intermediateFound, leafFound}, nil, now))
+	})
+
+	t.Run("certificates whose issuer is missing should be reported in each location", func(t *testing.T) {
+		orphanCopy := found("/usr/share/ca-certificates/orphan.pem", orphan, false)
+		assert.Equal(t, []UnverifiableCert{
+			{Certificate: leafFound, Reason: "its issuer is not among the certificates found or the given roots"},
+			{Certificate: orphanFound, Reason: "its issuer is not among the certificates found or the given roots"},
+			{Certificate: orphanCopy, Reason: "its issuer is not among the certificates found or the given roots"},
+		}, unverifiableChains([]certificate.Found{rootFound, leafFound, orphanFound, orphanCopy}, nil, now))
+	})
+
+	t.Run("certificates should chain to the given roots", func(t *testing.T) {
+		given := found("roots.pem", otherRoot, true)
+		assert.Empty(t, unverifiableChains([]certificate.Found{orphanFound}, []certificate.Found{given}, now))
+	})
+
+	t.Run("chains should be verified as of the validator's clock", func(t *testing.T) {
+		founds := []certificate.Found{rootFound, intermediateFound, leafFound}
+		unverifiable := unverifiableChains(founds, nil, now.Add(24*time.Hour))
+		require.Len(t, unverifiable, 2)
+		assert.Contains(t, unverifiable[0].Reason, "expired")
+	})
+
+	t.Run("unverifiable certificates fail validation only when chains are verified", func(t *testing.T) {
+		founds := []certificate.Found{rootFound, leafFound}
+
+		validator, err := NewValidator(Config{}, true)
+		require.NoError(t, err)
+		r, err := validator.Validate(founds)
+		require.NoError(t, err)
+		assert.True(t, r.IsPass())
+
+		validator, err = NewValidator(Config{}, true, WithChainVerification(nil))
+		require.NoError(t, err)
+		r, err = validator.Validate(founds)
+		require.NoError(t, err)
+		require.Len(t, r.UnverifiableCertificates, 1)
+		assert.Equal(t, leafFound, r.UnverifiableCertificates[0].Certificate)
+		assert.Equal(t, []string{FindingUnverifiableChain}, r.Failures())
+		assert.Contains(t, validator.DescribeConfig(), "verifying certificates chain to a root")
+	})
+}
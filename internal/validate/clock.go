@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import "time"
+
+// Clock gives the time which a validator checks certificates' validity
+// periods, the expiry of entries, and the update times of CRLs against.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the clock of validators by default, giving the current time.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// FixedClock is a clock which always gives the same time, such as to check
+// whether an image will still pass validation on a future date.
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time { return time.Time(c) }
+
+// Option is a functional option that configures a validator.
+type Option func(*Validator)
+
+// WithClock is a functional option that configures the clock a validator
+// checks time-based rules against, instead of the current time.
+func WithClock(clock Clock) Option {
+	return func(v *Validator) {
+		if clock != nil {
+			v.clock = clock
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestWithClock(t *testing.T) {
+	cert := certificate.Found{
+		FingerprintSha256: anySHA256(),
+		Certificate: &x509.Certificate{
+			NotBefore: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:  time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	config := Config{
+		CheckExpiry:      true,
+		CheckNotYetValid: true,
+		ExpiryWarning:    30 * 24 * time.Hour,
+		Allow: []CertificateEntry{
+			{SubjectCNPattern: "*", ExpiresAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	validateAt := func(t *testing.T, now time.Time) Result {
+		validator, err := NewValidator(config, true, WithClock(FixedClock(now)))
+		require.NoError(t, err)
+		r, err := validator.Validate([]certificate.Found{cert})
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("before the certificate is valid", func(t *testing.T) {
+		r := validateAt(t, time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, []certificate.Found{cert}, r.NotYetValidCertificates)
+		assert.Empty(t, r.ExpiredEntries)
+	})
+
+	t.Run("after the entry expires", func(t *testing.T) {
+		r := validateAt(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.Empty(t, r.NotYetValidCertificates)
+		assert.Empty(t, r.ExpiredCertificates)
+		assert.Len(t, r.ExpiredEntries, 1)
+	})
+
+	t.Run("within the expiry warning window", func(t *testing.T) {
+		r := validateAt(t, time.Date(2029, 12, 15, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, []certificate.Found{cert}, r.ExpiringCertificates)
+		assert.Empty(t, r.ExpiredCertificates)
+	})
+
+	t.Run("after the certificate expires", func(t *testing.T) {
+		r := validateAt(t, time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, []certificate.Found{cert}, r.ExpiredCertificates)
+	})
+
+	t.Run("the time is described", func(t *testing.T) {
+		validator, err := NewValidator(config, true, WithClock(FixedClock(time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC))))
+		require.NoError(t, err)
+		assert.Contains(t, validator.DescribeConfig(), ", as of 2030-06-01T00:00:00Z")
+	})
+}
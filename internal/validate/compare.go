@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// Finding is a single finding of a validation result, identifying what it is
+// about so that the findings of different results can be compared.
+type Finding struct {
+	// Kind is the kind of finding, such as FindingForbidden.
+	Kind string
+	// Location is the location of the certificate the finding is about. It
+	// is empty for required certificates which are absent.
+	Location string
+	// Certificate identifies the certificate the finding is about by its
+	// SHA256 fingerprint, or for required certificates which are absent, by
+	// how their entries identify them. Partial certificates are identified
+	// by why they couldn't be parsed.
+	Certificate string
+}
+
+// ResultDiff is the difference between the findings of two results, such as
+// the results of validating the same certificates with two configs.
+type ResultDiff struct {
+	// Added are the findings of the second result which are not findings of
+	// the first.
+	Added []Finding
+	// Removed are the findings of the first result which are not findings
+	// of the second.
+	Removed []Finding
+}
+
+// ListFindings returns every finding of the result, whether or not it fails
+// validation, in order of decreasing severity. Warnings, such as expiring
+// certificates, are not findings.
+func (r *Result) ListFindings() []Finding {
+	var findings []Finding
+	addFound := func(kind string, founds ...certificate.Found) {
+		for _, f := range founds {
+			findings = append(findings, Finding{Kind: kind, Location: f.Location, Certificate: foundFingerprint(f)})
+		}
+	}
+
+	for _, f := range r.ForbiddenCertificates {
+		addFound(FindingForbidden, f.Certificate)
+	}
+	for _, rc := range r.RevokedCertificates {
+		addFound(FindingRevoked, rc.Certificate)
+	}
+	for _, e := range r.RequiredButAbsent {
+		findings = append(findings, Finding{Kind: FindingRequired, Certificate: findingEntry(e)})
+	}
+	for _, group := range r.RequiredGroupsUnsatisfied {
+		findings = append(findings, Finding{Kind: FindingRequiredAnyOf, Certificate: groupFinding(group)})
+	}
+	addFound(FindingNotAllowed, r.NotAllowedCertificates...)
+	addFound(FindingExpired, r.ExpiredCertificates...)
+	addFound(FindingNotYetValid, r.NotYetValidCertificates...)
+	addFound(FindingOverlongValidity, r.OverlongValidityCertificates...)
+	addFound(FindingWeakSignature, r.WeakSignatureCertificates...)
+	addFound(FindingWeakKey, r.WeakKeyCertificates...)
+	addFound(FindingUnhandledCriticalExtension, r.UnhandledCriticalExtensionCertificates...)
+	addFound(FindingInvalidAnchor, r.InvalidAnchorCertificates...)
+	for _, u := range r.UnverifiableCertificates {
+		addFound(FindingUnverifiableChain, u.Certificate)
+	}
+	for _, p := range r.PartialCertificates {
+		findings = append(findings, partialFinding(p))
+	}
+
+	return findings
+}
+
+// CompareResults returns the findings added and removed between two results.
+func CompareResults(before, after Result) ResultDiff {
+	return ResultDiff{
+		Added:   subtractFindings(after.ListFindings(), before.ListFindings()),
+		Removed: subtractFindings(before.ListFindings(), after.ListFindings()),
+	}
+}
+
+// subtractFindings returns the findings of a which are not findings of b,
+// in the order of a.
+func subtractFindings(a, b []Finding) []Finding {
+	inB := make(map[Finding]bool, len(b))
+	for _, f := range b {
+		inB[f] = true
+	}
+	var out []Finding
+	for _, f := range a {
+		if !inB[f] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func foundFingerprint(f certificate.Found) string {
+	return entryFingerprint{kind: "SHA256", value: hex.EncodeToString(f.FingerprintSha256[:])}.String()
+}
+
+// findingEntry describes a required entry for a finding. The comment is left
+// out, so that rewording it doesn't change the finding.
+func findingEntry(e CertificateEntry) string {
+	e.Comment = ""
+	return describeEntry(e)
+}
+
+// groupFinding describes a group of required entries, of which none were
+// found, for a finding.
+func groupFinding(group []CertificateEntry) string {
+	descriptions := make([]string, len(group))
+	for i, e := range group {
+		descriptions[i] = findingEntry(e)
+	}
+	return "one of " + strings.Join(descriptions, ", ")
+}
+
+func partialFinding(p certificate.Partial) Finding {
+	return Finding{Kind: FindingPartial, Location: p.Location, Certificate: "partial certificate: " + p.Reason}
+}
@@ -0,0 +1,53 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+func TestCompareResults(t *testing.T) {
+	keptSHA256 := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+	droppedSHA256 := "edfa7caf7f1274d54bacec91e21a5b1a04a7b94bf197f5c92070b8de148d9b37"
+	requiredSHA256 := "4749c6f4aeb2e06f6b71129a9697219e97166db4a4f7a1e0b2c1c49b1a1f7a5e"
+
+	kept := certificate.Found{Location: "kept", FingerprintSha256: checksum.MustParseSHA256(keptSHA256)}
+	dropped := certificate.Found{Location: "dropped", FingerprintSha256: checksum.MustParseSHA256(droppedSHA256)}
+	founds := []certificate.Found{kept, dropped}
+
+	validate := func(config Config) Result {
+		validator, err := NewValidator(config, true)
+		require.NoError(t, err)
+		r, err := validator.Validate(founds)
+		require.NoError(t, err)
+		return r
+	}
+
+	before := validate(Config{
+		Forbid: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: keptSHA256}}, Comment: "old comment"},
+			{Fingerprints: FingerprintList{{Sha256: droppedSHA256}}},
+		},
+	})
+	after := validate(Config{
+		Forbid: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: keptSHA256}}, Comment: "new comment"},
+		},
+		Require: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: requiredSHA256}}, Comment: "corporate root"},
+		},
+	})
+
+	assert.Equal(t, ResultDiff{
+		Added:   []Finding{{Kind: FindingRequired, Certificate: "SHA256 fingerprint " + requiredSHA256}},
+		Removed: []Finding{{Kind: FindingForbidden, Location: "dropped", Certificate: "SHA256 fingerprint " + droppedSHA256}},
+	}, CompareResults(before, after))
+
+	t.Run("Identical results have no differences", func(t *testing.T) {
+		assert.Equal(t, ResultDiff{}, CompareResults(after, after))
+	})
+}
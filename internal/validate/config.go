@@ -3,31 +3,263 @@
 package validate
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/util/checksum"
 )
 
 var ExpectedVersion = "1"
 
 type Config struct {
-	Version string             `json:"version"`
-	Allow   []CertificateEntry `json:"allow,omitempty"`
-	Forbid  []CertificateEntry `json:"forbid,omitempty"`
-	Require []CertificateEntry `json:"require,omitempty"`
+	// Source describes where the config was loaded from, such as its file
+	// name. It is not part of the config file.
+	Source string `json:"-" yaml:"-"`
+
+	Version string             `json:"version" yaml:"version"`
+	Allow   []CertificateEntry `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Forbid  []CertificateEntry `json:"forbid,omitempty" yaml:"forbid,omitempty"`
+	Require []CertificateEntry `json:"require,omitempty" yaml:"require,omitempty"`
+
+	// RequireAnyOf are groups of certificates, of which at least one of each
+	// group is required, such as redundant trust anchors. Like required
+	// certificates, these are implicitly allowed.
+	RequireAnyOf [][]CertificateEntry `json:"requireAnyOf,omitempty" yaml:"requireAnyOf,omitempty"`
+
+	// Pkcs12Passwords are candidate passwords tried when decoding password
+	// protected PKCS#12 files found in the image.
+	Pkcs12Passwords []string `json:"pkcs12Passwords,omitempty" yaml:"pkcs12Passwords,omitempty"`
+
+	// CheckExpiry enables failing validation on certificates which have
+	// expired.
+	CheckExpiry bool `json:"checkExpiry,omitempty" yaml:"checkExpiry,omitempty"`
+
+	// ExpiryWarning is the window before expiry in which a certificate is
+	// reported as expiring. Expiring certificates are a warning, and do not
+	// fail validation. Only used if CheckExpiry is enabled.
+	ExpiryWarning time.Duration `json:"expiryWarning,omitempty" yaml:"expiryWarning,omitempty"`
+
+	// CheckNotYetValid enables failing validation on certificates whose
+	// validity period has not yet started, which suggests clock skew or a
+	// forged certificate.
+	CheckNotYetValid bool `json:"checkNotYetValid,omitempty" yaml:"checkNotYetValid,omitempty"`
+
+	// MaxValidityDuration is the longest permitted validity period of a
+	// certificate, from its NotBefore to its NotAfter time. If zero, validity
+	// periods are not checked.
+	MaxValidityDuration time.Duration `json:"maxValidityDuration,omitempty" yaml:"maxValidityDuration,omitempty"`
+
+	// MaxValidityExemptSelfSigned exempts self-signed certificates, which are
+	// usually long-lived roots, from MaxValidityDuration.
+	MaxValidityExemptSelfSigned bool `json:"maxValidityExemptSelfSigned,omitempty" yaml:"maxValidityExemptSelfSigned,omitempty"`
+
+	// ForbidWeakSignatureAlgorithms enables failing validation on
+	// certificates signed using a weak algorithm, such as SHA-1 or MD5.
+	// Self-signed certificates are exempt, since their signature is not
+	// relied upon, unless WeakSignatureIncludeSelfSigned is set.
+	ForbidWeakSignatureAlgorithms bool `json:"forbidWeakSignatureAlgorithms,omitempty" yaml:"forbidWeakSignatureAlgorithms,omitempty"`
+
+	// WeakSignatureIncludeSelfSigned includes self-signed certificates when
+	// forbidding weak signature algorithms.
+	WeakSignatureIncludeSelfSigned bool `json:"weakSignatureIncludeSelfSigned,omitempty" yaml:"weakSignatureIncludeSelfSigned,omitempty"`
+
+	// MinRSAKeySize is the minimum permitted size, in bits, of RSA public
+	// keys. If zero, RSA key sizes are not checked.
+	MinRSAKeySize int `json:"minRSAKeySize,omitempty" yaml:"minRSAKeySize,omitempty"`
+
+	// AllowedECDSACurves are the names of the permitted ECDSA curves, such as
+	// "P-256". If empty, ECDSA curves are not checked.
+	AllowedECDSACurves []string `json:"allowedECDSACurves,omitempty" yaml:"allowedECDSACurves,omitempty"`
+
+	// ForbidUnhandledCriticalExtensions enables failing validation on
+	// certificates with an extension marked critical which is not understood,
+	// and so which a verifier must reject.
+	ForbidUnhandledCriticalExtensions bool `json:"forbidUnhandledCriticalExtensions,omitempty" yaml:"forbidUnhandledCriticalExtensions,omitempty"`
+
+	// WarnSuspiciousSANs enables warning about certificates with subject
+	// alternative names only meaningful on a private network, such as RFC
+	// 1918 IP addresses or names under .local, which suggest an internal
+	// certificate was leaked into the image.
+	WarnSuspiciousSANs bool `json:"warnSuspiciousSANs,omitempty" yaml:"warnSuspiciousSANs,omitempty"`
+
+	// WarnKeyIdAnomalies enables warning about certificate authorities
+	// without a subject key identifier, and about certificates with
+	// different keys sharing a subject key identifier, either of which can
+	// break chain building.
+	WarnKeyIdAnomalies bool `json:"warnKeyIdAnomalies,omitempty" yaml:"warnKeyIdAnomalies,omitempty"`
+
+	// AnchorPaths are glob patterns, in the syntax of path.Match, of the
+	// paths of trust stores, such as "/etc/ssl/certs". Certificates found
+	// in these paths are trusted as roots, so must be valid certificate
+	// authorities. A pattern matching a directory matches every file below
+	// it. If empty, certificates are not checked.
+	AnchorPaths []string `json:"anchorPaths,omitempty" yaml:"anchorPaths,omitempty"`
 }
 
 type CertificateEntry struct {
-	Fingerprints CertificateFingerprints `json:"fingerprints"`
-	Comment      string                  `json:"comment,omitempty"`
+	// Source describes where the entry was loaded from, such as the file name
+	// of its config. It is not part of the config file.
+	Source string `json:"-" yaml:"-"`
+
+	Comment string `json:"comment,omitempty" yaml:"comment,omitempty"`
+
+	// Fingerprints identify the certificate. Several may be listed, such as
+	// for a root across its reissuances, in which case a certificate
+	// matching any of them matches the entry.
+	Fingerprints FingerprintList `json:"fingerprints" yaml:"fingerprints,omitempty"`
+
+	// SubjectCN matches certificates whose subject common name is exactly
+	// this value.
+	SubjectCN string `json:"subjectCN,omitempty" yaml:"subjectCN,omitempty"`
+
+	// SubjectCNPattern matches certificates whose subject common name matches
+	// this glob pattern, such as "*.corp.internal".
+	SubjectCNPattern string `json:"subjectCNPattern,omitempty" yaml:"subjectCNPattern,omitempty"`
+
+	// IssuerDN matches certificates whose issuer distinguished name, in the
+	// form "CN=Example CA,O=Example,C=US", is exactly this value.
+	IssuerDN string `json:"issuerDN,omitempty" yaml:"issuerDN,omitempty"`
+
+	// SerialNumber matches certificates with this serial number, either in
+	// decimal, or in hex with a "0x" prefix or bytes separated by colons, as
+	// given by advisories. Serial numbers are only unique to their issuer, so
+	// it must be given with IssuerDN.
+	SerialNumber string `json:"serialNumber,omitempty" yaml:"serialNumber,omitempty"`
+
+	// SubjectRegex matches certificates whose subject distinguished name, in
+	// the form "CN=Example CA,O=Example,C=US", matches this regular
+	// expression. The expression is not anchored, so may match any part of
+	// the subject.
+	SubjectRegex string `json:"subjectRegex,omitempty" yaml:"subjectRegex,omitempty"`
+
+	// SANRegex matches certificates with a DNS name, IP address, or email
+	// address subject alternative name matching this regular expression. The
+	// expression is not anchored, so may match any part of the name.
+	SANRegex string `json:"sanRegex,omitempty" yaml:"sanRegex,omitempty"`
+
+	// PolicyOID matches certificates asserting the certificate policy with
+	// this object identifier, in dotted decimal form such as
+	// "2.23.140.1.1" for extended validation. Certificates with several
+	// policies match if any of them is this one, and certificates without
+	// policies never match.
+	PolicyOID string `json:"policyOID,omitempty" yaml:"policyOID,omitempty"`
+
+	// ExpiresAt is when an allow or forbid entry expires, after which it is
+	// ignored and reported as expired, so that temporary exceptions don't
+	// outlive their purpose. If zero, the entry doesn't expire. It is
+	// omitted from JSON, which can't omit a zero time, and is instead
+	// reported with expired entries.
+	ExpiresAt time.Time `json:"-" yaml:"expiresAt,omitempty"`
+
+	// Scope limits an allow or forbid entry to certificates acting as a
+	// root, intermediate, or leaf, such as to forbid a certificate only when
+	// it is trusted as a root. If empty, the entry matches any certificate.
+	Scope string `json:"scope,omitempty" yaml:"scope,omitempty"`
+}
+
+// The scopes a certificate entry may be limited to. Roots are self-signed,
+// intermediates are other certificate authorities, and leaves are every
+// other certificate.
+const (
+	ScopeAny          = "any"
+	ScopeRoot         = "root"
+	ScopeIntermediate = "intermediate"
+	ScopeLeaf         = "leaf"
+)
+
+// Scopes are the scopes a certificate entry may be limited to.
+var Scopes = []string{ScopeAny, ScopeRoot, ScopeIntermediate, ScopeLeaf}
+
+// CertificateScope returns the scope of the found certificate; a root if it
+// is self-signed, an intermediate if it is another certificate authority,
+// and otherwise a leaf.
+func CertificateScope(f certificate.Found) string {
+	switch {
+	case f.SelfSigned:
+		return ScopeRoot
+	case f.IsCA:
+		return ScopeIntermediate
+	default:
+		return ScopeLeaf
+	}
+}
+
+// inScope returns true if the entry's scope includes the found certificate.
+func (ce CertificateEntry) inScope(f certificate.Found) bool {
+	return ce.Scope == "" || ce.Scope == ScopeAny || ce.Scope == CertificateScope(f)
+}
+
+// hasFingerprint returns true if the entry identifies a certificate by a
+// fingerprint.
+func (ce CertificateEntry) hasFingerprint() bool {
+	return len(entryFingerprints(ce)) > 0
+}
+
+// Identity describes how the entry identifies certificates, either by a
+// fingerprint, such as `SHA256 fingerprint 01be...`, or by attributes, such
+// as `subject CN "Acme Root CA"`, so that it's clear which a failure is about.
+func (ce CertificateEntry) Identity() string {
+	var parts []string
+	if fps := entryFingerprints(ce); len(fps) > 0 {
+		alternatives := make([]string, len(fps))
+		for i, fp := range fps {
+			alternatives[i] = fp.String()
+		}
+		parts = append(parts, strings.Join(alternatives, " or "))
+	}
+	for _, attr := range []struct {
+		name  string
+		value string
+	}{
+		{name: "subject CN", value: ce.SubjectCN},
+		{name: "subject CN pattern", value: ce.SubjectCNPattern},
+		{name: "issuer DN", value: ce.IssuerDN},
+		{name: "serial number", value: ce.SerialNumber},
+		{name: "subject regex", value: ce.SubjectRegex},
+		{name: "SAN regex", value: ce.SANRegex},
+		{name: "policy OID", value: ce.PolicyOID},
+	} {
+		if attr.value != "" {
+			parts = append(parts, fmt.Sprintf("%s %q", attr.name, attr.value))
+		}
+	}
+	return strings.Join(parts, " and ")
+}
+
+// hasAttributes returns true if the entry matches certificates by their
+// attributes, rather than a fingerprint.
+func (ce CertificateEntry) hasAttributes() bool {
+	return ce.SubjectCN != "" || ce.SubjectCNPattern != "" || ce.IssuerDN != "" || ce.SerialNumber != "" || ce.SubjectRegex != "" || ce.SANRegex != "" || ce.PolicyOID != ""
 }
 
 type CertificateFingerprints struct {
-	Sha1   string `json:"sha1,omitempty"`
-	Sha256 string `json:"sha256,omitempty"`
+	// Md5 is the MD5 fingerprint of the certificate. MD5 is insecure, and is
+	// only supported for matching against externally supplied hashes.
+	Md5 string `json:"md5,omitempty" yaml:"md5,omitempty"`
+
+	Sha1   string `json:"sha1,omitempty" yaml:"sha1,omitempty"`
+	Sha256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+
+	// Sha256Prefix is a prefix of the SHA-256 fingerprint of the certificate,
+	// such as those given in advisories, and matches every certificate whose
+	// fingerprint starts with it. Short prefixes may match unrelated
+	// certificates.
+	Sha256Prefix string `json:"sha256Prefix,omitempty" yaml:"sha256Prefix,omitempty"`
+	Sha512       string `json:"sha512,omitempty" yaml:"sha512,omitempty"`
+
+	// SpkiSha256 is the SHA-256 digest of the certificate's
+	// SubjectPublicKeyInfo, which survives the certificate being reissued with
+	// the same key.
+	SpkiSha256 string `json:"spkiSha256,omitempty" yaml:"spkiSha256,omitempty"`
 }
 
 func LoadConfig(fileName string) (*Config, error) {
@@ -35,18 +267,63 @@ func LoadConfig(fileName string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseConfig(fileName, b)
+}
+
+// parseConfig parses the contents of a config, recording where it was loaded
+// from as its source.
+func parseConfig(source string, b []byte) (*Config, error) {
 	var contents map[string]interface{}
-	err = yaml.Unmarshal(b, &contents)
+	err := yaml.Unmarshal(b, &contents)
 	if err != nil {
 		return nil, err
 	}
-	if contents["version"].(string) != ExpectedVersion {
-		return nil, errors.New("Unsupported config version, expected " + ExpectedVersion + ", found" + contents["version"].(string))
+	version, _ := contents["version"].(string)
+	if version != ExpectedVersion {
+		return nil, errors.New("Unsupported config version, expected " + ExpectedVersion + ", found " + fmt.Sprintf("%q", version))
 	}
 
-	var c Config
-	err = yaml.Unmarshal(b, &c)
-	return &c, err
+	c := Config{Source: source}
+	if err := yaml.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	for _, list := range append([][]CertificateEntry{c.Allow, c.Forbid, c.Require}, c.RequireAnyOf...) {
+		for i := range list {
+			list[i].Source = source
+		}
+	}
+	return &c, nil
+}
+
+// NewAllowConfig returns a config allowing every one of the given
+// certificates, identified by their SHA256 fingerprints. Each entry's comment
+// is the certificate's subject common name, or its full subject if it has no
+// common name. Certificates found in several locations are allowed once.
+func NewAllowConfig(founds []certificate.Found) Config {
+	config := Config{Version: ExpectedVersion}
+	seen := make(map[[32]byte]bool)
+	for _, f := range founds {
+		if seen[f.FingerprintSha256] {
+			continue
+		}
+		seen[f.FingerprintSha256] = true
+
+		var comment string
+		if f.Certificate != nil {
+			comment = f.Certificate.Subject.CommonName
+			if comment == "" {
+				comment = f.Certificate.Subject.String()
+			}
+		}
+
+		config.Allow = append(config.Allow, CertificateEntry{
+			Comment: comment,
+			Fingerprints: FingerprintList{{
+				Sha256: hex.EncodeToString(f.FingerprintSha256[:]),
+			}},
+		})
+	}
+	return config
 }
 
 func stderr(s string) {
@@ -57,11 +334,106 @@ func stderr(s string) {
 }
 
 func IsConfigValid(config *Config) bool {
-	isValid := true
-	for _, list := range []struct {
-		list []CertificateEntry
-		name string
-	}{
+	problems := entryProblems(config)
+	for _, p := range problems {
+		stderr(p)
+	}
+	return len(problems) == 0
+}
+
+// ConfigProblems returns a description of every problem with the given
+// config. This includes the structural problems reported by IsConfigValid,
+// along with malformed fingerprints and subject patterns, which otherwise are
+// only found when constructing a Validator.
+func ConfigProblems(config *Config) []string {
+	problems := entryProblems(config)
+	for _, list := range configLists(config) {
+		for i, ce := range list.list {
+			for _, f := range ce.Fingerprints {
+				for _, fp := range []struct {
+					value string
+					name  string
+					parse func(string) error
+				}{
+					{value: f.Md5, name: "MD5", parse: func(s string) error { _, err := checksum.ParseMD5(s); return err }},
+					{value: f.Sha1, name: "SHA1", parse: func(s string) error { _, err := checksum.ParseSHA1(s); return err }},
+					{value: f.Sha256, name: "SHA256", parse: func(s string) error { _, err := checksum.ParseSHA256(s); return err }},
+					{value: f.Sha256Prefix, name: "SHA256 prefix", parse: func(s string) error { _, err := parseSHA256Prefix(s); return err }},
+					{value: f.Sha512, name: "SHA512", parse: func(s string) error { _, err := checksum.ParseSHA512(s); return err }},
+					{value: f.SpkiSha256, name: "SPKI SHA256", parse: func(s string) error { _, err := checksum.ParseSHA256(s); return err }},
+				} {
+					if fp.value == "" {
+						continue
+					}
+					if err := fp.parse(fp.value); err != nil {
+						problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has an invalid %s fingerprint %q: %s", i, list.name, fp.name, fp.value, err))
+					}
+				}
+			}
+			if ce.hasAttributes() {
+				if _, err := newAttributeMatcher(ce); err != nil {
+					problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has invalid attributes: %s", i, list.name, err))
+				}
+			}
+		}
+	}
+	for _, pattern := range config.AnchorPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problems = append(problems, fmt.Sprintf("Anchor path %q is an invalid pattern: %s", pattern, err))
+		}
+	}
+	for _, fp := range allowForbidConflicts(config) {
+		problems = append(problems, fmt.Sprintf("Certificate with %s is both allowed and forbidden", fp))
+	}
+	return problems
+}
+
+// allowForbidConflicts returns every fingerprint which is forbidden, but is
+// also allowed or required, which almost always indicates a mistake, as the
+// forbid list silently takes precedence. Fingerprints are only compared with
+// fingerprints of the same kind, and entries scoped to different roles never
+// conflict.
+func allowForbidConflicts(config *Config) []entryFingerprint {
+	allowed := make(map[string][]string)
+	for _, list := range append([][]CertificateEntry{config.Allow, config.Require}, config.RequireAnyOf...) {
+		for _, e := range list {
+			for _, fp := range entryFingerprints(e) {
+				allowed[fp.key()] = append(allowed[fp.key()], e.Scope)
+			}
+		}
+	}
+	var conflicts []entryFingerprint
+	for _, e := range config.Forbid {
+		for _, fp := range entryFingerprints(e) {
+			for _, scope := range allowed[fp.key()] {
+				if scopesOverlap(scope, e.Scope) {
+					conflicts = append(conflicts, fp)
+					break
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// scopesOverlap returns true if a certificate could be in both scopes.
+func scopesOverlap(a, b string) bool {
+	if a == "" || a == ScopeAny || b == "" || b == ScopeAny {
+		return true
+	}
+	return a == b
+}
+
+type configList struct {
+	list []CertificateEntry
+	name string
+	// required is true if the list holds required certificates, which may
+	// not expire.
+	required bool
+}
+
+func configLists(config *Config) []configList {
+	lists := []configList{
 		{
 			list: config.Allow,
 			name: "allow",
@@ -71,20 +443,86 @@ func IsConfigValid(config *Config) bool {
 			name: "forbid",
 		},
 		{
-			list: config.Require,
-			name: "require",
+			list:     config.Require,
+			name:     "require",
+			required: true,
 		},
-	} {
+	}
+	for g, group := range config.RequireAnyOf {
+		lists = append(lists, configList{
+			list:     group,
+			name:     fmt.Sprintf("requireAnyOf group %d", g),
+			required: true,
+		})
+	}
+	return lists
+}
+
+// entryProblems returns a description of every structural problem with the
+// entries of the given config.
+func entryProblems(config *Config) []string {
+	var problems []string
+	for g, group := range config.RequireAnyOf {
+		if len(group) == 0 {
+			problems = append(problems, fmt.Sprintf("Group at position %d in requireAnyOf list is empty. A group must contain at least one certificate.", g))
+		}
+	}
+	for _, list := range configLists(config) {
+		for i, ce := range list.list {
+			for j, f := range ce.Fingerprints {
+				numFingerprints := len(fingerprintValues(f))
+				if numFingerprints > 1 {
+					problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has more than one of MD5, SHA1, SHA256, SHA256 prefix, SHA512, and SPKI SHA256 fingerprints. Only one type of fingerprint is permitted on a certificate.", i, list.name))
+				} else if numFingerprints == 0 && len(ce.Fingerprints) > 1 {
+					problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has no fingerprint at position %d of its fingerprints.", i, list.name, j))
+				}
+			}
+			if ce.hasFingerprint() && ce.hasAttributes() {
+				problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has both a fingerprint and subject or issuer attributes. A certificate is identified by either, not both.", i, list.name))
+			} else if !ce.hasFingerprint() && !ce.hasAttributes() {
+				problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has no fingerprints. A fingerprint is required to identify the certificate.", i, list.name))
+			}
+			if ce.SerialNumber != "" && ce.IssuerDN == "" {
+				problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has a serial number without an issuer DN. Serial numbers are only unique to their issuer, so both are required.", i, list.name))
+			}
+			if list.required && !ce.ExpiresAt.IsZero() {
+				problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has an expiry. Only allow and forbid entries may expire.", i, list.name))
+			}
+			if ce.Scope != "" {
+				if list.required && ce.Scope != ScopeAny {
+					problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has a scope. Only allow and forbid entries may be scoped.", i, list.name))
+				} else if !validScope(ce.Scope) {
+					problems = append(problems, fmt.Sprintf("Entry at position %d in %s list has an unknown scope %q, expected one of %s.", i, list.name, ce.Scope, strings.Join(Scopes, ", ")))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+func validScope(scope string) bool {
+	for _, s := range Scopes {
+		if scope == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigWarnings returns a description of every part of the given config
+// which is valid, but likely to behave unexpectedly.
+func ConfigWarnings(config *Config) []string {
+	var warnings []string
+	for _, list := range configLists(config) {
 		for i, ce := range list.list {
-			f := ce.Fingerprints
-			if f.Sha1 == "" && f.Sha256 == "" {
-				isValid = false
-				stderr(fmt.Sprintf("Entry at position %d in %s list has no fingerprints. A fingerprint is required to identify the certificate.", i, list.name))
-			} else if f.Sha1 != "" && f.Sha256 != "" {
-				isValid = false
-				stderr(fmt.Sprintf("Entry at position %d in %s list has both SHA1 and SHA256 fingerprints. Only one type of fingerprint is permitted on a certificate.", i, list.name))
+			for _, f := range ce.Fingerprints {
+				// Prefixes are matched without separators, so only the hex
+				// digits are counted.
+				if p := f.Sha256Prefix; p != "" && len(checksum.Normalize(p)) < minSafeSHA256PrefixLength {
+					warnings = append(warnings, fmt.Sprintf("Entry at position %d in %s list has a SHA256 prefix %q shorter than %d characters, which may match unrelated certificates.", i, list.name, p, minSafeSHA256PrefixLength))
+				}
 			}
 		}
 	}
-	return isValid
+	return warnings
 }
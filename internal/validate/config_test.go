@@ -0,0 +1,244 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".paranoia.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`version: "1"
+checkExpiry: true
+expiryWarning: 720h
+allow:
+  - comment: "ISRG X1 Root"
+    fingerprints:
+      sha256: 96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6
+    expiresAt: 2030-06-30
+`), 0600))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Config{
+		Source:        path,
+		Version:       "1",
+		CheckExpiry:   true,
+		ExpiryWarning: time.Hour * 720,
+		Allow: []CertificateEntry{
+			{
+				Source:  path,
+				Comment: "ISRG X1 Root",
+				Fingerprints: FingerprintList{{
+					Sha256: "96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6",
+				}},
+				ExpiresAt: time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}, config)
+}
+
+func TestLoadConfig_FingerprintList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".paranoia.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`version: "1"
+forbid:
+  - comment: "Example Root, across reissuances"
+    fingerprints:
+      - sha256: 96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6
+      - spkiSha256: 01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413
+`), 0600))
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, config.Forbid, 1)
+	assert.Equal(t, FingerprintList{
+		{Sha256: "96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6"},
+		{SpkiSha256: "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"},
+	}, config.Forbid[0].Fingerprints)
+	assert.Empty(t, ConfigProblems(config))
+
+	// A single set of fingerprints is written as a mapping, as before lists
+	// were supported, and a list as a list.
+	b, err := yaml.Marshal(FingerprintList{{Sha1: "abcd"}})
+	require.NoError(t, err)
+	assert.Equal(t, "sha1: abcd\n", string(b))
+	b, err = yaml.Marshal(config.Forbid[0].Fingerprints)
+	require.NoError(t, err)
+	assert.Equal(t, "- sha256: 96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6\n- spkiSha256: 01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413\n", string(b))
+
+	b, err = json.Marshal(FingerprintList{{Sha1: "abcd"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sha1": "abcd"}`, string(b))
+	var fingerprints FingerprintList
+	require.NoError(t, json.Unmarshal([]byte(`[{"sha1": "abcd"}, {"md5": "ef01"}]`), &fingerprints))
+	assert.Equal(t, FingerprintList{{Sha1: "abcd"}, {Md5: "ef01"}}, fingerprints)
+}
+
+func TestNewAllowConfig(t *testing.T) {
+	cert, _ := generateCertificate(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Example Root"},
+	}, nil, nil)
+	noCN := &x509.Certificate{
+		Raw:     []byte("no common name"),
+		Subject: pkix.Name{Organization: []string{"Example"}},
+	}
+
+	founds := []certificate.Found{
+		{Location: "/etc/ssl/cert.pem", Certificate: cert, FingerprintSha256: sha256.Sum256(cert.Raw)},
+		{Location: "/usr/share/cert.pem", Certificate: cert, FingerprintSha256: sha256.Sum256(cert.Raw)},
+		{Location: "/etc/ssl/other.pem", Certificate: noCN, FingerprintSha256: sha256.Sum256(noCN.Raw)},
+	}
+
+	config := NewAllowConfig(founds)
+	require.Len(t, config.Allow, 2)
+	assert.Equal(t, "Example Root", config.Allow[0].Comment)
+	assert.Equal(t, "O=Example", config.Allow[1].Comment)
+
+	// The config must round trip through a file, and allow every certificate.
+	b, err := yaml.Marshal(&config)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), ".paranoia.yaml")
+	require.NoError(t, os.WriteFile(path, b, 0600))
+
+	loaded, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Allow, 2)
+	for i, e := range loaded.Allow {
+		assert.Equal(t, config.Allow[i].Comment, e.Comment)
+		assert.Equal(t, config.Allow[i].Fingerprints, e.Fingerprints)
+	}
+
+	validator, err := NewValidator(*loaded, false)
+	require.NoError(t, err)
+	res, err := validator.Validate(founds)
+	require.NoError(t, err)
+	assert.True(t, res.IsPass())
+}
+
+func TestLoadConfig_Version(t *testing.T) {
+	for name, contents := range map[string]string{
+		"missing version":     "allow: []\n",
+		"unsupported version": "version: \"2\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".paranoia.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+
+			_, err := LoadConfig(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestConfigProblems(t *testing.T) {
+	config := &Config{
+		Allow: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: "96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6"}}},
+			{Fingerprints: FingerprintList{{Sha1: "not hex"}}},
+			{SubjectCNPattern: "[invalid"},
+		},
+		Forbid: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: "abcd"}}},
+		},
+		Require: []CertificateEntry{
+			{Comment: "no fingerprint"},
+			{Fingerprints: FingerprintList{{Sha256: "96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6"}}, ExpiresAt: time.Now()},
+		},
+		RequireAnyOf: [][]CertificateEntry{
+			{},
+			{{SubjectCN: "Example Root"}, {SubjectCNPattern: "[invalid"}},
+		},
+	}
+
+	problems := ConfigProblems(config)
+	require.Len(t, problems, 7)
+	assert.Contains(t, problems[0], "Group at position 0 in requireAnyOf list is empty")
+	assert.Contains(t, problems[1], "Entry at position 0 in require list has no fingerprints")
+	assert.Contains(t, problems[2], "Entry at position 1 in require list has an expiry")
+	assert.Contains(t, problems[3], "Entry at position 1 in allow list has an invalid SHA1 fingerprint")
+	assert.Contains(t, problems[4], "Entry at position 2 in allow list has invalid attributes")
+	assert.Contains(t, problems[5], "Entry at position 0 in forbid list has an invalid SHA256 fingerprint")
+	assert.Contains(t, problems[6], "Entry at position 1 in requireAnyOf group 1 list has invalid attributes")
+}
+
+func TestConfigProblems_FingerprintList(t *testing.T) {
+	config := &Config{
+		Allow: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha1: "4ae840b224dccf3af3ac0827be5f885eded18a17"}, {}}},
+			{Fingerprints: FingerprintList{{Sha1: "4ae840b224dccf3af3ac0827be5f885eded18a17"}, {Sha256: "abcd"}}},
+			{Fingerprints: FingerprintList{{Sha1: "4ae840b224dccf3af3ac0827be5f885eded18a17"}}, SubjectCN: "Example Root"},
+		},
+	}
+
+	problems := ConfigProblems(config)
+	require.Len(t, problems, 3)
+	assert.Contains(t, problems[0], "Entry at position 0 in allow list has no fingerprint at position 1 of its fingerprints")
+	assert.Contains(t, problems[1], "Entry at position 2 in allow list has both a fingerprint and subject or issuer attributes")
+	assert.Contains(t, problems[2], "Entry at position 1 in allow list has an invalid SHA256 fingerprint")
+}
+
+func TestConfigProblems_Scope(t *testing.T) {
+	config := &Config{
+		Allow: []CertificateEntry{
+			{SubjectCN: "Example Root", Scope: ScopeRoot},
+			{SubjectCN: "Example Root", Scope: "roots"},
+		},
+		Require: []CertificateEntry{
+			{SubjectCN: "Example Root", Scope: ScopeAny},
+			{SubjectCN: "Example Root", Scope: ScopeLeaf},
+		},
+	}
+
+	problems := ConfigProblems(config)
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0], `Entry at position 1 in allow list has an unknown scope "roots"`)
+	assert.Contains(t, problems[1], "Entry at position 1 in require list has a scope")
+}
+
+func TestConfigProblems_SerialNumber(t *testing.T) {
+	config := &Config{
+		Forbid: []CertificateEntry{
+			{IssuerDN: "CN=Example CA", SerialNumber: "0x0a3f"},
+			{SerialNumber: "2623"},
+			{IssuerDN: "CN=Example CA", SerialNumber: "-1"},
+		},
+	}
+
+	problems := ConfigProblems(config)
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0], "Entry at position 1 in forbid list has a serial number without an issuer DN")
+	assert.Contains(t, problems[1], `Entry at position 2 in forbid list has invalid attributes: invalid serial number "-1"`)
+}
+
+func TestConfigWarnings(t *testing.T) {
+	config := &Config{
+		Allow: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256Prefix: "96bcec06"}}},
+			{Fingerprints: FingerprintList{{Sha256Prefix: "ab:cd:ef:01:23:45"}}},
+		},
+		Forbid: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256Prefix: "abcd"}}},
+			{Fingerprints: FingerprintList{{Sha256Prefix: "ab:cd:ef"}}},
+		},
+	}
+
+	assert.Empty(t, ConfigProblems(config))
+	warnings := ConfigWarnings(config)
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "Entry at position 0 in forbid list has a SHA256 prefix \"abcd\" shorter than 8 characters")
+	assert.Contains(t, warnings[1], "Entry at position 1 in forbid list has a SHA256 prefix \"ab:cd:ef\" shorter than 8 characters")
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	encpem "encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// RevocationList is a certificate revocation list, and where it was loaded
+// from.
+type RevocationList struct {
+	// Source describes where the CRL was loaded from, such as its file name
+	// or URL.
+	Source string
+
+	List *x509.RevocationList
+}
+
+// RevokedCert is a certificate which was revoked by its issuer.
+type RevokedCert struct {
+	Certificate certificate.Found
+
+	// RevokedAt is when the issuer revoked the certificate.
+	RevokedAt time.Time
+
+	// Source describes where the revocation came from, such as the location
+	// of a CRL or the URL of an OCSP responder.
+	Source string
+}
+
+// revocation is an entry of a CRL which revokes a certificate.
+type revocation struct {
+	authorityKeyID []byte
+	revokedAt      time.Time
+	source         string
+}
+
+// LoadRevocationList loads a PEM or DER encoded CRL from a file, or fetches
+// it if the location is a HTTP or HTTPS URL.
+func LoadRevocationList(ctx context.Context, location string) (*RevocationList, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if isURL(location) {
+		b, _, err = fetch(ctx, location, nil)
+	} else {
+		b, err = ioutil.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Contains(b, []byte("-----BEGIN X509 CRL-----")) {
+		block, _ := encpem.Decode(b)
+		if block == nil || block.Type != "X509 CRL" {
+			return nil, fmt.Errorf("data looks like a PEM encoded CRL, but cannot be decoded")
+		}
+		b = block.Bytes
+	}
+
+	list, err := x509.ParseRevocationList(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CRL: %w", err)
+	}
+
+	return &RevocationList{Source: location, List: list}, nil
+}
+
+// AuthenticateRevocationLists checks the signature of each CRL with its
+// issuer, when the issuer is among the given certificates, such as those
+// found by a scan or given as roots. CRLs whose signature is not valid are not
+// returned, as anyone able to replace a CRL could otherwise revoke any
+// certificate, or hide a revocation. CRLs whose issuer is not among the
+// certificates are returned, as they may still be trusted by how they were
+// fetched, but a warning is returned for each, as well as for each CRL which
+// is not returned.
+func AuthenticateRevocationLists(crls []*RevocationList, issuers []certificate.Found) ([]*RevocationList, []string) {
+	var (
+		authentic []*RevocationList
+		warnings  []string
+	)
+	for _, crl := range crls {
+		var (
+			found, passed bool
+			sigErr        error
+		)
+		for _, issuer := range issuers {
+			cert := issuer.Certificate
+			if cert == nil || !bytes.Equal(cert.RawSubject, crl.List.RawIssuer) {
+				continue
+			}
+			// Issuers may share a name, so when both the CRL and certificate
+			// identify the issuer's key, they must match.
+			if len(crl.List.AuthorityKeyId) > 0 && len(cert.SubjectKeyId) > 0 && !bytes.Equal(crl.List.AuthorityKeyId, cert.SubjectKeyId) {
+				continue
+			}
+			found = true
+			if err := crl.List.CheckSignatureFrom(cert); err != nil {
+				sigErr = err
+				continue
+			}
+			passed = true
+			break
+		}
+
+		switch {
+		case passed:
+			authentic = append(authentic, crl)
+		case found:
+			warnings = append(warnings, fmt.Sprintf("CRL %s issued by %s is not signed by its issuer, so is not used: %s", crl.Source, crl.List.Issuer, sigErr))
+		default:
+			warnings = append(warnings, fmt.Sprintf("CRL %s issued by %s could not be authenticated, as its issuer is not among the certificates found or given", crl.Source, crl.List.Issuer))
+			authentic = append(authentic, crl)
+		}
+	}
+	return authentic, warnings
+}
+
+// AddRevocationLists adds CRLs whose revoked certificates fail validation.
+// Their signatures should already have been checked with
+// AuthenticateRevocationLists.
+// CRLs which have passed their next update time are not trusted, as a newer
+// CRL may have revoked more certificates, and a warning is returned for each.
+func (v *Validator) AddRevocationLists(crls ...*RevocationList) []string {
+	var warnings []string
+	now := v.clock.Now()
+	for _, crl := range crls {
+		if !crl.List.NextUpdate.IsZero() && now.After(crl.List.NextUpdate) {
+			warnings = append(warnings, fmt.Sprintf("CRL %s issued by %s expired on %s, so is not used", crl.Source, crl.List.Issuer, crl.List.NextUpdate.Format(time.RFC3339)))
+			continue
+		}
+
+		for _, revoked := range crl.List.RevokedCertificates {
+			key := revocationKey(crl.List.RawIssuer, revoked.SerialNumber.String())
+			v.revocations[key] = append(v.revocations[key], revocation{
+				authorityKeyID: crl.List.AuthorityKeyId,
+				revokedAt:      revoked.RevocationTime,
+				source:         crl.Source,
+			})
+		}
+	}
+	return warnings
+}
+
+// isRevoked returns the revocation of the certificate by a CRL from its
+// issuer, if it has been revoked. The certificate must be parsed.
+func (v *Validator) isRevoked(cert *x509.Certificate) (revocation, bool) {
+	for _, r := range v.revocations[revocationKey(cert.RawIssuer, cert.SerialNumber.String())] {
+		// Issuers may share a name, so when both the CRL and certificate
+		// identify the issuer's key, they must match.
+		if len(r.authorityKeyID) > 0 && len(cert.AuthorityKeyId) > 0 && !bytes.Equal(r.authorityKeyID, cert.AuthorityKeyId) {
+			continue
+		}
+		return r, true
+	}
+	return revocation{}, false
+}
+
+func revocationKey(rawIssuer []byte, serial string) string {
+	return string(rawIssuer) + "/" + serial
+}
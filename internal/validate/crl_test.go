@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	encpem "encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestLoadRevocationList(t *testing.T) {
+	ca, caKey := generateCertificate(t, &x509.Certificate{IsCA: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign}, nil, nil)
+	der := mustCreateCRL(t, ca, caKey, time.Now().Add(time.Hour), big.NewInt(42))
+
+	dir := t.TempDir()
+	derPath := filepath.Join(dir, "ca.crl")
+	require.NoError(t, os.WriteFile(derPath, der, 0600))
+	pemPath := filepath.Join(dir, "ca.crl.pem")
+	require.NoError(t, os.WriteFile(pemPath, encpem.EncodeToMemory(&encpem.Block{Type: "X509 CRL", Bytes: der}), 0600))
+	invalidPath := filepath.Join(dir, "invalid.crl")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not a CRL"), 0600))
+
+	for _, path := range []string{derPath, pemPath} {
+		crl, err := LoadRevocationList(context.TODO(), path)
+		require.NoError(t, err)
+		assert.Equal(t, path, crl.Source)
+		require.Len(t, crl.List.RevokedCertificates, 1)
+		assert.Equal(t, big.NewInt(42), crl.List.RevokedCertificates[0].SerialNumber)
+	}
+
+	_, err := LoadRevocationList(context.TODO(), invalidPath)
+	assert.ErrorContains(t, err, "failed to parse CRL")
+}
+
+func TestValidator_AddRevocationLists(t *testing.T) {
+	caTemplate := &x509.Certificate{IsCA: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign, Subject: pkix.Name{CommonName: "Paranoia Test CA"}}
+	ca, caKey := generateCertificate(t, caTemplate, nil, nil)
+	revoked, _ := generateCertificate(t, &x509.Certificate{IsCA: true}, ca, caKey)
+	valid, _ := generateCertificate(t, &x509.Certificate{IsCA: true}, ca, caKey)
+
+	// A different CA with the same name, whose CRL must not revoke
+	// certificates issued by the first.
+	otherCA, otherCAKey := generateCertificate(t, &x509.Certificate{IsCA: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign, Subject: pkix.Name{CommonName: "Paranoia Test CA"}}, nil, nil)
+
+	founds := []certificate.Found{
+		{Location: "revoked", Certificate: revoked},
+		{Location: "valid", Certificate: valid},
+		{Location: "unparsed"},
+	}
+
+	mustParseCRL := func(source string, der []byte) *RevocationList {
+		list, err := x509.ParseRevocationList(der)
+		require.NoError(t, err)
+		return &RevocationList{Source: source, List: list}
+	}
+
+	t.Run("certificates revoked by their issuer fail", func(t *testing.T) {
+		validator, err := NewValidator(Config{}, true)
+		require.NoError(t, err)
+		warnings := validator.AddRevocationLists(mustParseCRL("ca.crl", mustCreateCRL(t, ca, caKey, time.Now().Add(time.Hour), revoked.SerialNumber)))
+		assert.Empty(t, warnings)
+
+		r, err := validator.Validate(founds)
+		assert.NoError(t, err)
+		assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+		require.Len(t, r.RevokedCertificates, 1)
+		assert.Equal(t, founds[0], r.RevokedCertificates[0].Certificate)
+		assert.Equal(t, "ca.crl", r.RevokedCertificates[0].Source)
+		assert.Equal(t, []string{FindingRevoked}, r.Failures())
+	})
+
+	t.Run("certificates revoked by another issuer with the same name pass", func(t *testing.T) {
+		validator, err := NewValidator(Config{}, true)
+		require.NoError(t, err)
+		validator.AddRevocationLists(mustParseCRL("other.crl", mustCreateCRL(t, otherCA, otherCAKey, time.Now().Add(time.Hour), revoked.SerialNumber)))
+
+		r, err := validator.Validate(founds)
+		assert.NoError(t, err)
+		assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+	})
+
+	t.Run("expired CRLs are not trusted", func(t *testing.T) {
+		validator, err := NewValidator(Config{}, true)
+		require.NoError(t, err)
+		warnings := validator.AddRevocationLists(mustParseCRL("expired.crl", mustCreateCRL(t, ca, caKey, time.Now().Add(-time.Minute), revoked.SerialNumber)))
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "CRL expired.crl issued by CN=Paranoia Test CA expired on")
+
+		r, err := validator.Validate(founds)
+		assert.NoError(t, err)
+		assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+	})
+}
+
+func TestAuthenticateRevocationLists(t *testing.T) {
+	caTemplate := &x509.Certificate{IsCA: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign, Subject: pkix.Name{CommonName: "Paranoia Test CA"}}
+	ca, caKey := generateCertificate(t, caTemplate, nil, nil)
+	_, otherKey := generateCertificate(t, caTemplate, nil, nil)
+	issuers := []certificate.Found{{Location: "unparsed"}, {Location: "ca", Certificate: ca}}
+
+	mustParseCRL := func(source string, der []byte) *RevocationList {
+		list, err := x509.ParseRevocationList(der)
+		require.NoError(t, err)
+		return &RevocationList{Source: source, List: list}
+	}
+	signed := mustParseCRL("ca.crl", mustCreateCRL(t, ca, caKey, time.Now().Add(time.Hour), big.NewInt(42)))
+	forged := mustParseCRL("forged.crl", mustCreateCRL(t, ca, otherKey, time.Now().Add(time.Hour), big.NewInt(42)))
+
+	t.Run("CRLs signed by their issuer are used", func(t *testing.T) {
+		crls, warnings := AuthenticateRevocationLists([]*RevocationList{signed}, issuers)
+		assert.Equal(t, []*RevocationList{signed}, crls)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("CRLs not signed by their issuer are rejected", func(t *testing.T) {
+		crls, warnings := AuthenticateRevocationLists([]*RevocationList{forged, signed}, issuers)
+		assert.Equal(t, []*RevocationList{signed}, crls)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "CRL forged.crl issued by CN=Paranoia Test CA is not signed by its issuer, so is not used")
+	})
+
+	t.Run("CRLs whose issuer is unknown are used with a warning", func(t *testing.T) {
+		crls, warnings := AuthenticateRevocationLists([]*RevocationList{forged}, nil)
+		assert.Equal(t, []*RevocationList{forged}, crls)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "CRL forged.crl issued by CN=Paranoia Test CA could not be authenticated")
+	})
+}
+
+// mustCreateCRL returns a DER encoded CRL from the issuer, revoking the
+// certificates with the given serial numbers.
+func mustCreateCRL(t *testing.T, issuer *x509.Certificate, key crypto.Signer, nextUpdate time.Time, serials ...*big.Int) []byte {
+	var revoked []pkix.RevokedCertificate
+	for _, s := range serials {
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: s, RevocationTime: time.Now().Add(-time.Hour)})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(1),
+		ThisUpdate:          time.Now().Add(-2 * time.Hour),
+		NextUpdate:          nextUpdate,
+		RevokedCertificates: revoked,
+	}, issuer, key)
+	require.NoError(t, err)
+	return der
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"fmt"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// The verdicts which strict mode validation can reach for a certificate.
+const (
+	VerdictAllowed    = "allowed"
+	VerdictForbidden  = "forbidden"
+	VerdictNotAllowed = "notAllowed"
+)
+
+// Explanation describes the verdict strict mode validation would reach for a
+// certificate, and why.
+type Explanation struct {
+	Certificate certificate.Found
+
+	// Verdict is one of VerdictAllowed, VerdictForbidden, or
+	// VerdictNotAllowed.
+	Verdict string
+
+	// Reason is a human-readable description of why the verdict was reached.
+	Reason string
+
+	// Rule is the rule which decided the verdict, one of Rules, or empty if
+	// no entry matched the certificate.
+	Rule string
+}
+
+// Explain evaluates each certificate against the allow and forbid lists as
+// strict mode validation would, even if the validator is in permissive mode.
+// This is advisory only, and is independent of the result of Validate. It
+// allows users of permissive mode to see what strict mode would report before
+// switching to it.
+func (v *Validator) Explain(founds []certificate.Found) []Explanation {
+	var explanations []Explanation
+	for _, f := range founds {
+		e := Explanation{Certificate: f}
+		d, ok := v.decide(f, true)
+		e.Rule = d.Rule
+		switch {
+		case !ok:
+			e.Verdict = VerdictNotAllowed
+			e.Reason = "not in the allow or require lists"
+		case d.Forbids():
+			e.Verdict = VerdictForbidden
+			e.Reason = "forbidden by entry with " + describeEntry(d.Entry) + describeMatch(d)
+		case d.Rule == RuleAllowFingerprint:
+			e.Verdict = VerdictAllowed
+			e.Reason = "allowed by fingerprint" + describeMatch(d)
+		default:
+			e.Verdict = VerdictAllowed
+			e.Reason = "allowed by entry with " + describeEntry(d.Entry)
+		}
+		explanations = append(explanations, e)
+	}
+	return explanations
+}
+
+// allowMatcher returns the first allow list attribute matcher which matches
+// the certificate.
+func (v *Validator) allowMatcher(f certificate.Found) (attributeMatcher, bool) {
+	for _, m := range v.allowMatchers {
+		if m.matches(f.Certificate) && m.entry.inScope(f) {
+			return m, true
+		}
+	}
+	return attributeMatcher{}, false
+}
+
+// describeMatch describes which fingerprint of the deciding entry matched, if
+// it lists several.
+func describeMatch(d Decision) string {
+	if len(d.Entry.Fingerprints) < 2 {
+		return ""
+	}
+	return fmt.Sprintf(", matching its %s", d.Fingerprint)
+}
+
+// describeEntry describes how a certificate entry identifies certificates,
+// along with its comment if it has one.
+func describeEntry(ce CertificateEntry) string {
+	s := ce.Identity()
+	if ce.Comment != "" {
+		s += fmt.Sprintf(" (comment: %s)", ce.Comment)
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package validate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+func TestValidator_Explain(t *testing.T) {
+	allowedSHA256 := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+	forbiddenSHA256 := "edfa7caf7f1274d54bacec91e21a5b1a04a7b94bf197f5c92070b8de148d9b37"
+	config := Config{
+		Allow: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: allowedSHA256}}},
+			{SubjectCNPattern: "*.allowed.internal"},
+		},
+		Forbid: []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: forbiddenSHA256}}, Comment: "banned per SEC-1234"},
+		},
+	}
+
+	allowed := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(allowedSHA256)}
+	allowedBySubject := certificate.Found{
+		FingerprintSha256: anySHA256(),
+		Certificate:       &x509.Certificate{Subject: pkix.Name{CommonName: "foo.allowed.internal"}},
+	}
+	forbidden := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(forbiddenSHA256)}
+	other := certificate.Found{FingerprintSha256: anySHA256()}
+	founds := []certificate.Found{allowed, allowedBySubject, forbidden, other}
+
+	want := []Explanation{
+		{Certificate: allowed, Verdict: VerdictAllowed, Reason: "allowed by fingerprint", Rule: RuleAllowFingerprint},
+		{Certificate: allowedBySubject, Verdict: VerdictAllowed, Reason: `allowed by entry with subject CN pattern "*.allowed.internal"`, Rule: RuleAllowAttribute},
+		{Certificate: forbidden, Verdict: VerdictForbidden, Reason: "forbidden by entry with SHA256 fingerprint " + forbiddenSHA256 + " (comment: banned per SEC-1234)", Rule: RuleForbidFingerprint},
+		{Certificate: other, Verdict: VerdictNotAllowed, Reason: "not in the allow or require lists"},
+	}
+
+	for _, permissive := range []bool{false, true} {
+		validator, err := NewValidator(config, permissive)
+		require.NoError(t, err)
+		assert.Equal(t, want, validator.Explain(founds), "permissive: %t", permissive)
+	}
+
+	t.Run("Reports which of several fingerprints matched", func(t *testing.T) {
+		validator, err := NewValidator(Config{
+			Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: allowedSHA256}, {Sha256: forbiddenSHA256}}}},
+		}, false)
+		require.NoError(t, err)
+
+		explanations := validator.Explain([]certificate.Found{forbidden})
+		require.Len(t, explanations, 1)
+		assert.Equal(t, "forbidden by entry with SHA256 fingerprint "+allowedSHA256+" or SHA256 fingerprint "+forbiddenSHA256+
+			", matching its SHA256 fingerprint "+forbiddenSHA256, explanations[0].Reason)
+	})
+
+	t.Run("Explaining doesn't affect the permissive result", func(t *testing.T) {
+		validator, err := NewValidator(config, true)
+		require.NoError(t, err)
+
+		r, err := validator.Validate([]certificate.Found{allowed, other})
+		require.NoError(t, err)
+		assert.True(t, r.IsPass())
+	})
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+// forbidFeedContentTypes are the content types a remote forbid feed may be
+// served with.
+var forbidFeedContentTypes = []string{"text/plain"}
+
+// ForbidFeed is a shared list of distrusted certificates, such as formerly
+// trusted certificate authorities, every one of which is forbidden.
+type ForbidFeed struct {
+	// Source is the file name or URL the feed was loaded from.
+	Source string
+
+	// Entries are the forbid entries of the feed, one for each distinct
+	// fingerprint, in the order they first appear.
+	Entries []CertificateEntry
+}
+
+// LoadForbidFeed loads a forbid feed from a file, or fetches it if the
+// location is a HTTP or HTTPS URL. An error is returned if the feed has no
+// fingerprints, as an empty feed is almost always a mistake.
+func LoadForbidFeed(ctx context.Context, location string) (*ForbidFeed, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if isURL(location) {
+		b, _, err = fetch(ctx, location, forbidFeedContentTypes)
+	} else {
+		b, err = ioutil.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseForbidFeed(location, b)
+}
+
+// parseForbidFeed parses a forbid feed, which has one SHA1, SHA256 or SHA512
+// fingerprint per line, optionally followed by a comment starting with "#".
+// Blank lines and lines which are only a comment are ignored. Fingerprints
+// which are given more than once are only forbidden once, keeping the first
+// comment.
+func parseForbidFeed(source string, b []byte) (*ForbidFeed, error) {
+	feed := &ForbidFeed{Source: source}
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	var lineNum int
+	for scanner.Scan() {
+		lineNum++
+		line, comment := scanner.Text(), ""
+		if i := strings.Index(line, "#"); i >= 0 {
+			line, comment = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fp, err := parseFeedFingerprint(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %w", lineNum, source, err)
+		}
+		key := fingerprintValues(fp)[0].key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		feed.Entries = append(feed.Entries, CertificateEntry{
+			Comment:      comment,
+			Fingerprints: FingerprintList{fp},
+			Source:       source,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(feed.Entries) == 0 {
+		return nil, fmt.Errorf("no fingerprints found in %s", source)
+	}
+	return feed, nil
+}
+
+// parseFeedFingerprint parses a fingerprint of a forbid feed, whose kind is
+// given by its length.
+func parseFeedFingerprint(s string) (CertificateFingerprints, error) {
+	value := checksum.Normalize(s)
+	var err error
+	switch len(value) {
+	case 40:
+		_, err = checksum.ParseSHA1(value)
+		if err == nil {
+			return CertificateFingerprints{Sha1: value}, nil
+		}
+	case 64:
+		_, err = checksum.ParseSHA256(value)
+		if err == nil {
+			return CertificateFingerprints{Sha256: value}, nil
+		}
+	case 128:
+		_, err = checksum.ParseSHA512(value)
+		if err == nil {
+			return CertificateFingerprints{Sha512: value}, nil
+		}
+	}
+	if err != nil {
+		return CertificateFingerprints{}, fmt.Errorf("invalid fingerprint %q: %w", strings.TrimSpace(s), err)
+	}
+	return CertificateFingerprints{}, fmt.Errorf("invalid fingerprint %q, expected a hex encoded SHA1, SHA256, or SHA512 fingerprint", strings.TrimSpace(s))
+}
+
+// Config returns a config forbidding every certificate in the feed, so that
+// it can be merged with other configs.
+func (f *ForbidFeed) Config() Config {
+	return Config{
+		Version: ExpectedVersion,
+		Source:  f.Source,
+		Forbid:  f.Entries,
+	}
+}
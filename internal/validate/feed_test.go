@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadForbidFeed(t *testing.T) {
+	const feed = `# Formerly trusted certificate authorities
+bd40be0eccfce513ab318882f03962e4e2ec3799b51392e82805d9249e426d28 # Distrusted Root
+BD:40:BE:0E:CC:FC:E5:13:AB:31:88:82:F0:39:62:E4:E2:EC:37:99:B5:13:92:E8:28:05:D9:24:9E:42:6D:28 # Same root, copied from a browser
+
+a1db6393916f17e4185509400415c70240b0ae6b
+`
+
+	t.Run("fingerprints should be forbidden once each", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "distrusted.txt")
+		require.NoError(t, os.WriteFile(path, []byte(feed), 0o644))
+
+		f, err := LoadForbidFeed(context.TODO(), path)
+		require.NoError(t, err)
+		require.Len(t, f.Entries, 2)
+		assert.Equal(t, "Distrusted Root", f.Entries[0].Comment)
+		assert.Equal(t, "bd40be0eccfce513ab318882f03962e4e2ec3799b51392e82805d9249e426d28", f.Entries[0].Fingerprints[0].Sha256)
+		assert.Equal(t, "", f.Entries[1].Comment)
+		assert.Equal(t, "a1db6393916f17e4185509400415c70240b0ae6b", f.Entries[1].Fingerprints[0].Sha1)
+
+		config := f.Config()
+		assert.Equal(t, path, config.Source)
+		assert.Equal(t, path, config.Forbid[0].Source)
+		assert.True(t, IsConfigValid(&config))
+	})
+
+	t.Run("feeds should be fetched from URLs", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(feed))
+		}))
+		defer server.Close()
+
+		f, err := LoadForbidFeed(context.TODO(), server.URL+"/distrusted.txt")
+		require.NoError(t, err)
+		assert.Len(t, f.Entries, 2)
+	})
+
+	t.Run("feed certificates should conflict with allowed certificates", func(t *testing.T) {
+		f, err := parseForbidFeed("distrusted.txt", []byte(feed))
+		require.NoError(t, err)
+		_, err = MergeConfigs(Config{
+			Version: ExpectedVersion,
+			Allow: []CertificateEntry{{
+				Fingerprints: FingerprintList{{Sha1: "A1DB6393916F17E4185509400415C70240B0AE6B"}},
+			}},
+		}, f.Config())
+		assert.ErrorContains(t, err, "forbidden by distrusted.txt")
+	})
+
+	for name, test := range map[string]struct {
+		feed string
+		err  string
+	}{
+		"invalid hex":    {feed: "zz40be0eccfce513ab318882f03962e4e2ec3799b51392e82805d9249e426d28\n", err: "line 1 of feed: invalid fingerprint"},
+		"unknown length": {feed: "# comment\nbd40be0e\n", err: "line 2 of feed: invalid fingerprint \"bd40be0e\", expected a hex encoded SHA1, SHA256, or SHA512 fingerprint"},
+		"only comments":  {feed: "# nothing to see\n\n", err: "no fingerprints found in feed"},
+		"empty":          {feed: "", err: "no fingerprints found in feed"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseForbidFeed("feed", []byte(test.feed))
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+// FingerprintList is the fingerprints of a certificate entry, any of which
+// identifies the certificate, such as the fingerprints of a root across its
+// reissuances. In config files it may be given as a single set of
+// fingerprints, or a list of them.
+type FingerprintList []CertificateFingerprints
+
+// UnmarshalYAML accepts either a single set of fingerprints, or a list.
+func (l *FingerprintList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.MappingNode {
+		var f CertificateFingerprints
+		if err := value.Decode(&f); err != nil {
+			return err
+		}
+		*l = FingerprintList{f}
+		return nil
+	}
+	var fs []CertificateFingerprints
+	if err := value.Decode(&fs); err != nil {
+		return err
+	}
+	*l = fs
+	return nil
+}
+
+// MarshalYAML writes a single set of fingerprints as a mapping, as configs
+// without alternative fingerprints have always been written.
+func (l FingerprintList) MarshalYAML() (interface{}, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+	return []CertificateFingerprints(l), nil
+}
+
+// UnmarshalJSON accepts either a single set of fingerprints, or a list.
+func (l *FingerprintList) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*l = nil
+		return nil
+	}
+	var f CertificateFingerprints
+	if err := json.Unmarshal(b, &f); err == nil {
+		*l = FingerprintList{f}
+		return nil
+	}
+	var fs []CertificateFingerprints
+	if err := json.Unmarshal(b, &fs); err != nil {
+		return err
+	}
+	*l = fs
+	return nil
+}
+
+// MarshalJSON writes a single set of fingerprints, or none, as an object, so
+// that the output for entries without alternative fingerprints is unchanged.
+func (l FingerprintList) MarshalJSON() ([]byte, error) {
+	switch len(l) {
+	case 0:
+		return json.Marshal(CertificateFingerprints{})
+	case 1:
+		return json.Marshal(l[0])
+	default:
+		return json.Marshal([]CertificateFingerprints(l))
+	}
+}
+
+// String describes the fingerprint, such as `SHA256 fingerprint 01be...`.
+func (f CertificateFingerprints) String() string {
+	var parts []string
+	for _, fp := range fingerprintValues(f) {
+		parts = append(parts, fp.String())
+	}
+	return strings.Join(parts, " and ")
+}
+
+// fingerprintMatch is an entry which matched a certificate by fingerprint,
+// and which of its fingerprints matched.
+type fingerprintMatch struct {
+	entry       CertificateEntry
+	fingerprint CertificateFingerprints
+}
+
+// fingerprintSet is the fingerprints of the entries of a list, such as the
+// allow list, each mapped to the entries which have it. Several entries may
+// share a fingerprint, such as a root allowed in more than one scope.
+type fingerprintSet struct {
+	md5    map[[16]byte][]fingerprintMatch
+	sha1   map[[20]byte][]fingerprintMatch
+	sha256 map[[32]byte][]fingerprintMatch
+	sha512 map[[64]byte][]fingerprintMatch
+	spki   map[[32]byte][]fingerprintMatch
+	// SHA256 prefixes can't be looked up in a map, so are matched by
+	// scanning each in turn.
+	sha256Prefixes []sha256Prefix
+}
+
+func newFingerprintSet() fingerprintSet {
+	return fingerprintSet{
+		md5:    make(map[[16]byte][]fingerprintMatch),
+		sha1:   make(map[[20]byte][]fingerprintMatch),
+		sha256: make(map[[32]byte][]fingerprintMatch),
+		sha512: make(map[[64]byte][]fingerprintMatch),
+		spki:   make(map[[32]byte][]fingerprintMatch),
+	}
+}
+
+// len returns the number of fingerprints in the set.
+func (s *fingerprintSet) len() int {
+	return len(s.md5) + len(s.sha1) + len(s.sha256) + len(s.sha256Prefixes) + len(s.sha512) + len(s.spki)
+}
+
+// add adds every fingerprint of the entry to the set. The entry is described
+// by desc in errors, such as "entry at position 0 in allow list".
+func (s *fingerprintSet) add(entry CertificateEntry, desc string) error {
+	for _, f := range entry.Fingerprints {
+		m := fingerprintMatch{entry: entry, fingerprint: f}
+		if f.SpkiSha256 != "" {
+			sha, err := checksum.ParseSHA256(f.SpkiSha256)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("%s had invalid SPKI SHA256", desc))
+			}
+			s.spki[sha] = append(s.spki[sha], m)
+		} else if f.Sha512 != "" {
+			sha, err := checksum.ParseSHA512(f.Sha512)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("%s had invalid SHA512", desc))
+			}
+			s.sha512[sha] = append(s.sha512[sha], m)
+		} else if f.Sha256 != "" {
+			sha, err := checksum.ParseSHA256(f.Sha256)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("%s had invalid SHA256", desc))
+			}
+			s.sha256[sha] = append(s.sha256[sha], m)
+		} else if f.Sha1 != "" {
+			sha, err := checksum.ParseSHA1(f.Sha1)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("%s had invalid SHA1", desc))
+			}
+			s.sha1[sha] = append(s.sha1[sha], m)
+		} else if f.Md5 != "" {
+			sum, err := checksum.ParseMD5(f.Md5)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("%s had invalid MD5", desc))
+			}
+			s.md5[sum] = append(s.md5[sum], m)
+		} else if f.Sha256Prefix != "" {
+			prefix, err := parseSHA256Prefix(f.Sha256Prefix)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("%s had invalid SHA256 prefix", desc))
+			}
+			s.sha256Prefixes = append(s.sha256Prefixes, sha256Prefix{prefix: prefix, match: m})
+		}
+	}
+	return nil
+}
+
+// match returns the entry, and its fingerprint, which matches the found
+// certificate, if any entry does in its scope. If several entries share the
+// fingerprint, the first in scope is returned.
+func (s *fingerprintSet) match(f certificate.Found) (fingerprintMatch, bool) {
+	for _, matches := range [][]fingerprintMatch{
+		s.sha1[f.FingerprintSha1],
+		s.sha256[f.FingerprintSha256],
+		s.sha512[f.FingerprintSha512],
+		s.spki[f.SpkiSha256],
+		s.md5[f.FingerprintMd5],
+	} {
+		for _, m := range matches {
+			if m.entry.inScope(f) {
+				return m, true
+			}
+		}
+	}
+
+	for _, p := range s.sha256Prefixes {
+		if hasSHA256Prefix(f.FingerprintSha256, p.prefix) && p.match.entry.inScope(f) {
+			return p.match, true
+		}
+	}
+
+	return fingerprintMatch{}, false
+}
+
+// fingerprintPresent returns true if a certificate with the fingerprint is
+// among the fingerprints of the found certificates.
+func fingerprintPresent(f CertificateFingerprints, found *foundFingerprints) (bool, error) {
+	switch {
+	case f.SpkiSha256 != "":
+		s, err := checksum.ParseSHA256(f.SpkiSha256)
+		return found.spki[s], err
+	case f.Sha512 != "":
+		s, err := checksum.ParseSHA512(f.Sha512)
+		return found.sha512[s], err
+	case f.Sha256 != "":
+		s, err := checksum.ParseSHA256(f.Sha256)
+		return found.sha256[s], err
+	case f.Sha1 != "":
+		s, err := checksum.ParseSHA1(f.Sha1)
+		return found.sha1[s], err
+	case f.Md5 != "":
+		s, err := checksum.ParseMD5(f.Md5)
+		return found.md5[s], err
+	case f.Sha256Prefix != "":
+		prefix, err := parseSHA256Prefix(f.Sha256Prefix)
+		if err != nil {
+			return false, err
+		}
+		for s := range found.sha256 {
+			if hasSHA256Prefix(s, prefix) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, nil
+	}
+}
+
+// foundFingerprints are the fingerprints of the certificates found by a
+// scan, used to check required certificates are present.
+type foundFingerprints struct {
+	md5    map[[16]byte]bool
+	sha1   map[[20]byte]bool
+	sha256 map[[32]byte]bool
+	sha512 map[[64]byte]bool
+	spki   map[[32]byte]bool
+}
+
+func newFoundFingerprints(founds []certificate.Found) *foundFingerprints {
+	found := &foundFingerprints{
+		md5:    make(map[[16]byte]bool),
+		sha1:   make(map[[20]byte]bool),
+		sha256: make(map[[32]byte]bool),
+		sha512: make(map[[64]byte]bool),
+		spki:   make(map[[32]byte]bool),
+	}
+	for _, f := range founds {
+		found.md5[f.FingerprintMd5] = true
+		found.sha1[f.FingerprintSha1] = true
+		found.sha256[f.FingerprintSha256] = true
+		found.sha512[f.FingerprintSha512] = true
+		found.spki[f.SpkiSha256] = true
+	}
+	return found
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"fmt"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// KeyIdAnomalyCert is a certificate whose subject key identifier is likely to
+// break chain building, as verifiers find issuers by their key identifier.
+type KeyIdAnomalyCert struct {
+	Certificate certificate.Found
+	// Reason is a human-readable explanation of the anomaly, such as that a
+	// certificate authority has no subject key identifier.
+	Reason string
+}
+
+// keyIdAnomalies returns the found certificates which are certificate
+// authorities without a subject key identifier, or which share their subject
+// key identifier with a certificate with a different public key. Certificates
+// sharing a key, such as a root and its cross-signed copy, may share an
+// identifier, as they are interchangeable when building chains.
+func keyIdAnomalies(founds []certificate.Found) []KeyIdAnomalyCert {
+	// keys are the distinct public keys of the certificates with each
+	// subject key identifier, in the order they were found.
+	keys := make(map[string][]certificate.Found)
+	for _, f := range founds {
+		if f.Certificate == nil || len(f.Certificate.SubjectKeyId) == 0 {
+			continue
+		}
+		ski := string(f.Certificate.SubjectKeyId)
+		seen := false
+		for _, k := range keys[ski] {
+			if k.SpkiSha256 == f.SpkiSha256 {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			keys[ski] = append(keys[ski], f)
+		}
+	}
+
+	var anomalies []KeyIdAnomalyCert
+	for _, f := range founds {
+		if f.Certificate == nil {
+			continue
+		}
+
+		if len(f.Certificate.SubjectKeyId) == 0 {
+			if f.Certificate.BasicConstraintsValid && f.Certificate.IsCA {
+				anomalies = append(anomalies, KeyIdAnomalyCert{
+					Certificate: f,
+					Reason:      "it is a certificate authority without a subject key identifier",
+				})
+			}
+			continue
+		}
+
+		others := keys[string(f.Certificate.SubjectKeyId)]
+		if len(others) < 2 {
+			continue
+		}
+		for _, o := range others {
+			if o.SpkiSha256 != f.SpkiSha256 {
+				anomalies = append(anomalies, KeyIdAnomalyCert{
+					Certificate: f,
+					Reason: fmt.Sprintf("its subject key identifier %X is shared with the certificate with SHA256 fingerprint %X, which has a different public key",
+						f.Certificate.SubjectKeyId, o.FingerprintSha256),
+				})
+				break
+			}
+		}
+	}
+	return anomalies
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestKeyIdAnomalies(t *testing.T) {
+	found := func(cn string, ski []byte, spki byte, isCA bool) certificate.Found {
+		return certificate.Found{
+			Location:          "/etc/ssl/certs/" + cn + ".pem",
+			FingerprintSha256: anySHA256(),
+			SpkiSha256:        [32]byte{spki},
+			Certificate: &x509.Certificate{
+				Subject:               pkix.Name{CommonName: cn},
+				SubjectKeyId:          ski,
+				BasicConstraintsValid: true,
+				IsCA:                  isCA,
+			},
+		}
+	}
+
+	root := found("Root", []byte{0x01}, 1, true)
+	crossSigned := found("Root Cross-Signed", []byte{0x01}, 1, true)
+	noKeyID := found("No Key ID", nil, 2, true)
+	leaf := found("Leaf", nil, 3, false)
+	first := found("First Intermediate", []byte{0xab, 0xcd}, 4, true)
+	second := found("Second Intermediate", []byte{0xab, 0xcd}, 5, true)
+
+	assert.Equal(t, []KeyIdAnomalyCert{
+		{Certificate: noKeyID, Reason: "it is a certificate authority without a subject key identifier"},
+		{Certificate: first, Reason: fmt.Sprintf("its subject key identifier ABCD is shared with the certificate with SHA256 fingerprint %X, which has a different public key", second.FingerprintSha256)},
+		{Certificate: second, Reason: fmt.Sprintf("its subject key identifier ABCD is shared with the certificate with SHA256 fingerprint %X, which has a different public key", first.FingerprintSha256)},
+	}, keyIdAnomalies([]certificate.Found{root, crossSigned, noKeyID, leaf, first, second, {Location: "/partial"}}))
+
+	t.Run("anomalies are only reported when enabled", func(t *testing.T) {
+		validator, err := NewValidator(Config{}, true)
+		require.NoError(t, err)
+		r, err := validator.Validate([]certificate.Found{noKeyID})
+		require.NoError(t, err)
+		assert.Empty(t, r.KeyIdAnomalyCertificates)
+
+		validator, err = NewValidator(Config{WarnKeyIdAnomalies: true}, true)
+		require.NoError(t, err)
+		r, err = validator.Validate([]certificate.Found{noKeyID})
+		require.NoError(t, err)
+		assert.Len(t, r.KeyIdAnomalyCertificates, 1)
+		assert.True(t, r.IsPass(), "expected key identifier anomalies not to fail validation")
+	})
+}
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// attributeMatcher matches certificates by their attributes, such as their
+// subject, rather than by fingerprint.
+type attributeMatcher struct {
+	entry CertificateEntry
+
+	// subjectRegex and sanRegex are the compiled regular expressions of the
+	// entry, which are compiled once rather than for every certificate.
+	subjectRegex *regexp.Regexp
+	sanRegex     *regexp.Regexp
+
+	// serialNumber is the parsed serial number of the entry, if it has one.
+	serialNumber *big.Int
+
+	// policyOID is the parsed certificate policy of the entry, if it has
+	// one.
+	policyOID asn1.ObjectIdentifier
+}
+
+func newAttributeMatcher(entry CertificateEntry) (attributeMatcher, error) {
+	m := attributeMatcher{entry: entry}
+
+	if entry.SubjectCNPattern != "" {
+		// Match against an empty name to check the pattern is well-formed.
+		if _, err := path.Match(entry.SubjectCNPattern, ""); err != nil {
+			return attributeMatcher{}, err
+		}
+	}
+
+	if entry.SubjectRegex != "" {
+		re, err := regexp.Compile(entry.SubjectRegex)
+		if err != nil {
+			return attributeMatcher{}, fmt.Errorf("invalid subject regex %q: %w", entry.SubjectRegex, err)
+		}
+		m.subjectRegex = re
+	}
+
+	if entry.SANRegex != "" {
+		re, err := regexp.Compile(entry.SANRegex)
+		if err != nil {
+			return attributeMatcher{}, fmt.Errorf("invalid SAN regex %q: %w", entry.SANRegex, err)
+		}
+		m.sanRegex = re
+	}
+
+	if entry.SerialNumber != "" {
+		serial, err := parseSerialNumber(entry.SerialNumber)
+		if err != nil {
+			return attributeMatcher{}, err
+		}
+		m.serialNumber = serial
+	}
+
+	if entry.PolicyOID != "" {
+		oid, err := parsePolicyOID(entry.PolicyOID)
+		if err != nil {
+			return attributeMatcher{}, err
+		}
+		m.policyOID = oid
+	}
+
+	return m, nil
+}
+
+// parsePolicyOID parses a certificate policy object identifier in dotted
+// decimal form, such as "2.23.140.1.1". Object identifiers have at least two
+// arcs, the first of which is 0, 1, or 2.
+func parsePolicyOID(s string) (asn1.ObjectIdentifier, error) {
+	invalid := fmt.Errorf("invalid policy OID %q, expected dotted decimal such as \"2.23.140.1.1\"", s)
+
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, invalid
+	}
+	oid := make(asn1.ObjectIdentifier, len(parts))
+	for i, part := range parts {
+		// Arcs are unsigned, so a sign is never valid.
+		if part == "" || strings.ContainsAny(part, "+-") {
+			return nil, invalid
+		}
+		arc, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, invalid
+		}
+		oid[i] = arc
+	}
+	if oid[0] > 2 {
+		return nil, invalid
+	}
+	return oid, nil
+}
+
+// parseSerialNumber parses a certificate serial number, which is decimal
+// unless it has a "0x" prefix or its bytes are separated by colons, in which
+// case it is hex, such as "0x0a3f" or "0a:3f". Serial numbers may be up to 20
+// bytes long, so are parsed as big integers.
+func parseSerialNumber(s string) (*big.Int, error) {
+	digits, base := s, 10
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		digits, base = s[2:], 16
+	} else if strings.Contains(s, ":") {
+		digits, base = strings.ReplaceAll(s, ":", ""), 16
+	}
+
+	serial, ok := new(big.Int).SetString(digits, base)
+	if !ok || serial.Sign() < 0 {
+		return nil, fmt.Errorf("invalid serial number %q, expected a decimal or hex integer", s)
+	}
+	return serial, nil
+}
+
+// matches returns true if the certificate matches all the attributes of the
+// entry. Certificates which failed to parse never match.
+func (m attributeMatcher) matches(cert *x509.Certificate) bool {
+	if cert == nil {
+		return false
+	}
+
+	if m.entry.SubjectCN != "" && cert.Subject.CommonName != m.entry.SubjectCN {
+		return false
+	}
+
+	if m.entry.SubjectCNPattern != "" {
+		if ok, _ := path.Match(m.entry.SubjectCNPattern, cert.Subject.CommonName); !ok {
+			return false
+		}
+	}
+
+	if m.entry.IssuerDN != "" && cert.Issuer.String() != m.entry.IssuerDN {
+		return false
+	}
+
+	if m.serialNumber != nil && (cert.SerialNumber == nil || cert.SerialNumber.Cmp(m.serialNumber) != 0) {
+		return false
+	}
+
+	if m.subjectRegex != nil && !m.subjectRegex.MatchString(cert.Subject.String()) {
+		return false
+	}
+
+	if m.sanRegex != nil && !m.matchesSAN(cert) {
+		return false
+	}
+
+	if m.policyOID != nil && !m.matchesPolicy(cert) {
+		return false
+	}
+
+	return true
+}
+
+// matchesSAN returns true if any of the DNS name, IP address, or email address
+// subject alternative names of the certificate match the SAN regex.
+func (m attributeMatcher) matchesSAN(cert *x509.Certificate) bool {
+	var names []string
+	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, cert.EmailAddresses...)
+
+	for _, name := range names {
+		if m.sanRegex.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPolicy returns true if any of the certificate policies asserted by
+// the certificate is the policy OID.
+func (m attributeMatcher) matchesPolicy(cert *x509.Certificate) bool {
+	for _, policy := range cert.PolicyIdentifiers {
+		if policy.Equal(m.policyOID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+// MergeConfigs merges the given configs into a single config, such as a
+// shared baseline policy and team specific overrides. The allow, forbid,
+// require, and requireAnyOf lists are concatenated. Where configs disagree on a setting, such as
+// the minimum RSA key size, the strictest setting is used.
+//
+// An error is returned if a certificate fingerprint is allowed or required by
+// one config, but forbidden by another.
+func MergeConfigs(configs ...Config) (Config, error) {
+	merged := Config{Version: ExpectedVersion}
+
+	var (
+		sources   []string
+		allowed   = make(map[string]string)
+		forbidden = make(map[string]string)
+		// Self-signed certificates are only exempt from the maximum validity
+		// if every config with a maximum exempts them.
+		maxValidityExempt = true
+	)
+	for i, c := range configs {
+		source := c.Source
+		if source == "" {
+			source = fmt.Sprintf("config %d", i+1)
+		}
+		sources = append(sources, source)
+
+		if c.Version != "" && c.Version != ExpectedVersion {
+			return Config{}, fmt.Errorf("unsupported config version in %s, expected %s, found %s", source, ExpectedVersion, c.Version)
+		}
+
+		for _, list := range append([][]CertificateEntry{c.Allow, c.Require}, c.RequireAnyOf...) {
+			for _, e := range list {
+				for _, fp := range entryFingerprints(e) {
+					if other, ok := forbidden[fp.key()]; ok && other != source {
+						return Config{}, fmt.Errorf("certificate with %s is allowed by %s, but forbidden by %s", fp, source, other)
+					}
+					if _, ok := allowed[fp.key()]; !ok {
+						allowed[fp.key()] = source
+					}
+				}
+			}
+		}
+		for _, e := range c.Forbid {
+			for _, fp := range entryFingerprints(e) {
+				if other, ok := allowed[fp.key()]; ok && other != source {
+					return Config{}, fmt.Errorf("certificate with %s is allowed by %s, but forbidden by %s", fp, other, source)
+				}
+				if _, ok := forbidden[fp.key()]; !ok {
+					forbidden[fp.key()] = source
+				}
+			}
+		}
+
+		merged.Allow = append(merged.Allow, c.Allow...)
+		merged.Forbid = append(merged.Forbid, c.Forbid...)
+		merged.Require = append(merged.Require, c.Require...)
+		merged.RequireAnyOf = append(merged.RequireAnyOf, c.RequireAnyOf...)
+		merged.Pkcs12Passwords = appendUnique(merged.Pkcs12Passwords, c.Pkcs12Passwords...)
+
+		merged.CheckExpiry = merged.CheckExpiry || c.CheckExpiry
+		if c.ExpiryWarning > merged.ExpiryWarning {
+			merged.ExpiryWarning = c.ExpiryWarning
+		}
+		merged.CheckNotYetValid = merged.CheckNotYetValid || c.CheckNotYetValid
+		if c.MaxValidityDuration > 0 {
+			if merged.MaxValidityDuration == 0 || c.MaxValidityDuration < merged.MaxValidityDuration {
+				merged.MaxValidityDuration = c.MaxValidityDuration
+			}
+			maxValidityExempt = maxValidityExempt && c.MaxValidityExemptSelfSigned
+		}
+		merged.ForbidWeakSignatureAlgorithms = merged.ForbidWeakSignatureAlgorithms || c.ForbidWeakSignatureAlgorithms
+		merged.WeakSignatureIncludeSelfSigned = merged.WeakSignatureIncludeSelfSigned || c.WeakSignatureIncludeSelfSigned
+		if c.MinRSAKeySize > merged.MinRSAKeySize {
+			merged.MinRSAKeySize = c.MinRSAKeySize
+		}
+		curves := intersectCurves(merged.AllowedECDSACurves, c.AllowedECDSACurves)
+		if len(curves) == 0 && len(merged.AllowedECDSACurves) > 0 && len(c.AllowedECDSACurves) > 0 {
+			return Config{}, fmt.Errorf("allowed ECDSA curves in %s have none in common with the other configs", source)
+		}
+		merged.AllowedECDSACurves = curves
+		merged.ForbidUnhandledCriticalExtensions = merged.ForbidUnhandledCriticalExtensions || c.ForbidUnhandledCriticalExtensions
+		merged.WarnSuspiciousSANs = merged.WarnSuspiciousSANs || c.WarnSuspiciousSANs
+		merged.WarnKeyIdAnomalies = merged.WarnKeyIdAnomalies || c.WarnKeyIdAnomalies
+		merged.AnchorPaths = appendUnique(merged.AnchorPaths, c.AnchorPaths...)
+	}
+
+	merged.MaxValidityExemptSelfSigned = merged.MaxValidityDuration > 0 && maxValidityExempt
+	merged.Source = strings.Join(sources, ", ")
+
+	return merged, nil
+}
+
+// entryFingerprint is a single fingerprint of a certificate entry.
+type entryFingerprint struct {
+	kind  string
+	value string
+}
+
+func (f entryFingerprint) key() string {
+	return f.kind + ":" + checksum.Normalize(f.value)
+}
+
+func (f entryFingerprint) String() string {
+	return fmt.Sprintf("%s fingerprint %s", f.kind, f.value)
+}
+
+// entryFingerprints returns every fingerprint of the entry.
+func entryFingerprints(e CertificateEntry) []entryFingerprint {
+	var fps []entryFingerprint
+	for _, f := range e.Fingerprints {
+		fps = append(fps, fingerprintValues(f)...)
+	}
+	return fps
+}
+
+// fingerprintValues returns each fingerprint of a set which is given.
+func fingerprintValues(f CertificateFingerprints) []entryFingerprint {
+	var fps []entryFingerprint
+	for _, fp := range []entryFingerprint{
+		{kind: "MD5", value: f.Md5},
+		{kind: "SHA1", value: f.Sha1},
+		{kind: "SHA256", value: f.Sha256},
+		{kind: "SHA256 prefix", value: f.Sha256Prefix},
+		{kind: "SHA512", value: f.Sha512},
+		{kind: "SPKI SHA256", value: f.SpkiSha256},
+	} {
+		if fp.value != "" {
+			fps = append(fps, fp)
+		}
+	}
+	return fps
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// intersectCurves returns the ECDSA curves allowed by both lists. An empty
+// list allows every curve.
+func intersectCurves(a, b []string) []string {
+	if len(a) == 0 {
+		return append([]string{}, b...)
+	}
+	if len(b) == 0 {
+		return a
+	}
+
+	var curves []string
+	for _, c := range a {
+		for _, d := range b {
+			if c == d {
+				curves = append(curves, c)
+				break
+			}
+		}
+	}
+	return curves
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConfigs(t *testing.T) {
+	sha256A := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+	sha256B := "edfa7caf7f1274d54bacec91e21a5b1a04a7b94bf197f5c92070b8de148d9b37"
+
+	t.Run("lists are concatenated and the strictest settings are used", func(t *testing.T) {
+		baseline := Config{
+			Source:             "baseline.yaml",
+			Version:            "1",
+			Allow:              []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256A}}}},
+			Pkcs12Passwords:    []string{"changeit"},
+			ExpiryWarning:      time.Hour,
+			MinRSAKeySize:      2048,
+			AllowedECDSACurves: []string{"P-256", "P-384"},
+			AnchorPaths:        []string{"/etc/ssl/certs"},
+		}
+		team := Config{
+			Source:             "team.yaml",
+			Version:            "1",
+			Forbid:             []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256B}}}},
+			Require:            []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256A}}}},
+			Pkcs12Passwords:    []string{"changeit", "s3cret"},
+			CheckExpiry:        true,
+			ExpiryWarning:      time.Minute,
+			MinRSAKeySize:      3072,
+			AllowedECDSACurves: []string{"P-384"},
+			AnchorPaths:        []string{"/etc/ssl/certs", "/etc/pki/ca-trust"},
+		}
+
+		merged, err := MergeConfigs(baseline, team)
+		require.NoError(t, err)
+		assert.Equal(t, Config{
+			Source:             "baseline.yaml, team.yaml",
+			Version:            "1",
+			Allow:              baseline.Allow,
+			Forbid:             team.Forbid,
+			Require:            team.Require,
+			Pkcs12Passwords:    []string{"changeit", "s3cret"},
+			CheckExpiry:        true,
+			ExpiryWarning:      time.Hour,
+			MinRSAKeySize:      3072,
+			AllowedECDSACurves: []string{"P-384"},
+			AnchorPaths:        []string{"/etc/ssl/certs", "/etc/pki/ca-trust"},
+		}, merged)
+
+		_, err = NewValidator(merged, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("a fingerprint allowed and forbidden by different configs is a conflict", func(t *testing.T) {
+		_, err := MergeConfigs(
+			Config{Source: "baseline.yaml", Allow: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256A}}}}},
+			Config{Source: "team.yaml", Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256A}}}}},
+		)
+		assert.EqualError(t, err, "certificate with SHA256 fingerprint "+sha256A+" is allowed by baseline.yaml, but forbidden by team.yaml")
+	})
+
+	t.Run("a fingerprint forbidden and required by different configs is a conflict", func(t *testing.T) {
+		_, err := MergeConfigs(
+			Config{Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha1: "4ae840b224dccf3af3ac0827be5f885eded18a17"}}}}},
+			Config{Require: []CertificateEntry{{Fingerprints: FingerprintList{{Sha1: "4AE840B224DCCF3AF3AC0827BE5F885EDED18A17"}}}}},
+		)
+		assert.EqualError(t, err, "certificate with SHA1 fingerprint 4AE840B224DCCF3AF3AC0827BE5F885EDED18A17 is allowed by config 2, but forbidden by config 1")
+	})
+
+	t.Run("a fingerprint allowed and forbidden by the same config is not a conflict", func(t *testing.T) {
+		_, err := MergeConfigs(Config{
+			Allow:  []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256A}}}},
+			Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256A}}}},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("the shortest maximum validity is used, exempting self-signed certificates only if every config does", func(t *testing.T) {
+		merged, err := MergeConfigs(
+			Config{Source: "baseline.yaml", MaxValidityDuration: 8760 * time.Hour, MaxValidityExemptSelfSigned: true},
+			Config{Source: "team.yaml", MaxValidityDuration: 2160 * time.Hour},
+			Config{Source: "other.yaml"},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 2160*time.Hour, merged.MaxValidityDuration)
+		assert.False(t, merged.MaxValidityExemptSelfSigned)
+
+		merged, err = MergeConfigs(
+			Config{Source: "baseline.yaml", MaxValidityDuration: 8760 * time.Hour, MaxValidityExemptSelfSigned: true},
+			Config{Source: "other.yaml"},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 8760*time.Hour, merged.MaxValidityDuration)
+		assert.True(t, merged.MaxValidityExemptSelfSigned)
+	})
+
+	t.Run("disjoint ECDSA curves are an error", func(t *testing.T) {
+		_, err := MergeConfigs(
+			Config{AllowedECDSACurves: []string{"P-256"}},
+			Config{AllowedECDSACurves: []string{"P-384"}},
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported versions are an error", func(t *testing.T) {
+		_, err := MergeConfigs(Config{Version: "2"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+const (
+	// DefaultOCSPTimeout is the default time allowed for each OCSP request.
+	DefaultOCSPTimeout = 10 * time.Second
+
+	// DefaultOCSPConcurrency is the default number of OCSP requests which
+	// are made at once.
+	DefaultOCSPConcurrency = 4
+
+	// maxOCSPResponseSize is the largest OCSP response which will be read.
+	maxOCSPResponseSize = 1 << 20
+)
+
+// OCSPUnknownCert is a certificate whose revocation status could not be
+// determined using OCSP.
+type OCSPUnknownCert struct {
+	Certificate certificate.Found
+
+	// Reason is a human-readable explanation of why the status is unknown.
+	Reason string
+}
+
+// OCSPChecker checks whether certificates have been revoked by querying the
+// OCSP responders given in their authority information access extension.
+type OCSPChecker struct {
+	// Timeout is the time allowed for each OCSP request.
+	Timeout time.Duration
+
+	// Concurrency is the number of OCSP requests which are made at once.
+	Concurrency int
+}
+
+// ocspStatus is the outcome of checking a certificate with OCSP.
+type ocspStatus struct {
+	revoked   bool
+	revokedAt time.Time
+	responder string
+	// unknown is the reason the status is unknown, or empty if it is known.
+	unknown string
+}
+
+// Check queries the OCSP responders of the certificates, returning those which
+// have been revoked, and those whose status could not be determined, such as
+// because the responder was unreachable. Self-signed certificates, and those
+// without an OCSP responder, are skipped. Each distinct certificate is only
+// checked once, however many locations it was found in.
+func (c *OCSPChecker) Check(ctx context.Context, founds []certificate.Found) ([]RevokedCert, []OCSPUnknownCert) {
+	concurrency := c.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultOCSPConcurrency
+	}
+
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		statuses = make(map[[32]byte]*ocspStatus)
+	)
+	for _, f := range founds {
+		if f.Certificate == nil || len(f.Certificate.OCSPServer) == 0 || certificate.IsSelfSigned(f.Certificate) {
+			continue
+		}
+		if _, ok := statuses[f.FingerprintSha256]; ok {
+			continue
+		}
+
+		status := &ocspStatus{}
+		statuses[f.FingerprintSha256] = status
+
+		issuer := findIssuer(f.Certificate, founds)
+		if issuer == nil {
+			status.unknown = "the issuer was not found in the image, so no OCSP request could be made"
+			continue
+		}
+
+		wg.Add(1)
+		go func(cert *x509.Certificate) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			*status = c.check(ctx, cert, issuer)
+		}(f.Certificate)
+	}
+	wg.Wait()
+
+	var (
+		revoked []RevokedCert
+		unknown []OCSPUnknownCert
+	)
+	for _, f := range founds {
+		status, ok := statuses[f.FingerprintSha256]
+		if !ok {
+			continue
+		}
+		switch {
+		case status.unknown != "":
+			unknown = append(unknown, OCSPUnknownCert{Certificate: f, Reason: status.unknown})
+		case status.revoked:
+			revoked = append(revoked, RevokedCert{
+				Certificate: f,
+				RevokedAt:   status.revokedAt,
+				Source:      "OCSP responder " + status.responder,
+			})
+		}
+	}
+	return revoked, unknown
+}
+
+// check queries the certificate's OCSP responders in turn, until one gives a
+// response.
+func (c *OCSPChecker) check(ctx context.Context, cert, issuer *x509.Certificate) ocspStatus {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return ocspStatus{unknown: fmt.Sprintf("failed to create OCSP request: %s", err)}
+	}
+
+	var reasons []string
+	for _, server := range cert.OCSPServer {
+		resp, err := c.query(ctx, server, req, cert, issuer)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", server, err))
+			continue
+		}
+
+		switch resp.Status {
+		case ocsp.Good:
+			return ocspStatus{responder: server}
+		case ocsp.Revoked:
+			return ocspStatus{revoked: true, revokedAt: resp.RevokedAt, responder: server}
+		default:
+			reasons = append(reasons, fmt.Sprintf("%s: the responder does not know the certificate", server))
+		}
+	}
+
+	return ocspStatus{unknown: fmt.Sprintf("no OCSP responder gave a status: %s", strings.Join(reasons, "; "))}
+}
+
+func (c *OCSPChecker) query(ctx context.Context, server string, req []byte, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultOCSPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+	httpReq.Header.Set("Accept", "application/ocsp-response")
+
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("responder returned %q", httpResp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(httpResp.Body, maxOCSPResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return ocsp.ParseResponseForCert(b, cert, issuer)
+}
+
+// findIssuer returns the certificate among those found which issued the given
+// certificate, or nil if there is none.
+func findIssuer(cert *x509.Certificate, founds []certificate.Found) *x509.Certificate {
+	for _, f := range founds {
+		if f.Certificate != nil && bytes.Equal(f.Certificate.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(f.Certificate) == nil {
+			return f.Certificate
+		}
+	}
+	return nil
+}
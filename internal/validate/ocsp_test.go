@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestOCSPChecker_Check(t *testing.T) {
+	var (
+		ca      *x509.Certificate
+		caKey   crypto.Signer
+		revoked = make(map[string]bool)
+	)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req, err := ocsp.ParseRequest(body)
+		require.NoError(t, err)
+
+		template := ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Hour),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}
+		if revoked[req.SerialNumber.String()] {
+			template.Status = ocsp.Revoked
+			template.RevokedAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		resp, err := ocsp.CreateResponse(ca, ca, template, caKey)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(resp)
+	}))
+	defer responder.Close()
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	ca, caKey = generateCertificate(t, &x509.Certificate{IsCA: true, OCSPServer: []string{responder.URL}}, nil, nil)
+	good, _ := generateCertificate(t, &x509.Certificate{OCSPServer: []string{responder.URL}}, ca, caKey)
+	bad, _ := generateCertificate(t, &x509.Certificate{OCSPServer: []string{unreachable.URL, responder.URL}}, ca, caKey)
+	revoked[bad.SerialNumber.String()] = true
+	offline, _ := generateCertificate(t, &x509.Certificate{OCSPServer: []string{unreachable.URL}}, ca, caKey)
+	noOCSP, _ := generateCertificate(t, &x509.Certificate{}, ca, caKey)
+	orphanCA, orphanCAKey := generateCertificate(t, &x509.Certificate{IsCA: true}, nil, nil)
+	orphan, _ := generateCertificate(t, &x509.Certificate{OCSPServer: []string{responder.URL}}, orphanCA, orphanCAKey)
+
+	founds := []certificate.Found{
+		{Location: "ca", Certificate: ca},
+		{Location: "good", Certificate: good, FingerprintSha256: [32]byte{1}},
+		{Location: "revoked", Certificate: bad, FingerprintSha256: [32]byte{2}},
+		{Location: "revoked-copy", Certificate: bad, FingerprintSha256: [32]byte{2}},
+		{Location: "offline", Certificate: offline, FingerprintSha256: [32]byte{3}},
+		{Location: "no-ocsp", Certificate: noOCSP, FingerprintSha256: [32]byte{4}},
+		{Location: "orphan", Certificate: orphan, FingerprintSha256: [32]byte{5}},
+		{Location: "unparsed"},
+	}
+
+	checker := OCSPChecker{Timeout: 5 * time.Second, Concurrency: 2}
+	revokedCerts, unknown := checker.Check(context.TODO(), founds)
+
+	assert.Equal(t, []RevokedCert{
+		{Certificate: founds[2], RevokedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Source: "OCSP responder " + responder.URL},
+		{Certificate: founds[3], RevokedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Source: "OCSP responder " + responder.URL},
+	}, revokedCerts)
+
+	require.Len(t, unknown, 2)
+	assert.Equal(t, founds[4], unknown[0].Certificate)
+	assert.Contains(t, unknown[0].Reason, "no OCSP responder gave a status: "+unreachable.URL)
+	assert.Equal(t, founds[6], unknown[1].Certificate)
+	assert.Equal(t, "the issuer was not found in the image, so no OCSP request could be made", unknown[1].Reason)
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// DefaultPinTimeout is the default time allowed for connecting to a server
+// and completing the TLS handshake when fetching its certificate chain.
+const DefaultPinTimeout = 10 * time.Second
+
+// ChainFetcher fetches the certificate chain presented by a TLS server, so
+// that its certificate authorities can be pinned.
+type ChainFetcher struct {
+	// ServerName is the name sent with server name indication, and which the
+	// server's certificate is verified for. If empty, the host of the address
+	// is used.
+	ServerName string
+
+	// Insecure skips verifying the server's certificate, so that chains
+	// which aren't trusted, such as those of private certificate
+	// authorities, can be fetched.
+	Insecure bool
+
+	// Timeout is the time allowed for connecting and completing the TLS
+	// handshake.
+	Timeout time.Duration
+
+	// rootCAs are the certificate authorities trusted when verifying the
+	// chain. If nil, the system's are used.
+	rootCAs *x509.CertPool
+}
+
+// Fetch connects to the TLS server at the address, in the form "host:port",
+// and returns its certificate chain, starting with the server's certificate.
+// If the chain is verified, it ends with the trusted root certificate, even
+// if the server doesn't present it. Otherwise, the chain is as presented.
+func (f *ChainFetcher) Fetch(ctx context.Context, addr string) ([]*x509.Certificate, error) {
+	serverName := f.ServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+		serverName = host
+	}
+
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPinTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := &tls.Dialer{Config: &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: f.Insecure,
+		RootCAs:            f.rootCAs,
+	}}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.VerifiedChains) > 0 {
+		return state.VerifiedChains[0], nil
+	}
+	if len(state.PeerCertificates) == 0 {
+		return nil, errors.New("server presented no certificates")
+	}
+	return state.PeerCertificates, nil
+}
+
+// PinConfig returns a config pinning the certificate authorities of a chain,
+// starting with the server's certificate, by their SHA256 fingerprints. The
+// last certificate of the chain, which is its root when the chain was
+// verified, is required, and any intermediates between it and the server's
+// certificate are allowed. The server's certificate is only pinned if it is
+// the whole chain, such as when it is self-signed. Each entry is commented
+// with the certificate's subject.
+func PinConfig(chain []*x509.Certificate) Config {
+	config := Config{Version: ExpectedVersion}
+	if len(chain) == 0 {
+		return config
+	}
+
+	root := chain[len(chain)-1]
+	config.Require = []CertificateEntry{pinEntry(root)}
+	if len(chain) > 2 {
+		for _, cert := range chain[1 : len(chain)-1] {
+			config.Allow = append(config.Allow, pinEntry(cert))
+		}
+	}
+	return config
+}
+
+func pinEntry(cert *x509.Certificate) CertificateEntry {
+	sum := sha256.Sum256(cert.Raw)
+	return CertificateEntry{
+		Comment:      cert.Subject.String(),
+		Fingerprints: FingerprintList{{Sha256: hex.EncodeToString(sum[:])}},
+	}
+}
@@ -0,0 +1,92 @@
+package validate
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainFetcher_Fetch(t *testing.T) {
+	root, rootKey := generateCertificate(t, &x509.Certificate{IsCA: true}, nil, nil)
+	intermediate, intermediateKey := generateCertificate(t, &x509.Certificate{IsCA: true}, root, rootKey)
+	leaf, leafKey := generateCertificate(t, &x509.Certificate{
+		DNSNames:    []string{"paranoia.test"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	}, intermediate, intermediateKey)
+
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.Raw, intermediate.Raw},
+		PrivateKey:  leafKey,
+	}}}
+	server.StartTLS()
+	defer server.Close()
+	addr := server.Listener.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	t.Run("Verified chains end with the root", func(t *testing.T) {
+		chain, err := (&ChainFetcher{rootCAs: roots}).Fetch(context.TODO(), addr)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{leaf, intermediate, root}, chain)
+	})
+
+	t.Run("Server name is verified", func(t *testing.T) {
+		_, err := (&ChainFetcher{ServerName: "paranoia.test", rootCAs: roots}).Fetch(context.TODO(), addr)
+		assert.NoError(t, err)
+
+		_, err = (&ChainFetcher{ServerName: "other.test", rootCAs: roots}).Fetch(context.TODO(), addr)
+		assert.Error(t, err)
+	})
+
+	t.Run("Untrusted chains fail unless insecure", func(t *testing.T) {
+		_, err := (&ChainFetcher{}).Fetch(context.TODO(), addr)
+		assert.Error(t, err)
+
+		chain, err := (&ChainFetcher{Insecure: true}).Fetch(context.TODO(), addr)
+		require.NoError(t, err)
+		assert.Equal(t, []*x509.Certificate{leaf, intermediate}, chain)
+	})
+}
+
+func TestPinConfig(t *testing.T) {
+	root, rootKey := generateCertificate(t, &x509.Certificate{IsCA: true}, nil, nil)
+	intermediate, intermediateKey := generateCertificate(t, &x509.Certificate{IsCA: true}, root, rootKey)
+	leaf, _ := generateCertificate(t, &x509.Certificate{}, intermediate, intermediateKey)
+
+	entry := func(cert *x509.Certificate) CertificateEntry {
+		sum := sha256.Sum256(cert.Raw)
+		return CertificateEntry{
+			Comment:      "CN=" + cert.Subject.CommonName,
+			Fingerprints: FingerprintList{{Sha256: hex.EncodeToString(sum[:])}},
+		}
+	}
+
+	assert.Equal(t, Config{
+		Version: "1",
+		Allow:   []CertificateEntry{entry(intermediate)},
+		Require: []CertificateEntry{entry(root)},
+	}, PinConfig([]*x509.Certificate{leaf, intermediate, root}))
+
+	t.Run("Self-signed certificates are required", func(t *testing.T) {
+		assert.Equal(t, Config{
+			Version: "1",
+			Require: []CertificateEntry{entry(root)},
+		}, PinConfig([]*x509.Certificate{root}))
+	})
+
+	t.Run("Pinned configs have no problems", func(t *testing.T) {
+		config := PinConfig([]*x509.Certificate{leaf, intermediate, root})
+		assert.Empty(t, ConfigProblems(&config))
+	})
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// The rules by which an entry can decide a certificate, in order of
+// decreasing precedence. When a certificate matches entries of several rules,
+// the rule with the highest precedence decides it, so a forbid entry always
+// wins over an allow entry, and a fingerprint always wins over attributes.
+const (
+	RuleForbidFingerprint = "forbidByFingerprint"
+	RuleForbidAttribute   = "forbidByAttribute"
+	RuleAllowFingerprint  = "allowByFingerprint"
+	RuleAllowAttribute    = "allowByAttribute"
+)
+
+// Rules are the rules by which an entry can decide a certificate, in order of
+// decreasing precedence.
+var Rules = []string{
+	RuleForbidFingerprint,
+	RuleForbidAttribute,
+	RuleAllowFingerprint,
+	RuleAllowAttribute,
+}
+
+// Decision is the rule which decided whether a certificate is allowed or
+// forbidden, and the entry which matched it.
+type Decision struct {
+	Certificate certificate.Found
+
+	// Rule is one of Rules.
+	Rule string
+
+	Entry CertificateEntry
+
+	// Fingerprint is the fingerprint of the entry which matched, as an entry
+	// may list several. It is empty if the rule matches attributes.
+	Fingerprint CertificateFingerprints
+}
+
+// Forbids returns true if the decision forbids the certificate.
+func (d Decision) Forbids() bool {
+	return d.Rule == RuleForbidFingerprint || d.Rule == RuleForbidAttribute
+}
+
+// decide returns the decision of the entry with the highest precedence which
+// matches the certificate, or false if none do. The allow list, including
+// required entries, is only considered in strict mode.
+func (v *Validator) decide(f certificate.Found, strict bool) (Decision, bool) {
+	if m, ok := v.forbid.match(f); ok {
+		return Decision{Certificate: f, Rule: RuleForbidFingerprint, Entry: m.entry, Fingerprint: m.fingerprint}, true
+	}
+
+	for _, m := range v.forbidMatchers {
+		if m.matches(f.Certificate) && m.entry.inScope(f) {
+			return Decision{Certificate: f, Rule: RuleForbidAttribute, Entry: m.entry}, true
+		}
+	}
+
+	if !strict {
+		return Decision{}, false
+	}
+
+	if m, ok := v.allow.match(f); ok {
+		return Decision{Certificate: f, Rule: RuleAllowFingerprint, Entry: m.entry, Fingerprint: m.fingerprint}, true
+	}
+
+	if m, ok := v.allowMatcher(f); ok {
+		return Decision{Certificate: f, Rule: RuleAllowAttribute, Entry: m.entry}, true
+	}
+
+	return Decision{}, false
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+func TestValidator_Precedence(t *testing.T) {
+	forbiddenSHA256 := "edfa7caf7f1274d54bacec91e21a5b1a04a7b94bf197f5c92070b8de148d9b37"
+	allowedSHA1 := "4ae840b224dccf3af3ac0827be5f885eded18a17"
+	found := certificate.Found{
+		FingerprintSha1:   checksum.MustParseSHA1(allowedSHA1),
+		FingerprintSha256: checksum.MustParseSHA256(forbiddenSHA256),
+		Certificate: &x509.Certificate{
+			Subject: pkix.Name{CommonName: "leaf.corp.internal"},
+			Issuer:  pkix.Name{CommonName: "Compromised CA"},
+		},
+	}
+
+	forbidFingerprint := CertificateEntry{Fingerprints: FingerprintList{{Sha256: forbiddenSHA256}}}
+	forbidAttribute := CertificateEntry{IssuerDN: "CN=Compromised CA"}
+	allowFingerprint := CertificateEntry{Fingerprints: FingerprintList{{Sha1: allowedSHA1}}}
+	allowAttribute := CertificateEntry{SubjectCNPattern: "*.corp.internal"}
+
+	// Each case removes the entry of the rule with the highest precedence in
+	// the case before, so the next rule decides.
+	testCases := []struct {
+		name   string
+		config Config
+		want   Decision
+	}{
+		{
+			name: "a forbidden fingerprint wins over every other rule",
+			config: Config{
+				Allow:  []CertificateEntry{allowAttribute},
+				Forbid: []CertificateEntry{forbidAttribute, forbidFingerprint},
+			},
+			want: Decision{Certificate: found, Rule: RuleForbidFingerprint, Entry: forbidFingerprint, Fingerprint: forbidFingerprint.Fingerprints[0]},
+		},
+		{
+			name: "forbidden attributes win over an allowed fingerprint",
+			config: Config{
+				Allow:  []CertificateEntry{allowFingerprint, allowAttribute},
+				Forbid: []CertificateEntry{forbidAttribute},
+			},
+			want: Decision{Certificate: found, Rule: RuleForbidAttribute, Entry: forbidAttribute},
+		},
+		{
+			name: "an allowed fingerprint wins over allowed attributes",
+			config: Config{
+				Allow: []CertificateEntry{allowAttribute, allowFingerprint},
+			},
+			want: Decision{Certificate: found, Rule: RuleAllowFingerprint, Entry: allowFingerprint, Fingerprint: allowFingerprint.Fingerprints[0]},
+		},
+		{
+			name: "allowed attributes decide when no other rule matches",
+			config: Config{
+				Allow: []CertificateEntry{allowAttribute},
+			},
+			want: Decision{Certificate: found, Rule: RuleAllowAttribute, Entry: allowAttribute},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			validator, err := NewValidator(tc.config, false)
+			require.NoError(t, err)
+			r, err := validator.Validate([]certificate.Found{found})
+			require.NoError(t, err)
+			assert.Equal(t, []Decision{tc.want}, r.Decisions)
+			assert.Equal(t, tc.want.Forbids(), len(r.ForbiddenCertificates) == 1)
+
+			explanations := validator.Explain([]certificate.Found{found})
+			require.Len(t, explanations, 1)
+			assert.Equal(t, tc.want.Rule, explanations[0].Rule)
+		})
+	}
+
+	t.Run("a required fingerprint is allowed by fingerprint", func(t *testing.T) {
+		validator, err := NewValidator(Config{Require: []CertificateEntry{allowFingerprint}}, false)
+		require.NoError(t, err)
+		r, err := validator.Validate([]certificate.Found{found})
+		require.NoError(t, err)
+		require.Len(t, r.Decisions, 1)
+		assert.Equal(t, RuleAllowFingerprint, r.Decisions[0].Rule)
+	})
+
+	t.Run("allow rules don't decide in permissive mode", func(t *testing.T) {
+		validator, err := NewValidator(Config{Allow: []CertificateEntry{allowFingerprint}}, true)
+		require.NoError(t, err)
+		r, err := validator.Validate([]certificate.Found{found})
+		require.NoError(t, err)
+		assert.Empty(t, r.Decisions)
+	})
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+// minSafeSHA256PrefixLength is the length, in hex characters, below which a
+// SHA256 fingerprint prefix is warned about, as it is likely to match
+// unrelated certificates.
+const minSafeSHA256PrefixLength = 8
+
+// sha256Prefix is an allowed or forbidden SHA256 fingerprint prefix, and the
+// entry which allows or forbids it.
+type sha256Prefix struct {
+	prefix string
+	match  fingerprintMatch
+}
+
+// parseSHA256Prefix parses a hex encoded prefix of a SHA256 fingerprint,
+// which may be separated as accepted by checksum.Normalize, returning it in
+// lower case.
+func parseSHA256Prefix(s string) (string, error) {
+	s = checksum.Normalize(s)
+	if s == "" {
+		return "", errors.New("empty prefix for SHA256")
+	}
+	if len(s) >= hex.EncodedLen(32) {
+		return "", errors.New("prefix is as long as a SHA256 fingerprint, use sha256 instead")
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return "", errors.New("invalid hex character in prefix")
+		}
+	}
+	return s, nil
+}
+
+// hasSHA256Prefix returns true if the fingerprint starts with the given lower
+// case hex prefix.
+func hasSHA256Prefix(fingerprint [32]byte, prefix string) bool {
+	return strings.HasPrefix(hex.EncodeToString(fingerprint[:]), prefix)
+}
@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import "github.com/jetstack/paranoia/internal/certificate"
+
+// Redact hides the subject alternative names listed by the result, and the
+// subjects and subject alternative names of the allow bundles' certificates
+// which were not found, as certificate.Redact does. The certificates found by
+// a scan are shared with its result, so should be redacted with
+// certificate.Redact. This should only be done once every use of the result
+// which needs the certificates' names, such as explaining decisions, is done.
+func (r *Result) Redact() {
+	for i := range r.SuspiciousSANCertificates {
+		sans := make([]string, len(r.SuspiciousSANCertificates[i].SANs))
+		for j := range sans {
+			sans[j] = certificate.Redacted
+		}
+		r.SuspiciousSANCertificates[i].SANs = sans
+	}
+	certificate.Redact(r.UnseenBundleCertificates)
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+func TestResult_Redact(t *testing.T) {
+	private := certificate.Found{Location: "private", Certificate: &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "printer.local"},
+		DNSNames:    []string{"printer.local"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+	}}
+	unseen := certificate.Found{Location: "roots.pem", Certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "Internal Root"}}}
+
+	validator, err := NewValidator(Config{WarnSuspiciousSANs: true}, true)
+	require.NoError(t, err)
+	r, err := validator.Validate([]certificate.Found{private})
+	require.NoError(t, err)
+	r.UnseenBundleCertificates = []certificate.Found{unseen}
+
+	r.Redact()
+
+	require.Len(t, r.SuspiciousSANCertificates, 1)
+	assert.Equal(t, []string{certificate.Redacted, certificate.Redacted}, r.SuspiciousSANCertificates[0].SANs)
+	assert.Equal(t, "CN=[redacted]", unseen.Certificate.Subject.String())
+	assert.Equal(t, "CN=printer.local", private.Certificate.Subject.String(), "expected found certificates to be redacted separately")
+}
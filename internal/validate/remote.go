@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/jetstack/paranoia/internal/util/checksum"
+)
+
+// maxRemoteSize is the largest config or CRL which will be fetched from a
+// remote URL, guarding against reading an unbounded response.
+const maxRemoteSize = 10 << 20
+
+// remoteConfigContentTypes are the content types a remote config may be
+// served with. Plain text is accepted, as many servers, such as those serving
+// files from source control, do not know the YAML content type.
+var remoteConfigContentTypes = []string{
+	"application/yaml",
+	"application/x-yaml",
+	"text/yaml",
+	"text/x-yaml",
+	"application/json",
+	"text/plain",
+}
+
+// IsRemoteConfig returns true if the config location is a HTTP or HTTPS URL,
+// rather than a file.
+func IsRemoteConfig(location string) bool {
+	return isURL(location)
+}
+
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// FetchConfig fetches a config from a HTTP or HTTPS URL, and parses it in the
+// same way as LoadConfig. If the hex encoded SHA256 checksum is not empty, the
+// fetched config must match it.
+func FetchConfig(ctx context.Context, url, sha256Checksum string) (*Config, error) {
+	var want [32]byte
+	if sha256Checksum != "" {
+		var err error
+		want, err = checksum.ParseSHA256(sha256Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checksum %q: %w", sha256Checksum, err)
+		}
+	}
+
+	b, contentType, err := fetch(ctx, url, remoteConfigContentTypes)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRemoteConfigContentType(contentType); err != nil {
+		return nil, err
+	}
+
+	if sha256Checksum != "" {
+		if got := sha256.Sum256(b); got != want {
+			return nil, fmt.Errorf("config has SHA256 checksum %x, but %x was expected; the config may have been changed or tampered with", got, want)
+		}
+	}
+
+	return parseConfig(url, b)
+}
+
+// fetch returns the body and content type of the response to a GET request
+// for the URL, which must be successful.
+func fetch(ctx context.Context, url string, accept []string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fetching returned %q, expected \"200 OK\"; check the URL is correct and publicly readable", resp.Status)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(b) > maxRemoteSize {
+		return nil, "", fmt.Errorf("response is larger than the maximum of %d bytes", maxRemoteSize)
+	}
+
+	return b, resp.Header.Get("Content-Type"), nil
+}
+
+func checkRemoteConfigContentType(contentType string) error {
+	if contentType == "" {
+		return fmt.Errorf("config was served without a content type, expected one of %s", strings.Join(remoteConfigContentTypes, ", "))
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("config was served with invalid content type %q: %w", contentType, err)
+	}
+	for _, t := range remoteConfigContentTypes {
+		if mediaType == t {
+			return nil
+		}
+	}
+	return fmt.Errorf("config was served with content type %q, expected one of %s", mediaType, strings.Join(remoteConfigContentTypes, ", "))
+}
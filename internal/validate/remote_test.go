@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRemoteConfig(t *testing.T) {
+	assert.True(t, IsRemoteConfig("https://example.com/paranoia.yaml"))
+	assert.True(t, IsRemoteConfig("http://example.com/paranoia.yaml"))
+	assert.False(t, IsRemoteConfig(".paranoia.yaml"))
+	assert.False(t, IsRemoteConfig("/etc/paranoia/https.yaml"))
+}
+
+func TestFetchConfig(t *testing.T) {
+	const body = `version: "1"
+checkExpiry: true
+forbid:
+  - fingerprints:
+      sha256: 96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6
+`
+	sum := sha256.Sum256([]byte(body))
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/paranoia.yaml":
+			w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		case "/raw/paranoia.yaml":
+			w.Header().Set("Content-Type", "text/plain")
+		case "/paranoia.html":
+			w.Header().Set("Content-Type", "text/html")
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	t.Run("a config is fetched and parsed", func(t *testing.T) {
+		for _, path := range []string{"/paranoia.yaml", "/raw/paranoia.yaml"} {
+			config, err := FetchConfig(context.TODO(), server.URL+path, "")
+			require.NoError(t, err)
+			assert.Equal(t, server.URL+path, config.Source)
+			assert.True(t, config.CheckExpiry)
+			require.Len(t, config.Forbid, 1)
+			assert.Equal(t, server.URL+path, config.Forbid[0].Source)
+		}
+	})
+
+	t.Run("a config matching its checksum is fetched", func(t *testing.T) {
+		_, err := FetchConfig(context.TODO(), server.URL+"/paranoia.yaml", strings.ToUpper(checksum))
+		assert.NoError(t, err)
+	})
+
+	t.Run("a config not matching its checksum is an error", func(t *testing.T) {
+		_, err := FetchConfig(context.TODO(), server.URL+"/paranoia.yaml", strings.Repeat("0", 64))
+		assert.ErrorContains(t, err, "config has SHA256 checksum "+checksum+", but "+strings.Repeat("0", 64)+" was expected")
+	})
+
+	t.Run("an invalid checksum is an error", func(t *testing.T) {
+		_, err := FetchConfig(context.TODO(), server.URL+"/paranoia.yaml", "abc")
+		assert.ErrorContains(t, err, `invalid checksum "abc"`)
+	})
+
+	t.Run("an unexpected content type is an error", func(t *testing.T) {
+		_, err := FetchConfig(context.TODO(), server.URL+"/paranoia.html", "")
+		assert.ErrorContains(t, err, `config was served with content type "text/html"`)
+	})
+
+	t.Run("a response other than 200 OK is an error", func(t *testing.T) {
+		_, err := FetchConfig(context.TODO(), server.URL+"/missing.yaml", "")
+		assert.ErrorContains(t, err, `fetching returned "404 Not Found"`)
+	})
+
+	t.Run("a network failure is an error", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		_, err := FetchConfig(context.TODO(), closed.URL+"/paranoia.yaml", "")
+		assert.ErrorContains(t, err, "failed to fetch")
+	})
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"crypto/x509"
+	"net"
+	"strings"
+
+	"github.com/jetstack/paranoia/internal/certificate"
+)
+
+// reservedDomains are the top-level domains, and other suffixes, which are
+// reserved for private networks or otherwise never publicly resolvable, or
+// are widely used for internal names.
+var reservedDomains = []string{
+	"local",     // multicast DNS, RFC 6762
+	"localhost", // RFC 6761
+	"internal",  // reserved by ICANN for private use
+	"home.arpa", // home networks, RFC 8375
+	"test",      // RFC 6761
+	"invalid",   // RFC 6761
+	"lan",
+	"corp",
+	"home",
+	"intranet",
+	"private",
+}
+
+// SuspiciousSANCert is a certificate with subject alternative names only
+// meaningful on a private network.
+type SuspiciousSANCert struct {
+	Certificate certificate.Found
+
+	// SANs are the certificate's suspicious subject alternative names, as
+	// given by SuspiciousSANs.
+	SANs []string
+}
+
+// SuspiciousSANs returns the subject alternative names of the certificate
+// which are only meaningful on a private network, such as RFC 1918 IP
+// addresses or names under the .local domain. A certificate for such names
+// found in a distributable image is likely to be a leaked internal
+// certificate. If nil is given, nil is returned.
+func SuspiciousSANs(cert *x509.Certificate) []string {
+	if cert == nil {
+		return nil
+	}
+
+	var sans []string
+	for _, name := range cert.DNSNames {
+		if isReservedName(name) {
+			sans = append(sans, name)
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		if isPrivateIP(ip) {
+			sans = append(sans, ip.String())
+		}
+	}
+	return sans
+}
+
+// isReservedName returns true if the DNS name is under a reserved domain,
+// ignoring case and a trailing dot.
+func isReservedName(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for _, d := range reservedDomains {
+		if name == d || strings.HasSuffix(name, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateIP returns true if the IP address is only reachable on a private
+// network or the local host, such as RFC 1918 and RFC 4193 addresses, or
+// the unspecified address.
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+}
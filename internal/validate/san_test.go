@@ -0,0 +1,48 @@
+package validate
+
+import (
+	"crypto/x509"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuspiciousSANs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cert *x509.Certificate
+		want []string
+	}{
+		"no certificate": {},
+		"public names and addresses": {
+			cert: &x509.Certificate{
+				DNSNames:    []string{"example.com", "local.example.com", "internal-tools.io"},
+				IPAddresses: []net.IP{net.ParseIP("8.8.8.8"), net.ParseIP("2001:4860:4860::8888")},
+			},
+		},
+		"reserved domains": {
+			cert: &x509.Certificate{
+				DNSNames: []string{"printer.local", "*.svc.cluster.INTERNAL", "localhost", "router.home.arpa.", "build.corp"},
+			},
+			want: []string{"printer.local", "*.svc.cluster.INTERNAL", "localhost", "router.home.arpa.", "build.corp"},
+		},
+		"private addresses": {
+			cert: &x509.Certificate{
+				IPAddresses: []net.IP{
+					net.ParseIP("10.1.2.3"),
+					net.ParseIP("172.16.0.1"),
+					net.ParseIP("192.168.1.1"),
+					net.ParseIP("127.0.0.1"),
+					net.ParseIP("169.254.169.254"),
+					net.ParseIP("fd00::1"),
+					net.ParseIP("172.32.0.1"),
+				},
+			},
+			want: []string{"10.1.2.3", "172.16.0.1", "192.168.1.1", "127.0.0.1", "169.254.169.254", "fd00::1"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, SuspiciousSANs(tc.cert))
+		})
+	}
+}
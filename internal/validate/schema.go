@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// schemaDialect is the JSON Schema draft the config schema is written in.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	fingerprintListType = reflect.TypeOf(FingerprintList{})
+)
+
+// Schema returns a JSON Schema describing config files, for editor completion
+// and linting. It is generated from the Config structure, using the names
+// from its YAML tags, so that it stays in sync as fields are added.
+func Schema() ([]byte, error) {
+	g := schemaGenerator{defs: make(map[string]interface{})}
+	schema := g.object(reflect.TypeOf(Config{}))
+	schema["$schema"] = schemaDialect
+	schema["title"] = "Paranoia validate config"
+	schema["$defs"] = g.defs
+
+	// The version is checked before the rest of the config is parsed, and
+	// is the only one supported.
+	schema["properties"].(map[string]interface{})["version"] = map[string]interface{}{
+		"type": "string",
+		"enum": []string{ExpectedVersion},
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaGenerator generates schemas for Go types. Structs other than the
+// top level config are added to defs, and referenced by name.
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+func (g schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	if t == durationType {
+		// Durations are given as a string such as "720h", or as an integer
+		// number of nanoseconds.
+		return map[string]interface{}{
+			"type":    []string{"string", "integer"},
+			"pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+		}
+	}
+
+	if t == timeType {
+		// Times are given as a YAML timestamp, such as "2024-06-30" or
+		// "2024-06-30T12:00:00Z".
+		return map[string]interface{}{
+			"type":    "string",
+			"pattern": `^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt ].*)?$`,
+		}
+	}
+
+	if t == fingerprintListType {
+		// Fingerprints are given as a single set, or a list of sets any of
+		// which match.
+		fingerprints := g.schema(t.Elem())
+		return map[string]interface{}{
+			"oneOf": []interface{}{fingerprints, map[string]interface{}{"type": "array", "items": fingerprints}},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			// Reserve the name before generating, in case the type refers
+			// to itself.
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// object returns the schema of a struct. Fields without a YAML tag omitempty
+// option are required.
+func (g schemaGenerator) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		properties[name] = g.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,52 @@
+package validate
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	b, err := Schema()
+	require.NoError(t, err)
+
+	var schema struct {
+		Schema     string                     `json:"$schema"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+		Defs       map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal(b, &schema))
+
+	assert.Equal(t, schemaDialect, schema.Schema)
+	assert.Equal(t, []string{"version"}, schema.Required)
+	assert.JSONEq(t, `{"type": "string", "enum": ["1"]}`, string(schema.Properties["version"]))
+	assert.JSONEq(t, `{"type": "array", "items": {"$ref": "#/$defs/CertificateEntry"}}`, string(schema.Properties["allow"]))
+	assert.JSONEq(t, `{"type": "array", "items": {"type": "array", "items": {"$ref": "#/$defs/CertificateEntry"}}}`, string(schema.Properties["requireAnyOf"]))
+	assert.Contains(t, string(schema.Properties["expiryWarning"]), `"integer"`)
+
+	// Every field of the config must be described, so the schema doesn't
+	// fall out of sync as fields are added.
+	for typ, properties := range map[reflect.Type]map[string]json.RawMessage{
+		reflect.TypeOf(Config{}):                  schema.Properties,
+		reflect.TypeOf(CertificateEntry{}):        schema.Defs["CertificateEntry"].Properties,
+		reflect.TypeOf(CertificateFingerprints{}): schema.Defs["CertificateFingerprints"].Properties,
+	} {
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+			if name == "-" {
+				assert.NotContains(t, properties, typ.Field(i).Name)
+				continue
+			}
+			assert.Contains(t, properties, name, "expected %s.%s to be in the schema", typ.Name(), typ.Field(i).Name)
+		}
+	}
+	assert.Empty(t, schema.Defs["CertificateEntry"].Required, "expected entries to not require fingerprints, as they may match by attributes")
+}
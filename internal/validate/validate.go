@@ -3,29 +3,121 @@
 package validate
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
 	"fmt"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/jetstack/paranoia/internal/certificate"
-	"github.com/jetstack/paranoia/internal/util/checksum"
 )
 
+// weakSignatureAlgorithms are the signature algorithms which are forbidden by
+// the ForbidWeakSignatureAlgorithms option.
+var weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
 type Validator struct {
 	config         Config
 	permissiveMode bool
-	allowSHA1      map[[20]byte]bool
-	allowSHA256    map[[32]byte]bool
-	forbidSHA1     map[[20]byte]CertificateEntry
-	forbidSHA256   map[[32]byte]CertificateEntry
+	// allow and forbid are the fingerprints of the allow and forbid lists,
+	// including the fingerprints of required certificates, which are
+	// implicitly allowed.
+	allow          fingerprintSet
+	forbid         fingerprintSet
+	allowMatchers  []attributeMatcher
+	forbidMatchers []attributeMatcher
 	required       []CertificateEntry
+	requiredGroups [][]CertificateEntry
+	// revocations are the entries of CRLs, keyed by the issuer and serial
+	// number of the certificates they revoke.
+	revocations map[string][]revocation
+	// expiredEntries are the allow and forbid entries which had expired when
+	// the validator was created, so are ignored.
+	expiredEntries []ExpiredEntry
+	// clock gives the time certificates and entries are checked against.
+	clock Clock
+	// verifyChains enables verifying that certificates chain to a root,
+	// trusting the self-signed certificates found and chainRoots.
+	verifyChains bool
+	chainRoots   []certificate.Found
+}
+
+// ExpiredEntry is an allow or forbid entry which has expired, so is ignored.
+type ExpiredEntry struct {
+	Entry CertificateEntry
+	// List is the name of the list holding the entry, "allow" or "forbid".
+	List string
+	// Position is the position of the entry in its list.
+	Position int
 }
 
 func (v *Validator) DescribeConfig() string {
 	s := fmt.Sprintf("%d allowed, %d forbidden, and %d required certificates",
-		len(v.allowSHA1)+len(v.allowSHA256),
-		len(v.forbidSHA1)+len(v.forbidSHA256),
+		v.allow.len()+len(v.allowMatchers),
+		v.forbid.len()+len(v.forbidMatchers),
 		len(v.required))
+	if len(v.requiredGroups) > 0 {
+		s += fmt.Sprintf(", with %d groups of which at least one certificate is required", len(v.requiredGroups))
+	}
+	if len(v.revocations) > 0 {
+		s += fmt.Sprintf(", checking %d certificates revoked by CRLs", len(v.revocations))
+	}
+	if v.config.CheckExpiry {
+		s += ", checking expiry"
+		if v.config.ExpiryWarning > 0 {
+			s += fmt.Sprintf(" with a warning window of %s", v.config.ExpiryWarning)
+		}
+	}
+	if v.config.CheckNotYetValid {
+		s += ", checking certificates are valid yet"
+	}
+	if v.config.MaxValidityDuration > 0 {
+		s += fmt.Sprintf(", requiring validity periods of at most %s", v.config.MaxValidityDuration)
+		if v.config.MaxValidityExemptSelfSigned {
+			s += " for certificates which are not self-signed"
+		}
+	}
+	if v.config.ForbidWeakSignatureAlgorithms {
+		s += ", forbidding weak signature algorithms"
+	}
+	if v.config.MinRSAKeySize > 0 {
+		s += fmt.Sprintf(", requiring RSA keys of at least %d bits", v.config.MinRSAKeySize)
+	}
+	if len(v.config.AllowedECDSACurves) > 0 {
+		s += fmt.Sprintf(", allowing ECDSA curves %s", strings.Join(v.config.AllowedECDSACurves, ", "))
+	}
+	if v.config.ForbidUnhandledCriticalExtensions {
+		s += ", forbidding unhandled critical extensions"
+	}
+	if v.config.WarnSuspiciousSANs {
+		s += ", warning about private network subject alternative names"
+	}
+	if v.config.WarnKeyIdAnomalies {
+		s += ", warning about missing or colliding subject key identifiers"
+	}
+	if len(v.config.AnchorPaths) > 0 {
+		s += fmt.Sprintf(", checking certificates in %d anchor paths are certificate authorities", len(v.config.AnchorPaths))
+	}
+	if v.verifyChains {
+		s += ", verifying certificates chain to a root"
+		if len(v.chainRoots) > 0 {
+			s += fmt.Sprintf(" or to %d given roots", len(v.chainRoots))
+		}
+	}
+	if c, ok := v.clock.(FixedClock); ok {
+		s += fmt.Sprintf(", as of %s", time.Time(c).Format(time.RFC3339))
+	}
 	if v.permissiveMode {
 		s += ", in permissive mode"
 	} else {
@@ -34,155 +126,631 @@ func (v *Validator) DescribeConfig() string {
 	return s
 }
 
-func NewValidator(config Config, permissiveMode bool) (*Validator, error) {
+func NewValidator(config Config, permissiveMode bool, opts ...Option) (*Validator, error) {
 	if !IsConfigValid(&config) {
 		return nil, fmt.Errorf("invalid validator config")
 	}
+	for _, pattern := range config.AnchorPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid anchor path %q", pattern))
+		}
+	}
+	if conflicts := allowForbidConflicts(&config); len(conflicts) > 0 {
+		return nil, fmt.Errorf("certificate with %s is both allowed and forbidden", conflicts[0])
+	}
 	v := Validator{
 		config:         config,
 		permissiveMode: permissiveMode,
-		allowSHA1:      make(map[[20]byte]bool),
-		allowSHA256:    make(map[[32]byte]bool),
-		forbidSHA1:     make(map[[20]byte]CertificateEntry),
-		forbidSHA256:   make(map[[32]byte]CertificateEntry),
+		allow:          newFingerprintSet(),
+		forbid:         newFingerprintSet(),
 		required:       config.Require,
+		requiredGroups: config.RequireAnyOf,
+		revocations:    make(map[string][]revocation),
+		clock:          realClock{},
 	}
-	if !permissiveMode {
-		for i, allowed := range config.Allow {
-			if allowed.Fingerprints.Sha256 != "" {
-				sha, err := checksum.ParseSHA256(allowed.Fingerprints.Sha256)
-				if err != nil {
-					return nil, errors.Wrap(err, fmt.Sprintf("entry at position %d in allow list had invalid SHA256", i))
-				}
-				v.allowSHA256[sha] = true
-			} else if allowed.Fingerprints.Sha1 != "" {
-				sha, err := checksum.ParseSHA1(allowed.Fingerprints.Sha1)
-				if err != nil {
-					return nil, errors.Wrap(err, fmt.Sprintf("entry at position %d in allow list had invalid SHA1", i))
-				}
-				v.allowSHA1[sha] = true
+	for _, opt := range opts {
+		opt(&v)
+	}
+	now := v.clock.Now()
+
+	// The allow list is built even in permissive mode, where it is not
+	// enforced, so that certificates can be explained as in strict mode.
+	for i, allowed := range config.Allow {
+		if v.isExpired(allowed, "allow", i, now) {
+			continue
+		}
+		if allowed.hasAttributes() {
+			m, err := newAttributeMatcher(allowed)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("entry at position %d in allow list had invalid attributes", i))
 			}
+			v.allowMatchers = append(v.allowMatchers, m)
+		} else if err := v.allow.add(allowed, fmt.Sprintf("entry at position %d in allow list", i)); err != nil {
+			return nil, err
 		}
+	}
 
-		for i, required := range config.Require {
-			if required.Fingerprints.Sha256 != "" {
-				sha, err := checksum.ParseSHA256(required.Fingerprints.Sha256)
-				if err != nil {
-					return nil, errors.Wrap(err, fmt.Sprintf("entry at position %d in require list had invalid SHA256", i))
-				}
-				v.allowSHA256[sha] = true
-			} else if required.Fingerprints.Sha1 != "" {
-				sha, err := checksum.ParseSHA1(required.Fingerprints.Sha1)
-				if err != nil {
-					return nil, errors.Wrap(err, fmt.Sprintf("entry at position %d in require list had invalid SHA1", i))
-				}
-				v.allowSHA1[sha] = true
-			}
+	for i, required := range config.Require {
+		if err := v.allowRequired(required, i, "require list"); err != nil {
+			return nil, err
+		}
+	}
 
+	for g, group := range config.RequireAnyOf {
+		for i, required := range group {
+			if err := v.allowRequired(required, i, fmt.Sprintf("requireAnyOf group %d", g)); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	for i, forbidden := range config.Forbid {
-		if forbidden.Fingerprints.Sha256 != "" {
-			sha, err := checksum.ParseSHA256(forbidden.Fingerprints.Sha256)
-			if err != nil {
-				return nil, errors.Wrap(err, fmt.Sprintf("entry at position %d in forbid list had invalid SHA256", i))
-			}
-			v.forbidSHA256[sha] = forbidden
-		} else if forbidden.Fingerprints.Sha1 != "" {
-			sha, err := checksum.ParseSHA1(forbidden.Fingerprints.Sha1)
+		if v.isExpired(forbidden, "forbid", i, now) {
+			continue
+		}
+		if forbidden.hasAttributes() {
+			m, err := newAttributeMatcher(forbidden)
 			if err != nil {
-				return nil, errors.Wrap(err, fmt.Sprintf("entry at position %d in forbid list had invalid SHA1", i))
+				return nil, errors.Wrap(err, fmt.Sprintf("entry at position %d in forbid list had invalid attributes", i))
 			}
-			v.forbidSHA1[sha] = forbidden
+			v.forbidMatchers = append(v.forbidMatchers, m)
+		} else if err := v.forbid.add(forbidden, fmt.Sprintf("entry at position %d in forbid list", i)); err != nil {
+			return nil, err
 		}
 	}
 	return &v, nil
 }
 
+// allowRequired adds the fingerprint of a required certificate entry, or its
+// attributes if it has no fingerprint, to the allow list, as required
+// certificates are implicitly allowed. The position
+// and list of the entry are used to describe it in errors.
+func (v *Validator) allowRequired(required CertificateEntry, i int, list string) error {
+	if required.hasFingerprint() {
+		if err := v.allow.add(required, fmt.Sprintf("entry at position %d in %s", i, list)); err != nil {
+			return err
+		}
+	} else if required.hasAttributes() {
+		m, err := newAttributeMatcher(required)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("entry at position %d in %s had invalid attributes", i, list))
+		}
+		v.allowMatchers = append(v.allowMatchers, m)
+	}
+	return nil
+}
+
+// isExpired returns true if the entry at the given position in the list has
+// expired, recording it as an expired entry.
+func (v *Validator) isExpired(entry CertificateEntry, list string, i int, now time.Time) bool {
+	if entry.ExpiresAt.IsZero() || now.Before(entry.ExpiresAt) {
+		return false
+	}
+	v.expiredEntries = append(v.expiredEntries, ExpiredEntry{Entry: entry, List: list, Position: i})
+	return true
+}
+
 type ForbiddenCert struct {
 	Certificate certificate.Found
 	Entry       CertificateEntry
+	// Fingerprint is the fingerprint of the entry which the certificate
+	// matched, as an entry may list several. It is empty if the certificate
+	// was forbidden by its attributes.
+	Fingerprint CertificateFingerprints
+	// Rule is the rule which forbade the certificate, either
+	// RuleForbidFingerprint or RuleForbidAttribute.
+	Rule string
 }
 
 type Result struct {
 	NotAllowedCertificates []certificate.Found
 	ForbiddenCertificates  []ForbiddenCert
 	RequiredButAbsent      []CertificateEntry
+
+	// RevokedCertificates are certificates which have been revoked by their
+	// issuer, according to a CRL or OCSP. These fail validation.
+	RevokedCertificates []RevokedCert
+
+	// OCSPUnknownCertificates are certificates whose revocation status
+	// could not be determined using OCSP. These are a warning only, and do
+	// not fail validation.
+	OCSPUnknownCertificates []OCSPUnknownCert
+
+	// RequiredGroupsUnsatisfied are the groups of the RequireAnyOf list for
+	// which none of the certificates were found. These fail validation.
+	RequiredGroupsUnsatisfied [][]CertificateEntry
+
+	// ExpiredCertificates are certificates which have expired. These fail
+	// validation.
+	ExpiredCertificates []certificate.Found
+	// ExpiringCertificates are certificates which expire within the
+	// configured warning window. These are a warning only, and do not fail
+	// validation.
+	ExpiringCertificates []certificate.Found
+
+	// NotYetValidCertificates are certificates whose validity period has not
+	// yet started. These fail validation.
+	NotYetValidCertificates []certificate.Found
+
+	// OverlongValidityCertificates are certificates whose validity period is
+	// longer than the configured maximum. These fail validation.
+	OverlongValidityCertificates []certificate.Found
+
+	// WeakSignatureCertificates are certificates signed using a weak
+	// signature algorithm. These fail validation.
+	WeakSignatureCertificates []certificate.Found
+
+	// WeakKeyCertificates are certificates with an RSA key smaller than the
+	// configured minimum, or an ECDSA key on a curve which is not allowed.
+	// These fail validation.
+	WeakKeyCertificates []certificate.Found
+
+	// UnhandledCriticalExtensionCertificates are certificates with an
+	// extension marked critical which is not understood. These fail
+	// validation.
+	UnhandledCriticalExtensionCertificates []certificate.Found
+
+	// InvalidAnchorCertificates are certificates found in a trust store,
+	// matched by the configured anchor paths, which are not valid
+	// certificate authorities. These fail validation.
+	InvalidAnchorCertificates []certificate.Found
+
+	// UnverifiableCertificates are certificates, other than self-signed
+	// ones, for which no valid chain could be built to a root, when chains
+	// are verified. These fail validation.
+	UnverifiableCertificates []UnverifiableCert
+
+	// SuspiciousSANCertificates are certificates with subject alternative
+	// names only meaningful on a private network, such as RFC 1918 IP
+	// addresses. These are a warning only, and do not fail validation.
+	SuspiciousSANCertificates []SuspiciousSANCert
+
+	// KeyIdAnomalyCertificates are certificate authorities without a subject
+	// key identifier, and certificates sharing a subject key identifier with
+	// a certificate with a different key, which can break chain building.
+	// These are a warning only, and do not fail validation.
+	KeyIdAnomalyCertificates []KeyIdAnomalyCert
+
+	// PartialCertificates are the partial certificates found, such as
+	// truncated certificates or files which a parser failed to read. As
+	// partials are often false positives, these are only recorded when asked
+	// for, in which case they fail validation.
+	PartialCertificates []certificate.Partial
+
+	// UnsupportedKeyCertificates are partials for certificates whose key
+	// type could not be checked against the key policy. These are a warning
+	// only, and do not fail validation.
+	UnsupportedKeyCertificates []certificate.Partial
+
+	// BaselinedFindings are the findings which were accepted by a baseline,
+	// so were removed from the other lists. These are informational only,
+	// and do not fail validation.
+	BaselinedFindings []Finding
+
+	// UnseenBundleCertificates are the certificates of the allow bundles
+	// which were not found, located in their bundle. These are informational
+	// only, and do not fail validation.
+	UnseenBundleCertificates []certificate.Found
+
+	// Decisions are the rules which decided each certificate matching an
+	// allow or forbid entry, in the order the certificates were found. The
+	// allow list is only considered in strict mode. These are informational
+	// only; forbidden certificates are also in ForbiddenCertificates.
+	Decisions []Decision
+
+	// ExpiredEntries are the allow and forbid entries which have expired, so
+	// were ignored. These are a warning only, and do not fail validation,
+	// but should be removed or renewed by their owners.
+	ExpiredEntries []ExpiredEntry
+
+	// FailOn are the kinds of finding which fail validation. Other kinds of
+	// finding are still reported, but do not fail validation. If empty,
+	// every kind of finding fails validation.
+	FailOn []string
 }
 
+// IsPass returns true if the result has no findings of the kinds which fail
+// validation.
 func (r *Result) IsPass() bool {
-	return r != nil && len(r.ForbiddenCertificates) == 0 && len(r.NotAllowedCertificates) == 0 && len(r.RequiredButAbsent) == 0
+	return r != nil && len(r.Failures()) == 0
+}
+
+// The kinds of finding which fail validation, in order of decreasing severity.
+const (
+	FindingForbidden                  = "forbidden"
+	FindingRevoked                    = "revoked"
+	FindingRequired                   = "required"
+	FindingRequiredAnyOf              = "requiredAnyOf"
+	FindingNotAllowed                 = "notAllowed"
+	FindingExpired                    = "expired"
+	FindingNotYetValid                = "notYetValid"
+	FindingOverlongValidity           = "overlongValidity"
+	FindingWeakSignature              = "weakSignature"
+	FindingWeakKey                    = "weakKey"
+	FindingUnhandledCriticalExtension = "unhandledCriticalExtension"
+	FindingInvalidAnchor              = "invalidAnchor"
+	FindingUnverifiableChain          = "unverifiableChain"
+	FindingPartial                    = "partial"
+)
+
+// Findings are the kinds of finding which fail validation, in order of
+// decreasing severity.
+var Findings = []string{
+	FindingForbidden,
+	FindingRevoked,
+	FindingRequired,
+	FindingRequiredAnyOf,
+	FindingNotAllowed,
+	FindingExpired,
+	FindingNotYetValid,
+	FindingOverlongValidity,
+	FindingWeakSignature,
+	FindingWeakKey,
+	FindingUnhandledCriticalExtension,
+	FindingInvalidAnchor,
+	FindingUnverifiableChain,
+	FindingPartial,
+}
+
+// Failures returns the kinds of finding present in the result which fail
+// validation, in order of decreasing severity.
+func (r *Result) Failures() []string {
+	if r == nil {
+		return nil
+	}
+	var failures []string
+	for _, f := range r.Kinds() {
+		if r.Fails(f) {
+			failures = append(failures, f)
+		}
+	}
+	return failures
+}
+
+// Fails returns true if the given kind of finding fails validation.
+func (r *Result) Fails(finding string) bool {
+	if len(r.FailOn) == 0 {
+		return true
+	}
+	for _, f := range r.FailOn {
+		if f == finding {
+			return true
+		}
+	}
+	return false
+}
+
+// Kinds returns the kinds of finding present in the result, whether or not
+// they fail validation, in order of decreasing severity.
+func (r *Result) Kinds() []string {
+	if r == nil {
+		return nil
+	}
+	present := map[string]bool{
+		FindingForbidden:                  len(r.ForbiddenCertificates) > 0,
+		FindingRevoked:                    len(r.RevokedCertificates) > 0,
+		FindingRequired:                   len(r.RequiredButAbsent) > 0,
+		FindingRequiredAnyOf:              len(r.RequiredGroupsUnsatisfied) > 0,
+		FindingNotAllowed:                 len(r.NotAllowedCertificates) > 0,
+		FindingExpired:                    len(r.ExpiredCertificates) > 0,
+		FindingNotYetValid:                len(r.NotYetValidCertificates) > 0,
+		FindingOverlongValidity:           len(r.OverlongValidityCertificates) > 0,
+		FindingWeakSignature:              len(r.WeakSignatureCertificates) > 0,
+		FindingWeakKey:                    len(r.WeakKeyCertificates) > 0,
+		FindingUnhandledCriticalExtension: len(r.UnhandledCriticalExtensionCertificates) > 0,
+		FindingInvalidAnchor:              len(r.InvalidAnchorCertificates) > 0,
+		FindingUnverifiableChain:          len(r.UnverifiableCertificates) > 0,
+		FindingPartial:                    len(r.PartialCertificates) > 0,
+	}
+	var kinds []string
+	for _, f := range Findings {
+		if present[f] {
+			kinds = append(kinds, f)
+		}
+	}
+	return kinds
+}
+
+// ExitCode returns the exit code for the result. This is the code for the
+// most severe kind of finding present in the result which has a non-zero
+// code, where a kind of finding missing from codes has the code 1. A result
+// which passes has the exit code 0.
+func (r *Result) ExitCode(codes map[string]int) int {
+	for _, f := range r.Failures() {
+		code, ok := codes[f]
+		if !ok {
+			code = 1
+		}
+		if code != 0 {
+			return code
+		}
+	}
+	return 0
 }
 
 func (v *Validator) Validate(founds []certificate.Found) (Result, error) {
-	var result Result
+	result := Result{ExpiredEntries: v.expiredEntries}
 
-	sha1checksums := make(map[[20]byte]bool)
-	sha256checksums := make(map[[32]byte]bool)
+	now := v.clock.Now()
 
 	for _, cert := range founds {
-		sha1checksums[cert.FingerprintSha1] = true
-		sha256checksums[cert.FingerprintSha256] = true
-
 		if !v.permissiveMode {
 			if !v.IsAllowed(cert) {
 				result.NotAllowedCertificates = append(result.NotAllowedCertificates, cert)
 			}
 		}
 
-		if b, ce := v.IsForbidden(cert); b {
-			result.ForbiddenCertificates = append(result.ForbiddenCertificates, ForbiddenCert{
-				Certificate: cert,
-				Entry:       *ce,
-			})
+		if d, ok := v.decide(cert, !v.permissiveMode); ok {
+			result.Decisions = append(result.Decisions, d)
+			if d.Forbids() {
+				result.ForbiddenCertificates = append(result.ForbiddenCertificates, ForbiddenCert{
+					Certificate: cert,
+					Entry:       d.Entry,
+					Fingerprint: d.Fingerprint,
+					Rule:        d.Rule,
+				})
+			}
+		}
+
+		if cert.Certificate != nil {
+			if r, ok := v.isRevoked(cert.Certificate); ok {
+				result.RevokedCertificates = append(result.RevokedCertificates, RevokedCert{
+					Certificate: cert,
+					RevokedAt:   r.revokedAt,
+					Source:      r.source,
+				})
+			}
+		}
+
+		// Certificates which failed to parse can't be checked for expiry.
+		if v.config.CheckExpiry && cert.Certificate != nil {
+			if now.After(cert.Certificate.NotAfter) {
+				result.ExpiredCertificates = append(result.ExpiredCertificates, cert)
+			} else if now.Add(v.config.ExpiryWarning).After(cert.Certificate.NotAfter) {
+				result.ExpiringCertificates = append(result.ExpiringCertificates, cert)
+			}
+		}
+
+		if v.config.CheckNotYetValid && cert.Certificate != nil && now.Before(cert.Certificate.NotBefore) {
+			result.NotYetValidCertificates = append(result.NotYetValidCertificates, cert)
+		}
+
+		if v.config.MaxValidityDuration > 0 && v.hasOverlongValidity(cert) {
+			result.OverlongValidityCertificates = append(result.OverlongValidityCertificates, cert)
+		}
+
+		if v.config.ForbidWeakSignatureAlgorithms && v.hasWeakSignature(cert) {
+			result.WeakSignatureCertificates = append(result.WeakSignatureCertificates, cert)
+		}
+
+		if (v.config.MinRSAKeySize > 0 || len(v.config.AllowedECDSACurves) > 0) && cert.Certificate != nil {
+			weak, err := v.hasWeakKey(cert.Certificate)
+			if err != nil {
+				result.UnsupportedKeyCertificates = append(result.UnsupportedKeyCertificates, certificate.Partial{
+					Location: cert.Location,
+					Parser:   cert.Parser,
+					Reason:   err.Error(),
+				})
+			} else if weak {
+				result.WeakKeyCertificates = append(result.WeakKeyCertificates, cert)
+			}
+		}
+
+		if v.config.ForbidUnhandledCriticalExtensions && len(UnhandledCriticalExtensions(cert.Certificate)) > 0 {
+			result.UnhandledCriticalExtensionCertificates = append(result.UnhandledCriticalExtensionCertificates, cert)
+		}
+
+		if v.config.WarnSuspiciousSANs {
+			if sans := SuspiciousSANs(cert.Certificate); len(sans) > 0 {
+				result.SuspiciousSANCertificates = append(result.SuspiciousSANCertificates, SuspiciousSANCert{Certificate: cert, SANs: sans})
+			}
+		}
+
+		if v.isAnchor(cert.Location) && len(AnchorProblems(cert.Certificate)) > 0 {
+			result.InvalidAnchorCertificates = append(result.InvalidAnchorCertificates, cert)
 		}
 	}
 
-	// Check for missing required certificates
-	for _, required := range v.required {
-		if required.Fingerprints.Sha256 != "" {
-			s, err := checksum.ParseSHA256(required.Fingerprints.Sha256)
+	if v.config.WarnKeyIdAnomalies {
+		result.KeyIdAnomalyCertificates = keyIdAnomalies(founds)
+	}
+
+	if v.verifyChains {
+		result.UnverifiableCertificates = unverifiableChains(founds, v.chainRoots, now)
+	}
+
+	// present returns true if the certificate identified by any of the
+	// entry's fingerprints was found, or for entries without a fingerprint,
+	// if any certificate matching its attributes was found.
+	found := newFoundFingerprints(founds)
+	present := func(entry CertificateEntry) (bool, error) {
+		switch {
+		case entry.hasFingerprint():
+			for _, f := range entry.Fingerprints {
+				ok, err := fingerprintPresent(f, found)
+				if ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		case entry.hasAttributes():
+			m, err := newAttributeMatcher(entry)
 			if err != nil {
-				return Result{}, err
+				return false, err
 			}
-			if _, ok := sha256checksums[s]; !ok {
-				result.RequiredButAbsent = append(result.RequiredButAbsent, required)
+			for _, f := range founds {
+				if m.matches(f.Certificate) {
+					return true, nil
+				}
 			}
-		} else if required.Fingerprints.Sha1 != "" {
-			s, err := checksum.ParseSHA1(required.Fingerprints.Sha1)
+			return false, nil
+		default:
+			return true, nil
+		}
+	}
+
+	// Check for missing required certificates
+	for _, required := range v.required {
+		ok, err := present(required)
+		if err != nil {
+			return Result{}, err
+		}
+		if !ok {
+			result.RequiredButAbsent = append(result.RequiredButAbsent, required)
+		}
+	}
+
+	// Check that at least one certificate of each group is present
+	for _, group := range v.requiredGroups {
+		satisfied := false
+		for _, required := range group {
+			ok, err := present(required)
 			if err != nil {
 				return Result{}, err
 			}
-			if _, ok := sha1checksums[s]; !ok {
-				result.RequiredButAbsent = append(result.RequiredButAbsent, required)
+			if ok {
+				satisfied = true
+				break
 			}
 		}
+		if !satisfied {
+			result.RequiredGroupsUnsatisfied = append(result.RequiredGroupsUnsatisfied, group)
+		}
 	}
 
 	return result, nil
 }
 
-func (v *Validator) IsAllowed(result certificate.Found) bool {
-	if _, ok := v.allowSHA1[result.FingerprintSha1]; ok {
-		return true
+// hasWeakSignature returns true if the certificate is signed using a weak
+// signature algorithm, and is not exempt from the check by being self-signed.
+func (v *Validator) hasWeakSignature(cert certificate.Found) bool {
+	if cert.Certificate == nil || !weakSignatureAlgorithms[cert.Certificate.SignatureAlgorithm] {
+		return false
 	}
 
-	if _, ok := v.allowSHA256[result.FingerprintSha256]; ok {
-		return true
+	return v.config.WeakSignatureIncludeSelfSigned || !certificate.IsSelfSigned(cert.Certificate)
+}
+
+// hasOverlongValidity returns true if the certificate's validity period is
+// longer than the configured maximum, and it is not exempt from the check by
+// being self-signed.
+func (v *Validator) hasOverlongValidity(cert certificate.Found) bool {
+	if cert.Certificate == nil || ValidityPeriod(cert.Certificate) <= v.config.MaxValidityDuration {
+		return false
+	}
+
+	return !v.config.MaxValidityExemptSelfSigned || !certificate.IsSelfSigned(cert.Certificate)
+}
+
+// ValidityPeriod returns how long the certificate is valid for, from its
+// NotBefore to its NotAfter time.
+func ValidityPeriod(cert *x509.Certificate) time.Duration {
+	return cert.NotAfter.Sub(cert.NotBefore)
+}
+
+// ValidityDays returns the number of whole days the certificate is valid for.
+func ValidityDays(cert *x509.Certificate) int {
+	return int(ValidityPeriod(cert).Hours() / 24)
+}
+
+// UnhandledCriticalExtensions returns the OIDs, in dotted form, of the
+// extensions of the certificate which are marked critical but were not
+// understood when it was parsed.
+func UnhandledCriticalExtensions(cert *x509.Certificate) []string {
+	if cert == nil {
+		return nil
+	}
+	oids := make([]string, 0, len(cert.UnhandledCriticalExtensions))
+	for _, oid := range cert.UnhandledCriticalExtensions {
+		oids = append(oids, oid.String())
 	}
+	return oids
+}
 
+// isAnchor returns true if the location is matched by any of the configured
+// anchor paths, so the certificate there is trusted as a root.
+func (v *Validator) isAnchor(location string) bool {
+	for _, pattern := range v.config.AnchorPaths {
+		if certificate.MatchPath(pattern, location) {
+			return true
+		}
+	}
 	return false
 }
 
-func (v *Validator) IsForbidden(result certificate.Found) (bool, *CertificateEntry) {
-	if ce, ok := v.forbidSHA1[result.FingerprintSha1]; ok {
-		return true, &ce
+// AnchorProblems returns why the certificate is not a valid certificate
+// authority, so should not be trusted as a root. A certificate is a valid
+// certificate authority if its basic constraints mark it as one, and its key
+// usage, if it has one, permits signing certificates. If the certificate is
+// valid, nil is returned.
+func AnchorProblems(cert *x509.Certificate) []string {
+	if cert == nil {
+		return nil
+	}
+	var problems []string
+	if !cert.BasicConstraintsValid || !cert.IsCA {
+		problems = append(problems, "basic constraints do not mark it as a certificate authority")
+	}
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		problems = append(problems, "key usage does not permit signing certificates")
+	}
+	return problems
+}
+
+// hasWeakKey returns true if the certificate's public key is an RSA key
+// smaller than the configured minimum, or an ECDSA key on a curve which isn't
+// allowed. An error is returned for key types which can't be checked.
+func (v *Validator) hasWeakKey(cert *x509.Certificate) (bool, error) {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return v.config.MinRSAKeySize > 0 && pub.N.BitLen() < v.config.MinRSAKeySize, nil
+	case *ecdsa.PublicKey:
+		if len(v.config.AllowedECDSACurves) == 0 {
+			return false, nil
+		}
+		name := pub.Curve.Params().Name
+		for _, allowed := range v.config.AllowedECDSACurves {
+			if name == allowed {
+				return false, nil
+			}
+		}
+		return true, nil
+	case ed25519.PublicKey:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported public key algorithm %s, key strength could not be checked", cert.PublicKeyAlgorithm)
+	}
+}
+
+// IsAllowed returns true if the certificate is allowed, either by fingerprint
+// or by its attributes.
+func (v *Validator) IsAllowed(result certificate.Found) bool {
+	if v.isAllowedByFingerprint(result) {
+		return true
 	}
 
-	if ce, ok := v.forbidSHA256[result.FingerprintSha256]; ok {
-		return true, &ce
+	for _, m := range v.allowMatchers {
+		if m.matches(result.Certificate) && m.entry.inScope(result) {
+			return true
+		}
 	}
 
-	return false, nil
+	return false
+}
+
+func (v *Validator) isAllowedByFingerprint(result certificate.Found) bool {
+	_, ok := v.allow.match(result)
+	return ok
+}
+
+// IsForbidden returns true, and the matching entry, if the certificate is
+// forbidden. Forbid entries take precedence over allow entries, so a
+// certificate which matches both is forbidden. See Rules for the precedence
+// of every entry.
+func (v *Validator) IsForbidden(result certificate.Found) (bool, *CertificateEntry) {
+	d, ok := v.decide(result, false)
+	if !ok {
+		return false, nil
+	}
+	return true, &d.Entry
 }
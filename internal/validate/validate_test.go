@@ -3,8 +3,21 @@
 package validate
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	mathrand "math/rand"
+	"net"
 	"strconv"
 	"testing"
 	"time"
@@ -24,14 +37,14 @@ func TestValidator(t *testing.T) {
 		config := Config{
 			Allow: []CertificateEntry{
 				{
-					Fingerprints: CertificateFingerprints{
+					Fingerprints: FingerprintList{{
 						Sha1: allowedSHA1,
-					},
+					}},
 				},
 				{
-					Fingerprints: CertificateFingerprints{
+					Fingerprints: FingerprintList{{
 						Sha256: allowedSHA256,
-					},
+					}},
 				},
 			},
 		}
@@ -99,14 +112,14 @@ func TestValidator(t *testing.T) {
 		config := Config{
 			Forbid: []CertificateEntry{
 				{
-					Fingerprints: CertificateFingerprints{
+					Fingerprints: FingerprintList{{
 						Sha1: forbiddenSHA1,
-					},
+					}},
 				},
 				{
-					Fingerprints: CertificateFingerprints{
+					Fingerprints: FingerprintList{{
 						Sha256: forbiddenSHA256,
-					},
+					}},
 				},
 			},
 		}
@@ -123,7 +136,7 @@ func TestValidator(t *testing.T) {
 			r, err := validator.Validate([]certificate.Found{forbiddenCert})
 			assert.NoError(t, err)
 			assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
-			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[0]})
+			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[0], Fingerprint: config.Forbid[0].Fingerprints[0], Rule: RuleForbidFingerprint})
 		})
 
 		t.Run("Fails on forbidden SHA256", func(t *testing.T) {
@@ -135,7 +148,7 @@ func TestValidator(t *testing.T) {
 			r, err := validator.Validate([]certificate.Found{forbiddenCert})
 			assert.NoError(t, err)
 			assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
-			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[1]})
+			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[1], Fingerprint: config.Forbid[1].Fingerprints[0], Rule: RuleForbidFingerprint})
 		})
 	})
 
@@ -145,16 +158,16 @@ func TestValidator(t *testing.T) {
 		config := Config{
 			Allow: []CertificateEntry{
 				{
-					Fingerprints: CertificateFingerprints{
+					Fingerprints: FingerprintList{{
 						Sha1: forbiddenSHA1,
-					},
+					}},
 				},
 			},
 			Forbid: []CertificateEntry{
 				{
-					Fingerprints: CertificateFingerprints{
+					Fingerprints: FingerprintList{{
 						Sha256: forbiddenSHA256,
-					},
+					}},
 				},
 			},
 		}
@@ -170,7 +183,7 @@ func TestValidator(t *testing.T) {
 		r, err := validator.Validate([]certificate.Found{forbiddenCert})
 		assert.NoError(t, err)
 		assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
-		assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[0]})
+		assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[0], Fingerprint: config.Forbid[0].Fingerprints[0], Rule: RuleForbidFingerprint})
 	})
 
 	t.Run("Require List", func(t *testing.T) {
@@ -179,14 +192,14 @@ func TestValidator(t *testing.T) {
 		config := Config{
 			Require: []CertificateEntry{
 				{
-					Fingerprints: CertificateFingerprints{
+					Fingerprints: FingerprintList{{
 						Sha256: requiredSHA256,
-					},
+					}},
 				},
 				{
-					Fingerprints: CertificateFingerprints{
+					Fingerprints: FingerprintList{{
 						Sha1: requiredSHA1,
-					},
+					}},
 				},
 			},
 		}
@@ -214,11 +227,1102 @@ func TestValidator(t *testing.T) {
 			r, err := validator.Validate([]certificate.Found{foundCert})
 			assert.NoError(t, err)
 			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
-			assert.Contains(t, r.RequiredButAbsent, CertificateEntry{Fingerprints: CertificateFingerprints{Sha1: requiredSHA1}})
-			assert.Contains(t, r.RequiredButAbsent, CertificateEntry{Fingerprints: CertificateFingerprints{Sha256: requiredSHA256}})
+			assert.Contains(t, r.RequiredButAbsent, CertificateEntry{Fingerprints: FingerprintList{{Sha1: requiredSHA1}}})
+			assert.Contains(t, r.RequiredButAbsent, CertificateEntry{Fingerprints: FingerprintList{{Sha256: requiredSHA256}}})
 		})
 
 	})
+
+	t.Run("Require by Subject", func(t *testing.T) {
+		byCN := CertificateEntry{SubjectCN: "Acme Root CA"}
+		byIssuer := CertificateEntry{IssuerDN: "CN=Acme Root CA,O=Acme"}
+		validator, err := NewValidator(Config{Require: []CertificateEntry{byCN, byIssuer}}, false)
+		require.NoError(t, err)
+
+		root := certificate.Found{
+			FingerprintSha256: anySHA256(),
+			Certificate: &x509.Certificate{
+				Subject: pkix.Name{CommonName: "Acme Root CA", Organization: []string{"Acme"}},
+				Issuer:  pkix.Name{CommonName: "Acme Root CA", Organization: []string{"Acme"}},
+			},
+		}
+		other := certificate.Found{
+			FingerprintSha256: anySHA256(),
+			Certificate: &x509.Certificate{
+				Subject: pkix.Name{CommonName: "Other Root CA"},
+				Issuer:  pkix.Name{CommonName: "Other Root CA"},
+			},
+		}
+
+		t.Run("Any matching certificate satisfies the entry", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{other, root})
+			assert.NoError(t, err)
+			assert.Empty(t, r.RequiredButAbsent)
+			assert.Equal(t, []certificate.Found{other}, r.NotAllowedCertificates, "expected matching certificates to be implicitly allowed")
+		})
+
+		t.Run("Unmatched entries are required but absent", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{other})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+			assert.Equal(t, []CertificateEntry{byCN, byIssuer}, r.RequiredButAbsent)
+			assert.Equal(t, `subject CN "Acme Root CA"`, r.RequiredButAbsent[0].Identity())
+			assert.Equal(t, `issuer DN "CN=Acme Root CA,O=Acme"`, r.RequiredButAbsent[1].Identity())
+		})
+	})
+
+	t.Run("Require Any Of", func(t *testing.T) {
+		primarySHA256 := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+		backupSHA1 := "4ae840b224dccf3af3ac0827be5f885eded18a17"
+		otherSHA256 := "96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6"
+		group := []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: primarySHA256}}},
+			{Fingerprints: FingerprintList{{Sha1: backupSHA1}}},
+		}
+		otherGroup := []CertificateEntry{
+			{Fingerprints: FingerprintList{{Sha256: otherSHA256}}},
+		}
+
+		validator, err := NewValidator(Config{RequireAnyOf: [][]CertificateEntry{group, otherGroup}}, false)
+		require.NoError(t, err)
+
+		other := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(otherSHA256)}
+
+		t.Run("Any one certificate of each group is enough", func(t *testing.T) {
+			for _, found := range []certificate.Found{
+				{FingerprintSha256: checksum.MustParseSHA256(primarySHA256)},
+				{FingerprintSha1: checksum.MustParseSHA1(backupSHA1)},
+			} {
+				r, err := validator.Validate([]certificate.Found{found, other})
+				assert.NoError(t, err)
+				assert.Truef(t, r.IsPass(), "Validation reported as failed, when we expected it to pass")
+				assert.Empty(t, r.NotAllowedCertificates, "expected certificates in groups to be implicitly allowed")
+			}
+		})
+
+		t.Run("Missing every certificate of a group", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{other})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+			assert.Equal(t, [][]CertificateEntry{group}, r.RequiredGroupsUnsatisfied)
+			assert.Equal(t, []string{FindingRequiredAnyOf}, r.Failures())
+		})
+
+		t.Run("Rejects invalid fingerprints", func(t *testing.T) {
+			_, err := NewValidator(Config{RequireAnyOf: [][]CertificateEntry{{{Fingerprints: FingerprintList{{Sha256: "abcd"}}}}}}, false)
+			assert.ErrorContains(t, err, "entry at position 0 in requireAnyOf group 0 had invalid SHA256")
+		})
+	})
+
+	t.Run("SHA512 and Mixed Digests", func(t *testing.T) {
+		allowedSHA512 := "0ae2b7d4a5e8c4a2d1f4d8e0b6e4c1f1a2b3c4d5e6f708192a3b4c5d6e7f80910a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+		forbiddenSHA512 := "ff0e1d2c3b4a59687766554433221100ffeeddccbbaa99887766554433221100ffeeddccbbaa99887766554433221100ffeeddccbbaa9988776655443322110f"
+		requiredSHA512 := "1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"
+		allowedSHA1 := "4ae840b224dccf3af3ac0827be5f885eded18a17"
+		config := Config{
+			Allow: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Sha512: allowedSHA512}}},
+				{Fingerprints: FingerprintList{{Sha1: allowedSHA1}}},
+			},
+			Forbid: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Sha512: forbiddenSHA512}}},
+			},
+			Require: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Sha512: requiredSHA512}}},
+			},
+		}
+
+		validator, err := NewValidator(config, false)
+		require.NoError(t, err)
+
+		requiredCert := certificate.Found{
+			FingerprintSha1:   anySHA1(),
+			FingerprintSha256: anySHA256(),
+			FingerprintSha512: checksum.MustParseSHA512(requiredSHA512),
+		}
+
+		t.Run("Accepts permitted certificates with SHA512 and SHA1", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{
+				requiredCert,
+				{
+					FingerprintSha1:   anySHA1(),
+					FingerprintSha256: anySHA256(),
+					FingerprintSha512: checksum.MustParseSHA512(allowedSHA512),
+				},
+				{
+					FingerprintSha1:   checksum.MustParseSHA1(allowedSHA1),
+					FingerprintSha256: anySHA256(),
+					FingerprintSha512: anySHA512(),
+				},
+			})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+
+		t.Run("Fails on forbidden SHA512", func(t *testing.T) {
+			forbiddenCert := certificate.Found{
+				FingerprintSha1:   anySHA1(),
+				FingerprintSha256: anySHA256(),
+				FingerprintSha512: checksum.MustParseSHA512(forbiddenSHA512),
+			}
+
+			r, err := validator.Validate([]certificate.Found{requiredCert, forbiddenCert})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
+			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[0], Fingerprint: config.Forbid[0].Fingerprints[0], Rule: RuleForbidFingerprint})
+		})
+
+		t.Run("Missing required SHA512", func(t *testing.T) {
+			r, err := validator.Validate(nil)
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+			assert.Contains(t, r.RequiredButAbsent, config.Require[0])
+		})
+
+		t.Run("Rejects entries with more than one fingerprint", func(t *testing.T) {
+			_, err := NewValidator(Config{
+				Allow: []CertificateEntry{
+					{Fingerprints: FingerprintList{{Sha1: allowedSHA1, Sha512: allowedSHA512}}},
+				},
+			}, false)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("SPKI Pins", func(t *testing.T) {
+		allowedSPKI := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+		forbiddenSPKI := "edfa7caf7f1274d54bacec91e21a5b1a04a7b94bf197f5c92070b8de148d9b37"
+		config := Config{
+			Allow: []CertificateEntry{
+				{Fingerprints: FingerprintList{{SpkiSha256: allowedSPKI}}},
+			},
+			Forbid: []CertificateEntry{
+				{Fingerprints: FingerprintList{{SpkiSha256: forbiddenSPKI}}},
+			},
+		}
+
+		validator, err := NewValidator(config, false)
+		require.NoError(t, err)
+
+		t.Run("Accepts reissued certificates with the same key", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{
+				{
+					FingerprintSha1:   checksum.MustParseSHA1("4ae840b224dccf3af3ac0827be5f885eded18a17"),
+					FingerprintSha256: checksum.MustParseSHA256("4348a0e9444c78cb265e058d5e8944b4d84f9662bd26db257f8934a443c70161"),
+					SpkiSha256:        checksum.MustParseSHA256(allowedSPKI),
+				},
+				{
+					FingerprintSha1:   checksum.MustParseSHA1("673e582506961a8ebc133cb7890cee768501b84a"),
+					FingerprintSha256: checksum.MustParseSHA256("96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6"),
+					SpkiSha256:        checksum.MustParseSHA256(allowedSPKI),
+				},
+			})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+
+		t.Run("Fails on forbidden SPKI", func(t *testing.T) {
+			forbiddenCert := certificate.Found{
+				FingerprintSha1:   anySHA1(),
+				FingerprintSha256: anySHA256(),
+				SpkiSha256:        checksum.MustParseSHA256(forbiddenSPKI),
+			}
+
+			r, err := validator.Validate([]certificate.Found{forbiddenCert})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
+			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[0], Fingerprint: config.Forbid[0].Fingerprints[0], Rule: RuleForbidFingerprint})
+		})
+	})
+
+	t.Run("MD5", func(t *testing.T) {
+		allowedMD5 := "3ee8ab5e2ddf51c5fa6e2ba5d1c0b1f6"
+		forbiddenMD5 := "b0d2f8a2b4d7e8b7d0b3e1c5e6f9a1c2"
+		requiredMD5 := "9e107d9d372bb6826bd81d3542a419d6"
+		config := Config{
+			Allow: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Md5: allowedMD5}}},
+			},
+			Forbid: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Md5: forbiddenMD5}}},
+			},
+			Require: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Md5: requiredMD5}}},
+			},
+		}
+
+		validator, err := NewValidator(config, false)
+		require.NoError(t, err)
+
+		allowedCert := certificate.Found{
+			FingerprintMd5:    checksum.MustParseMD5(allowedMD5),
+			FingerprintSha1:   anySHA1(),
+			FingerprintSha256: anySHA256(),
+		}
+		requiredCert := certificate.Found{
+			FingerprintMd5:    checksum.MustParseMD5(requiredMD5),
+			FingerprintSha1:   anySHA1(),
+			FingerprintSha256: anySHA256(),
+		}
+
+		t.Run("Accepts allowed and required certificates", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{allowedCert, requiredCert})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+
+		t.Run("Fails on forbidden MD5", func(t *testing.T) {
+			forbiddenCert := certificate.Found{
+				FingerprintMd5:    checksum.MustParseMD5(forbiddenMD5),
+				FingerprintSha1:   anySHA1(),
+				FingerprintSha256: anySHA256(),
+			}
+
+			r, err := validator.Validate([]certificate.Found{requiredCert, forbiddenCert})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
+			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbiddenCert, Entry: config.Forbid[0], Fingerprint: config.Forbid[0].Fingerprints[0], Rule: RuleForbidFingerprint})
+		})
+
+		t.Run("Missing required MD5", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{allowedCert})
+			assert.NoError(t, err)
+			assert.Equal(t, config.Require, r.RequiredButAbsent)
+		})
+
+		t.Run("Rejects invalid MD5", func(t *testing.T) {
+			_, err := NewValidator(Config{Allow: []CertificateEntry{{Fingerprints: FingerprintList{{Md5: allowedMD5 + "00"}}}}}, false)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("SHA256 Prefix", func(t *testing.T) {
+		allowedSHA256 := "96bcec06264976f37460779acf28c5a7cfe8a3c0aae11a8ffcee05c0bddf08c6"
+		forbiddenSHA256 := "bd40be0eccfce513ab318882f03962e4e2ec3799b51392e82805d9249e426d28"
+		config := Config{
+			Allow:   []CertificateEntry{{Fingerprints: FingerprintList{{Sha256Prefix: "96BCEC06"}}}},
+			Forbid:  []CertificateEntry{{Fingerprints: FingerprintList{{Sha256Prefix: "bd40"}}}},
+			Require: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256Prefix: "96bcec0626"}}}},
+		}
+		validator, err := NewValidator(config, false)
+		require.NoError(t, err)
+
+		allowed := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(allowedSHA256)}
+		forbidden := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(forbiddenSHA256)}
+
+		t.Run("Accepts and requires certificates matching a prefix", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{allowed})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, when we expected it to pass")
+		})
+
+		t.Run("Fails on forbidden prefix, even when short", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{allowed, forbidden})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+			require.Len(t, r.ForbiddenCertificates, 1)
+			assert.Equal(t, forbidden, r.ForbiddenCertificates[0].Certificate)
+		})
+
+		t.Run("Missing required prefix", func(t *testing.T) {
+			r, err := validator.Validate(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, config.Require, r.RequiredButAbsent)
+		})
+
+		t.Run("Rejects invalid prefixes", func(t *testing.T) {
+			for _, prefix := range []string{"not hex", allowedSHA256} {
+				_, err := NewValidator(Config{Allow: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256Prefix: prefix}}}}}, false)
+				assert.Error(t, err)
+			}
+		})
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		validator, err := NewValidator(Config{CheckExpiry: true, ExpiryWarning: time.Hour * 24 * 30}, true)
+		require.NoError(t, err)
+
+		expired := certificate.Found{Certificate: &x509.Certificate{NotAfter: time.Now().Add(-time.Hour)}}
+		expiring := certificate.Found{Certificate: &x509.Certificate{NotAfter: time.Now().Add(time.Hour * 24)}}
+		valid := certificate.Found{Certificate: &x509.Certificate{NotAfter: time.Now().Add(time.Hour * 24 * 365)}}
+		unparsed := certificate.Found{}
+
+		t.Run("Expiring certificates are a warning", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{expiring, valid, unparsed})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+			assert.Equal(t, []certificate.Found{expiring}, r.ExpiringCertificates)
+			assert.Empty(t, r.ExpiredCertificates)
+		})
+
+		t.Run("Expired certificates fail", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{expired, valid})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+			assert.Equal(t, []certificate.Found{expired}, r.ExpiredCertificates)
+		})
+
+		t.Run("Expiry is not checked unless enabled", func(t *testing.T) {
+			validator, err := NewValidator(Config{}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate([]certificate.Found{expired, expiring})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+			assert.Empty(t, r.ExpiringCertificates)
+		})
+	})
+
+	t.Run("Not Yet Valid", func(t *testing.T) {
+		validator, err := NewValidator(Config{CheckNotYetValid: true}, true)
+		require.NoError(t, err)
+
+		future := certificate.Found{Certificate: &x509.Certificate{NotBefore: time.Now().Add(time.Hour), NotAfter: time.Now().Add(time.Hour * 24)}}
+		valid := certificate.Found{Certificate: &x509.Certificate{NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour * 24)}}
+		unparsed := certificate.Found{}
+
+		t.Run("Certificates which are not yet valid fail", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{future, valid, unparsed})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+			assert.Equal(t, []certificate.Found{future}, r.NotYetValidCertificates)
+			assert.Equal(t, []string{FindingNotYetValid}, r.Failures())
+		})
+
+		t.Run("Validity is not checked unless enabled", func(t *testing.T) {
+			validator, err := NewValidator(Config{}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate([]certificate.Found{future})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+			assert.Empty(t, r.NotYetValidCertificates)
+		})
+	})
+
+	t.Run("Overlong Validity", func(t *testing.T) {
+		now := time.Now()
+		root, rootKey := generateCertificate(t, &x509.Certificate{IsCA: true, NotBefore: now.Add(-time.Hour), NotAfter: now.AddDate(20, 0, 0)}, nil, nil)
+		long, _ := generateCertificate(t, &x509.Certificate{NotBefore: now.Add(-time.Hour), NotAfter: now.AddDate(3, 0, 0)}, root, rootKey)
+		short, _ := generateCertificate(t, &x509.Certificate{NotBefore: now.Add(-time.Hour), NotAfter: now.AddDate(0, 3, 0)}, root, rootKey)
+
+		founds := []certificate.Found{
+			{Location: "root", Certificate: root},
+			{Location: "long", Certificate: long},
+			{Location: "short", Certificate: short},
+			{Location: "unparsed"},
+		}
+
+		t.Run("Certificates valid for longer than the maximum fail", func(t *testing.T) {
+			validator, err := NewValidator(Config{MaxValidityDuration: 398 * 24 * time.Hour}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+			assert.Equal(t, []certificate.Found{founds[0], founds[1]}, r.OverlongValidityCertificates)
+			assert.Equal(t, []string{FindingOverlongValidity}, r.Failures())
+		})
+
+		t.Run("Self-signed certificates may be exempt", func(t *testing.T) {
+			validator, err := NewValidator(Config{MaxValidityDuration: 398 * 24 * time.Hour, MaxValidityExemptSelfSigned: true}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Equal(t, []certificate.Found{founds[1]}, r.OverlongValidityCertificates)
+		})
+
+		t.Run("Validity periods are not checked unless a maximum is given", func(t *testing.T) {
+			validator, err := NewValidator(Config{}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+			assert.Empty(t, r.OverlongValidityCertificates)
+		})
+
+		assert.Equal(t, 91, ValidityDays(&x509.Certificate{NotBefore: now, NotAfter: now.Add(91*24*time.Hour + time.Hour)}))
+	})
+
+	t.Run("Weak Signature Algorithms", func(t *testing.T) {
+		weakRoot, weakRootKey := generateCertificate(t, &x509.Certificate{IsCA: true, SignatureAlgorithm: x509.ECDSAWithSHA1}, nil, nil)
+		weakLeaf, _ := generateCertificate(t, &x509.Certificate{SignatureAlgorithm: x509.ECDSAWithSHA1}, weakRoot, weakRootKey)
+		strongLeaf, _ := generateCertificate(t, &x509.Certificate{SignatureAlgorithm: x509.ECDSAWithSHA256}, weakRoot, weakRootKey)
+
+		founds := []certificate.Found{
+			{Location: "root", Certificate: weakRoot},
+			{Location: "weak", Certificate: weakLeaf},
+			{Location: "strong", Certificate: strongLeaf},
+			{Location: "unparsed"},
+		}
+
+		t.Run("Self-signed certificates are exempt by default", func(t *testing.T) {
+			validator, err := NewValidator(Config{ForbidWeakSignatureAlgorithms: true}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+			assert.Equal(t, []certificate.Found{founds[1]}, r.WeakSignatureCertificates)
+		})
+
+		t.Run("Self-signed certificates can be included", func(t *testing.T) {
+			validator, err := NewValidator(Config{ForbidWeakSignatureAlgorithms: true, WeakSignatureIncludeSelfSigned: true}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Equal(t, []certificate.Found{founds[0], founds[1]}, r.WeakSignatureCertificates)
+		})
+
+		t.Run("Weak signatures are allowed unless forbidden", func(t *testing.T) {
+			validator, err := NewValidator(Config{}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+	})
+
+	t.Run("Weak Keys", func(t *testing.T) {
+		validator, err := NewValidator(Config{MinRSAKeySize: 2048, AllowedECDSACurves: []string{"P-256", "P-384"}}, true)
+		require.NoError(t, err)
+
+		rsaKey := func(bits int) *rsa.PublicKey {
+			return &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), uint(bits-1)), E: 65537}
+		}
+		ecdsaKey := func(curve elliptic.Curve) *ecdsa.PublicKey {
+			return &ecdsa.PublicKey{Curve: curve}
+		}
+
+		smallRSA := certificate.Found{Location: "small-rsa", Certificate: &x509.Certificate{PublicKey: rsaKey(1024)}}
+		largeRSA := certificate.Found{Location: "large-rsa", Certificate: &x509.Certificate{PublicKey: rsaKey(2048)}}
+		allowedCurve := certificate.Found{Location: "p256", Certificate: &x509.Certificate{PublicKey: ecdsaKey(elliptic.P256())}}
+		forbiddenCurve := certificate.Found{Location: "p224", Certificate: &x509.Certificate{PublicKey: ecdsaKey(elliptic.P224())}}
+		unknownKey := certificate.Found{Location: "unknown", Parser: "pem", Certificate: &x509.Certificate{PublicKeyAlgorithm: x509.UnknownPublicKeyAlgorithm}}
+
+		r, err := validator.Validate([]certificate.Found{smallRSA, largeRSA, allowedCurve, forbiddenCurve, unknownKey})
+		assert.NoError(t, err)
+		assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+		assert.Equal(t, []certificate.Found{smallRSA, forbiddenCurve}, r.WeakKeyCertificates)
+		require.Len(t, r.UnsupportedKeyCertificates, 1)
+		assert.Equal(t, "unknown", r.UnsupportedKeyCertificates[0].Location)
+
+		t.Run("Unsupported keys don't fail validation", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{largeRSA, unknownKey})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+	})
+
+	t.Run("Separated Fingerprints", func(t *testing.T) {
+		found := certificate.Found{
+			Location:          "found",
+			FingerprintSha256: checksum.MustParseSHA256("edfa7caf7f1274d54bacec91e21a5b1a04a7b94bf197f5c92070b8de148d9b37"),
+		}
+		validator, err := NewValidator(Config{
+			Allow: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Sha256: "ED:FA:7C:AF:7F:12:74:D5:4B:AC:EC:91:E2:1A:5B:1A:04:A7:B9:4B:F1:97:F5:C9:20:70:B8:DE:14:8D:9B:37"}}},
+			},
+			Forbid: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Sha256Prefix: "ED:FA:7C:AF"}}},
+			},
+		}, false)
+		require.NoError(t, err)
+		r, err := validator.Validate([]certificate.Found{found})
+		assert.NoError(t, err)
+		assert.Empty(t, r.NotAllowedCertificates)
+		assert.Len(t, r.ForbiddenCertificates, 1)
+	})
+
+	t.Run("Expired Entries", func(t *testing.T) {
+		found := certificate.Found{Location: "found", Certificate: &x509.Certificate{Subject: pkix.Name{CommonName: "Temporary"}}}
+		expired := CertificateEntry{Comment: "temporary exception", SubjectCN: "Temporary", ExpiresAt: time.Now().Add(-time.Hour)}
+		unexpired := CertificateEntry{SubjectCN: "Temporary", ExpiresAt: time.Now().Add(time.Hour)}
+
+		t.Run("Expired allow entries don't allow certificates", func(t *testing.T) {
+			validator, err := NewValidator(Config{Allow: []CertificateEntry{expired}}, false)
+			require.NoError(t, err)
+			r, err := validator.Validate([]certificate.Found{found})
+			assert.NoError(t, err)
+			assert.Equal(t, []certificate.Found{found}, r.NotAllowedCertificates)
+			assert.Equal(t, []ExpiredEntry{{Entry: expired, List: "allow", Position: 0}}, r.ExpiredEntries)
+		})
+
+		t.Run("Expired forbid entries don't forbid certificates", func(t *testing.T) {
+			validator, err := NewValidator(Config{Forbid: []CertificateEntry{unexpired, expired}}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate([]certificate.Found{found})
+			assert.NoError(t, err)
+			require.Len(t, r.ForbiddenCertificates, 1)
+			assert.Equal(t, unexpired, r.ForbiddenCertificates[0].Entry)
+			assert.Equal(t, []ExpiredEntry{{Entry: expired, List: "forbid", Position: 1}}, r.ExpiredEntries)
+		})
+
+		t.Run("Unexpired entries apply", func(t *testing.T) {
+			validator, err := NewValidator(Config{Allow: []CertificateEntry{unexpired}}, false)
+			require.NoError(t, err)
+			r, err := validator.Validate([]certificate.Found{found})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+			assert.Empty(t, r.ExpiredEntries)
+		})
+	})
+
+	t.Run("Unhandled Critical Extensions", func(t *testing.T) {
+		unhandled := certificate.Found{Location: "unhandled", Certificate: &x509.Certificate{
+			UnhandledCriticalExtensions: []asn1.ObjectIdentifier{{1, 2, 3, 4}, {1, 3, 6, 1, 4, 1, 99999, 1}},
+		}}
+		handled := certificate.Found{Location: "handled", Certificate: &x509.Certificate{}}
+		founds := []certificate.Found{unhandled, handled}
+
+		validator, err := NewValidator(Config{ForbidUnhandledCriticalExtensions: true}, true)
+		require.NoError(t, err)
+		r, err := validator.Validate(founds)
+		assert.NoError(t, err)
+		assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+		assert.Equal(t, []certificate.Found{unhandled}, r.UnhandledCriticalExtensionCertificates)
+		assert.Equal(t, []string{"1.2.3.4", "1.3.6.1.4.1.99999.1"}, UnhandledCriticalExtensions(unhandled.Certificate))
+
+		t.Run("Unhandled critical extensions are allowed unless forbidden", func(t *testing.T) {
+			validator, err := NewValidator(Config{}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+	})
+
+	t.Run("Suspicious SANs", func(t *testing.T) {
+		private := certificate.Found{Location: "private", Certificate: &x509.Certificate{
+			DNSNames:    []string{"example.com", "printer.local"},
+			IPAddresses: []net.IP{net.ParseIP("8.8.8.8"), net.ParseIP("10.0.0.1")},
+		}}
+		public := certificate.Found{Location: "public", Certificate: &x509.Certificate{DNSNames: []string{"example.com"}}}
+		founds := []certificate.Found{private, public}
+
+		validator, err := NewValidator(Config{WarnSuspiciousSANs: true}, true)
+		require.NoError(t, err)
+		r, err := validator.Validate(founds)
+		assert.NoError(t, err)
+		assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass as suspicious SANs are a warning")
+		assert.Equal(t, []SuspiciousSANCert{{Certificate: private, SANs: []string{"printer.local", "10.0.0.1"}}}, r.SuspiciousSANCertificates)
+
+		t.Run("Suspicious SANs are not checked unless enabled", func(t *testing.T) {
+			validator, err := NewValidator(Config{}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Empty(t, r.SuspiciousSANCertificates)
+		})
+	})
+
+	t.Run("Anchor Paths", func(t *testing.T) {
+		ca := &x509.Certificate{BasicConstraintsValid: true, IsCA: true, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign}
+		legacyCA := &x509.Certificate{BasicConstraintsValid: true, IsCA: true}
+		leaf := &x509.Certificate{BasicConstraintsValid: true, KeyUsage: x509.KeyUsageDigitalSignature}
+		noCertSign := &x509.Certificate{BasicConstraintsValid: true, IsCA: true, KeyUsage: x509.KeyUsageDigitalSignature}
+
+		anchor := func(location string, cert *x509.Certificate) certificate.Found {
+			return certificate.Found{Location: location, FingerprintSha256: anySHA256(), Certificate: cert}
+		}
+		founds := []certificate.Found{
+			anchor("etc/ssl/certs/ca.pem", ca),
+			anchor("etc/ssl/certs/legacy.pem", legacyCA),
+			anchor("etc/ssl/certs/leaf.pem", leaf),
+			anchor("etc/ssl/certs/no-cert-sign.pem", noCertSign),
+			anchor("usr/share/app/leaf.pem", leaf),
+		}
+
+		validator, err := NewValidator(Config{AnchorPaths: []string{"/etc/ssl/certs"}}, true)
+		require.NoError(t, err)
+		r, err := validator.Validate(founds)
+		assert.NoError(t, err)
+		assert.Falsef(t, r.IsPass(), "Validation reported as passed, when we expected it to fail")
+		assert.Equal(t, []certificate.Found{founds[2], founds[3]}, r.InvalidAnchorCertificates, "expected only certificates in anchor paths which aren't CAs")
+		assert.Equal(t, []string{FindingInvalidAnchor}, r.Failures())
+
+		assert.Empty(t, AnchorProblems(ca))
+		assert.Empty(t, AnchorProblems(legacyCA), "expected a missing key usage to permit signing certificates")
+		assert.Equal(t, []string{"basic constraints do not mark it as a certificate authority", "key usage does not permit signing certificates"}, AnchorProblems(leaf))
+		assert.Equal(t, []string{"key usage does not permit signing certificates"}, AnchorProblems(noCertSign))
+
+		t.Run("Certificates are not checked without anchor paths", func(t *testing.T) {
+			validator, err := NewValidator(Config{}, true)
+			require.NoError(t, err)
+			r, err := validator.Validate(founds)
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+
+		t.Run("Rejects invalid patterns", func(t *testing.T) {
+			_, err := NewValidator(Config{AnchorPaths: []string{"/etc/[ssl"}}, true)
+			assert.ErrorContains(t, err, `invalid anchor path "/etc/[ssl"`)
+			assert.Equal(t, []string{`Anchor path "/etc/[ssl" is an invalid pattern: syntax error in pattern`}, ConfigProblems(&Config{AnchorPaths: []string{"/etc/[ssl"}}))
+		})
+	})
+
+	t.Run("Allowed and forbidden fingerprints", func(t *testing.T) {
+		sha1 := "6f3a4f0a2b1c6a4c1d5e7f8091a2b3c4d5e6f708"
+		// Fingerprints are compared case insensitively.
+		upperSHA1 := "6F3A4F0A2B1C6A4C1D5E7F8091A2B3C4D5E6F708"
+		sha256 := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+
+		t.Run("Rejects a SHA1 fingerprint in both lists", func(t *testing.T) {
+			config := Config{
+				Allow:  []CertificateEntry{{Fingerprints: FingerprintList{{Sha1: sha1}}}},
+				Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha1: upperSHA1}}}},
+			}
+			_, err := NewValidator(config, false)
+			assert.EqualError(t, err, "certificate with SHA1 fingerprint "+upperSHA1+" is both allowed and forbidden")
+			assert.Equal(t, []string{"Certificate with SHA1 fingerprint " + upperSHA1 + " is both allowed and forbidden"}, ConfigProblems(&config))
+		})
+
+		t.Run("Rejects a required SHA256 fingerprint which is forbidden", func(t *testing.T) {
+			config := Config{
+				Require: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256}}}},
+				Forbid:  []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256}}}},
+			}
+			_, err := NewValidator(config, false)
+			assert.EqualError(t, err, "certificate with SHA256 fingerprint "+sha256+" is both allowed and forbidden")
+		})
+
+		t.Run("Fingerprints of different kinds are not compared", func(t *testing.T) {
+			_, err := NewValidator(Config{
+				Allow:  []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256}}}},
+				Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha1: sha1}}}},
+			}, false)
+			assert.NoError(t, err)
+		})
+		t.Run("Fingerprints scoped to different roles do not conflict", func(t *testing.T) {
+			_, err := NewValidator(Config{
+				Allow:  []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256}}, Scope: ScopeIntermediate}},
+				Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256}}, Scope: ScopeRoot}},
+			}, false)
+			assert.NoError(t, err)
+
+			_, err = NewValidator(Config{
+				Allow:  []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256}}, Scope: ScopeAny}},
+				Forbid: []CertificateEntry{{Fingerprints: FingerprintList{{Sha256: sha256}}, Scope: ScopeRoot}},
+			}, false)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("Subject Common Name", func(t *testing.T) {
+		rootSHA256 := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+		config := Config{
+			Allow: []CertificateEntry{
+				{SubjectCN: "Acme Root CA"},
+				{SubjectCNPattern: "*.allowed.internal"},
+				{Fingerprints: FingerprintList{{Sha256: rootSHA256}}},
+			},
+			Forbid: []CertificateEntry{
+				{SubjectCNPattern: "*.corp.internal", Comment: "internal only"},
+				{SubjectCN: "bad.allowed.internal"},
+			},
+		}
+
+		validator, err := NewValidator(config, false)
+		require.NoError(t, err)
+
+		withCN := func(cn string) certificate.Found {
+			return certificate.Found{
+				FingerprintSha1:   anySHA1(),
+				FingerprintSha256: anySHA256(),
+				Certificate:       &x509.Certificate{Subject: pkix.Name{CommonName: cn}},
+			}
+		}
+
+		t.Run("Allows by exact and pattern match", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{withCN("Acme Root CA"), withCN("foo.allowed.internal")})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+
+		t.Run("Forbids by pattern match", func(t *testing.T) {
+			forbidden := withCN("foo.corp.internal")
+			r, err := validator.Validate([]certificate.Found{forbidden})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
+			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbidden, Entry: config.Forbid[0], Rule: RuleForbidAttribute})
+		})
+
+		t.Run("Forbid wins over allow when both match by subject", func(t *testing.T) {
+			forbidden := withCN("bad.allowed.internal")
+			r, err := validator.Validate([]certificate.Found{forbidden})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
+			assert.Contains(t, r.ForbiddenCertificates, ForbiddenCert{Certificate: forbidden, Entry: config.Forbid[1], Rule: RuleForbidAttribute})
+		})
+
+		t.Run("Subject forbid wins over fingerprint allow", func(t *testing.T) {
+			forbidden := withCN("root.corp.internal")
+			forbidden.FingerprintSha256 = checksum.MustParseSHA256(rootSHA256)
+			r, err := validator.Validate([]certificate.Found{forbidden})
+			assert.NoError(t, err)
+			assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
+			assert.Equal(t, []ForbiddenCert{{Certificate: forbidden, Entry: config.Forbid[0], Rule: RuleForbidAttribute}}, r.ForbiddenCertificates)
+		})
+
+		t.Run("Certificates which failed to parse never match", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{{FingerprintSha256: anySHA256()}})
+			assert.NoError(t, err)
+			assert.Empty(t, r.ForbiddenCertificates)
+			assert.Len(t, r.NotAllowedCertificates, 1)
+		})
+
+		t.Run("Invalid patterns are rejected", func(t *testing.T) {
+			_, err := NewValidator(Config{Forbid: []CertificateEntry{{SubjectCNPattern: "[a-"}}}, false)
+			assert.Error(t, err)
+		})
+
+		t.Run("Entries with both a fingerprint and subject are rejected", func(t *testing.T) {
+			_, err := NewValidator(Config{Forbid: []CertificateEntry{{SubjectCN: "Acme", Fingerprints: FingerprintList{{Sha256: rootSHA256}}}}}, false)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("Regular Expressions", func(t *testing.T) {
+		config := Config{
+			Allow: []CertificateEntry{
+				{SubjectRegex: `O=Acme( Corp)?(,|$)`},
+			},
+			Forbid: []CertificateEntry{
+				{SANRegex: `\.corp\.internal$`, Comment: "internal only"},
+				{SANRegex: `^10\.`},
+				{SANRegex: `@acme\.example$`},
+			},
+		}
+
+		validator, err := NewValidator(config, false)
+		require.NoError(t, err)
+
+		withCert := func(cert *x509.Certificate) certificate.Found {
+			return certificate.Found{
+				FingerprintSha1:   anySHA1(),
+				FingerprintSha256: anySHA256(),
+				Certificate:       cert,
+			}
+		}
+
+		t.Run("Allows by subject", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{
+				withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "Acme Root", Organization: []string{"Acme Corp"}}}),
+				withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "Acme Intermediate", Organization: []string{"Acme"}}}),
+			})
+			assert.NoError(t, err)
+			assert.Truef(t, r.IsPass(), "Validation reported as failed, expected pass")
+		})
+
+		t.Run("Rejects subjects which don't match", func(t *testing.T) {
+			r, err := validator.Validate([]certificate.Found{
+				withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "Other Root", Organization: []string{"Acme Corporation"}}}),
+			})
+			assert.NoError(t, err)
+			assert.Len(t, r.NotAllowedCertificates, 1)
+		})
+
+		t.Run("Forbids by DNS, IP, and email SANs", func(t *testing.T) {
+			dns := withCert(&x509.Certificate{DNSNames: []string{"example.com", "foo.corp.internal"}})
+			ip := withCert(&x509.Certificate{IPAddresses: []net.IP{net.ParseIP("10.0.0.1")}})
+			email := withCert(&x509.Certificate{EmailAddresses: []string{"admin@acme.example"}})
+			other := withCert(&x509.Certificate{DNSNames: []string{"corp.internal.example.com"}, IPAddresses: []net.IP{net.ParseIP("192.168.10.1")}})
+
+			r, err := validator.Validate([]certificate.Found{dns, ip, email, other})
+			assert.NoError(t, err)
+			assert.Equal(t, []ForbiddenCert{
+				{Certificate: dns, Entry: config.Forbid[0], Rule: RuleForbidAttribute},
+				{Certificate: ip, Entry: config.Forbid[1], Rule: RuleForbidAttribute},
+				{Certificate: email, Entry: config.Forbid[2], Rule: RuleForbidAttribute},
+			}, r.ForbiddenCertificates)
+		})
+
+		t.Run("Invalid regular expressions are rejected", func(t *testing.T) {
+			_, err := NewValidator(Config{Forbid: []CertificateEntry{{SubjectRegex: "("}}}, false)
+			assert.ErrorContains(t, err, "invalid subject regex")
+
+			_, err = NewValidator(Config{Forbid: []CertificateEntry{{SANRegex: "[a-"}}}, false)
+			assert.ErrorContains(t, err, "invalid SAN regex")
+		})
+	})
+
+	t.Run("Certificate Policies", func(t *testing.T) {
+		config := Config{
+			Forbid: []CertificateEntry{
+				{PolicyOID: "1.3.6.1.4.1.99999.1.2", Comment: "deprecated internal policy"},
+			},
+		}
+
+		validator, err := NewValidator(config, false)
+		require.NoError(t, err)
+
+		withPolicies := func(policies ...asn1.ObjectIdentifier) certificate.Found {
+			return certificate.Found{
+				FingerprintSha256: anySHA256(),
+				Certificate:       &x509.Certificate{PolicyIdentifiers: policies},
+			}
+		}
+
+		t.Run("Forbids certificates asserting the policy among others", func(t *testing.T) {
+			deprecated := withPolicies(asn1.ObjectIdentifier{2, 23, 140, 1, 1}, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 2})
+			other := withPolicies(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1})
+			none := withPolicies()
+
+			r, err := validator.Validate([]certificate.Found{deprecated, other, none})
+			assert.NoError(t, err)
+			assert.Equal(t, []ForbiddenCert{
+				{Certificate: deprecated, Entry: config.Forbid[0], Rule: RuleForbidAttribute},
+			}, r.ForbiddenCertificates)
+			assert.Equal(t, `policy OID "1.3.6.1.4.1.99999.1.2"`, config.Forbid[0].Identity())
+		})
+
+		t.Run("Invalid policy OIDs are rejected", func(t *testing.T) {
+			for _, oid := range []string{"1", "3.1", "1..2", "1.-2", "2.23.ev"} {
+				_, err := NewValidator(Config{Forbid: []CertificateEntry{{PolicyOID: oid}}}, false)
+				assert.ErrorContains(t, err, "invalid policy OID", oid)
+			}
+		})
+	})
+
+	t.Run("Issuer Distinguished Name", func(t *testing.T) {
+		config := Config{
+			Forbid: []CertificateEntry{
+				{IssuerDN: "CN=Compromised CA,O=Example,C=US", Comment: "compromised"},
+			},
+		}
+
+		validator, err := NewValidator(config, true)
+		require.NoError(t, err)
+
+		issuedBy := func(issuer pkix.Name) certificate.Found {
+			return certificate.Found{
+				FingerprintSha256: anySHA256(),
+				Certificate:       &x509.Certificate{Issuer: issuer},
+			}
+		}
+
+		forbidden := issuedBy(pkix.Name{CommonName: "Compromised CA", Organization: []string{"Example"}, Country: []string{"US"}})
+		allowed := issuedBy(pkix.Name{CommonName: "Trusted CA", Organization: []string{"Example"}, Country: []string{"US"}})
+
+		r, err := validator.Validate([]certificate.Found{forbidden, allowed, {FingerprintSha256: anySHA256()}})
+		assert.NoError(t, err)
+		assert.Falsef(t, r.IsPass(), "Validation reported passed, when expected it to fail")
+		assert.Equal(t, []ForbiddenCert{{Certificate: forbidden, Entry: config.Forbid[0], Rule: RuleForbidAttribute}}, r.ForbiddenCertificates)
+	})
+
+	t.Run("Serial Number and Issuer", func(t *testing.T) {
+		// Serial numbers may be up to 20 bytes, larger than any integer type.
+		serial, ok := new(big.Int).SetString("7dd9fe07cfa81eb7107967fba78934c6", 16)
+		require.True(t, ok)
+		issuer := pkix.Name{CommonName: "Compromised CA", Organization: []string{"Example"}}
+
+		withSerial := func(issuer pkix.Name, serial *big.Int) certificate.Found {
+			return certificate.Found{
+				FingerprintSha256: anySHA256(),
+				Certificate:       &x509.Certificate{Issuer: issuer, SerialNumber: serial},
+			}
+		}
+		forbidden := withSerial(issuer, serial)
+		otherSerial := withSerial(issuer, new(big.Int).Add(serial, big.NewInt(1)))
+		otherIssuer := withSerial(pkix.Name{CommonName: "Trusted CA", Organization: []string{"Example"}}, serial)
+
+		for _, s := range []string{
+			serial.String(),
+			"0x7DD9FE07CFA81EB7107967FBA78934C6",
+			"7d:d9:fe:07:cf:a8:1e:b7:10:79:67:fb:a7:89:34:c6",
+		} {
+			t.Run(s, func(t *testing.T) {
+				config := Config{
+					Forbid: []CertificateEntry{{IssuerDN: "CN=Compromised CA,O=Example", SerialNumber: s}},
+				}
+				validator, err := NewValidator(config, true)
+				require.NoError(t, err)
+
+				r, err := validator.Validate([]certificate.Found{forbidden, otherSerial, otherIssuer})
+				assert.NoError(t, err)
+				assert.Equal(t, []ForbiddenCert{{Certificate: forbidden, Entry: config.Forbid[0], Rule: RuleForbidAttribute}}, r.ForbiddenCertificates)
+			})
+		}
+
+		t.Run("Invalid serial numbers are rejected", func(t *testing.T) {
+			_, err := NewValidator(Config{Forbid: []CertificateEntry{{IssuerDN: "CN=Compromised CA", SerialNumber: "12ab"}}}, false)
+			assert.ErrorContains(t, err, `invalid serial number "12ab"`)
+		})
+	})
+
+	t.Run("Entries with several fingerprints match any of them", func(t *testing.T) {
+		rootSHA256 := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+		reissuedSHA1 := "4ae840b224dccf3af3ac0827be5f885eded18a17"
+		entry := CertificateEntry{Fingerprints: FingerprintList{{Sha256: rootSHA256}, {Sha1: reissuedSHA1}}}
+		root := certificate.Found{FingerprintSha1: anySHA1(), FingerprintSha256: checksum.MustParseSHA256(rootSHA256)}
+		reissued := certificate.Found{FingerprintSha1: checksum.MustParseSHA1(reissuedSHA1), FingerprintSha256: anySHA256()}
+
+		validator, err := NewValidator(Config{Forbid: []CertificateEntry{entry}}, true)
+		require.NoError(t, err)
+		r, err := validator.Validate([]certificate.Found{root, reissued})
+		assert.NoError(t, err)
+		assert.Equal(t, []ForbiddenCert{
+			{Certificate: root, Entry: entry, Fingerprint: CertificateFingerprints{Sha256: rootSHA256}, Rule: RuleForbidFingerprint},
+			{Certificate: reissued, Entry: entry, Fingerprint: CertificateFingerprints{Sha1: reissuedSHA1}, Rule: RuleForbidFingerprint},
+		}, r.ForbiddenCertificates)
+
+		validator, err = NewValidator(Config{Require: []CertificateEntry{entry}}, false)
+		require.NoError(t, err)
+		r, err = validator.Validate([]certificate.Found{reissued})
+		assert.NoError(t, err)
+		assert.True(t, r.IsPass(), "expected a required entry to be satisfied, and allowed, by any of its fingerprints")
+
+		r, err = validator.Validate(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []CertificateEntry{entry}, r.RequiredButAbsent)
+	})
+
+	t.Run("Scoped entries only match certificates acting in that scope", func(t *testing.T) {
+		sha := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+		config := Config{
+			Allow: []CertificateEntry{
+				{SubjectCN: "Example Leaf", Scope: ScopeLeaf},
+			},
+			Forbid: []CertificateEntry{
+				{Fingerprints: FingerprintList{{Sha256: sha}}, Scope: ScopeRoot},
+			},
+		}
+
+		validator, err := NewValidator(config, false)
+		require.NoError(t, err)
+
+		root := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(sha), SelfSigned: true, IsCA: true}
+		intermediate := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(sha), IsCA: true}
+		leaf := certificate.Found{
+			Certificate:       &x509.Certificate{Subject: pkix.Name{CommonName: "Example Leaf"}},
+			FingerprintSha256: anySHA256(),
+		}
+		leafCA := certificate.Found{
+			Certificate:       &x509.Certificate{Subject: pkix.Name{CommonName: "Example Leaf"}},
+			FingerprintSha256: anySHA256(),
+			IsCA:              true,
+		}
+
+		r, err := validator.Validate([]certificate.Found{root, intermediate, leaf, leafCA})
+		assert.NoError(t, err)
+		require.Len(t, r.ForbiddenCertificates, 1)
+		assert.Equal(t, root, r.ForbiddenCertificates[0].Certificate)
+		assert.Contains(t, r.NotAllowedCertificates, intermediate)
+		assert.Contains(t, r.NotAllowedCertificates, leafCA)
+		assert.NotContains(t, r.NotAllowedCertificates, leaf)
+	})
+
+	t.Run("Entries sharing a fingerprint each match in their scope", func(t *testing.T) {
+		sha := "01be162c36a6e26951a7ba4fbe6fba11dc7f4b9d589a072fc9d0183fc3386413"
+		asRoot := CertificateEntry{Fingerprints: FingerprintList{{Sha256: sha}}, Scope: ScopeRoot, Comment: "as a root"}
+		asIntermediate := CertificateEntry{Fingerprints: FingerprintList{{Sha256: sha}}, Scope: ScopeIntermediate, Comment: "cross-signed"}
+
+		validator, err := NewValidator(Config{Allow: []CertificateEntry{asRoot, asIntermediate}}, false)
+		require.NoError(t, err)
+
+		root := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(sha), SelfSigned: true, IsCA: true}
+		intermediate := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(sha), IsCA: true}
+		leaf := certificate.Found{FingerprintSha256: checksum.MustParseSHA256(sha)}
+
+		r, err := validator.Validate([]certificate.Found{root, intermediate, leaf})
+		assert.NoError(t, err)
+		assert.Equal(t, []certificate.Found{leaf}, r.NotAllowedCertificates)
+		require.Len(t, r.Decisions, 2)
+		assert.Equal(t, asRoot, r.Decisions[0].Entry)
+		assert.Equal(t, asIntermediate, r.Decisions[1].Entry)
+
+		// A required entry sharing the fingerprint of a scoped allow entry
+		// still allows the certificate in any scope.
+		required := CertificateEntry{Fingerprints: FingerprintList{{Sha256: sha}}}
+		validator, err = NewValidator(Config{Allow: []CertificateEntry{asRoot}, Require: []CertificateEntry{required}}, false)
+		require.NoError(t, err)
+		r, err = validator.Validate([]certificate.Found{intermediate})
+		assert.NoError(t, err)
+		assert.True(t, r.IsPass())
+	})
+}
+
+func TestResult_ExitCode(t *testing.T) {
+	found := certificate.Found{FingerprintSha256: anySHA256()}
+	codes := map[string]int{
+		FindingForbidden:  1,
+		FindingRequired:   2,
+		FindingNotAllowed: 3,
+	}
+
+	tests := map[string]struct {
+		result Result
+		codes  map[string]int
+		want   int
+	}{
+		"Passing result": {
+			result: Result{ExpiringCertificates: []certificate.Found{found}},
+			codes:  codes,
+			want:   0,
+		},
+		"Defaults to 1 for any failure": {
+			result: Result{NotAllowedCertificates: []certificate.Found{found}},
+			want:   1,
+		},
+		"Uses the code for the finding": {
+			result: Result{NotAllowedCertificates: []certificate.Found{found}},
+			codes:  codes,
+			want:   3,
+		},
+		"Uses the code of the most severe finding": {
+			result: Result{
+				NotAllowedCertificates: []certificate.Found{found},
+				RequiredButAbsent:      []CertificateEntry{{}},
+			},
+			codes: codes,
+			want:  2,
+		},
+		"Findings missing from the map use 1": {
+			result: Result{ExpiredCertificates: []certificate.Found{found}},
+			codes:  codes,
+			want:   1,
+		},
+		"Findings with code 0 are ignored": {
+			result: Result{
+				ForbiddenCertificates: []ForbiddenCert{{Certificate: found}},
+				WeakKeyCertificates:   []certificate.Found{found},
+			},
+			codes: map[string]int{FindingForbidden: 0, FindingWeakKey: 4},
+			want:  4,
+		},
+		"Partials are the least severe finding": {
+			result: Result{
+				PartialCertificates: []certificate.Partial{{Location: "etc/ssl/certs/truncated.pem"}},
+				WeakKeyCertificates: []certificate.Found{found},
+			},
+			codes: map[string]int{FindingWeakKey: 4, FindingPartial: 5},
+			want:  4,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.result.ExitCode(test.codes))
+		})
+	}
+}
+
+func TestResult_FailOn(t *testing.T) {
+	found := certificate.Found{FingerprintSha256: anySHA256()}
+	result := Result{
+		NotAllowedCertificates: []certificate.Found{found},
+		ExpiredCertificates:    []certificate.Found{found},
+	}
+
+	assert.Equal(t, []string{FindingNotAllowed, FindingExpired}, result.Failures(), "expected every kind of finding to fail by default")
+	assert.False(t, result.IsPass())
+
+	result.FailOn = []string{FindingForbidden, FindingExpired}
+	assert.Equal(t, []string{FindingNotAllowed, FindingExpired}, result.Kinds())
+	assert.Equal(t, []string{FindingExpired}, result.Failures())
+	assert.False(t, result.IsPass())
+	assert.Equal(t, 1, result.ExitCode(map[string]int{FindingNotAllowed: 3}))
+
+	result.FailOn = []string{FindingForbidden}
+	assert.Empty(t, result.Failures())
+	assert.True(t, result.IsPass(), "expected findings which were not selected to not fail validation")
+	assert.Equal(t, 0, result.ExitCode(nil))
 }
 
 func anySHA1() [20]byte {
@@ -230,3 +1334,39 @@ func anySHA256() [32]byte {
 	timestamp := time.Now().Unix()
 	return sha256.Sum256([]byte(strconv.FormatInt(timestamp, 10)))
 }
+
+func anySHA512() [64]byte {
+	timestamp := time.Now().Unix()
+	return sha512.Sum512([]byte(strconv.FormatInt(timestamp, 10)))
+}
+
+// generateCertificate creates a certificate from the given template, signed
+// by the parent certificate and key. If parent is nil, the certificate is
+// self-signed.
+func generateCertificate(t *testing.T, template, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(mathrand.Int63())
+	if template.Subject.CommonName == "" {
+		template.Subject.CommonName = fmt.Sprintf("paranoia-test-%d", template.SerialNumber)
+	}
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(time.Hour)
+	}
+	template.BasicConstraintsValid = true
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert, key
+}